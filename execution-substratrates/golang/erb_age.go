@@ -0,0 +1,206 @@
+// ERB SDK - Encrypted Record Files
+// ================================
+// Record files ending in .age (scenario overlays holding unpublished
+// argument drafts, say) are encrypted with age (https://age-encryption.org),
+// so private what-if data can be committed alongside the public tests. The
+// format is picked from the name without .age, and the identity (an
+// age-keygen key file) comes from --identity or $ERB_AGE_IDENTITY:
+//
+//	scenario encrypt drafts.json --identity ~/.config/erb/key.txt   # -> drafts.json.age
+//	compute --scenario drafts.json.age --identity ~/.config/erb/key.txt
+//
+// Pipelines read .age import and overlay files with the pipeline's
+// identity. Like the SQLite store, encryption runs the age command-line tool
+// instead of linking a library; keys never pass through this process.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AgeExecutable is the age command-line tool encrypted files are read and written with
+var AgeExecutable = "age"
+
+// EncryptedExt marks an age-encrypted file
+const EncryptedExt = ".age"
+
+// IsEncryptedPath reports whether path names an age-encrypted file
+func IsEncryptedPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), EncryptedExt)
+}
+
+// PlainPath returns path without its .age extension
+func PlainPath(path string) string {
+	if IsEncryptedPath(path) {
+		return path[:len(path)-len(EncryptedExt)]
+	}
+	return path
+}
+
+// AgeIdentity returns the identity file to decrypt with: the flag value, else $ERB_AGE_IDENTITY
+func AgeIdentity(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("ERB_AGE_IDENTITY")
+}
+
+// ReadFileDecrypted reads a file, decrypting it with identity if it ends in .age
+func ReadFileDecrypted(path, identity string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsEncryptedPath(path) {
+		return data, err
+	}
+	if identity == "" {
+		return nil, fmt.Errorf("%s is encrypted: pass --identity or set ERB_AGE_IDENTITY", path)
+	}
+	out, err := runAge(data, "--decrypt", "--identity", identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// EncryptFile writes data to path encrypted to the recipients (age public
+// keys) and to the public keys of the identity files
+func EncryptFile(path string, data []byte, recipients, identities []string) error {
+	if len(recipients) == 0 && len(identities) == 0 {
+		return fmt.Errorf("no recipients: pass --identity or --recipient")
+	}
+	args := []string{"--encrypt"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	for _, i := range identities {
+		args = append(args, "--identity", i)
+	}
+	out, err := runAge(data, args...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// runAge runs the age tool with input on stdin and returns stdout
+func runAge(input []byte, args ...string) ([]byte, error) {
+	ctx := commandContext()
+	cmd := subprocess(ctx, AgeExecutable, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, subprocessError(ctx, "age", stderr.String(), err)
+	}
+	return out, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// stringList collects a repeated flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// runScenario implements `scenario encrypt|decrypt FILE [--identity KEY]
+// [--recipient KEY]... [--out FILE]`: encrypt writes FILE.age, decrypt
+// prints the plain text (or writes --out)
+func runScenario(args []string) error {
+	const usage = "usage: scenario encrypt FILE [--identity KEY] [--recipient KEY]... [--out FILE] | scenario decrypt FILE.age [--identity KEY] [--out FILE]"
+	if len(args) == 0 || (args[0] != "encrypt" && args[0] != "decrypt") {
+		return fmt.Errorf(usage)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("scenario "+action, flag.ContinueOnError)
+	identity := fs.String("identity", "", "age identity file (default: $ERB_AGE_IDENTITY); encrypt also encrypts to its public key")
+	out := fs.String("out", "", "file to write (encrypt: FILE.age; decrypt: stdout)")
+	var recipients stringList
+	if action == "encrypt" {
+		fs.Var(&recipients, "recipient", "age public key to encrypt to (repeatable)")
+	}
+
+	// FILE comes first; flags may follow it
+	var positional []string
+	rest := args[1:]
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 {
+		return fmt.Errorf(usage)
+	}
+	path := positional[0]
+
+	if action == "decrypt" {
+		if !IsEncryptedPath(path) {
+			return fmt.Errorf("%s does not end in %s", path, EncryptedExt)
+		}
+		data, err := ReadFileDecrypted(path, AgeIdentity(*identity))
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return writePrivateFile(*out, data)
+	}
+
+	if IsEncryptedPath(path) {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var identities []string
+	if id := AgeIdentity(*identity); id != "" {
+		identities = append(identities, id)
+	}
+	target := *out
+	if target == "" {
+		target = path + EncryptedExt
+	}
+	if err := EncryptFile(target, data, recipients, identities); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Encrypted %s to %s\n", path, target)
+	return nil
+}
+
+// writePrivateFile writes decrypted data to path readable by its owner
+// only, narrowing the mode before writing if the file already exists
+func writePrivateFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,438 @@
+// ERB SDK - Airtable Sync
+// =======================
+// The rulebook is exported from an Airtable base, so its formulas are
+// Airtable's. This client reads the LanguageCandidates and
+// IsEverythingALanguage tables back through the Airtable REST API into a
+// Rulebook, with the local rulebook's schema, and pushes edits to raw fields
+// back. Calculated fields are never pushed; Airtable computes its own.
+//
+//	client := NewAirtableClient(os.Getenv("AIRTABLE_TOKEN"), "appC8XTj95lubn6hz")
+//	pulled, err := client.Pull(ctx, rb)
+//	patches, err := client.PushPlan(ctx, rb)    // what Push would change
+//	patches, err := client.Push(ctx, rb)
+//
+// Airtable field names are matched to schema fields in any casing. Airtable
+// leaves empty fields out of its responses: a missing checkbox reads as
+// false, any other missing field as null, and null matches "" when comparing.
+// Push only updates records that exist in both places, matched by the
+// table's id field; it never creates or deletes Airtable records.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAirtableURL is the Airtable REST API root
+const DefaultAirtableURL = "https://api.airtable.com/v0"
+
+// AirtableTables are the tables Pull and Push sync
+var AirtableTables = []string{"LanguageCandidates", "IsEverythingALanguage"}
+
+// airtableBatchSize is the most records Airtable accepts in one update
+const airtableBatchSize = 10
+
+// AirtableClient reads and updates the tables of one Airtable base
+type AirtableClient struct {
+	Token   string // personal access token
+	BaseID  string // e.g. appC8XTj95lubn6hz
+	BaseURL string // default DefaultAirtableURL
+	Client  *http.Client
+}
+
+// NewAirtableClient returns a client for the base
+func NewAirtableClient(token, baseID string) *AirtableClient {
+	return &AirtableClient{Token: token, BaseID: baseID, BaseURL: DefaultAirtableURL}
+}
+
+// AirtableRecord is a record as the API returns it
+type AirtableRecord struct {
+	ID     string         `json:"id"`
+	Fields map[string]any `json:"fields"`
+}
+
+// AirtablePatch is one record update Push sends
+type AirtablePatch struct {
+	Table    string
+	RecordID string // the rulebook id
+	Remote   string // the Airtable record id (rec...)
+	Changes  []ValueChange
+	fields   map[string]any // Airtable field name -> new value
+}
+
+func (p AirtablePatch) String() string {
+	s := fmt.Sprintf("%s %s (%s):", p.Table, p.RecordID, p.Remote)
+	for _, c := range p.Changes {
+		s += fmt.Sprintf(" %s %s -> %s", c.Field, formatValue(c.Old), formatValue(c.New))
+	}
+	return s
+}
+
+// AirtableBaseID returns the Airtable base a rulebook was exported from
+// (_meta._conversion_metadata.source_base_id, else an app... model_name)
+func (rb *Rulebook) AirtableBaseID() string {
+	var meta struct {
+		Conversion struct {
+			SourceBaseID string `json:"source_base_id"`
+		} `json:"_conversion_metadata"`
+	}
+	if len(rb.Meta) > 0 && json.Unmarshal(rb.Meta, &meta) == nil && meta.Conversion.SourceBaseID != "" {
+		return meta.Conversion.SourceBaseID
+	}
+	if strings.HasPrefix(rb.ModelName, "app") {
+		return rb.ModelName
+	}
+	return ""
+}
+
+// ListRecords returns every record of a table, following the API's pages
+func (c *AirtableClient) ListRecords(ctx context.Context, table string) ([]AirtableRecord, error) {
+	var records []AirtableRecord
+	offset := ""
+	for {
+		query := url.Values{"pageSize": {"100"}}
+		if offset != "" {
+			query.Set("offset", offset)
+		}
+		var page struct {
+			Records []AirtableRecord `json:"records"`
+			Offset  string           `json:"offset"`
+		}
+		if err := c.do(ctx, http.MethodGet, table, query, nil, &page); err != nil {
+			return nil, err
+		}
+		records = append(records, page.Records...)
+		if page.Offset == "" {
+			return records, nil
+		}
+		offset = page.Offset
+	}
+}
+
+// Pull returns a rulebook with rb's schema and the base's current data for
+// the synced tables; other tables are copied from rb
+func (c *AirtableClient) Pull(ctx context.Context, rb *Rulebook) (*Rulebook, error) {
+	data := map[string][]map[string]any{}
+	for _, name := range AirtableTables {
+		t := rb.Table(name)
+		if t == nil {
+			continue
+		}
+		records, err := c.ListRecords(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]map[string]any, len(records))
+		for i, rec := range records {
+			rows[i] = airtableRow(t, rec)
+		}
+		data[name] = rows
+	}
+	pulled, err := rb.withTableData(data)
+	if err != nil {
+		return nil, fmt.Errorf("pulled rulebook does not load: %w", err)
+	}
+	return pulled, nil
+}
+
+// PushPlan returns the updates Push would send: every raw field whose local
+// value differs from the base's
+func (c *AirtableClient) PushPlan(ctx context.Context, rb *Rulebook) ([]AirtablePatch, error) {
+	var patches []AirtablePatch
+	for _, name := range AirtableTables {
+		t := rb.Table(name)
+		if t == nil {
+			continue
+		}
+		records, err := c.ListRecords(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		remoteNames := map[string]string{} // nameKey -> Airtable field name
+		remote := map[string]AirtableRecord{}
+		for _, rec := range records {
+			for k := range rec.Fields {
+				remoteNames[nameKey(k)] = k
+			}
+			remote[fmt.Sprint(airtableRow(t, rec)[t.IDField()])] = rec
+		}
+
+		idField := t.IDField()
+		for _, row := range t.Data {
+			id := fmt.Sprint(row[idField])
+			rec, ok := remote[id]
+			if !ok {
+				continue
+			}
+			current := airtableRow(t, rec)
+			patch := AirtablePatch{Table: name, RecordID: id, Remote: rec.ID, fields: map[string]any{}}
+			for _, f := range t.Schema {
+				if f.IsCalculated() || f.Name == idField || airtableEqual(row[f.Name], current[f.Name]) {
+					continue
+				}
+				field, ok := remoteNames[nameKey(f.Name)]
+				if !ok {
+					field = f.Name
+				}
+				patch.fields[field] = row[f.Name]
+				patch.Changes = append(patch.Changes, ValueChange{RecordID: id, Record: optGet(recordName(row), id), Field: f.Name, Old: current[f.Name], New: row[f.Name]})
+			}
+			if len(patch.Changes) > 0 {
+				patches = append(patches, patch)
+			}
+		}
+	}
+	return patches, nil
+}
+
+// Push sends the PushPlan updates, in batches of ten records per request,
+// and returns them
+func (c *AirtableClient) Push(ctx context.Context, rb *Rulebook) ([]AirtablePatch, error) {
+	patches, err := c.PushPlan(ctx, rb)
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(patches); {
+		table := patches[start].Table
+		end := start
+		var batch []AirtableRecord
+		for end < len(patches) && patches[end].Table == table && len(batch) < airtableBatchSize {
+			batch = append(batch, AirtableRecord{ID: patches[end].Remote, Fields: patches[end].fields})
+			end++
+		}
+		body := map[string]any{"records": batch}
+		if err := c.do(ctx, http.MethodPatch, table, nil, body, nil); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", table, err)
+		}
+		start = end
+	}
+	return patches, nil
+}
+
+// do sends one API request, waiting and retrying while the base is rate limited
+func (c *AirtableClient) do(ctx context.Context, method, table string, query url.Values, body, out any) error {
+	baseURL, client := c.BaseURL, c.Client
+	if baseURL == "" {
+		baseURL = DefaultAirtableURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(c.BaseID) + "/" + url.PathEscape(table)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to call Airtable: %w", err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read the Airtable response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			wait := 30 * time.Second // Airtable's penalty for exceeding 5 requests a second
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Airtable %s %s: %s: %s", method, table, resp.Status, bytes.TrimSpace(data))
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("unexpected Airtable response: %w", err)
+		}
+		return nil
+	}
+}
+
+// airtableRow converts an Airtable record into a rulebook row of t
+func airtableRow(t *Table, rec AirtableRecord) map[string]any {
+	byKey := make(map[string]any, len(rec.Fields))
+	for k, v := range rec.Fields {
+		byKey[nameKey(k)] = v
+	}
+	row := make(map[string]any, len(t.Schema))
+	for _, f := range t.Schema {
+		v, ok := byKey[nameKey(f.Name)]
+		if !ok && f.Datatype == "boolean" {
+			v = false
+		}
+		row[f.Name] = airtableValue(f, v)
+	}
+	return row
+}
+
+// airtableValue converts an API value to the field's rulebook value:
+// lookups and links (arrays) are joined, error values become null
+func airtableValue(f Field, v any) any {
+	switch x := v.(type) {
+	case []any:
+		if len(x) == 1 {
+			return airtableValue(f, x[0])
+		}
+		parts := make([]string, len(x))
+		for i, item := range x {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any: // {"error": "#ERROR!"} or {"specialValue": "NaN"}
+		return nil
+	case float64:
+		if f.Datatype == "integer" && x == float64(int(x)) {
+			return int(x)
+		}
+	}
+	return v
+}
+
+// airtableEqual compares a local and a remote value; Airtable does not
+// distinguish an empty text from an empty field
+func airtableEqual(local, remote any) bool {
+	if local == "" {
+		local = nil
+	}
+	if remote == "" {
+		remote = nil
+	}
+	return valuesEqual(local, remote)
+}
+
+// recordName returns a row's Name, if it has one
+func recordName(row map[string]any) *string {
+	if s, ok := row["Name"].(string); ok && s != "" {
+		return &s
+	}
+	return nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runAirtable implements `airtable pull|push [--rulebook PATH] [--base ID]
+// [--out FILE] [--dry-run]`
+func runAirtable(args []string) error {
+	const usage = "usage: airtable pull|push [flags]"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("airtable "+action, flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	base := fs.String("base", "", "Airtable base id (default: the base the rulebook was exported from)")
+	out := fs.String("out", "", "pull: file to write the pulled rulebook to (default: stdout)")
+	dryRun := fs.Bool("dry-run", false, "push: print the updates instead of sending them")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	if action != "pull" && action != "push" {
+		return fmt.Errorf(usage)
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	token := os.Getenv("AIRTABLE_TOKEN")
+	if token == "" {
+		token = os.Getenv("AIRTABLE_API_KEY")
+	}
+	if token == "" {
+		return fmt.Errorf("airtable needs AIRTABLE_TOKEN (a personal access token)")
+	}
+	if *base == "" {
+		*base = rb.AirtableBaseID()
+	}
+	if *base == "" {
+		return fmt.Errorf("the rulebook does not name its Airtable base; pass --base")
+	}
+	client := NewAirtableClient(token, *base)
+	if u := os.Getenv("AIRTABLE_BASE_URL"); u != "" {
+		client.BaseURL = u
+	}
+	ctx := commandContext()
+
+	if action == "pull" {
+		pulled, err := client.Pull(ctx, rb)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(pulled, "", "  ")
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *out, err)
+		}
+		fmt.Fprintf(os.Stderr, "Pulled %d candidates and %d argument steps from %s to %s\n", len(pulled.LanguageCandidates), len(pulled.IsEverythingALanguage), *base, *out)
+		return nil
+	}
+
+	var patches []AirtablePatch
+	if *dryRun {
+		patches, err = client.PushPlan(ctx, rb)
+	} else {
+		patches, err = client.Push(ctx, rb)
+	}
+	if err != nil {
+		return err
+	}
+	for _, p := range patches {
+		fmt.Println(p)
+	}
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d records in %s\n", verb, len(patches), *base)
+	return nil
+}
@@ -0,0 +1,136 @@
+// ERB SDK - Tolerant Field Names
+// ==============================
+// Record files from other substrates do not always use the snake_case JSON
+// keys: some write the rulebook's PascalCase (LanguageCandidateId), and older
+// datasets use field names that have since been renamed
+// (Meaning_Is_Serialized, IsOngologyDescriptor). WithTolerantFields maps every
+// key spelled in any casing (see nameKey), or listed in FieldAliases, to the
+// field's JSON key before decoding, and reports each key it renamed:
+//
+//	records, err := LoadRecords("answers.json", WithTolerantFields(func(u AliasUse) {
+//		fmt.Println(u) // record 0 (english): LanguageCandidateId read as language_candidate_id
+//	}))
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// FieldAliases lists the historical names of fields, by table and rulebook
+// field name; each alias is accepted in any casing, like the field's own name
+var FieldAliases = map[string]map[string][]string{
+	"LanguageCandidates": {
+		"HasLinearDecodingPressure": {"Meaning_Is_Serialized", "MeaningIsSerialized"},
+		"IsStableOntologyReference": {"IsOngologyDescriptor", "StableOntologyReference"},
+		"FamilyFuedQuestion":        {"FamilyFeudQuestion"},
+	},
+}
+
+// AliasUse records a key that was read as a field under another spelling
+type AliasUse struct {
+	Record     int    `json:"record"` // zero-based position in the file
+	ID         string `json:"id,omitempty"`
+	Key        string `json:"key"`        // as spelled in the file
+	Field      string `json:"field"`      // the JSON key it was read as
+	Historical bool   `json:"historical"` // a FieldAliases name, not just another casing
+}
+
+func (u AliasUse) String() string {
+	name := fmt.Sprintf("record %d", u.Record)
+	if u.ID != "" {
+		name += " (" + u.ID + ")"
+	}
+	how := "read as"
+	if u.Historical {
+		how = "is a historical name of"
+	}
+	return fmt.Sprintf("%s: %s %s %s", name, u.Key, how, u.Field)
+}
+
+// WithTolerantFields accepts record keys in any casing and FieldAliases
+// names; onAlias (may be nil) is called for every key that was renamed
+func WithTolerantFields(onAlias func(AliasUse)) RecordOption {
+	return func(c *recordConfig) {
+		c.tolerant = true
+		c.onAlias = onAlias
+	}
+}
+
+// fieldSpelling is the JSON key a folded name stands for
+type fieldSpelling struct {
+	field      string
+	historical bool
+}
+
+// fieldSpellings maps the nameKey of every accepted spelling of T's fields
+// to the field's JSON key
+func fieldSpellings(t reflect.Type) map[string]fieldSpelling {
+	var aliases map[string][]string
+	for table, a := range FieldAliases {
+		if structName(table) == t.Name() {
+			aliases = a
+		}
+	}
+
+	spellings := map[string]fieldSpelling{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, _ := jsonKey(sf)
+		if key == "" || !sf.IsExported() {
+			continue
+		}
+		spellings[nameKey(key)] = fieldSpelling{field: key}
+		spellings[nameKey(sf.Name)] = fieldSpelling{field: key}
+		for _, alias := range aliases[sf.Name] {
+			spellings[nameKey(alias)] = fieldSpelling{field: key, historical: true}
+		}
+	}
+	return spellings
+}
+
+// normalizeRecordKeys rewrites the keys of a JSON array of records to T's
+// JSON keys; keys that spell no field are kept for the strict check to report
+func normalizeRecordKeys(t reflect.Type, data []byte, onAlias func(AliasUse)) ([]byte, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	spellings := fieldSpellings(t)
+	idKey := ""
+	if t.NumField() > 0 {
+		idKey, _ = jsonKey(t.Field(0))
+	}
+	for i, rec := range raw {
+		out := make(map[string]json.RawMessage, len(rec))
+		var uses []AliasUse
+		for _, k := range sortedKeys(rec) {
+			s, ok := spellings[nameKey(k)]
+			if !ok || s.field == k {
+				if _, dup := out[k]; dup {
+					return nil, fmt.Errorf("record %d: %s is set more than once (in different spellings)", i, k)
+				}
+				out[k] = rec[k]
+				continue
+			}
+			if _, dup := out[s.field]; dup || rec[s.field] != nil {
+				return nil, fmt.Errorf("record %d: %s and %s both set %s", i, k, s.field, s.field)
+			}
+			out[s.field] = rec[k]
+			uses = append(uses, AliasUse{Record: i, Key: k, Field: s.field, Historical: s.historical})
+		}
+		if onAlias != nil {
+			var id string
+			json.Unmarshal(out[idKey], &id)
+			for _, u := range uses {
+				u.ID = id
+				onAlias(u)
+			}
+		}
+		raw[i] = out
+	}
+	return json.Marshal(raw)
+}
@@ -0,0 +1,212 @@
+// ERB SDK - Argument Report
+// =========================
+// Renders the IsEverythingALanguage table as a Markdown document, so the
+// argument can be published straight from the data: steps are grouped by
+// ArgumentName, then ArgumentCategory (both in table order), each with its
+// Statement, Formalization and Notes. Steps that reference a candidate link
+// to an evidence section showing the candidate's computed Family Feud answer
+// and which of the TopFamilyFeudAnswer criteria it meets:
+//
+//	argument --out ../../docs/argument.md
+//	err := rb.WriteArgumentReport(os.Stdout)
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// WriteArgumentReport writes the argument as Markdown
+func (rb *Rulebook) WriteArgumentReport(w io.Writer) error {
+	criteria, err := topAnswerCriteria()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Is Everything a Language?\n\n")
+	fmt.Fprintf(&b, "%d argument steps from the IsEverythingALanguage table", len(rb.IsEverythingALanguage))
+	if rb.ModelName != "" {
+		fmt.Fprintf(&b, " of %s", rb.ModelName)
+	}
+	b.WriteString(".\n")
+
+	steps := make([]*IsEverythingALanguage, len(rb.IsEverythingALanguage))
+	for i := range rb.IsEverythingALanguage {
+		steps[i] = &rb.IsEverythingALanguage[i]
+	}
+	var evidence []*LanguageCandidate
+	seen := map[string]bool{}
+	for _, argument := range argumentGroups(steps, func(s *IsEverythingALanguage) string { return optGet(s.ArgumentName, "") }) {
+		fmt.Fprintf(&b, "\n## %s\n", headingText(argument.name, "Unnamed argument"))
+		for _, category := range argumentGroups(argument.steps, func(s *IsEverythingALanguage) string { return optGet(s.ArgumentCategory, "") }) {
+			fmt.Fprintf(&b, "\n### %s\n", headingText(category.name, "Uncategorized"))
+			for _, step := range category.steps {
+				writeArgumentStep(&b, rb, step)
+				if lc := step.Candidate(rb); lc != nil && !seen[lc.LanguageCandidateId] {
+					seen[lc.LanguageCandidateId] = true
+					evidence = append(evidence, lc)
+				}
+			}
+		}
+	}
+
+	if len(evidence) > 0 {
+		b.WriteString("\n## Evidence\n")
+		for _, lc := range evidence {
+			if err := writeCandidateEvidence(&b, lc.ToView(), criteria); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// argumentGroup is the steps sharing one ArgumentName or ArgumentCategory
+type argumentGroup struct {
+	name  string
+	steps []*IsEverythingALanguage
+}
+
+// argumentGroups groups steps by key, in order of each key's first step
+func argumentGroups(steps []*IsEverythingALanguage, key func(*IsEverythingALanguage) string) []argumentGroup {
+	var groups []argumentGroup
+	index := map[string]int{}
+	for _, step := range steps {
+		k := key(step)
+		if _, ok := index[k]; !ok {
+			index[k] = len(groups)
+			groups = append(groups, argumentGroup{name: k})
+		}
+		groups[index[k]].steps = append(groups[index[k]].steps, step)
+	}
+	return groups
+}
+
+// writeArgumentStep writes one step: its name and type, then its texts
+func writeArgumentStep(b *strings.Builder, rb *Rulebook, step *IsEverythingALanguage) {
+	fmt.Fprintf(b, "\n**%s**", headingText(optGet(step.Name, ""), step.IsEverythingALanguageId))
+	if t := optGet(step.StepType, ""); t != "" {
+		fmt.Fprintf(b, " · *%s*", t)
+	}
+	b.WriteString("\n\n")
+	if s := strings.TrimSpace(optGet(step.Statement, "")); s != "" {
+		b.WriteString(s + "\n\n")
+	}
+	if f := strings.TrimSpace(optGet(step.Formalization, "")); f != "" {
+		fmt.Fprintf(b, "> %s\n\n", strings.ReplaceAll(f, "\n", "\n> "))
+	}
+
+	name := optGet(step.RelatedCandidateName, "")
+	if lc := step.Candidate(rb); lc != nil {
+		fmt.Fprintf(b, "- Evidence: [%s](#%s)", optGet(lc.Name, lc.LanguageCandidateId), candidateAnchor(lc.LanguageCandidateId))
+		if e := strings.TrimSpace(optGet(step.EvidenceFromRulebook, "")); e != "" {
+			fmt.Fprintf(b, " - %s", e)
+		}
+		b.WriteString("\n")
+	} else if name != "" {
+		fmt.Fprintf(b, "- Candidate: %s (not linked to a LanguageCandidates record)\n", name)
+	}
+	if n := strings.TrimSpace(optGet(step.Notes, "")); n != "" {
+		fmt.Fprintf(b, "- Notes: %s\n", n)
+	}
+}
+
+// writeCandidateEvidence writes a candidate's computed answer and the
+// TopFamilyFeudAnswer criteria it meets
+func writeCandidateEvidence(b *strings.Builder, v LanguageCandidateView, criteria []FormulaNode) error {
+	fmt.Fprintf(b, "\n<a id=\"%s\"></a>\n\n### %s\n\n", candidateAnchor(v.LanguageCandidateId), optGet(v.Name, v.LanguageCandidateId))
+	if c := optGet(v.Category, ""); c != "" {
+		fmt.Fprintf(b, "- Category: %s\n", c)
+	}
+	fmt.Fprintf(b, "- Family Feud answer: %s\n", yesNo(optGet(v.TopFamilyFeudAnswer, false)))
+	fmt.Fprintf(b, "- Chosen language candidate: %s\n", yesNo(optGet(v.ChosenLanguageCandidate, false)))
+	if m := strings.TrimSpace(optGet(v.FamilyFeudMismatch, "")); m != "" {
+		fmt.Fprintf(b, "- Mismatch: %s\n", m)
+	}
+
+	b.WriteString("\n| Criterion | Holds |\n|---|---|\n")
+	eval := &FormulaEvaluator{Lookup: func(name string) any { return recordField(&v, name) }}
+	for _, c := range criteria {
+		value, err := eval.Eval(c)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate TopFamilyFeudAnswer criterion %s: %w", c, err)
+		}
+		fmt.Fprintf(b, "| `%s` | %s |\n", markdownCell.Replace(c.String()), yesNo(formulaBool(value)))
+	}
+	return nil
+}
+
+// candidateAnchor is the id of a candidate's evidence section
+func candidateAnchor(id string) string {
+	return "candidate-" + id
+}
+
+// headingText is text, or fallback when it is blank
+func headingText(text, fallback string) string {
+	if strings.TrimSpace(text) == "" {
+		return fallback
+	}
+	return text
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runArgument implements `argument [--rulebook PATH] [--format
+// markdown|dot|chains] [--out FILE]`
+func runArgument(args []string) error {
+	fs := flag.NewFlagSet("argument", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path or URL of the rulebook")
+	format := fs.String("format", "markdown", "markdown (the report), dot (the argument graph for Graphviz) or chains (proof chains and orphan steps)")
+	out := fs.String("out", "", "file to write to (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var write func(io.Writer) error
+	switch *format {
+	case "markdown":
+		write = rb.WriteArgumentReport
+	case "dot":
+		write = rb.ArgumentGraph().WriteDOT
+	case "chains":
+		write = rb.ArgumentGraph().writeChains
+	default:
+		return fmt.Errorf("unknown format %q (want markdown, dot or chains)", *format)
+	}
+	if *out == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *out, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the argument (%d steps) to %s\n", len(rb.IsEverythingALanguage), *out)
+	return nil
+}
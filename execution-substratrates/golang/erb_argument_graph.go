@@ -0,0 +1,304 @@
+// ERB SDK - Argument Graph
+// ========================
+// The IsEverythingALanguage steps as a graph of support: within an argument
+// (ArgumentName), every premise supports the argument's conclusion, and a
+// conclusion that another argument builds on is a lemma supporting the steps
+// that cite it. A step's StepType decides its role:
+//
+//	Conclusion                                   conclusion (a lemma when cited)
+//	Motivation, PredicateSet, Definition,        premise
+//	Witness, Entailment, Counterexample,
+//	NonLanguageExample, FuzzyBoundary, Refinement
+//
+// A step cites a conclusion when its Formalization contains the
+// conclusion's consequent (the text after its last ⇒), so NEIAL-006's
+// "... ⇒ Formalizable(Language)" is a lemma of NEIAL-016's
+// "Formalizable(Language) ∧ ∃x ¬Language(x) ⇒ ...":
+//
+//	g := rb.ArgumentGraph()
+//	for _, chain := range g.ProofChains() {
+//		fmt.Println(chain) // NEIAL-016: NEIAL-001, NEIAL-002, ..., NEIAL-006, NEIAL-007, ...
+//	}
+//	orphans := g.Orphans() // steps supporting nothing and supported by nothing
+//	err := g.WriteDOT(os.Stdout)
+//
+//	argument --format dot | dot -Tsvg > argument.svg
+//	argument --format chains
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Argument step roles
+const (
+	RolePremise    = "premise"
+	RoleLemma      = "lemma"
+	RoleConclusion = "conclusion"
+)
+
+// argumentStepRoles maps each known StepType to its role; steps of other
+// types have no role and support nothing
+var argumentStepRoles = map[string]string{
+	"Motivation":         RolePremise,
+	"PredicateSet":       RolePremise,
+	"Definition":         RolePremise,
+	"Witness":            RolePremise,
+	"Entailment":         RolePremise,
+	"Counterexample":     RolePremise,
+	"NonLanguageExample": RolePremise,
+	"FuzzyBoundary":      RolePremise,
+	"Refinement":         RolePremise,
+	"Conclusion":         RoleConclusion,
+}
+
+// ArgumentNode is one step in the argument graph
+type ArgumentNode struct {
+	Step        *IsEverythingALanguage
+	Role        string          // RolePremise, RoleLemma, RoleConclusion, or "" for an unknown StepType
+	Supports    []*ArgumentNode // the steps this one supports, in table order
+	SupportedBy []*ArgumentNode // the steps supporting this one, in table order
+
+	pos int // position in the table
+}
+
+// ID is the step's IsEverythingALanguageId
+func (n *ArgumentNode) ID() string {
+	return n.Step.IsEverythingALanguageId
+}
+
+// Label is the step's Name, or its id when it has none
+func (n *ArgumentNode) Label() string {
+	return headingText(optGet(n.Step.Name, ""), n.ID())
+}
+
+// ArgumentGraph is the support graph of the rulebook's argument steps
+type ArgumentGraph struct {
+	Nodes []*ArgumentNode // in table order
+	index map[string]*ArgumentNode
+}
+
+// ProofChain is a final conclusion with every step it rests on, each step
+// after the steps supporting it
+type ProofChain struct {
+	Conclusion *ArgumentNode
+	Steps      []*ArgumentNode // ends with Conclusion
+}
+
+func (c ProofChain) String() string {
+	labels := make([]string, len(c.Steps))
+	for i, n := range c.Steps {
+		labels[i] = n.Label()
+	}
+	return c.Conclusion.Label() + ": " + strings.Join(labels, ", ")
+}
+
+// ArgumentGraph builds the support graph of the IsEverythingALanguage steps
+func (rb *Rulebook) ArgumentGraph() *ArgumentGraph {
+	g := &ArgumentGraph{index: map[string]*ArgumentNode{}}
+	for i := range rb.IsEverythingALanguage {
+		step := &rb.IsEverythingALanguage[i]
+		n := &ArgumentNode{Step: step, Role: argumentStepRoles[optGet(step.StepType, "")], pos: i}
+		g.Nodes = append(g.Nodes, n)
+		g.index[n.ID()] = n
+	}
+
+	arguments := argumentGroups(stepsOf(g.Nodes), func(s *IsEverythingALanguage) string { return optGet(s.ArgumentName, "") })
+	for _, argument := range arguments {
+		if argument.name == "" {
+			continue // steps outside any argument support nothing
+		}
+		var premises, conclusions []*ArgumentNode
+		for _, step := range argument.steps {
+			switch n := g.index[step.IsEverythingALanguageId]; n.Role {
+			case RolePremise:
+				premises = append(premises, n)
+			case RoleConclusion:
+				conclusions = append(conclusions, n)
+			}
+		}
+		for _, c := range conclusions {
+			for _, p := range premises {
+				g.link(p, c)
+			}
+		}
+	}
+
+	// A conclusion cited by a step of another argument is a lemma of it
+	for _, c := range g.Nodes {
+		consequent := conclusionConsequent(c.Step)
+		if c.Role != RoleConclusion || consequent == "" {
+			continue
+		}
+		for _, n := range g.Nodes {
+			if n.Role != "" && optGet(n.Step.ArgumentName, "") != optGet(c.Step.ArgumentName, "") &&
+				strings.Contains(optGet(n.Step.Formalization, ""), consequent) {
+				g.link(c, n)
+				c.Role = RoleLemma
+			}
+		}
+	}
+	byPos := func(a, b *ArgumentNode) int { return a.pos - b.pos }
+	for _, n := range g.Nodes {
+		slices.SortFunc(n.Supports, byPos)
+		slices.SortFunc(n.SupportedBy, byPos)
+	}
+	return g
+}
+
+// link records that from supports to
+func (g *ArgumentGraph) link(from, to *ArgumentNode) {
+	from.Supports = append(from.Supports, to)
+	to.SupportedBy = append(to.SupportedBy, from)
+}
+
+// stepsOf returns the steps of nodes
+func stepsOf(nodes []*ArgumentNode) []*IsEverythingALanguage {
+	steps := make([]*IsEverythingALanguage, len(nodes))
+	for i, n := range nodes {
+		steps[i] = n.Step
+	}
+	return steps
+}
+
+// conclusionConsequent is what a conclusion step establishes: its
+// Formalization after the last ⇒, or all of it
+func conclusionConsequent(step *IsEverythingALanguage) string {
+	f := optGet(step.Formalization, "")
+	if i := strings.LastIndex(f, "⇒"); i >= 0 {
+		f = f[i+len("⇒"):]
+	}
+	return strings.TrimSpace(f)
+}
+
+// Node returns the step with the id, or nil
+func (g *ArgumentGraph) Node(id string) *ArgumentNode {
+	return g.index[id]
+}
+
+// Conclusions returns the final conclusions: conclusions no other step builds on
+func (g *ArgumentGraph) Conclusions() []*ArgumentNode {
+	var conclusions []*ArgumentNode
+	for _, n := range g.Nodes {
+		if n.Role == RoleConclusion {
+			conclusions = append(conclusions, n)
+		}
+	}
+	return conclusions
+}
+
+// ProofChain returns the step with the id and every step it rests on,
+// supporting steps first (in table order among steps at the same depth)
+func (g *ArgumentGraph) ProofChain(id string) (ProofChain, error) {
+	n := g.Node(id)
+	if n == nil {
+		return ProofChain{}, fmt.Errorf("no IsEverythingALanguage step %q", id)
+	}
+	chain := ProofChain{Conclusion: n}
+	visited := map[*ArgumentNode]bool{}
+	var visit func(*ArgumentNode)
+	visit = func(n *ArgumentNode) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		for _, s := range n.SupportedBy {
+			visit(s)
+		}
+		chain.Steps = append(chain.Steps, n)
+	}
+	visit(n)
+	return chain, nil
+}
+
+// ProofChains returns the proof chain of every final conclusion
+func (g *ArgumentGraph) ProofChains() []ProofChain {
+	var chains []ProofChain
+	for _, c := range g.Conclusions() {
+		chain, _ := g.ProofChain(c.ID()) // c is in the graph
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// Orphans returns the steps that neither support nor are supported by any
+// other step: steps outside an argument, of an unknown StepType, or in an
+// argument with no conclusion (or with nothing but a conclusion)
+func (g *ArgumentGraph) Orphans() []*ArgumentNode {
+	var orphans []*ArgumentNode
+	for _, n := range g.Nodes {
+		if len(n.Supports) == 0 && len(n.SupportedBy) == 0 {
+			orphans = append(orphans, n)
+		}
+	}
+	return orphans
+}
+
+// writeChains writes every proof chain, one step per line, then the orphan steps
+func (g *ArgumentGraph) writeChains(w io.Writer) error {
+	var b strings.Builder
+	for _, chain := range g.ProofChains() {
+		fmt.Fprintf(&b, "%s (%s)\n", chain.Conclusion.Label(), optGet(chain.Conclusion.Step.ArgumentName, ""))
+		for i, n := range chain.Steps {
+			fmt.Fprintf(&b, "  %2d. %-10s %s  %s\n", i+1, n.Role, n.Label(), optGet(n.Step.StepType, ""))
+		}
+	}
+	if orphans := g.Orphans(); len(orphans) > 0 {
+		b.WriteString("Orphan steps\n")
+		for _, n := range orphans {
+			fmt.Fprintf(&b, "  %s  %s\n", n.Label(), optGet(n.Step.StepType, ""))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteDOT writes the graph in Graphviz DOT, one cluster per argument, with
+// edges from each step to the steps it supports
+func (g *ArgumentGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph argument {\n  rankdir=BT;\n  node [shape=box];\n")
+	arguments := argumentGroups(stepsOf(g.Nodes), func(s *IsEverythingALanguage) string { return optGet(s.ArgumentName, "") })
+	for i, argument := range arguments {
+		indent := "  "
+		if argument.name != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(argument.name))
+			indent = "    "
+		}
+		for _, step := range argument.steps {
+			n := g.Node(step.IsEverythingALanguageId)
+			label := n.Label()
+			if t := optGet(step.StepType, ""); t != "" {
+				label += "\n" + t
+			}
+			fmt.Fprintf(&b, "%s%s [label=%s%s];\n", indent, dotQuote(n.ID()), dotQuote(label), argumentNodeStyle[n.Role])
+		}
+		if argument.name != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, n := range g.Nodes {
+		for _, s := range n.Supports {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(n.ID()), dotQuote(s.ID()))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// argumentNodeStyle are the DOT attributes of each role's nodes
+var argumentNodeStyle = map[string]string{
+	RoleLemma:      ", style=filled, fillcolor=lightyellow",
+	RoleConclusion: ", style=\"filled,bold\", fillcolor=lightblue",
+	"":             ", style=dashed",
+}
+
+// dotQuote quotes s as a DOT string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
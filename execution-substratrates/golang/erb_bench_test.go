@@ -0,0 +1,156 @@
+// ERB SDK - Calculation Benchmarks
+// ================================
+// Throughput of the generated calculations and of rulebook loading on
+// synthetic datasets of 10k, 100k and 1M candidates (random raw fields, as in
+// the property tests, with unique IDs). BenchmarkComputeAll runs each size on
+// one goroutine and on GOMAXPROCS workers, so the parallel path can be
+// weighed against its overhead:
+//
+//	go test $(ls *.go) -run '^$' -bench .
+//	go test $(ls *.go) -run '^$' -bench 'ComputeAll/n=10000' -benchmem
+//
+// The 1M-candidate rulebook is about 450 MB on disk and takes minutes to
+// load; pass -short to skip the 1M sizes.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// benchmarkSizes are the dataset sizes every benchmark runs
+var benchmarkSizes = []int{10_000, 100_000, 1_000_000}
+
+// benchmarkCandidateCache holds the synthetic candidates of each size
+var benchmarkCandidateCache = map[int][]LanguageCandidate{}
+
+func BenchmarkComputeAll(b *testing.B) {
+	workerCounts := []int{1}
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		workerCounts = append(workerCounts, procs)
+	}
+	for _, n := range benchmarkSizes {
+		for _, workers := range workerCounts {
+			b.Run(fmt.Sprintf("n=%d/workers=%d", n, workers), func(b *testing.B) {
+				skipLargeBenchmark(b, n)
+				records := benchmarkCandidates(n)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					ComputeAllRecords(records, WithWorkers(workers))
+				}
+				b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "records/s")
+			})
+		}
+	}
+}
+
+func BenchmarkLoadRulebook(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			skipLargeBenchmark(b, n)
+			path := writeBenchmarkRulebook(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rb, err := LoadFromRulebook(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(rb.LanguageCandidates) != n {
+					b.Fatalf("loaded %d candidates, want %d", len(rb.LanguageCandidates), n)
+				}
+			}
+			b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "records/s")
+		})
+	}
+}
+
+// skipLargeBenchmark skips the 1M sizes under -short
+func skipLargeBenchmark(b *testing.B, n int) {
+	if testing.Short() && n >= 1_000_000 {
+		b.Skip("1M candidates skipped with -short")
+	}
+}
+
+// benchmarkCandidates returns n synthetic raw candidates, the same on every call
+func benchmarkCandidates(n int) []LanguageCandidate {
+	if records, ok := benchmarkCandidateCache[n]; ok {
+		return records
+	}
+	r := rand.New(rand.NewPCG(uint64(n), 3))
+	records := make([]LanguageCandidate, n)
+	for i := range records {
+		records[i] = randomCandidate(func(n int) int { return r.IntN(n) }, randomWords[r.IntN(len(randomWords))])
+		records[i].LanguageCandidateId = fmt.Sprintf("candidate-%d", i)
+	}
+	benchmarkCandidateCache[n] = records
+	return records
+}
+
+// writeBenchmarkRulebook writes the default rulebook with its candidates
+// replaced by n synthetic ones, streaming the rows to keep memory flat
+func writeBenchmarkRulebook(b *testing.B, n int) string {
+	b.Helper()
+	data, err := os.ReadFile(DefaultRulebookPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		b.Fatal(err)
+	}
+	var table map[string]json.RawMessage
+	if err := json.Unmarshal(doc["LanguageCandidates"], &table); err != nil {
+		b.Fatal(err)
+	}
+	const placeholder = `"ERB_BENCHMARK_ROWS"`
+	table["data"] = json.RawMessage(placeholder)
+	if doc["LanguageCandidates"], err = json.Marshal(table); err != nil {
+		b.Fatal(err)
+	}
+	skeleton, err := json.Marshal(doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	head, tail, _ := bytes.Cut(skeleton, []byte(placeholder))
+
+	path := filepath.Join(b.TempDir(), "effortless-rulebook.json")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	w.Write(head)
+	w.WriteByte('[')
+	raw := rawCandidateFields()
+	for i, c := range benchmarkCandidates(n) {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		row := map[string]any{}
+		v := reflect.ValueOf(c)
+		for _, name := range raw {
+			row[name] = v.FieldByName(name).Interface()
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			b.Fatal(err)
+		}
+		w.Write(data)
+	}
+	w.WriteByte(']')
+	w.Write(tail)
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,385 @@
+// ERB SDK - Bulk Add
+// ==================
+// Turns a brainstormed list of candidate ideas (one per line; blank lines,
+// # comments and list bullets are ignored) into stub records: an ID derived
+// from the name, the name, and the calculated fields computed from them.
+// Every other raw field is left nil and listed in a Markdown review
+// checklist, so curating the new records is a matter of ticking boxes:
+//
+//	erb bulk-add ideas.txt --checklist review.md
+//	erb bulk-add ideas.txt --suggest        # ask an LLM for first guesses
+//
+// With --suggest an LLM (OpenAI's chat API: OPENAI_API_KEY, and
+// OPENAI_BASE_URL for a compatible server) proposes values for the raw
+// fields from their descriptions and the existing records. Suggestions are
+// written to the stubs but stay on the checklist, marked as suggested, until
+// a curator confirms them.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BulkStub is a stub record created by BulkAdd
+type BulkStub struct {
+	Record    Record
+	Suggested map[string]bool // raw fields whose values were suggested
+}
+
+// FieldSuggester proposes raw field values for a new record of a table
+type FieldSuggester interface {
+	Suggest(t *Table, name string) (map[string]any, error)
+}
+
+// listBullet matches a leading list marker such as "- ", "* " or "3. "
+var listBullet = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+
+// ParseIdeaList reads one name per line, skipping blank lines, # comments
+// and repeats (ignoring case), and stripping list bullets
+func ParseIdeaList(r io.Reader) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(listBullet.ReplaceAllString(line, ""))
+		if line == "" || seen[strings.ToLower(line)] {
+			continue
+		}
+		seen[strings.ToLower(line)] = true
+		names = append(names, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read idea list: %w", err)
+	}
+	return names, nil
+}
+
+// BulkAdd returns a stub record of the table for every name that is not
+// already a record's Name, with IDs made unique by a numeric suffix. A nil
+// suggester leaves the raw fields nil; skipped lists the names already present.
+func (rb *Rulebook) BulkAdd(table string, names []string, suggester FieldSuggester) (stubs []BulkStub, skipped []string, err error) {
+	t := rb.Table(table)
+	if t == nil {
+		return nil, nil, unknownTable(table)
+	}
+	if f, ok := t.Field("Name"); !ok || f.IsCalculated() {
+		return nil, nil, fmt.Errorf("%s has no raw Name field", t.Name)
+	}
+
+	ids, existing := map[string]bool{}, map[string]bool{}
+	for _, row := range t.Data {
+		ids[fmt.Sprint(row[t.IDField()])] = true
+		if name, ok := row["Name"].(string); ok {
+			existing[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+
+	for _, name := range names {
+		if existing[strings.ToLower(name)] {
+			skipped = append(skipped, name)
+			continue
+		}
+		existing[strings.ToLower(name)] = true
+
+		id := uniqueSlugID(name, "candidate", func(id string) bool { return ids[id] })
+		ids[id] = true
+
+		row := map[string]any{t.IDField(): id, "Name": name}
+		suggested := map[string]bool{}
+		if suggester != nil {
+			values, err := suggester.Suggest(t, name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("suggesting values for %s: %w", name, err)
+			}
+			for field, v := range values {
+				if f, ok := t.Field(field); ok && f.Datatype == "integer" {
+					if n, ok := v.(float64); ok && n == float64(int(n)) {
+						v = int(n)
+					}
+				}
+				if v == nil || field == "Name" || checkTemplateValue(t, field, v) != nil {
+					continue // an unusable suggestion leaves the field to the curator
+				}
+				row[field] = v
+				suggested[field] = true
+			}
+		}
+
+		computed, err := t.ComputeRow(row)
+		if err != nil {
+			return nil, nil, err
+		}
+		rec := Record{Values: map[string]any{}}
+		for _, f := range t.Schema {
+			v := row[f.Name]
+			if f.IsCalculated() {
+				v, _ = computed.Get(toSnakeCase(f.Name))
+			}
+			if v != nil {
+				rec.Keys = append(rec.Keys, f.Name)
+				rec.Values[f.Name] = v
+			}
+		}
+		stubs = append(stubs, BulkStub{Record: rec, Suggested: suggested})
+	}
+	return stubs, skipped, nil
+}
+
+// ReviewChecklist renders the raw fields each stub still needs as a Markdown
+// checklist; suggested values are listed for confirmation
+func ReviewChecklist(t *Table, stubs []BulkStub) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Review checklist: %d new %s\n", len(stubs), t.Name)
+	for _, stub := range stubs {
+		id := stub.Record.Values[t.IDField()]
+		fmt.Fprintf(&b, "\n## %v (`%v`)\n\n", stub.Record.Values["Name"], id)
+		for _, f := range t.Schema {
+			if f.IsCalculated() || f.Name == t.IDField() || f.Name == "Name" {
+				continue
+			}
+			item := f.Name
+			if stub.Suggested[f.Name] {
+				item += fmt.Sprintf(" (suggested: %s)", pgDiffValue(stub.Record.Values[f.Name]))
+			} else if _, set := stub.Record.Values[f.Name]; set {
+				continue
+			}
+			if f.Description != "" {
+				item += " - " + strings.Join(strings.Fields(f.Description), " ")
+			}
+			fmt.Fprintf(&b, "- [ ] %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// =============================================================================
+// LLM SUGGESTIONS
+// =============================================================================
+
+// OpenAISuggester asks an OpenAI chat model for raw field values
+type OpenAISuggester struct {
+	APIKey  string
+	Model   string // default gpt-4o-mini
+	BaseURL string // default https://api.openai.com/v1
+	Client  *http.Client
+}
+
+// Suggest implements FieldSuggester
+func (s *OpenAISuggester) Suggest(t *Table, name string) (map[string]any, error) {
+	model, baseURL, client := s.Model, s.BaseURL, s.Client
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"model":           model,
+		"messages":        []map[string]string{{"role": "user", "content": suggestionPrompt(t, name)}},
+		"temperature":     0.1,
+		"response_format": map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(commandContext(), http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the LLM: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the LLM response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LLM request failed: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &completion); err != nil || len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("unexpected LLM response: %s", bytes.TrimSpace(data))
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &values); err != nil {
+		return nil, fmt.Errorf("LLM did not answer with a JSON object: %w", err)
+	}
+	return values, nil
+}
+
+// suggestionPrompt describes the table's raw fields and shows the existing
+// records as examples
+func suggestionPrompt(t *Table, name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are helping curate the %s table of a rulebook.", t.Name)
+	if t.Description != "" {
+		fmt.Fprintf(&b, " %s", strings.Join(strings.Fields(t.Description), " "))
+	}
+	fmt.Fprintf(&b, "\n\nSuggest values for a new record named %q. Answer with a JSON object mapping field names to values (use null when unsure). The fields are:\n\n", name)
+	var raw []string
+	for _, f := range t.Schema {
+		if f.IsCalculated() || f.Name == t.IDField() || f.Name == "Name" {
+			continue
+		}
+		raw = append(raw, f.Name)
+		fmt.Fprintf(&b, "- %s (%s)", f.Name, f.Datatype)
+		if f.Description != "" {
+			fmt.Fprintf(&b, ": %s", strings.Join(strings.Fields(f.Description), " "))
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("\nExisting records:\n\n")
+	for _, row := range t.Data {
+		example := map[string]any{"Name": row["Name"]}
+		for _, field := range raw {
+			example[field] = row[field]
+		}
+		line, _ := json.Marshal(example)
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+	return b.String()
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runBulkAdd implements `bulk-add FILE [--rulebook PATH] [--table T]
+// [--suggest] [--model M] [--checklist FILE] [--dry-run]`
+func runBulkAdd(args []string) error {
+	const usage = "usage: bulk-add FILE [flags]"
+	fs := flag.NewFlagSet("bulk-add", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the JSON rulebook to edit")
+	table := fs.String("table", "LanguageCandidates", "table to add the records to")
+	suggest := fs.Bool("suggest", false, "ask an LLM (OPENAI_API_KEY) for first guesses at the raw fields")
+	model := fs.String("model", "gpt-4o-mini", "model used by --suggest")
+	checklistPath := fs.String("checklist", "", "write the review checklist to this file instead of stdout")
+	dryRun := fs.Bool("dry-run", false, "print the stub records instead of editing the rulebook")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+
+	// FILE comes first; flags may follow it
+	var positional []string
+	rest := args
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 {
+		return fmt.Errorf(usage)
+	}
+
+	list, err := os.Open(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to open idea list: %w", err)
+	}
+	names, err := ParseIdeaList(list)
+	list.Close()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*rulebookPath)
+	if err != nil {
+		return rulebookReadError(err)
+	}
+	if DetectRulebookFormat(*rulebookPath, data) != FormatJSON {
+		return fmt.Errorf("bulk-add needs a JSON rulebook")
+	}
+	rb, err := ParseRulebook(data)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var suggester FieldSuggester
+	if *suggest {
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return fmt.Errorf("--suggest needs OPENAI_API_KEY")
+		}
+		suggester = &OpenAISuggester{APIKey: key, Model: *model, BaseURL: os.Getenv("OPENAI_BASE_URL")}
+	}
+	stubs, skipped, err := rb.BulkAdd(*table, names, suggester)
+	if err != nil {
+		return err
+	}
+	for _, name := range skipped {
+		fmt.Fprintf(os.Stderr, "note: %s is already in %s, skipped\n", name, *table)
+	}
+	if len(stubs) == 0 {
+		fmt.Fprintln(os.Stderr, "No new records")
+		return nil
+	}
+
+	if *dryRun {
+		records := make([]Record, len(stubs))
+		for i, stub := range stubs {
+			records[i] = stub.Record
+		}
+		out, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	edited := data
+	for _, stub := range stubs {
+		if edited, err = appendTableEntry(edited, *table, "data", stub.Record); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseRulebook(edited); err != nil {
+		return fmt.Errorf("edited rulebook does not load: %w", err)
+	}
+	if err := os.WriteFile(*rulebookPath, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write rulebook: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Added %d stub records to %s in %s\n", len(stubs), *table, *rulebookPath)
+
+	checklist := ReviewChecklist(rb.Table(*table), stubs)
+	if *checklistPath == "" {
+		fmt.Print(checklist)
+		return nil
+	}
+	if err := os.WriteFile(*checklistPath, []byte(checklist), 0644); err != nil {
+		return fmt.Errorf("failed to write checklist: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Review checklist: %s\n", *checklistPath)
+	return nil
+}
@@ -0,0 +1,201 @@
+// ERB SDK - Canonical JSON
+// ========================
+// Every JSON file the SDK writes (SaveRecords, TypedTable.Save, the json
+// exporter, published snapshots) goes through MarshalCanonical, so the same
+// records always serialize to the same bytes and diffs between runs and
+// substrates show only real changes:
+//
+//   - objects keep their field order (struct declaration order, Record key
+//     order, sorted map keys), or every object's keys are sorted with
+//     WithSortedKeys
+//   - nil values are written as explicit nulls
+//   - two-space indent, no HTML escaping (<, > and & are written as is),
+//     numbers as encoding/json writes them
+//   - a trailing newline
+//
+//	data, err := MarshalCanonical(records)
+//	data, err = MarshalCanonical(records, WithSortedKeys())
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// canonicalIndent is the indent of one nesting level
+const canonicalIndent = "  "
+
+// CanonicalOption configures MarshalCanonical
+type CanonicalOption func(*canonicalConfig)
+
+type canonicalConfig struct {
+	sortKeys bool
+}
+
+// WithSortedKeys sorts the keys of every object instead of keeping field order
+func WithSortedKeys() CanonicalOption {
+	return func(c *canonicalConfig) { c.sortKeys = true }
+}
+
+// MarshalCanonical encodes v as canonical JSON (see the file comment)
+func MarshalCanonical(v any, opts ...CanonicalOption) ([]byte, error) {
+	var cfg canonicalConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// Marshal once with encoding/json (so MarshalJSON and struct tags apply),
+	// then re-read the document keeping each object's key order
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	doc, err := readCanonical(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-read JSON: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := writeCanonical(&b, doc, 0, cfg); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// WriteCanonical writes v to w as canonical JSON
+func WriteCanonical(w io.Writer, v any, opts ...CanonicalOption) error {
+	data, err := MarshalCanonical(v, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readCanonical reads one JSON value: objects become Records (keys in
+// document order), arrays []any, numbers json.Number
+func readCanonical(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		rec := Record{Keys: []string{}, Values: map[string]any{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			val, err := readCanonical(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := rec.Values[key]; !dup {
+				rec.Keys = append(rec.Keys, key)
+			}
+			rec.Values[key] = val
+		}
+		_, err := dec.Token()
+		return rec, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			val, err := readCanonical(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, val)
+		}
+		_, err := dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// writeCanonical writes a value read by readCanonical at the given depth
+func writeCanonical(b *bytes.Buffer, v any, depth int, cfg canonicalConfig) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		b.WriteString(v.String())
+	case string:
+		return writeCanonicalString(b, v)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			canonicalNewline(b, depth+1)
+			if err := writeCanonical(b, item, depth+1, cfg); err != nil {
+				return err
+			}
+		}
+		canonicalNewline(b, depth)
+		b.WriteByte(']')
+	case Record:
+		if len(v.Keys) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		keys := v.Keys
+		if cfg.sortKeys {
+			keys = slices.Sorted(slices.Values(keys))
+		}
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			canonicalNewline(b, depth+1)
+			if err := writeCanonicalString(b, k); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := writeCanonical(b, v.Values[k], depth+1, cfg); err != nil {
+				return err
+			}
+		}
+		canonicalNewline(b, depth)
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value %T", v)
+	}
+	return nil
+}
+
+// writeCanonicalString writes a JSON string without HTML escaping
+func writeCanonicalString(b *bytes.Buffer, s string) error {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	b.WriteString(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+// canonicalNewline starts a new line indented to depth
+func canonicalNewline(b *bytes.Buffer, depth int) {
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(canonicalIndent, depth))
+}
@@ -0,0 +1,188 @@
+// ERB SDK - Capability Discovery
+// ==============================
+// Describes what this SDK build supports, so orchestration tooling and other
+// substrates can adapt to whichever version is installed instead of parsing
+// --help: the rulebook's schema, its tables, the import, export and rulebook
+// formats, the formula functions, the CLI commands, and optional features.
+//
+//	capabilities                     # human-readable summary
+//	capabilities --json | jq .formula_functions
+//	curl localhost:8080/capabilities # the same JSON from `serve`
+//
+// Features that need an external tool (age, sqlite3, psql) are reported as
+// available only when the tool is on PATH.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Capabilities is what this SDK build supports for a rulebook
+type Capabilities struct {
+	SDK              string             `json:"sdk"`
+	Schema           SchemaCapability   `json:"schema"`
+	Tables           []TableCapability  `json:"tables"`
+	Importers        []FormatCapability `json:"importers"`
+	Exporters        []FormatCapability `json:"exporters"`
+	RulebookFormats  []FormatCapability `json:"rulebook_formats"`
+	FormulaFunctions []FormulaFunction  `json:"formula_functions"`
+	Commands         []string           `json:"commands"`
+	Features         map[string]bool    `json:"features"`
+	// ExperimentalFlags are the experimental semantics in effect (see erb_flags.go)
+	ExperimentalFlags FeatureFlags `json:"experimental_flags"`
+}
+
+// SchemaCapability identifies the rulebook schema
+type SchemaCapability struct {
+	URI       string `json:"uri"`
+	ModelName string `json:"model_name"`
+	// GeneratedCurrent is false when erb_sdk.go was generated from a
+	// different schema (see check-generated)
+	GeneratedCurrent bool `json:"generated_current"`
+}
+
+// TableCapability lists a table's fields
+type TableCapability struct {
+	Name       string   `json:"name"`
+	Records    int      `json:"records"`
+	Fields     []string `json:"fields"`
+	Calculated []string `json:"calculated"`
+}
+
+// FormatCapability is a file format and the extensions it is picked by
+type FormatCapability struct {
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`
+}
+
+// cliCommands are the CLI command names; set in init, since the functions
+// the commands map holds may not refer back to it
+var cliCommands []string
+
+func init() {
+	cliCommands = sortedKeys(commands)
+}
+
+// Capabilities describes what this SDK build supports for the rulebook
+func (rb *Rulebook) Capabilities() Capabilities {
+	c := Capabilities{
+		SDK: "golang",
+		Schema: SchemaCapability{
+			URI:              rb.SchemaURI,
+			ModelName:        rb.ModelName,
+			GeneratedCurrent: len(rb.GeneratedDrift(GeneratedFieldHashes)) == 0,
+		},
+		RulebookFormats: []FormatCapability{
+			{Name: "json", Extensions: []string{".json"}},
+			{Name: "yaml", Extensions: []string{".yaml", ".yml"}},
+			{Name: "sqlite", Extensions: []string{".sqlite", ".sqlite3", ".db"}},
+		},
+		FormulaFunctions: FormulaFunctions,
+		Commands:         cliCommands,
+		Features: map[string]bool{
+			"graphql":   true,
+			"grpc":      true,
+			"snapshots": true,
+			"age":       onPath(AgeExecutable),
+			"sqlite":    onPath(SQLiteExecutable),
+			"pgsync":    onPath("psql"),
+		},
+		ExperimentalFlags: ActiveFlags(),
+	}
+	for _, t := range rb.Tables {
+		tc := TableCapability{Name: t.Name, Records: len(t.Data), Fields: []string{}, Calculated: []string{}}
+		for _, f := range t.Schema {
+			tc.Fields = append(tc.Fields, f.Name)
+			if f.IsCalculated() {
+				tc.Calculated = append(tc.Calculated, f.Name)
+			}
+		}
+		c.Tables = append(c.Tables, tc)
+	}
+	for _, i := range Importers() {
+		c.Importers = append(c.Importers, FormatCapability{Name: i.Name(), Extensions: append([]string{}, i.Extensions()...)})
+	}
+	for _, e := range Exporters() {
+		c.Exporters = append(c.Exporters, FormatCapability{Name: e.Name(), Extensions: append([]string{}, e.Extensions()...)})
+	}
+	return c
+}
+
+// onPath reports whether an executable can be found
+func onPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runCapabilities implements `capabilities [--rulebook PATH] [--json]`
+func runCapabilities(args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path or URL of the rulebook")
+	asJSON := fs.Bool("json", false, "print the capabilities as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+	c := rb.Capabilities()
+
+	if *asJSON {
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("SDK:       %s\n", c.SDK)
+	fmt.Printf("Schema:    %s (%s)", c.Schema.URI, c.Schema.ModelName)
+	if !c.Schema.GeneratedCurrent {
+		fmt.Print(" - generated code is stale")
+	}
+	fmt.Println()
+	fmt.Println("Tables:")
+	for _, t := range c.Tables {
+		fmt.Printf("  %-24s %d records, %d fields (%d calculated)\n", t.Name, t.Records, len(t.Fields), len(t.Calculated))
+	}
+	fmt.Printf("Importers: %s\n", formatNames(c.Importers))
+	fmt.Printf("Exporters: %s\n", formatNames(c.Exporters))
+	fmt.Printf("Rulebooks: %s\n", formatNames(c.RulebookFormats))
+	var functions []string
+	for _, f := range c.FormulaFunctions {
+		functions = append(functions, f.Name)
+	}
+	fmt.Printf("Functions: %s\n", strings.Join(functions, ", "))
+	fmt.Printf("Commands:  %s\n", strings.Join(c.Commands, ", "))
+	fmt.Printf("Semantics: %s\n", c.ExperimentalFlags)
+	fmt.Println("Features:")
+	for _, name := range sortedKeys(c.Features) {
+		state := "available"
+		if !c.Features[name] {
+			state = "unavailable"
+		}
+		fmt.Printf("  %-10s %s\n", name, state)
+	}
+	return nil
+}
+
+// formatNames joins format names for display
+func formatNames(formats []FormatCapability) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
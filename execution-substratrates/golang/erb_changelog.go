@@ -0,0 +1,370 @@
+// ERB SDK - Changelog
+// ===================
+// Compares two rulebook snapshots (git tags/revisions) and renders a
+// human-readable Markdown changelog of data and formula changes per table.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RulebookRepoPath is the rulebook location relative to the repository root
+const RulebookRepoPath = "effortless-rulebook/effortless-rulebook.json"
+
+// =============================================================================
+// DIFF TYPES
+// =============================================================================
+
+// SchemaChange describes a field added, removed, or redefined in a table schema
+type SchemaChange struct {
+	Field string
+	Kind  string // "added", "removed", "formula", "datatype"
+	Old   string
+	New   string
+}
+
+// ValueChange describes one field of one record whose value changed
+type ValueChange struct {
+	RecordID string
+	Record   string
+	Field    string
+	Old      any
+	New      any
+}
+
+// TableChanges groups every change to a single table between two snapshots
+type TableChanges struct {
+	Table           string
+	SchemaChanges   []SchemaChange
+	Added           []string
+	Removed         []string
+	CriteriaFlipped []ValueChange
+	OutcomesChanged []ValueChange
+	ValuesChanged   []ValueChange
+}
+
+// IsEmpty reports whether the table has no changes at all
+func (tc *TableChanges) IsEmpty() bool {
+	return len(tc.SchemaChanges) == 0 && len(tc.Added) == 0 && len(tc.Removed) == 0 &&
+		len(tc.CriteriaFlipped) == 0 && len(tc.OutcomesChanged) == 0 && len(tc.ValuesChanged) == 0
+}
+
+// =============================================================================
+// DIFFING
+// =============================================================================
+
+// DiffRulebooks compares two rulebooks table by table.
+// Raw boolean changes are criteria flips, calculated field changes are outcome changes.
+func DiffRulebooks(from, to *Rulebook) []TableChanges {
+	var names []string
+	seen := map[string]bool{}
+	for _, rb := range []*Rulebook{to, from} {
+		for _, t := range rb.Tables {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				names = append(names, t.Name)
+			}
+		}
+	}
+
+	var changes []TableChanges
+	for _, name := range names {
+		oldTable, newTable := from.Table(name), to.Table(name)
+		if oldTable == nil {
+			oldTable = &Table{Name: name}
+		}
+		if newTable == nil {
+			newTable = &Table{Name: name}
+		}
+		changes = append(changes, diffTable(oldTable, newTable))
+	}
+	return changes
+}
+
+func diffTable(from, to *Table) TableChanges {
+	tc := TableChanges{Table: to.Name}
+
+	// Schema and formula changes
+	for _, nf := range to.Schema {
+		of, ok := from.Field(nf.Name)
+		switch {
+		case !ok:
+			tc.SchemaChanges = append(tc.SchemaChanges, SchemaChange{Field: nf.Name, Kind: "added", New: describeField(nf)})
+		case of.Formula != nf.Formula:
+			tc.SchemaChanges = append(tc.SchemaChanges, SchemaChange{Field: nf.Name, Kind: "formula", Old: of.Formula, New: nf.Formula})
+		case of.Datatype != nf.Datatype || of.Type != nf.Type:
+			tc.SchemaChanges = append(tc.SchemaChanges, SchemaChange{Field: nf.Name, Kind: "datatype", Old: describeField(of), New: describeField(nf)})
+		}
+	}
+	for _, of := range from.Schema {
+		if _, ok := to.Field(of.Name); !ok {
+			tc.SchemaChanges = append(tc.SchemaChanges, SchemaChange{Field: of.Name, Kind: "removed", Old: describeField(of)})
+		}
+	}
+
+	// Record changes, matched by primary key
+	idField := to.IDField()
+	if idField == "" {
+		idField = from.IDField()
+	}
+	oldRows := indexRows(from.Data, idField)
+	newRows := indexRows(to.Data, idField)
+
+	for _, row := range to.Data {
+		id := fmt.Sprint(row[idField])
+		old, ok := oldRows[id]
+		if !ok {
+			tc.Added = append(tc.Added, recordLabel(row, idField))
+			continue
+		}
+		for _, f := range to.Schema {
+			if f.Name == idField || valuesEqual(old[f.Name], row[f.Name]) {
+				continue
+			}
+			if _, existed := from.Field(f.Name); !existed {
+				continue
+			}
+			vc := ValueChange{RecordID: id, Record: recordLabel(row, idField), Field: f.Name, Old: old[f.Name], New: row[f.Name]}
+			switch {
+			case f.IsCalculated():
+				tc.OutcomesChanged = append(tc.OutcomesChanged, vc)
+			case f.Datatype == "boolean":
+				tc.CriteriaFlipped = append(tc.CriteriaFlipped, vc)
+			default:
+				tc.ValuesChanged = append(tc.ValuesChanged, vc)
+			}
+		}
+	}
+	for _, row := range from.Data {
+		if _, ok := newRows[fmt.Sprint(row[idField])]; !ok {
+			tc.Removed = append(tc.Removed, recordLabel(row, idField))
+		}
+	}
+
+	sort.Strings(tc.Added)
+	sort.Strings(tc.Removed)
+	return tc
+}
+
+func indexRows(rows []map[string]any, idField string) map[string]map[string]any {
+	index := make(map[string]map[string]any, len(rows))
+	for _, row := range rows {
+		index[fmt.Sprint(row[idField])] = row
+	}
+	return index
+}
+
+// recordLabel returns the record's Name, falling back to its ID
+func recordLabel(row map[string]any, idField string) string {
+	if name, ok := row["Name"].(string); ok && name != "" {
+		return name
+	}
+	return fmt.Sprint(row[idField])
+}
+
+func describeField(f Field) string {
+	if f.IsCalculated() {
+		return fmt.Sprintf("%s (calculated): %s", f.Datatype, f.Formula)
+	}
+	return f.Datatype
+}
+
+// valuesEqual compares decoded JSON values, treating a missing key as null
+func valuesEqual(a, b any) bool {
+	return formatValue(a) == formatValue(b)
+}
+
+// formatValue renders a decoded JSON value for display
+func formatValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+// =============================================================================
+// RENDERING
+// =============================================================================
+
+// RenderChangelog renders table changes as a Markdown changelog
+func RenderChangelog(fromLabel, toLabel string, changes []TableChanges) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Rulebook Changelog: %s → %s\n", fromLabel, toLabel)
+
+	empty := true
+	for _, tc := range changes {
+		if tc.IsEmpty() {
+			continue
+		}
+		empty = false
+
+		fmt.Fprintf(&b, "\n## %s\n", tc.Table)
+
+		if len(tc.SchemaChanges) > 0 {
+			b.WriteString("\n### Schema & Formulas\n\n")
+			for _, sc := range tc.SchemaChanges {
+				switch sc.Kind {
+				case "added":
+					fmt.Fprintf(&b, "- Added field `%s` — %s\n", sc.Field, oneLine(sc.New))
+				case "removed":
+					fmt.Fprintf(&b, "- Removed field `%s`\n", sc.Field)
+				case "formula":
+					fmt.Fprintf(&b, "- Formula for `%s` changed\n  - was: `%s`\n  - now: `%s`\n", sc.Field, oneLine(sc.Old), oneLine(sc.New))
+				default:
+					fmt.Fprintf(&b, "- Type of `%s` changed: %s → %s\n", sc.Field, oneLine(sc.Old), oneLine(sc.New))
+				}
+			}
+		}
+
+		writeRecordList(&b, "Records Added", tc.Added)
+		writeRecordList(&b, "Records Removed", tc.Removed)
+		writeValueChanges(&b, "Criteria Flipped", tc.CriteriaFlipped)
+		writeValueChanges(&b, "Outcomes Changed", tc.OutcomesChanged)
+		writeValueChanges(&b, "Other Field Changes", tc.ValuesChanged)
+	}
+
+	if empty {
+		b.WriteString("\nNo changes.\n")
+	}
+	return b.String()
+}
+
+func writeRecordList(b *strings.Builder, title string, records []string) {
+	if len(records) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", title, len(records))
+	for _, r := range records {
+		fmt.Fprintf(b, "- %s\n", r)
+	}
+}
+
+func writeValueChanges(b *strings.Builder, title string, changes []ValueChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", title, len(changes))
+	for _, vc := range changes {
+		fmt.Fprintf(b, "- **%s**: `%s` %s → %s\n", vc.Record, vc.Field, oneLine(formatValue(vc.Old)), oneLine(formatValue(vc.New)))
+	}
+}
+
+// oneLine collapses whitespace so multi-line formulas fit in a list item
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// =============================================================================
+// SNAPSHOT LOADING
+// =============================================================================
+
+// LoadRulebookAtRevision loads the rulebook as committed at a git tag or revision
+func LoadRulebookAtRevision(ctx context.Context, rev string) (*Rulebook, error) {
+	out, err := subprocess(ctx, "git", "show", rev+":"+RulebookRepoPath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rulebook at %s: %w", rev, err)
+	}
+	return ParseRulebook(out)
+}
+
+// ParseRevisionRange splits "v1..v2" into its endpoints; an empty end means the working tree
+func ParseRevisionRange(spec string) (string, string, error) {
+	from, to, ok := strings.Cut(spec, "..")
+	if !ok || from == "" {
+		return "", "", fmt.Errorf("invalid range %q (expected v1..v2)", spec)
+	}
+	return from, to, nil
+}
+
+// Changelog builds the Markdown changelog for a git revision range such as "v1..v2"
+func Changelog(ctx context.Context, spec string) (string, error) {
+	return changelogWith(spec, "working tree", func(rev string) (*Rulebook, error) {
+		if rev == "" {
+			return LoadFromRulebookContext(ctx, filepath.FromSlash(DefaultRulebookPath))
+		}
+		return LoadRulebookAtRevision(ctx, rev)
+	})
+}
+
+// Changelog builds the Markdown changelog between two published versions;
+// an empty end version means the latest snapshot
+func (r *SnapshotReader) Changelog(ctx context.Context, spec string) (string, error) {
+	return changelogWith(spec, LatestVersion, func(version string) (*Rulebook, error) {
+		if version == "" {
+			version = LatestVersion
+		}
+		return r.LoadRulebook(ctx, version)
+	})
+}
+
+func changelogWith(spec, openEndLabel string, load func(version string) (*Rulebook, error)) (string, error) {
+	fromRev, toRev, err := ParseRevisionRange(spec)
+	if err != nil {
+		return "", err
+	}
+
+	from, err := load(fromRev)
+	if err != nil {
+		return "", err
+	}
+	to, err := load(toRev)
+	if err != nil {
+		return "", err
+	}
+
+	toLabel := toRev
+	if toLabel == "" {
+		toLabel = openEndLabel
+	}
+	return RenderChangelog(fromRev, toLabel, DiffRulebooks(from, to)), nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runChangelog implements `changelog [--out FILE] [--snapshots DIR|URL] v1..v2`
+func runChangelog(args []string) error {
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	out := fs.String("out", "", "write the changelog to this file instead of stdout")
+	snapshots := fs.String("snapshots", "", "resolve versions from a publish destination instead of git tags")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: changelog [--out FILE] [--snapshots DIR|URL] v1..v2")
+	}
+
+	var md string
+	var err error
+	if *snapshots != "" {
+		md, err = OpenSnapshots(*snapshots).Changelog(commandContext(), fs.Arg(0))
+	} else {
+		md, err = Changelog(commandContext(), fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Print(md)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(md), 0644); err != nil {
+		return fmt.Errorf("failed to write changelog: %w", err)
+	}
+	fmt.Printf("Wrote changelog to %s\n", *out)
+	return nil
+}
@@ -0,0 +1,116 @@
+// ERB SDK - Compute with Scenarios
+// ================================
+// `compute` computes one table's blank test with what-if scenarios laid over
+// it: each scenario file holds partial records (the id plus the fields that
+// change) merged into the records with the same id before computing, as a
+// pipeline overlay step does. Scenario files may be age-encrypted (.age; see
+// erb_age.go):
+//
+//	compute --scenario scenarios/no-syntax.json
+//	compute --scenario drafts.json.age --identity key.txt --out what-if.csv
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ComputeScenario computes a table's records with the scenarios overlaid in order
+func ComputeScenario(table string, records []Record, scenarios ...[]Record) ([]Record, error) {
+	records = append([]Record(nil), records...)
+	for i, scenario := range scenarios {
+		if err := OverlayRecords(table, records, scenario); err != nil {
+			return nil, fmt.Errorf("scenario %d: %w", i+1, err)
+		}
+	}
+	return computeTable(table, records)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runCompute implements `compute [--table NAME] [--input FILE] [--scenario
+// FILE]... [--identity KEY] [--out FILE] [--format NAME]`
+func runCompute(args []string) error {
+	fs := flag.NewFlagSet("compute", flag.ContinueOnError)
+	table := fs.String("table", RunnerTables[0].Table, "table to compute")
+	input := fs.String("input", "", "records to compute (default: the table's blank test in "+DefaultTestingDir+")")
+	identity := fs.String("identity", "", "age key file for .age inputs and scenarios (default: $ERB_AGE_IDENTITY)")
+	out := fs.String("out", "", "file to write the computed records to (default: stdout as JSON)")
+	format := fs.String("format", "", "output format (default: from --out's extension, else json)")
+	var scenarios stringList
+	fs.Var(&scenarios, "scenario", "scenario file to overlay before computing (repeatable; .age files are decrypted)")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: compute [--table NAME] [--input FILE] [--scenario FILE]... [--identity KEY] [--out FILE] [--format NAME]")
+	}
+
+	if *input == "" {
+		for _, t := range RunnerTables {
+			if t.Table == *table {
+				*input = filepath.Join(DefaultTestingDir, t.Input)
+			}
+		}
+		if *input == "" {
+			return fmt.Errorf("table %q has no calculated fields", *table)
+		}
+	}
+	key := AgeIdentity(*identity)
+
+	raw, err := readRecordFile(*input, "", key)
+	if err != nil {
+		return err
+	}
+	records, err := ImportRecords(*table, raw)
+	if err != nil {
+		return fmt.Errorf("invalid records in %s:\n%w", *input, err)
+	}
+	var overlays [][]Record
+	for _, path := range scenarios {
+		scenario, err := readRecordFile(path, "", key)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, scenario)
+	}
+	computed, err := ComputeScenario(*table, records, overlays...)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		name := *format
+		if name == "" {
+			name = "json"
+		}
+		e, ok := LookupExporter(name)
+		if !ok {
+			return fmt.Errorf("unknown format %q", name)
+		}
+		if err := e.Write(ExportViews{Table: *table, Records: computed}, os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	}
+	if *format == "" {
+		e, ok := ExporterForPath(*out)
+		if !ok {
+			return fmt.Errorf("no exporter for %s (use --format)", *out)
+		}
+		*format = e.Name()
+	}
+	if err := WriteOutput(OutputTarget{Format: *format, Path: *out}, *table, computed); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Computed %d %s records with %d scenario(s) to %s\n", len(computed), *table, len(scenarios), *out)
+	return nil
+}
@@ -0,0 +1,353 @@
+// ERB SDK - Flag Conflicts
+// ========================
+// Some calculated fields flag records whose raw flags contradict each other,
+// e.g. IsOpenClosedWorldConflicted for a candidate marked both IsOpenWorld
+// and IsClosedWorld. The formulas can only append a warning to the mismatch
+// message; `conflicts` reports them and `resolve-conflicts` walks through
+// them, asking which flag to keep and why:
+//
+//	LanguageCandidates falsifier-c (Falsifier C): IsOpenWorld and IsClosedWorld are both set
+//	Keep [1] IsOpenWorld, [2] IsClosedWorld, [s]kip or [q]uit? 2
+//	Rationale: A type system has no unknowns
+//
+// A resolution clears the other flags in the JSON rulebook (only those
+// values change in the file) and is appended, with its rationale, to the
+// resolution log next to it (conflict-resolutions.json).
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlagConflict is a calculated field that is true when more than one of a
+// set of mutually exclusive raw flags is set
+type FlagConflict struct {
+	Table   string
+	Field   string   // the calculated field flagging the conflict
+	Choices []string // the raw flags only one of which may be set
+}
+
+// flagConflicts lists the rulebook's conflict fields
+var flagConflicts = []FlagConflict{
+	{Table: "LanguageCandidates", Field: "IsOpenClosedWorldConflicted", Choices: []string{"IsOpenWorld", "IsClosedWorld"}},
+}
+
+// Conflict is one record flagged by a FlagConflict
+type Conflict struct {
+	Table   string   `json:"table"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Field   string   `json:"field"`
+	Choices []string `json:"choices"` // the flags that are set
+}
+
+func (c Conflict) String() string {
+	name := ""
+	if c.Name != "" {
+		name = " (" + c.Name + ")"
+	}
+	return fmt.Sprintf("%s %s%s: %s are both set", c.Table, c.ID, name, strings.Join(c.Choices, " and "))
+}
+
+// ConflictResolution records which flag was kept for a conflict, and why
+type ConflictResolution struct {
+	Table      string    `json:"table"`
+	ID         string    `json:"id"`
+	Field      string    `json:"field"`
+	Kept       string    `json:"kept"`
+	Cleared    []string  `json:"cleared"`
+	Rationale  string    `json:"rationale"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// Conflicts returns the records whose conflict fields compute to true
+func (rb *Rulebook) Conflicts() ([]Conflict, error) {
+	var conflicts []Conflict
+	for _, fc := range flagConflicts {
+		t := rb.Table(fc.Table)
+		if t == nil {
+			continue
+		}
+		if _, ok := t.Field(fc.Field); !ok {
+			continue
+		}
+		computed, err := t.Compute()
+		if err != nil {
+			return nil, err
+		}
+		for i, rec := range computed {
+			if flagged, _ := rec.Get(toSnakeCase(fc.Field)); flagged != true {
+				continue
+			}
+			c := Conflict{Table: t.Name, ID: recordID(rec), Field: fc.Field}
+			if name, ok := t.Data[i]["Name"].(string); ok {
+				c.Name = name
+			}
+			for _, choice := range fc.Choices {
+				if runtimeValue(t.Data[i][choice]) == true {
+					c.Choices = append(c.Choices, choice)
+				}
+			}
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts, nil
+}
+
+// ConflictResolutionsPath is the resolution log of a rulebook
+func ConflictResolutionsPath(rulebookPath string) string {
+	return filepath.Join(filepath.Dir(rulebookPath), "conflict-resolutions.json")
+}
+
+// LoadConflictResolutions reads a resolution log; a missing log is empty
+func LoadConflictResolutions(path string) ([]ConflictResolution, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var resolutions []ConflictResolution
+	if err := json.Unmarshal(data, &resolutions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return resolutions, nil
+}
+
+// SetRecordValue returns a JSON rulebook with one raw value replaced: field
+// of the table's record whose idField is id. Only the value's text differs
+// from data. The field must already be present in the record.
+func SetRecordValue(data []byte, table, idField, id, field string, value any) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	if err := seekKey(dec, table); err != nil {
+		return nil, err
+	}
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	if err := seekKey(dec, "data"); err != nil {
+		return nil, fmt.Errorf("table %s: %w", table, err)
+	}
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, err
+		}
+		var idValue any
+		start, end := -1, -1
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse rulebook: %w", err)
+			}
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("failed to parse rulebook: %w", err)
+			}
+			if key == field {
+				end = int(dec.InputOffset())
+				start = end - len(raw)
+			}
+			if key == idField {
+				json.Unmarshal(raw, &idValue)
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("failed to parse rulebook: %w", err)
+		}
+		if fmt.Sprint(idValue) != id {
+			continue
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("%s %s has no %s value", table, id, field)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		out.Write(data[:start])
+		out.Write(encoded)
+		out.Write(data[end:])
+		return out.Bytes(), nil
+	}
+	return nil, fmt.Errorf("no %s record %q", table, id)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runConflicts implements `conflicts [--rulebook PATH] [--json]`: lists
+// open conflicts and how many were resolved before
+func runConflicts(args []string) error {
+	fs := flag.NewFlagSet("conflicts", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	asJSON := fs.Bool("json", false, "print the conflicts as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	conflicts, err := rb.Conflicts()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(append([]Conflict{}, conflicts...))
+	}
+
+	for _, c := range conflicts {
+		fmt.Println(c)
+	}
+	logPath := ConflictResolutionsPath(*rulebookPath)
+	resolutions, err := LoadConflictResolutions(logPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d open conflicts, %d resolved (%s)\n", len(conflicts), len(resolutions), logPath)
+	return nil
+}
+
+// runResolveConflicts implements `resolve-conflicts [--rulebook PATH]
+// [--log FILE]`: prompts on stdin for every open conflict
+func runResolveConflicts(args []string) error {
+	fs := flag.NewFlagSet("resolve-conflicts", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the JSON rulebook to edit")
+	logPath := fs.String("log", "", "resolution log (default: conflict-resolutions.json next to the rulebook)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *logPath == "" {
+		*logPath = ConflictResolutionsPath(*rulebookPath)
+	}
+
+	data, err := os.ReadFile(*rulebookPath)
+	if err != nil {
+		return rulebookReadError(err)
+	}
+	if DetectRulebookFormat(*rulebookPath, data) != FormatJSON {
+		return fmt.Errorf("resolving conflicts needs a JSON rulebook")
+	}
+	rb, err := ParseRulebook(data)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+	conflicts, err := rb.Conflicts()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("No open conflicts")
+		return nil
+	}
+
+	in := bufio.NewScanner(os.Stdin)
+	ask := func(prompt string) (string, error) {
+		fmt.Print(prompt)
+		if !in.Scan() {
+			if err := in.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return strings.TrimSpace(in.Text()), nil
+	}
+
+	var resolved []ConflictResolution
+prompts:
+	for _, c := range conflicts {
+		fmt.Println(c)
+		var options []string
+		for i, choice := range c.Choices {
+			options = append(options, fmt.Sprintf("[%d] %s", i+1, choice))
+		}
+		var kept string
+		for kept == "" {
+			answer, err := ask("Keep " + strings.Join(options, ", ") + ", [s]kip or [q]uit? ")
+			if err == io.EOF || answer == "q" {
+				break prompts
+			}
+			if err != nil {
+				return err
+			}
+			if answer == "s" {
+				continue prompts
+			}
+			if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(c.Choices) {
+				kept = c.Choices[n-1]
+			}
+		}
+		var rationale string
+		for rationale == "" {
+			if rationale, err = ask("Rationale: "); err == io.EOF {
+				break prompts
+			} else if err != nil {
+				return err
+			}
+		}
+
+		r := ConflictResolution{Table: c.Table, ID: c.ID, Field: c.Field, Kept: kept, Rationale: rationale, ResolvedAt: time.Now().UTC()}
+		for _, choice := range c.Choices {
+			if choice == kept {
+				continue
+			}
+			if data, err = SetRecordValue(data, c.Table, rb.Table(c.Table).IDField(), c.ID, choice, false); err != nil {
+				return err
+			}
+			r.Cleared = append(r.Cleared, choice)
+		}
+		resolved = append(resolved, r)
+	}
+	if len(resolved) == 0 {
+		fmt.Println("Nothing resolved")
+		return nil
+	}
+
+	if _, err := ParseRulebook(data); err != nil {
+		return fmt.Errorf("edited rulebook does not load: %w", err)
+	}
+	resolutions, err := LoadConflictResolutions(*logPath)
+	if err != nil {
+		return err
+	}
+	logData, err := json.MarshalIndent(append(resolutions, resolved...), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*rulebookPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rulebook: %w", err)
+	}
+	if err := os.WriteFile(*logPath, append(logData, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *logPath, err)
+	}
+	fmt.Printf("Resolved %d of %d conflicts in %s (rationale in %s)\n", len(resolved), len(conflicts), *rulebookPath, *logPath)
+	return nil
+}
@@ -0,0 +1,176 @@
+// ERB SDK - Answer Comparison
+// ===========================
+// Every substrate writes test-answers.json; this compares a set of answers
+// with the answer key (or with another substrate's answers) field by field,
+// per record ID. A null and an empty string are the same answer, matching the
+// generated code storing "" as nil and substrates that cannot tell them apart:
+//
+//	report := CompareAnswers(expected, actual)
+//	if !report.OK() {
+//		fmt.Print(report)
+//	}
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// AnswerDiff is one field whose actual answer differs from the expected one
+type AnswerDiff struct {
+	ID       string `json:"id"`
+	Field    string `json:"field"`
+	Expected any    `json:"expected"`
+	Actual   any    `json:"actual"`
+}
+
+func (d AnswerDiff) String() string {
+	return fmt.Sprintf("%s.%s: expected %s, got %s", d.ID, d.Field, pgDiffValue(d.Expected), pgDiffValue(d.Actual))
+}
+
+// DiffReport is the result of comparing two sets of answers
+type DiffReport struct {
+	Records  int          `json:"records"`  // expected records
+	Fields   int          `json:"fields"`   // fields compared
+	Diffs    []AnswerDiff `json:"diffs"`    // in expected record order, then field order
+	Missing  []string     `json:"missing"`  // expected IDs with no actual record
+	Unwanted []string     `json:"unwanted"` // actual IDs that were not expected
+}
+
+// OK reports whether the answers match
+func (r *DiffReport) OK() bool {
+	return len(r.Diffs) == 0 && len(r.Missing) == 0 && len(r.Unwanted) == 0
+}
+
+// Passed returns the number of compared fields that match
+func (r *DiffReport) Passed() int {
+	return r.Fields - len(r.Diffs)
+}
+
+// Err returns nil if the answers match, else an error summarizing the differences
+func (r *DiffReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return fmt.Errorf("%d of %d fields differ, %d records missing, %d unexpected", len(r.Diffs), r.Fields, len(r.Missing), len(r.Unwanted))
+}
+
+// String lists every difference, one per line, followed by a summary line
+func (r *DiffReport) String() string {
+	var b strings.Builder
+	for _, d := range r.Diffs {
+		fmt.Fprintln(&b, d)
+	}
+	for _, id := range r.Missing {
+		fmt.Fprintf(&b, "%s: missing\n", id)
+	}
+	for _, id := range r.Unwanted {
+		fmt.Fprintf(&b, "%s: not expected\n", id)
+	}
+	fmt.Fprintf(&b, "%d/%d fields match across %d records\n", r.Passed(), r.Fields, r.Records)
+	return b.String()
+}
+
+// CompareAnswers compares computed candidates with the expected ones
+func CompareAnswers(expected, actual []LanguageCandidate) *DiffReport {
+	return CompareRecords(RecordsOf(expected), RecordsOf(actual))
+}
+
+// CompareRecords compares records of any table, matching them by their first
+// key (the primary key) and comparing every expected field
+func CompareRecords(expected, actual []Record) *DiffReport {
+	report := &DiffReport{Records: len(expected)}
+	byID := map[string]Record{}
+	for _, rec := range actual {
+		byID[recordID(rec)] = rec
+	}
+	for _, want := range expected {
+		id := recordID(want)
+		got, ok := byID[id]
+		if !ok {
+			report.Missing = append(report.Missing, id)
+			continue
+		}
+		delete(byID, id)
+		for _, k := range want.Keys {
+			report.Fields++
+			e, a := normalizeAnswer(want.Values[k]), normalizeAnswer(got.Values[k])
+			if !reflect.DeepEqual(e, a) {
+				report.Diffs = append(report.Diffs, AnswerDiff{ID: id, Field: k, Expected: want.Values[k], Actual: got.Values[k]})
+			}
+		}
+	}
+	for _, rec := range actual {
+		if _, ok := byID[recordID(rec)]; ok {
+			report.Unwanted = append(report.Unwanted, recordID(rec))
+		}
+	}
+	return report
+}
+
+// recordID is the text of a record's first (primary key) value
+func recordID(rec Record) string {
+	if len(rec.Keys) == 0 {
+		return ""
+	}
+	return fmt.Sprint(rec.Values[rec.Keys[0]])
+}
+
+// normalizeAnswer treats an empty string as null
+func normalizeAnswer(v any) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// readAnswers reads a JSON array of answer records
+func readAnswers(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+	defer f.Close()
+	records, err := jsonImporter{}.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	wholeNumbersToInt(records)
+	return records, nil
+}
+
+// compareAnswerFiles compares two answer files and prints the report
+func compareAnswerFiles(expectedPath, actualPath string) error {
+	expected, err := readAnswers(expectedPath)
+	if err != nil {
+		return err
+	}
+	actual, err := readAnswers(actualPath)
+	if err != nil {
+		return err
+	}
+	report := CompareRecords(expected, actual)
+	fmt.Print(report)
+	return report.Err()
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runCompareAnswers implements `compare-answers EXPECTED ACTUAL`: prints
+// every field that differs and fails unless the answers match
+func runCompareAnswers(args []string) error {
+	fs := flag.NewFlagSet("compare-answers", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: compare-answers EXPECTED ACTUAL")
+	}
+	return compareAnswerFiles(fs.Arg(0), fs.Arg(1))
+}
@@ -0,0 +1,417 @@
+// ERB SDK - Calculation Dependencies
+// ==================================
+// Calculated fields reference other fields as {{FieldName}}. Those references
+// form a graph that must be acyclic: the generator orders ComputeAll by it,
+// and a cycle would silently compute from stale (nil) inputs. References to
+// fields that do not exist are reported as warnings with a suggested fix.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fieldRefPattern matches a {{FieldName}} reference inside a formula
+var fieldRefPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// FormulaDependencies returns the fields a formula references, in order of first use
+func FormulaDependencies(formula string) []string {
+	var deps []string
+	seen := map[string]bool{}
+	for _, m := range fieldRefPattern.FindAllStringSubmatch(formula, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			deps = append(deps, m[1])
+		}
+	}
+	return deps
+}
+
+// Dependencies maps each calculated field to the fields its formula references
+func (t *Table) Dependencies() map[string][]string {
+	deps := map[string][]string{}
+	for _, f := range t.Schema {
+		if f.IsCalculated() {
+			deps[f.Name] = FormulaDependencies(f.Formula)
+		}
+	}
+	return deps
+}
+
+// CycleError reports calculated fields that depend on each other
+type CycleError struct {
+	Table  string
+	Fields []string // the cycle, first field repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("circular dependency between calculated fields in %s: %s", e.Table, strings.Join(e.Fields, " -> "))
+}
+
+// DependencyCycle returns the first cycle among the table's calculated fields
+// (e.g. [A B A]), or nil if they can be ordered
+func (t *Table) DependencyCycle() []string {
+	deps := t.Dependencies()
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range deps[name] {
+			if _, calculated := deps[dep]; !calculated {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i, n := range stack {
+					if n == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, f := range t.Schema {
+		if f.IsCalculated() && state[f.Name] == unvisited {
+			if cycle := visit(f.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// CheckCycles returns a *CycleError for the first table whose calculated fields form a cycle
+func (rb *Rulebook) CheckCycles() error {
+	for _, t := range rb.Tables {
+		if cycle := t.DependencyCycle(); cycle != nil {
+			return &CycleError{Table: t.Name, Fields: cycle}
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// DAG LEVELS
+// =============================================================================
+
+// Levels returns each calculated field's DAG level: 1 if it reads only raw
+// fields, otherwise one more than the deepest calculated field it reads.
+// The table must be acyclic (see DependencyCycle).
+func (t *Table) Levels() map[string]int {
+	deps := t.Dependencies()
+	levels := map[string]int{}
+
+	var level func(name string) int
+	level = func(name string) int {
+		if l, ok := levels[name]; ok {
+			return l
+		}
+		l := 1
+		for _, dep := range deps[name] {
+			if _, calculated := deps[dep]; calculated && dep != name {
+				l = max(l, level(dep)+1)
+			}
+		}
+		levels[name] = l
+		return l
+	}
+	for name := range deps {
+		level(name)
+	}
+	return levels
+}
+
+// LevelDrift is a calculated field whose generated level disagrees with the rulebook
+type LevelDrift struct {
+	Table     string
+	Field     string
+	Generated int // 0 if the generated code does not compute the field
+	Computed  int // 0 if the rulebook no longer has the calculated field
+}
+
+func (d LevelDrift) String() string {
+	switch {
+	case d.Generated == 0:
+		return fmt.Sprintf("%s.%s: level %d, not generated", d.Table, d.Field, d.Computed)
+	case d.Computed == 0:
+		return fmt.Sprintf("%s.%s: generated at level %d, no longer calculated", d.Table, d.Field, d.Generated)
+	}
+	return fmt.Sprintf("%s.%s: level %d, generated at level %d", d.Table, d.Field, d.Computed, d.Generated)
+}
+
+// StaleLevelsError reports generated code whose DAG levels no longer match the rulebook
+type StaleLevelsError struct {
+	Drift []LevelDrift
+}
+
+func (e *StaleLevelsError) Error() string {
+	lines := make([]string, len(e.Drift))
+	for i, d := range e.Drift {
+		lines[i] = d.String()
+	}
+	return "generated code is stale (re-run go generate main.go or inject-into-golang.py): " + strings.Join(lines, "; ")
+}
+
+// ValidateLevels compares the rulebook's DAG levels with the levels the
+// generator emitted (GeneratedLevels) and returns a *StaleLevelsError on any difference
+func (rb *Rulebook) ValidateLevels(generated map[string]map[string]int) error {
+	var drift []LevelDrift
+	seen := map[string]bool{}
+	for _, t := range rb.Tables {
+		seen[t.Name] = true
+		gen := generated[t.Name]
+		for _, f := range t.Schema {
+			if f.IsCalculated() && gen[f.Name] != f.Level {
+				drift = append(drift, LevelDrift{Table: t.Name, Field: f.Name, Generated: gen[f.Name], Computed: f.Level})
+			}
+		}
+		for _, name := range sortedKeys(gen) {
+			if f, ok := t.Field(name); !ok || !f.IsCalculated() {
+				drift = append(drift, LevelDrift{Table: t.Name, Field: name, Generated: gen[name]})
+			}
+		}
+	}
+	for _, table := range sortedKeys(generated) {
+		if seen[table] {
+			continue
+		}
+		for _, name := range sortedKeys(generated[table]) {
+			drift = append(drift, LevelDrift{Table: table, Field: name, Generated: generated[table][name]})
+		}
+	}
+
+	if len(drift) > 0 {
+		return &StaleLevelsError{Drift: drift}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// =============================================================================
+// UNKNOWN REFERENCES
+// =============================================================================
+
+// UnknownReference is a formula {{Reference}} that names no field of its table
+type UnknownReference struct {
+	Table      string
+	Field      string // the calculated field whose formula holds the reference; "" for an ad-hoc formula
+	Reference  string
+	Suggestion string // closest existing field name, if any is close
+}
+
+func (u *UnknownReference) Error() string {
+	msg := fmt.Sprintf("%s.%s references unknown field {{%s}}", u.Table, u.Field, u.Reference)
+	if u.Field == "" {
+		msg = fmt.Sprintf("formula references unknown %s field {{%s}}", u.Table, u.Reference)
+	}
+	if u.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean {{%s}}?)", u.Suggestion)
+	}
+	return msg
+}
+
+// CheckReferences returns an *UnknownReference for every formula reference
+// that does not name a field in the same table
+func (rb *Rulebook) CheckReferences() []error {
+	var problems []error
+	for _, t := range rb.Tables {
+		names := make([]string, len(t.Schema))
+		for i, f := range t.Schema {
+			names[i] = f.Name
+		}
+		for _, f := range t.Schema {
+			if !f.IsCalculated() {
+				continue
+			}
+			for _, ref := range FormulaDependencies(f.Formula) {
+				if _, ok := t.Field(ref); !ok {
+					problems = append(problems, &UnknownReference{
+						Table:      t.Name,
+						Field:      f.Name,
+						Reference:  ref,
+						Suggestion: closestName(ref, names),
+					})
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// checkUnknownReferences is the unknown-reference validation rule
+func checkUnknownReferences(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, err := range rb.CheckReferences() {
+		u := err.(*UnknownReference)
+		msg := fmt.Sprintf("formula references unknown field {{%s}}", u.Reference)
+		if u.Suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean {{%s}}?)", u.Suggestion)
+		}
+		issues = append(issues, ValidationIssue{Table: u.Table, Field: u.Field, Message: msg})
+	}
+	return issues
+}
+
+// checkFormulaFields returns an *UnknownReference for the first reference
+// in an ad-hoc formula over a table's records that names none of fields in
+// any casing (see nameKey)
+func checkFormulaFields(table string, node FormulaNode, fields []string) error {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[nameKey(f)] = true
+	}
+	for _, ref := range FormulaFieldRefs(node) {
+		if !known[nameKey(ref)] {
+			return &UnknownReference{Table: table, Reference: ref, Suggestion: closestName(ref, fields)}
+		}
+	}
+	return nil
+}
+
+// closestName returns the candidate nearest to name by edit distance
+// (ignoring case), or "" if none is plausibly a typo of it
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist < 0 || bestDist > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runLevels implements `levels [--rulebook PATH] [--format text|dot|mermaid]
+// [--table NAME]`: prints each calculated field's DAG level and fails if the
+// generated code disagrees, or draws the DAG (see erb_dag_export.go)
+func runLevels(args []string) error {
+	fs := flag.NewFlagSet("levels", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	format := fs.String("format", "text", "text, dot (Graphviz) or mermaid")
+	table := fs.String("table", "", "only this table (default: every table with calculated fields)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	tables := rb.Tables
+	if *table != "" {
+		t := rb.Table(*table)
+		if t == nil {
+			return unknownTable(*table)
+		}
+		tables = []*Table{t}
+	}
+	if *format != "text" {
+		return printDependencyGraphs(tables, *format)
+	}
+
+	for _, t := range tables {
+		fields := make([]Field, 0, len(t.Schema))
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		sort.SliceStable(fields, func(i, j int) bool { return fields[i].Level < fields[j].Level })
+
+		fmt.Println(t.Name)
+		for _, f := range fields {
+			fmt.Printf("  %d  %s\n", f.Level, f.Name)
+		}
+	}
+	return rb.ValidateLevels(GeneratedLevels)
+}
+
+// printDependencyGraphs prints the DAG of each table with calculated fields
+// in format (dot or mermaid)
+func printDependencyGraphs(tables []*Table, format string) error {
+	if format != "dot" && format != "mermaid" {
+		return fmt.Errorf("unknown format %q (want text, dot or mermaid)", format)
+	}
+	first := true
+	for _, t := range tables {
+		g, err := t.DependencyGraph()
+		if err != nil {
+			return err
+		}
+		if len(g.Levels) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		if format == "dot" {
+			fmt.Print(g.ExportDOT())
+		} else {
+			fmt.Print(g.ExportMermaid())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// ERB SDK - Dependency Diagrams
+// =============================
+// Renders a table's calculation DAG, grouped by level, as Graphviz DOT or a
+// Mermaid flowchart, so the diagrams in the docs are generated from the
+// rulebook instead of drawn by hand. An edge runs from each field a formula
+// reads to the calculated field it feeds; raw fields that no formula reads
+// are left out:
+//
+//	graph, err := rb.Table("LanguageCandidates").DependencyGraph()
+//	fmt.Print(graph.ExportDOT())
+//	fmt.Print(graph.ExportMermaid())
+//
+//	levels --format dot | dot -Tsvg > levels.svg
+//	levels --format mermaid --table LanguageCandidates > levels.mmd
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyGraph is a table's calculated fields by level and the fields they read
+type DependencyGraph struct {
+	Table  string
+	Raw    []string    // raw fields read by some formula, in schema order
+	Levels [][]string  // calculated fields of level 1, 2, ..., each in schema order
+	Edges  [][2]string // {read field, calculated field}, in schema order of the calculated field
+}
+
+// DependencyGraph returns the table's calculation DAG; it fails with a
+// *CycleError if the calculated fields are not acyclic
+func (t *Table) DependencyGraph() (*DependencyGraph, error) {
+	if cycle := t.DependencyCycle(); cycle != nil {
+		return nil, &CycleError{Table: t.Name, Fields: cycle}
+	}
+	deps := t.Dependencies()
+	levels := t.Levels()
+
+	g := &DependencyGraph{Table: t.Name}
+	read := map[string]bool{}
+	for _, f := range t.Schema {
+		if !f.IsCalculated() {
+			continue
+		}
+		for len(g.Levels) < levels[f.Name] {
+			g.Levels = append(g.Levels, nil)
+		}
+		g.Levels[levels[f.Name]-1] = append(g.Levels[levels[f.Name]-1], f.Name)
+		for _, dep := range deps[f.Name] {
+			if _, ok := t.Field(dep); ok && dep != f.Name {
+				g.Edges = append(g.Edges, [2]string{dep, f.Name})
+				read[dep] = true
+			}
+		}
+	}
+	for _, f := range t.Schema {
+		if !f.IsCalculated() && read[f.Name] {
+			g.Raw = append(g.Raw, f.Name)
+		}
+	}
+	return g, nil
+}
+
+// ExportDOT renders the graph in Graphviz DOT, one cluster per level
+func (g *DependencyGraph) ExportDOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n  rankdir=LR;\n  label=%s;\n", dotQuote(g.Table), dotQuote(g.Table+" calculated fields"))
+	writeCluster := func(i int, label, attrs string, fields []string) {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(label))
+		for _, f := range fields {
+			fmt.Fprintf(&b, "    %s [%s];\n", dotQuote(f), attrs)
+		}
+		b.WriteString("  }\n")
+	}
+	writeCluster(0, "Raw fields", "shape=ellipse", g.Raw)
+	for i, fields := range g.Levels {
+		writeCluster(i+1, fmt.Sprintf("Level %d", i+1), "shape=box, style=filled, fillcolor=lightblue", fields)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e[0]), dotQuote(e[1]))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ExportMermaid renders the graph as a Mermaid flowchart, one subgraph per
+// level; node ids are prefixed so field names never clash with keywords
+func (g *DependencyGraph) ExportMermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	writeSubgraph := func(id, label, open, close string, fields []string) {
+		fmt.Fprintf(&b, "  subgraph %s[\"%s\"]\n", id, label)
+		for _, f := range fields {
+			fmt.Fprintf(&b, "    f_%s%s\"%s\"%s\n", f, open, f, close)
+		}
+		b.WriteString("  end\n")
+	}
+	writeSubgraph("raw", "Raw fields", "([", "])", g.Raw)
+	for i, fields := range g.Levels {
+		writeSubgraph(fmt.Sprintf("level%d", i+1), fmt.Sprintf("Level %d", i+1), "[", "]", fields)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "  f_%s --> f_%s\n", e[0], e[1])
+	}
+	return b.String()
+}
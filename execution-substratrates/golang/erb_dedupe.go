@@ -0,0 +1,178 @@
+// ERB SDK - Duplicate Candidates
+// ==============================
+// Merged Airtable exports keep producing the same candidate twice under new
+// ids, which counts it twice in the Family Feud stats. Duplicates finds two
+// kinds of group:
+//
+//	name        the same Name, ignoring case and runs of whitespace
+//	            ("Python", " python ") - almost always one candidate twice
+//	raw-fields  the same answers in every raw field except the id, Name and
+//	            SortOrder - a renamed copy, or two candidates the rulebook
+//	            cannot tell apart, worth a look either way; candidates with
+//	            none of those fields set are not compared
+//
+//	for _, g := range rb.Duplicates() {
+//		fmt.Println(g) // raw-fields: english, spoken-words, sign-language
+//	}
+//
+// `dedupe` prints the groups (`--json` for tooling), and `validate` reports
+// name duplicates as the duplicate-name rule.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Duplicate kinds
+const (
+	DuplicateName      = "name"
+	DuplicateRawFields = "raw-fields"
+)
+
+// dedupeIgnoredFields are the raw fields left out of a raw-field signature
+var dedupeIgnoredFields = map[string]bool{"LanguageCandidateId": true, "Name": true, "SortOrder": true}
+
+// DuplicateGroup is two or more candidates that look like the same one
+type DuplicateGroup struct {
+	Kind string   `json:"kind"` // DuplicateName or DuplicateRawFields
+	Key  string   `json:"key"`  // the normalized name, or the raw-field signature
+	IDs  []string `json:"ids"`  // LanguageCandidateIds, in rulebook order
+}
+
+func (g DuplicateGroup) String() string {
+	return g.Kind + ": " + strings.Join(g.IDs, ", ")
+}
+
+// Duplicates returns the groups of candidates sharing a normalized Name,
+// then the groups sharing a raw-field signature, each in order of its first
+// candidate
+func (rb *Rulebook) Duplicates() []DuplicateGroup {
+	groups := duplicateGroups(rb.LanguageCandidates, DuplicateName, func(lc *LanguageCandidate) string {
+		return normalizeName(optGet(lc.Name, ""))
+	})
+	return append(groups, duplicateGroups(rb.LanguageCandidates, DuplicateRawFields, rawSignature)...)
+}
+
+// duplicateGroups groups candidates by key, skipping empty keys, and
+// returns the groups of two or more
+func duplicateGroups(candidates []LanguageCandidate, kind string, key func(*LanguageCandidate) string) []DuplicateGroup {
+	var groups []DuplicateGroup
+	index := map[string]int{}
+	for i := range candidates {
+		k := key(&candidates[i])
+		if k == "" {
+			continue
+		}
+		if _, ok := index[k]; !ok {
+			index[k] = len(groups)
+			groups = append(groups, DuplicateGroup{Kind: kind, Key: k})
+		}
+		groups[index[k]].IDs = append(groups[index[k]].IDs, candidates[i].LanguageCandidateId)
+	}
+	dups := groups[:0]
+	for _, g := range groups {
+		if len(g.IDs) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
+
+// normalizeName lowercases name and collapses its whitespace
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// rawSignature joins a candidate's raw field values, except the
+// dedupeIgnoredFields, as Field=value pairs in schema order; it is empty,
+// so the candidate is skipped, when none of those fields is set, since
+// candidates that say nothing yet are not copies of each other
+func rawSignature(lc *LanguageCandidate) string {
+	var parts []string
+	set := false
+	for _, field := range candidateFields() {
+		if _, calculated := LanguageCandidateFormulas[field]; calculated || dedupeIgnoredFields[field] {
+			continue
+		}
+		value := recordField(lc, field)
+		set = set || value != nil
+		parts = append(parts, field+"="+formatValue(value))
+	}
+	if !set {
+		return ""
+	}
+	return strings.Join(parts, " ")
+}
+
+// checkDuplicateNames is the duplicate-name validation rule
+func checkDuplicateNames(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	rows := map[string]int{}
+	for i, lc := range rb.LanguageCandidates {
+		rows[lc.LanguageCandidateId] = i + 1
+	}
+	for _, g := range rb.Duplicates() {
+		if g.Kind != DuplicateName {
+			continue
+		}
+		for _, id := range g.IDs[1:] {
+			issues = append(issues, ValidationIssue{Table: "LanguageCandidates", Row: rows[id], ID: id, Field: "Name", Message: fmt.Sprintf("same name as %s", g.IDs[0])})
+		}
+	}
+	return issues
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runDedupe implements `dedupe [--rulebook PATH] [--names-only] [--json]`;
+// it fails when there are name duplicates, so CI can catch a bad merge
+func runDedupe(args []string) error {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	namesOnly := fs.Bool("names-only", false, "report only candidates with the same name")
+	asJSON := fs.Bool("json", false, "print the groups as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var groups []DuplicateGroup
+	names := 0
+	for _, g := range rb.Duplicates() {
+		if g.Kind == DuplicateName {
+			names++
+		} else if *namesOnly {
+			continue
+		}
+		groups = append(groups, g)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(groups); err != nil {
+			return err
+		}
+	} else {
+		for _, g := range groups {
+			fmt.Println(g)
+		}
+		fmt.Printf("%d duplicate groups in %d candidates\n", len(groups), len(rb.LanguageCandidates))
+	}
+	if names > 0 {
+		return fmt.Errorf("%d candidate names are duplicated", names)
+	}
+	return nil
+}
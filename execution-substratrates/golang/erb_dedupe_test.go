@@ -0,0 +1,30 @@
+// ERB SDK - Duplicate Candidate Tests
+// ===================================
+// Unit tests for Duplicates: candidates with no raw fields set yet are not
+// raw-field duplicates of each other, while real copies still are:
+//
+//	go test $(ls *.go) -run Duplicates
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuplicatesSkipsBlankRawFields(t *testing.T) {
+	rb := &Rulebook{LanguageCandidates: []LanguageCandidate{
+		{LanguageCandidateId: "klingon", Name: optPtr("Klingon")},
+		{LanguageCandidateId: "esperanto", Name: optPtr("Esperanto")},
+		{LanguageCandidateId: "morse-code", Name: optPtr("Morse code")},
+		{LanguageCandidateId: "latin", Name: optPtr("Latin"), HasSyntax: optPtr(true), Category: optPtr("Natural")},
+		{LanguageCandidateId: "old-latin", Name: optPtr("Old Latin"), HasSyntax: optPtr(true), Category: optPtr("Natural")},
+	}}
+	var got [][]string
+	for _, g := range rb.Duplicates() {
+		got = append(got, g.IDs)
+	}
+	if want := [][]string{{"latin", "old-latin"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicates() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+// ERB SDK - Record Digests
+// ========================
+// Stable SHA-256 digests of records, so two substrates can check they read
+// the same inputs and computed the same outputs by comparing a few hashes
+// instead of every field. A record has two digests: Raw over its raw fields
+// and Computed over its calculated fields. Each hashes the fields as
+// canonical JSON with sorted keys, so field order, key casing (PascalCase
+// or snake_case) and null vs "" (equal, as in CompareAnswers) do not change
+// it. A table digest hashes its records' digests in record ID order:
+//
+//	view := candidate.ToView()
+//	d, err := view.Hash()      // d.Raw, d.Computed
+//	digest, err := rb.Digest() // one TableDigest per table
+//
+// Hashing fails only if a value cannot be marshaled as JSON (a NaN, say).
+//
+//	digest                                    every rulebook table
+//	digest --records test-answers.json        an answer file (LanguageCandidates)
+//	digest --records a.json --records b.json  compare two answer files
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RecordDigest is the pair of digests of one record
+type RecordDigest struct {
+	ID       string `json:"id"`
+	Raw      string `json:"raw"`      // SHA-256 hex of the raw fields
+	Computed string `json:"computed"` // SHA-256 hex of the calculated fields
+}
+
+// TableDigest is the digest of a table's records
+type TableDigest struct {
+	Table    string `json:"table"`
+	Records  int    `json:"records"`
+	Raw      string `json:"raw"`      // SHA-256 hex of the records' Raw digests, by ID
+	Computed string `json:"computed"` // SHA-256 hex of the records' Computed digests, by ID
+}
+
+// Hash returns the digests of the candidate's raw fields and of its
+// calculated fields as stored (call it on a computed record, e.g. ToView())
+func (tc *LanguageCandidate) Hash() (RecordDigest, error) {
+	views := ExportViews{Table: "LanguageCandidates"}
+	return digestRecord(recordFromStruct(reflect.ValueOf(tc)), views.IsCalculated)
+}
+
+// Hash returns the digests of the argument step (it has no calculated fields)
+func (step *IsEverythingALanguage) Hash() (RecordDigest, error) {
+	views := ExportViews{Table: "IsEverythingALanguage", Calculated: []string{}}
+	return digestRecord(recordFromStruct(reflect.ValueOf(step)), views.IsCalculated)
+}
+
+// DigestRecords returns the digest of a table's records; ExportViews.IsCalculated
+// splits raw from calculated fields
+func DigestRecords(views ExportViews) (TableDigest, error) {
+	digests := make([]RecordDigest, len(views.Records))
+	for i, rec := range views.Records {
+		d, err := digestRecord(rec, views.IsCalculated)
+		if err != nil {
+			return TableDigest{}, fmt.Errorf("%s record %d: %w", views.Table, i+1, err)
+		}
+		digests[i] = d
+	}
+	sort.SliceStable(digests, func(i, j int) bool { return digests[i].ID < digests[j].ID })
+
+	var raw, computed strings.Builder
+	for _, d := range digests {
+		fmt.Fprintf(&raw, "%s\x00%s\n", d.ID, d.Raw)
+		fmt.Fprintf(&computed, "%s\x00%s\n", d.ID, d.Computed)
+	}
+	return TableDigest{
+		Table:    views.Table,
+		Records:  len(digests),
+		Raw:      sha256Hex([]byte(raw.String())),
+		Computed: sha256Hex([]byte(computed.String())),
+	}, nil
+}
+
+// Digest returns the digest of every table's computed views (without internal
+// fields, like the exports), in rulebook order
+func (rb *Rulebook) Digest() ([]TableDigest, error) {
+	var digests []TableDigest
+	for _, t := range rb.Tables {
+		views, err := rb.TableViews(t.Name, false)
+		if err != nil {
+			return nil, err
+		}
+		d, err := DigestRecords(views)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
+
+// digestRecord hashes a record's raw and calculated fields separately; the
+// first key is the record ID
+func digestRecord(rec Record, isCalculated func(key string) bool) (RecordDigest, error) {
+	raw := Record{Values: map[string]any{}}
+	computed := Record{Values: map[string]any{}}
+	for _, k := range rec.Keys {
+		part := &raw
+		if isCalculated(k) {
+			part = &computed
+		}
+		key := nameKey(k)
+		v := rec.Values[k]
+		if v == "" {
+			v = nil
+		}
+		part.Keys = append(part.Keys, key)
+		part.Values[key] = v
+	}
+
+	var d RecordDigest
+	var err error
+	if len(rec.Keys) > 0 {
+		d.ID = exportText(rec.Values[rec.Keys[0]])
+	}
+	if d.Raw, err = digestJSON(raw); err != nil {
+		return RecordDigest{}, err
+	}
+	if d.Computed, err = digestJSON(computed); err != nil {
+		return RecordDigest{}, err
+	}
+	return d, nil
+}
+
+// digestJSON hashes a record as canonical JSON with sorted keys
+func digestJSON(rec Record) (string, error) {
+	data, err := MarshalCanonical(rec, WithSortedKeys())
+	if err != nil {
+		return "", fmt.Errorf("digest: %w", err)
+	}
+	return sha256Hex(data), nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// recordFiles collects repeated --records flags
+type recordFiles []string
+
+func (f *recordFiles) String() string     { return strings.Join(*f, ",") }
+func (f *recordFiles) Set(v string) error { *f = append(*f, v); return nil }
+
+// runDigest implements `digest [--rulebook PATH] [--records FILE]... [--table T]`:
+// prints the raw and computed digests of every rulebook table, or of record
+// files; with two or more files it fails unless all digests match
+func runDigest(args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path or URL of the rulebook")
+	table := fs.String("table", "LanguageCandidates", "table the --records files hold")
+	var files recordFiles
+	fs.Var(&files, "records", "record file (any importer format) to digest instead of the rulebook; repeat to compare")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var digests []TableDigest
+	var labels []string
+	if len(files) == 0 {
+		rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+		if err != nil {
+			return err
+		}
+		printWarnings(rb)
+		if digests, err = rb.Digest(); err != nil {
+			return err
+		}
+		for _, d := range digests {
+			labels = append(labels, d.Table)
+		}
+	}
+	for _, path := range files {
+		imp, ok := ImporterForPath(path)
+		if !ok {
+			return fmt.Errorf("no importer for %s", path)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		records, err := imp.Read(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		d, err := DigestRecords(ExportViews{Table: *table, Records: records})
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		digests = append(digests, d)
+		labels = append(labels, path)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tRECORDS\tRAW\tCOMPUTED")
+	for i, d := range digests {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", labels[i], d.Records, d.Raw, d.Computed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(files) < 2 {
+		return nil
+	}
+	for i, d := range digests[1:] {
+		if d.Raw != digests[0].Raw || d.Computed != digests[0].Computed {
+			return fmt.Errorf("%s and %s differ", files[0], files[i+1])
+		}
+	}
+	return nil
+}
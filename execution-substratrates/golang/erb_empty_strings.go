@@ -0,0 +1,120 @@
+// ERB SDK - Empty String Policy
+// =============================
+// The generated ComputeAll stores an empty computed string as null
+// (optNilIfZero), while other substrates keep "". An EmptyStringPolicy picks
+// one form for the calculated string fields, and ComputeAllRecords,
+// TypedTable.ComputeAll, ToView, SaveRecords and take-test apply it the same
+// way, so answers match the substrate they are compared with:
+//
+//	answers := ComputeAllRecords(records, WithEmptyStrings(PreserveEmptyStrings))
+//	view := candidate.ToView(WithEmptyStrings(PreserveEmptyStrings))
+//	err := SaveRecords("test-answers.json", answers, WithEmptyStrings(PreserveEmptyStrings))
+//
+// Raw string fields are never changed: a null raw value means the input was
+// absent, which "" would hide.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// EmptyStringPolicy decides how an empty calculated string is stored
+type EmptyStringPolicy int
+
+const (
+	// EmptyAsNull stores an empty calculated string as null (the default,
+	// what the generated ComputeAll does)
+	EmptyAsNull EmptyStringPolicy = iota
+	// PreserveEmptyStrings stores an empty calculated string as ""
+	PreserveEmptyStrings
+)
+
+// emptyStringPolicyNames are the policy names accepted on the command line
+var emptyStringPolicyNames = map[EmptyStringPolicy]string{
+	EmptyAsNull:          "null",
+	PreserveEmptyStrings: "preserve",
+}
+
+func (p EmptyStringPolicy) String() string {
+	if name, ok := emptyStringPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("EmptyStringPolicy(%d)", int(p))
+}
+
+// ParseEmptyStringPolicy parses a policy name: null or preserve
+func ParseEmptyStringPolicy(name string) (EmptyStringPolicy, error) {
+	for p, n := range emptyStringPolicyNames {
+		if strings.EqualFold(name, n) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown empty string policy %q (want null or preserve)", name)
+}
+
+// WithEmptyStrings sets how empty calculated strings are stored
+func WithEmptyStrings(p EmptyStringPolicy) RecordOption {
+	return func(c *recordConfig) {
+		c.emptyStrings = p
+	}
+}
+
+// calculatedFormulas maps each generated struct to its calculated fields
+// (see the <Struct>Formulas maps in erb_sdk.go)
+var calculatedFormulas = map[reflect.Type]map[string]string{
+	reflect.TypeFor[LanguageCandidate](): LanguageCandidateFormulas,
+}
+
+// calculatedStringFieldCache caches calculatedStringFields per struct type
+var calculatedStringFieldCache sync.Map // reflect.Type -> []int
+
+// calculatedStringFields returns the indexes of a struct's calculated *string fields
+func calculatedStringFields(t reflect.Type) []int {
+	if cached, ok := calculatedStringFieldCache.Load(t); ok {
+		return cached.([]int)
+	}
+	var fields []int
+	formulas := calculatedFormulas[t]
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if _, calc := formulas[sf.Name]; calc && sf.Type == reflect.TypeFor[*string]() {
+			fields = append(fields, i)
+		}
+	}
+	calculatedStringFieldCache.Store(t, fields)
+	return fields
+}
+
+// applyEmptyStrings rewrites a record's empty calculated strings to the policy's form
+func applyEmptyStrings[T any](rec *T, p EmptyStringPolicy) {
+	v := reflect.ValueOf(rec).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for _, i := range calculatedStringFields(v.Type()) {
+		f := v.Field(i)
+		switch {
+		case p == PreserveEmptyStrings && f.IsNil():
+			f.Set(reflect.ValueOf(optPtr("")))
+		case p == EmptyAsNull && !f.IsNil() && f.Elem().String() == "":
+			f.SetZero()
+		}
+	}
+}
+
+// withEmptyStrings returns a copy of records with the policy set by opts applied
+func withEmptyStrings[T any](records []T, opts []RecordOption) []T {
+	cfg := recordConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	out := append([]T(nil), records...)
+	for i := range out {
+		applyEmptyStrings(&out[i], cfg.emptyStrings)
+	}
+	return out
+}
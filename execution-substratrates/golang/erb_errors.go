@@ -0,0 +1,189 @@
+// ERB SDK - Errors and Exit Codes
+// ===============================
+// The failures callers most often need to tell apart are sentinel errors,
+// wrapped with the details, so they can be matched with errors.Is whatever
+// message surrounds them:
+//
+//	rb, err := LoadFromRulebook(path)
+//	switch {
+//	case errors.Is(err, ErrRulebookNotFound): // no file (or a 404 from LoadFromURL)
+//	case errors.Is(err, ErrMalformedRecord):  // a record that does not fit its table
+//	}
+//
+//	var bad *MalformedRecordError
+//	if errors.As(err, &bad) {
+//		fmt.Println(bad.Index, bad.Field) // 3 has_syntax: the fourth record
+//	}
+//
+// The CLI exits with ExitCode(err), so scripts can branch on them too:
+//
+//	0  success (or -h, which prints the usage of the command, or before one the global flags)
+//	1  any other failure
+//	2  usage: unknown command or bad global flags
+//	3  ErrRulebookNotFound
+//	4  ErrMalformedRecord (also *FieldError from --strict, *ImportError)
+//	5  ErrUnknownTable
+//	6  the --timeout passed (context.DeadlineExceeded)
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrRulebookNotFound is returned when a rulebook file (or URL) does not exist
+	ErrRulebookNotFound = errors.New("rulebook not found")
+
+	// ErrMalformedRecord matches every error about a record that cannot be
+	// read as its table's record: *MalformedRecordError, *FieldError and *ImportError
+	ErrMalformedRecord = errors.New("malformed record")
+
+	// ErrUnknownTable is returned when a table name is not in the rulebook
+	ErrUnknownTable = errors.New("unknown table")
+)
+
+// CLI exit codes (see ExitCode)
+const (
+	ExitFailure          = 1
+	ExitUsage            = 2
+	ExitRulebookNotFound = 3
+	ExitMalformedRecord  = 4
+	ExitUnknownTable     = 5
+	ExitTimeout          = 6
+)
+
+// MalformedRecordError reports a record of a JSON array that does not
+// decode into its table's struct; its message numbers records from 1
+type MalformedRecordError struct {
+	Index int    // zero-based position in the array
+	Field string // JSON key of the offending field, if known
+	Err   error
+}
+
+func (e *MalformedRecordError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("record %d: field %s: %v", e.Index+1, e.Field, e.Err)
+	}
+	return fmt.Sprintf("record %d: %v", e.Index+1, e.Err)
+}
+
+func (e *MalformedRecordError) Unwrap() error { return e.Err }
+
+func (e *MalformedRecordError) Is(target error) bool { return target == ErrMalformedRecord }
+
+func (e *FieldError) Is(target error) bool { return target == ErrMalformedRecord }
+
+func (e *ImportError) Is(target error) bool { return target == ErrMalformedRecord }
+
+// unknownTable returns ErrUnknownTable for the named table
+func unknownTable(name string) error {
+	return fmt.Errorf("%w %q", ErrUnknownTable, name)
+}
+
+// ExitCode returns the CLI exit status for a command's error
+func ExitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, ErrRulebookNotFound):
+		return ExitRulebookNotFound
+	case errors.Is(err, ErrMalformedRecord):
+		return ExitMalformedRecord
+	case errors.Is(err, ErrUnknownTable):
+		return ExitUnknownTable
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	default:
+		return ExitFailure
+	}
+}
+
+// recordDecoding configures decodeRecordArray
+type recordDecoding struct {
+	disallowUnknown bool
+	partial         bool          // skip records that fail, reporting them in a *MultiError
+	rejected        map[int]error // records already found malformed, by index (partial only)
+}
+
+// decodeRecordArray decodes a JSON array into out (a pointer to a slice) one
+// element at a time, so a record that does not fit is reported as a
+// *MalformedRecordError with its position and field. A JSON syntax error
+// fails the whole array, even when partial.
+func decodeRecordArray(data []byte, out any, d recordDecoding) error {
+	slice := reflect.ValueOf(out).Elem()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if d.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		slice.SetZero() // null, as json.Unmarshal reads it
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected a JSON array of records")
+	}
+	records := reflect.MakeSlice(slice.Type(), 0, 0)
+	var skipped []error
+	for i := 0; dec.More(); i++ {
+		rec := reflect.New(slice.Type().Elem())
+		var err error
+		if d.partial {
+			// Decode the element on its own, so a bad one leaves dec past it
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			if err = d.rejected[i]; err == nil {
+				err = decodeOneRecord(raw, rec.Interface(), d.disallowUnknown)
+			}
+		} else {
+			err = dec.Decode(rec.Interface())
+		}
+		if err == nil {
+			records = reflect.Append(records, rec.Elem())
+			continue
+		}
+
+		var bad *MalformedRecordError
+		if !errors.As(err, &bad) {
+			bad = &MalformedRecordError{Index: i, Err: err}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				bad.Field = typeErr.Field
+			}
+		}
+		if !d.partial {
+			return bad
+		}
+		skipped = append(skipped, bad)
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	slice.Set(records)
+	if len(skipped) > 0 {
+		return &MultiError{Errors: skipped}
+	}
+	return nil
+}
+
+// decodeOneRecord decodes one JSON record into out
+func decodeOneRecord(data []byte, out any, disallowUnknown bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(out)
+}
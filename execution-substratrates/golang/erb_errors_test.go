@@ -0,0 +1,34 @@
+// ERB SDK - Error Tests
+// =====================
+// Unit tests for the typed errors: a malformed record keeps its zero-based
+// Index, while its message numbers records from 1:
+//
+//	go test $(ls *.go) -run MalformedRecord
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMalformedRecordErrorNumbersFromOne(t *testing.T) {
+	var records []LanguageCandidate
+	data := []byte(`[{"language_candidate_id": "english"}, {"language_candidate_id": "latin", "distance_from_concept": "far"}]`)
+	err := decodeRecordArray(data, &records, recordDecoding{})
+
+	var bad *MalformedRecordError
+	if !errors.As(err, &bad) {
+		t.Fatalf("decodeRecordArray: %v, want a *MalformedRecordError", err)
+	}
+	if bad.Index != 1 {
+		t.Errorf("Index = %d, want 1", bad.Index)
+	}
+	if msg := bad.Error(); !strings.HasPrefix(msg, "record 2: field distance_from_concept: ") {
+		t.Errorf("Error() = %q, want it to start with %q", msg, "record 2: field distance_from_concept: ")
+	}
+	if msg := (&MalformedRecordError{Index: 0, Err: errors.New("bad")}).Error(); msg != "record 1: bad" {
+		t.Errorf("Error() = %q, want %q", msg, "record 1: bad")
+	}
+}
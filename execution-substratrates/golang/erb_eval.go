@@ -0,0 +1,241 @@
+// ERB SDK - Ad-hoc Formula Evaluation
+// ===================================
+// `eval` runs the formula engine on the command line. With --each it reads
+// records from stdin (a JSON array, NDJSON, or CSV with a header row) and
+// prints the formula's value for each, one line per record, so it fits in
+// shell one-liners like jq:
+//
+//	eval '{{Name}} & " => " & IF({{HasSyntax}}, "syntactic", "asyntactic")' --each < ../../testing/blank-test.json
+//	eval 'AND({{HasSyntax}}, NOT({{HasGrammar}}))' --each --where < candidates.csv   # matching records, as NDJSON
+//	eval 'IF(FIND("x", LOWER("XML")), "found", "missing")'
+//
+// Field references may use any casing of a record key ({{HasSyntax}} reads
+// has_syntax). A reference to a field that is neither in the --table schema
+// nor a key of any record fails up front, with a did-you-mean suggestion,
+// rather than reading null. --compute fills in a table's calculated fields
+// first, so formulas can read them from blank tests.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EvalRecords evaluates a formula against each record of a table; fields
+// are looked up by any casing of the record's keys. A field reference must
+// name a field of the table's schema or a key of some record, or it fails
+// with an *UnknownReference before any record is evaluated.
+func EvalRecords(table, formula string, records []Record) ([]any, error) {
+	ast, err := ParseFormula(formula)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse formula: %w", err)
+	}
+	fields, err := evalFields(table, records)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFormulaFields(table, ast, fields); err != nil {
+		return nil, err
+	}
+	values := make([]any, len(records))
+	for i, rec := range records {
+		byKey := make(map[string]any, len(rec.Keys))
+		for _, k := range rec.Keys {
+			byKey[nameKey(k)] = runtimeValue(rec.Values[k])
+		}
+		eval := &FormulaEvaluator{Lookup: func(name string) any { return byKey[nameKey(name)] }}
+		if values[i], err = eval.Eval(ast); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+	}
+	return values, nil
+}
+
+// evalFields are the field names a formula over the table's records may
+// reference: the schema's, then any other record keys
+func evalFields(table string, records []Record) ([]string, error) {
+	var fields []string
+	for _, t := range schemaTables {
+		if t.name == table {
+			for i := 0; i < t.record.NumField(); i++ {
+				fields = append(fields, t.record.Field(i).Name)
+			}
+		}
+	}
+	if fields == nil {
+		return nil, unknownTable(table)
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		seen[nameKey(f)] = true
+	}
+	for _, rec := range records {
+		for _, k := range rec.Keys {
+			if !seen[nameKey(k)] {
+				seen[nameKey(k)] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// readEvalRecords reads records with the importer for path's extension, or
+// sniffed from the first byte: [ is a JSON array, { NDJSON, anything else
+// CSV. Text cells are typed: true/false become booleans, whole numbers ints.
+func readEvalRecords(data []byte, path string) ([]Record, error) {
+	imp, ok := ImporterForPath(path)
+	if !ok {
+		imp = csvImporter{}
+		switch trimmed := bytes.TrimSpace(data); {
+		case len(trimmed) == 0:
+			return nil, nil
+		case trimmed[0] == '[':
+			imp = jsonImporter{}
+		case trimmed[0] == '{':
+			imp = ndjsonImporter{}
+		}
+	}
+	records, err := imp.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	wholeNumbersToInt(records)
+	for _, rec := range records {
+		for k, v := range rec.Values {
+			if text, ok := v.(string); ok {
+				rec.Values[k] = textCellValue(text)
+			}
+		}
+	}
+	return records, nil
+}
+
+// textCellValue types a CSV or spreadsheet cell; empty cells are null
+func textCellValue(text string) any {
+	trimmed := strings.TrimSpace(text)
+	switch strings.ToLower(trimmed) {
+	case "":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.Atoi(trimmed); err == nil {
+		return n
+	}
+	return text
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runEval implements `eval FORMULA [--each] [--input FILE] [--where]
+// [--json] [--compute] [--table NAME]`
+func runEval(args []string) error {
+	const usage = "usage: eval FORMULA [--each] [--input FILE] [--where] [--json] [--compute] [--table NAME]"
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	each := fs.Bool("each", false, "evaluate the formula for every record read from stdin")
+	input := fs.String("input", "", "read the records from this file instead of stdin (implies --each)")
+	where := fs.Bool("where", false, "print the records the formula is true for (NDJSON) instead of the values")
+	asJSON := fs.Bool("json", false, "print each value as JSON (strings quoted, null for no value)")
+	compute := fs.Bool("compute", false, "compute the table's calculated fields before evaluating")
+	table := fs.String("table", "LanguageCandidates", "table the records belong to, for --compute and checking field references")
+
+	// FORMULA comes first; flags may follow it
+	var positional []string
+	rest := args
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 {
+		return fmt.Errorf(usage)
+	}
+	formula := positional[0]
+
+	if !*each && *input == "" {
+		ast, err := ParseFormula(formula)
+		if err != nil {
+			return fmt.Errorf("failed to parse formula: %w", err)
+		}
+		if refs := FormulaFieldRefs(ast); len(refs) > 0 {
+			return fmt.Errorf("formula reads %s; pass --each to evaluate it for every record on stdin", strings.Join(refs, ", "))
+		}
+		value, err := (&FormulaEvaluator{}).Eval(ast)
+		if err != nil {
+			return err
+		}
+		return printEvalValue(value, *asJSON)
+	}
+
+	var data []byte
+	var err error
+	if *input != "" {
+		data, err = os.ReadFile(*input)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	records, err := readEvalRecords(data, *input)
+	if err != nil {
+		return fmt.Errorf("failed to read records: %w", err)
+	}
+	if *compute {
+		if records, err = ImportRecords(*table, records); err != nil {
+			return fmt.Errorf("invalid records:\n%w", err)
+		}
+		if records, err = computeTable(*table, records); err != nil {
+			return err
+		}
+	}
+
+	values, err := EvalRecords(*table, formula, records)
+	if err != nil {
+		return err
+	}
+	for i, v := range values {
+		if !*where {
+			if err := printEvalValue(v, *asJSON); err != nil {
+				return err
+			}
+			continue
+		}
+		if formulaBool(v) {
+			line, err := records[i].MarshalJSON()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		}
+	}
+	return nil
+}
+
+// printEvalValue prints a formula value as text (nil is an empty line) or JSON
+func printEvalValue(v any, asJSON bool) error {
+	if !asJSON {
+		fmt.Println(formulaText(v))
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
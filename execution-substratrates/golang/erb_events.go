@@ -0,0 +1,213 @@
+// ERB SDK - Event Bus
+// ===================
+// A small in-process publish/subscribe bus, so subsystems that react to each
+// other (watch, server, validation, compute, notifiers) compose without
+// calling or importing one another. Events are typed; subscribers name the
+// event type they want:
+//
+//	stop := Subscribe(Events, func(e RulebookLoaded) {
+//		server.SetRulebook(e.Rulebook)
+//	})
+//	defer stop()
+//
+//	RulebookLoaded     the watcher swapped in an edited rulebook
+//	RecordChanged      a record was added, removed or edited in a reload
+//	FieldChanged       a Store edit changed a field (raw or calculated)
+//	StoreCommitted     a Store transaction committed a new snapshot
+//	ComputeCompleted   a table's calculated fields were computed
+//	InvariantViolated  a validation rule failed (Rulebook.Validate, or a
+//	                   rejected reload)
+//
+// Publish calls the subscribers synchronously, in subscription order, on the
+// publisher's goroutine; a slow subscriber should hand the event off to its
+// own goroutine.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Event is a value published on a Bus
+type Event interface {
+	// EventName is the event's type name, for logs (e.g. "RulebookLoaded")
+	EventName() string
+}
+
+// RulebookLoaded is published when a new rulebook goes live
+type RulebookLoaded struct {
+	Source   string // path or URL it was loaded from
+	Rulebook *Rulebook
+}
+
+// RecordChanged is one record that differs between two rulebooks
+type RecordChanged struct {
+	Source   string
+	Table    string
+	RecordID string
+	Kind     string        // "added", "removed" or "changed"
+	Changes  []ValueChange // the changed fields, for "changed"
+}
+
+// FieldChanged is one field a Store edit changed
+type FieldChanged struct {
+	Table    string
+	RecordID string
+	Field    string
+	Old, New any
+}
+
+// StoreCommitted is published after a Store commit, with the new snapshot
+type StoreCommitted struct {
+	Snapshot *StoreSnapshot
+}
+
+// ComputeCompleted is published after a table's records are computed
+type ComputeCompleted struct {
+	Table    string
+	Records  int
+	Duration time.Duration
+}
+
+// InvariantViolated is a validation issue found outside an explicit check
+type InvariantViolated struct {
+	Source string // rulebook path, or empty for an in-memory rulebook
+	Issue  ValidationIssue
+}
+
+func (RulebookLoaded) EventName() string    { return "RulebookLoaded" }
+func (RecordChanged) EventName() string     { return "RecordChanged" }
+func (FieldChanged) EventName() string      { return "FieldChanged" }
+func (StoreCommitted) EventName() string    { return "StoreCommitted" }
+func (ComputeCompleted) EventName() string  { return "ComputeCompleted" }
+func (InvariantViolated) EventName() string { return "InvariantViolated" }
+
+func (e RecordChanged) String() string {
+	s := fmt.Sprintf("%s %s %s", e.Table, e.RecordID, e.Kind)
+	for i, c := range e.Changes {
+		if i == 0 {
+			s += ":"
+		}
+		s += fmt.Sprintf(" %s %s -> %s", c.Field, formatValue(c.Old), formatValue(c.New))
+	}
+	return s
+}
+
+// Bus delivers published events to the subscribers of their type
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[reflect.Type][]subscription // nil key: every event
+}
+
+type subscription struct {
+	id int
+	fn func(Event)
+}
+
+// NewBus returns an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: map[reflect.Type][]subscription{}}
+}
+
+// Events is the process-wide bus the SDK publishes on
+var Events = NewBus()
+
+// Subscribe calls fn with every event of type E published on b, and returns
+// the function that unsubscribes it
+func Subscribe[E Event](b *Bus, fn func(E)) func() {
+	return b.subscribe(reflect.TypeFor[E](), func(e Event) { fn(e.(E)) })
+}
+
+// SubscribeAll calls fn with every event published on b
+func (b *Bus) SubscribeAll(fn func(Event)) func() {
+	return b.subscribe(nil, fn)
+}
+
+func (b *Bus) subscribe(t reflect.Type, fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs[t] = append(b.subs[t], subscription{id: id, fn: fn})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			subs := b.subs[t]
+			for i, s := range subs {
+				if s.id == id {
+					b.subs[t] = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Publish delivers e to the subscribers of its type, then to those of every event
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	subs := append(append([]subscription(nil), b.subs[reflect.TypeOf(e)]...), b.subs[nil]...)
+	b.mu.RUnlock()
+	for _, s := range subs {
+		s.fn(e)
+	}
+}
+
+// HasSubscribers reports whether anything would receive an event of type E,
+// so publishers can skip building expensive events
+func HasSubscribers[E Event](b *Bus) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[reflect.TypeFor[E]()]) > 0 || len(b.subs[nil]) > 0
+}
+
+// publishRecordChanges publishes a RecordChanged for every record that
+// differs between two rulebooks, matched by primary key
+func publishRecordChanges(b *Bus, source string, from, to *Rulebook) {
+	if !HasSubscribers[RecordChanged](b) {
+		return
+	}
+	for _, tc := range DiffRulebooks(from, to) {
+		oldTable, newTable := from.Table(tc.Table), to.Table(tc.Table)
+		var oldRows, newRows []map[string]any
+		idField := ""
+		if oldTable != nil {
+			oldRows, idField = oldTable.Data, oldTable.IDField()
+		}
+		if newTable != nil {
+			newRows, idField = newTable.Data, newTable.IDField()
+		}
+		oldIndex, newIndex := indexRows(oldRows, idField), indexRows(newRows, idField)
+
+		changed := map[string][]ValueChange{}
+		var order []string
+		for _, group := range [][]ValueChange{tc.CriteriaFlipped, tc.OutcomesChanged, tc.ValuesChanged} {
+			for _, vc := range group {
+				if _, ok := changed[vc.RecordID]; !ok {
+					order = append(order, vc.RecordID)
+				}
+				changed[vc.RecordID] = append(changed[vc.RecordID], vc)
+			}
+		}
+		for _, row := range newRows {
+			if id := fmt.Sprint(row[idField]); oldIndex[id] == nil {
+				b.Publish(RecordChanged{Source: source, Table: tc.Table, RecordID: id, Kind: "added"})
+			}
+		}
+		for _, id := range order {
+			b.Publish(RecordChanged{Source: source, Table: tc.Table, RecordID: id, Kind: "changed", Changes: changed[id]})
+		}
+		for _, row := range oldRows {
+			if id := fmt.Sprint(row[idField]); newIndex[id] == nil {
+				b.Publish(RecordChanged{Source: source, Table: tc.Table, RecordID: id, Kind: "removed"})
+			}
+		}
+	}
+}
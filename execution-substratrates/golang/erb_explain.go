@@ -0,0 +1,179 @@
+// ERB SDK - Explain
+// =================
+// Explains how a calculated field got its value: the formula that ran, the
+// value of every input (with nested explanations for calculated inputs), each
+// intermediate result, and the final value.
+//
+//	exp, err := lc.Explain("FamilyFeudMismatch")
+//	fmt.Print(exp)
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Explanation is the provenance trace of one calculated field
+type Explanation struct {
+	Field   string         `json:"field"`
+	Formula string         `json:"formula"`
+	Inputs  []ExplainInput `json:"inputs"`
+	Steps   []ExplainStep  `json:"steps"`
+	Value   any            `json:"value"`
+}
+
+// ExplainInput is a field referenced by the formula; calculated inputs carry their own explanation
+type ExplainInput struct {
+	Field       string       `json:"field"`
+	Value       any          `json:"value"`
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// ExplainStep is an intermediate result, in evaluation order
+type ExplainStep struct {
+	Expression string `json:"expression"`
+	Value      any    `json:"value"`
+}
+
+// Explain traces how a calculated field is computed for this candidate
+func (tc *LanguageCandidate) Explain(field string) (*Explanation, error) {
+	return explainRecord(tc.ComputeAll(), LanguageCandidateFormulas, field)
+}
+
+// explainRecord explains field on a computed record (a pointer to a generated struct)
+func explainRecord(record any, formulas map[string]string, field string) (*Explanation, error) {
+	formula, ok := formulas[field]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a calculated field", field)
+	}
+	ast, err := ParseFormula(formula)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse formula for %s: %w", field, err)
+	}
+
+	exp := &Explanation{Field: field, Formula: formula}
+	for _, ref := range FormulaFieldRefs(ast) {
+		input := ExplainInput{Field: ref, Value: recordField(record, ref)}
+		if _, calculated := formulas[ref]; calculated {
+			if input.Explanation, err = explainRecord(record, formulas, ref); err != nil {
+				return nil, err
+			}
+		}
+		exp.Inputs = append(exp.Inputs, input)
+	}
+
+	eval := &FormulaEvaluator{
+		Lookup: func(name string) any { return recordField(record, name) },
+		Trace: func(node FormulaNode, value any) {
+			exp.Steps = append(exp.Steps, ExplainStep{Expression: node.String(), Value: value})
+		},
+	}
+	if exp.Value, err = eval.Eval(ast); err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s: %w", field, err)
+	}
+	if exp.Value == "" {
+		exp.Value = nil // ComputeAll stores empty strings as nil (optNilIfZero)
+	}
+	return exp, nil
+}
+
+// recordField reads a struct field by its rulebook (PascalCase) name, dereferencing pointers
+func recordField(record any, name string) any {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	f := v.FieldByName(name)
+	if !f.IsValid() {
+		return nil
+	}
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			return nil
+		}
+		f = f.Elem()
+	}
+	return f.Interface()
+}
+
+// String renders the explanation as an indented, human-readable trace
+func (e *Explanation) String() string {
+	var b strings.Builder
+	e.write(&b, "")
+	return b.String()
+}
+
+func (e *Explanation) write(b *strings.Builder, indent string) {
+	fmt.Fprintf(b, "%s%s = %s\n", indent, e.Field, formatValue(e.Value))
+	fmt.Fprintf(b, "%s  formula: %s\n", indent, oneLine(e.Formula))
+	if len(e.Inputs) > 0 {
+		fmt.Fprintf(b, "%s  inputs:\n", indent)
+		for _, in := range e.Inputs {
+			if in.Explanation != nil {
+				in.Explanation.write(b, indent+"    ")
+			} else {
+				fmt.Fprintf(b, "%s    %s = %s\n", indent, in.Field, formatValue(in.Value))
+			}
+		}
+	}
+	if len(e.Steps) > 0 {
+		fmt.Fprintf(b, "%s  steps:\n", indent)
+		for _, s := range e.Steps {
+			fmt.Fprintf(b, "%s    %s => %s\n", indent, s.Expression, formatValue(s.Value))
+		}
+	}
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runExplain implements `explain [--rulebook PATH] [--json] CANDIDATE FIELD`
+func runExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	asJSON := fs.Bool("json", false, "print the trace as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: explain [--rulebook PATH] [--json] CANDIDATE FIELD")
+	}
+	candidate, field := fs.Arg(0), fs.Arg(1)
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	for i := range rb.LanguageCandidates {
+		lc := &rb.LanguageCandidates[i]
+		if !strings.EqualFold(lc.LanguageCandidateId, candidate) && !strings.EqualFold(optGet(lc.Name, ""), candidate) {
+			continue
+		}
+
+		exp, err := lc.Explain(field)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			data, err := json.MarshalIndent(exp, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		fmt.Print(exp)
+		return nil
+	}
+	return fmt.Errorf("no candidate with id or name %q", candidate)
+}
@@ -0,0 +1,381 @@
+// ERB SDK - Exporters
+// ===================
+// An Exporter writes a table's computed records in one file format. Exporters
+// are registered by name, so the CLI (`export`, `take-test --outputs`) and
+// the server's content negotiation pick up a new format without changes:
+//
+//	RegisterExporter(myExporter{})
+//	e, ok := LookupExporter("csv")        // by name
+//	e, ok = ExporterForPath("answers.md") // by file extension
+//
+// Built-in formats: json, csv, md, xlsx, parquet, rdf (Turtle), html.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExportViews is the data handed to an exporter: one table's records, in order
+type ExportViews struct {
+	Table   string
+	Records []Record
+
+	// Calculated names the fields computed from formulas, which exporters
+	// may mark read-only (nil: the table's formulas in RunnerTables)
+	Calculated []string
+
+	// SortKeys asks exporters of keyed formats (json) to sort each record's
+	// keys instead of keeping field order
+	SortKeys bool
+}
+
+// IsCalculated reports whether a record key is a calculated field, in any casing
+func (v ExportViews) IsCalculated(key string) bool {
+	calculated := v.Calculated
+	if calculated == nil {
+		for _, t := range RunnerTables {
+			if t.Table == v.Table {
+				calculated = sortedKeys(t.Formulas)
+			}
+		}
+	}
+	for _, name := range calculated {
+		if nameKey(name) == nameKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exporter writes computed records in one file format
+type Exporter interface {
+	// Name is the format name used by --format and --outputs (e.g. "csv")
+	Name() string
+	// Extensions are the file extensions of the format, preferred first (e.g. ".csv")
+	Extensions() []string
+	// ContentType is the media type the server negotiates the format by
+	ContentType() string
+	// Write writes the views to w
+	Write(views ExportViews, w io.Writer) error
+}
+
+// exporters holds the registered exporters by name
+var exporters = map[string]Exporter{}
+
+func init() {
+	for _, e := range []Exporter{jsonExporter{}, csvExporter{}, markdownExporter{}, xlsxExporter{}, parquetExporter{}, rdfExporter{}, htmlExporter{}} {
+		RegisterExporter(e)
+	}
+}
+
+// RegisterExporter adds an exporter, replacing any registered under the same name
+func RegisterExporter(e Exporter) {
+	exporters[e.Name()] = e
+}
+
+// LookupExporter returns the exporter registered under name
+func LookupExporter(name string) (Exporter, bool) {
+	e, ok := exporters[strings.ToLower(name)]
+	return e, ok
+}
+
+// Exporters returns the registered exporters sorted by name
+func Exporters() []Exporter {
+	list := make([]Exporter, 0, len(exporters))
+	for _, name := range sortedKeys(exporters) {
+		list = append(list, exporters[name])
+	}
+	return list
+}
+
+// ExportFormats returns the names of the registered exporters
+func ExportFormats() []string {
+	return sortedKeys(exporters)
+}
+
+// ExporterForPath returns the exporter whose extensions include the path's extension
+func ExporterForPath(path string) (Exporter, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Exporters() {
+		for _, x := range e.Extensions() {
+			if x == ext {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// NegotiateExporter picks the exporter for an HTTP Accept header, honoring
+// q-values; it returns false if no registered format is acceptable
+func NegotiateExporter(accept string) (Exporter, bool) {
+	var best Exporter
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, e := range Exporters() {
+			if mediaTypeMatches(mediaType, e.ContentType()) {
+				best, bestQ = e, q
+				break
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// mediaTypeMatches reports whether an Accept media range covers a content
+// type; */* matches only JSON, the default format
+func mediaTypeMatches(mediaRange, contentType string) bool {
+	contentType, _, _ = mime.ParseMediaType(contentType)
+	switch {
+	case mediaRange == "*/*":
+		return contentType == "application/json"
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return mediaRange == contentType
+}
+
+// =============================================================================
+// OUTPUT TARGETS
+// =============================================================================
+
+// OutputTarget is one file a compute run writes
+type OutputTarget struct {
+	Format string
+	Path   string
+}
+
+// ParseOutputs parses a comma-separated list of format=path targets
+func ParseOutputs(spec string) ([]OutputTarget, error) {
+	var targets []OutputTarget
+	for _, item := range splitList(spec) {
+		format, path, ok := strings.Cut(item, "=")
+		format, path = strings.TrimSpace(format), strings.TrimSpace(path)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid output %q: want format=path", item)
+		}
+		if _, ok := LookupExporter(format); !ok {
+			return nil, fmt.Errorf("unknown output format %q (supported: %s)", format, strings.Join(ExportFormats(), ", "))
+		}
+		targets = append(targets, OutputTarget{Format: format, Path: path})
+	}
+	return targets, nil
+}
+
+// WithSuffix returns the target with suffix inserted before the file extension
+// (answers.csv -> answers.is_everything_a_language.csv)
+func (t OutputTarget) WithSuffix(suffix string) OutputTarget {
+	ext := filepath.Ext(t.Path)
+	t.Path = strings.TrimSuffix(t.Path, ext) + suffix + ext
+	return t
+}
+
+// WriteOutput writes a table's records to the target file
+func WriteOutput(target OutputTarget, table string, records []Record) error {
+	return writeOutputViews(commandContext(), target, ExportViews{Table: table, Records: records})
+}
+
+// writeOutputViews writes views to the target file, in a span under ctx
+func writeOutputViews(ctx context.Context, target OutputTarget, views ExportViews) (err error) {
+	e, ok := LookupExporter(target.Format)
+	if !ok {
+		return fmt.Errorf("unknown output format %q", target.Format)
+	}
+	_, span := startSpan(ctx, "erb.save_records", slog.String("erb.table", views.Table),
+		slog.String("erb.format", target.Format), slog.String("erb.path", target.Path), slog.Int("erb.records", len(views.Records)))
+	defer func() { endSpan(span, err) }()
+
+	f, err := os.Create(target.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target.Path, err)
+	}
+	if err := e.Write(views, f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", target.Path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	Logger.Info("records written", "table", views.Table, "format", target.Format, "path", target.Path, "records", len(views.Records))
+	return writeFlagsMeta(target.Path)
+}
+
+// =============================================================================
+// JSON, CSV, MARKDOWN
+// =============================================================================
+
+// jsonExporter writes the records as a canonical JSON array (see MarshalCanonical)
+type jsonExporter struct{}
+
+func (jsonExporter) Name() string         { return "json" }
+func (jsonExporter) Extensions() []string { return []string{".json"} }
+func (jsonExporter) ContentType() string  { return "application/json" }
+
+func (jsonExporter) Write(views ExportViews, w io.Writer) error {
+	var opts []CanonicalOption
+	if views.SortKeys {
+		opts = append(opts, WithSortedKeys())
+	}
+	return WriteCanonical(w, views.Records, opts...)
+}
+
+// csvExporter writes a header row of JSON keys and one row per record; nulls are empty cells
+type csvExporter struct{}
+
+func (csvExporter) Name() string         { return "csv" }
+func (csvExporter) Extensions() []string { return []string{".csv"} }
+func (csvExporter) ContentType() string  { return "text/csv; charset=utf-8" }
+
+func (csvExporter) Write(views ExportViews, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	keys := recordKeys(views.Records)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	for _, rec := range views.Records {
+		row := make([]string, len(keys))
+		for i, k := range keys {
+			row[i] = exportText(rec.Values[k])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownExporter writes a summary heading, the record count, and a table of the records
+type markdownExporter struct{}
+
+func (markdownExporter) Name() string         { return "md" }
+func (markdownExporter) Extensions() []string { return []string{".md", ".markdown"} }
+func (markdownExporter) ContentType() string  { return "text/markdown; charset=utf-8" }
+
+func (markdownExporter) Write(views ExportViews, w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%d records\n\n", views.Table, len(views.Records))
+
+	keys := recordKeys(views.Records)
+	if len(keys) > 0 {
+		b.WriteString("| " + strings.Join(keys, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat("---|", len(keys)) + "\n")
+		for _, rec := range views.Records {
+			cells := make([]string, len(keys))
+			for i, k := range keys {
+				cells[i] = markdownCell.Replace(exportText(rec.Values[k]))
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes pipes and flattens newlines inside a Markdown table cell
+var markdownCell = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// recordKeys returns the column order; records of one table share their keys
+func recordKeys(records []Record) []string {
+	if len(records) == 0 {
+		return nil
+	}
+	return records[0].Keys
+}
+
+// exportText renders a value as a text cell (nil is empty)
+func exportText(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runExport implements `export [--rulebook PATH] [--table NAME] [--format NAME] [--out FILE] [--include-internal] [--fields A,B] [--sort-keys] [--list]`:
+// writes a table's computed views in a registered format (chosen by --format,
+// else by the extension of --out, else JSON) to --out or stdout
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	table := fs.String("table", "LanguageCandidates", "table to export")
+	format := fs.String("format", "", "export format (default: from --out's extension, else json)")
+	out := fs.String("out", "", "file to write (default: stdout)")
+	includeInternal := fs.Bool("include-internal", false, `include fields marked "visibility": "internal"`)
+	sortKeys := fs.Bool("sort-keys", false, "sort each record's keys (json) instead of keeping field order")
+	fields := fs.String("fields", "", "comma-separated fields to export, in order (default: all)")
+	list := fs.Bool("list", false, "list the registered formats and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, e := range Exporters() {
+			fmt.Printf("%-8s %-18s %s\n", e.Name(), strings.Join(e.Extensions(), ","), e.ContentType())
+		}
+		return nil
+	}
+
+	var e Exporter
+	ok := false
+	switch {
+	case *format != "":
+		e, ok = LookupExporter(*format)
+	case *out != "":
+		e, ok = ExporterForPath(*out)
+	default:
+		e, ok = LookupExporter("json")
+	}
+	if !ok {
+		return fmt.Errorf("no exporter for format %q or file %q (supported: %s)", *format, *out, strings.Join(ExportFormats(), ", "))
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	views, err := rb.TableViews(*table, *includeInternal)
+	if err != nil {
+		return err
+	}
+	if f := fieldList(*fields); len(f) > 0 {
+		if views, err = views.Select(f...); err != nil {
+			return err
+		}
+	}
+	views.SortKeys = *sortKeys
+
+	if *out == "" {
+		return e.Write(views, os.Stdout)
+	}
+	return writeOutputViews(commandContext(), OutputTarget{Format: e.Name(), Path: *out}, views)
+}
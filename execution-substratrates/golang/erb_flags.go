@@ -0,0 +1,296 @@
+// ERB SDK - Experimental Feature Flags
+// ====================================
+// Flags switch the runtime formula evaluator (eval, explain, validate, the
+// mismatch report, tables without generated structs) to experimental
+// semantics. They are off by default; turn them on in erb-flags.json in the
+// working directory (or the file named by $ERB_FLAGS_FILE), or in $ERB_FLAGS,
+// which overrides the file:
+//
+//	{"three_valued_logic": true, "locale": "tr"}
+//
+//	ERB_FLAGS=three_valued_logic,probabilistic erb eval 'AND({{HasSyntax}}, {{HasGrammar}})' --each < probs.json
+//	ERB_FLAGS=locale=tr erb eval 'LOWER("ISTANBUL")'
+//
+//	three_valued_logic  nil is unknown: NOT, comparisons and AND/OR with an
+//	                    unknown operand are unknown unless decided (FALSE
+//	                    AND x is false, TRUE OR x is true)
+//	probabilistic       numbers in [0, 1] are probabilities of independent
+//	                    events: AND multiplies, OR is 1-(1-p)(1-q), NOT is
+//	                    1-p, and IF takes p >= 0.5 as true
+//	locale              LOWER uses the locale's case mapping (az, tr)
+//
+// Experiments must not leak into canonical artifacts: the generated code
+// ignores flags, take-test refuses to run with any on, every command notes
+// them on stderr, files written by WriteOutput get a <file>.meta.json
+// recording them, and `serve` sends them in an X-ERB-Experimental header.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultFlagsFile is the flags config read from the working directory
+const DefaultFlagsFile = "erb-flags.json"
+
+// FeatureFlags are the experimental semantics in effect; the zero value is canonical
+type FeatureFlags struct {
+	ThreeValuedLogic bool   `json:"three_valued_logic,omitempty"`
+	Probabilistic    bool   `json:"probabilistic,omitempty"`
+	Locale           string `json:"locale,omitempty"`
+}
+
+// localeCases are the locales with special case mappings
+var localeCases = map[string]unicode.SpecialCase{
+	"az": unicode.AzeriCase,
+	"tr": unicode.TurkishCase,
+}
+
+// Canonical reports whether no experimental flag is on
+func (f FeatureFlags) Canonical() bool {
+	return f == FeatureFlags{}
+}
+
+// Enabled lists the flags that are on, e.g. ["three_valued_logic", "locale=tr"]
+func (f FeatureFlags) Enabled() []string {
+	var on []string
+	if f.ThreeValuedLogic {
+		on = append(on, "three_valued_logic")
+	}
+	if f.Probabilistic {
+		on = append(on, "probabilistic")
+	}
+	if f.Locale != "" {
+		on = append(on, "locale="+f.Locale)
+	}
+	return on
+}
+
+func (f FeatureFlags) String() string {
+	if f.Canonical() {
+		return "canonical"
+	}
+	return strings.Join(f.Enabled(), ",")
+}
+
+// validate checks the locale has a case mapping
+func (f FeatureFlags) validate() error {
+	if _, ok := localeCases[f.Locale]; f.Locale != "" && !ok {
+		return fmt.Errorf("locale %q has no special case mapping (supported: %s)", f.Locale, strings.Join(sortedKeys(localeCases), ", "))
+	}
+	return nil
+}
+
+// activeFlags are the flags of the running command (see ActiveFlags)
+var activeFlags FeatureFlags
+
+// ActiveFlags returns the flags evaluators use when they have none of their own
+func ActiveFlags() FeatureFlags {
+	return activeFlags
+}
+
+// SetActiveFlags sets the flags of the running command
+func SetActiveFlags(f FeatureFlags) {
+	activeFlags = f
+}
+
+// LoadFeatureFlags reads the flags config ($ERB_FLAGS_FILE, else
+// erb-flags.json if it exists) and applies $ERB_FLAGS over it
+func LoadFeatureFlags() (FeatureFlags, error) {
+	var f FeatureFlags
+	path, explicit := os.LookupEnv("ERB_FLAGS_FILE")
+	if !explicit {
+		path = DefaultFlagsFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return f, fmt.Errorf("invalid flags file %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, os.ErrNotExist):
+		return f, fmt.Errorf("failed to read flags file: %w", err)
+	}
+	if env := os.Getenv("ERB_FLAGS"); env != "" {
+		if err := f.apply(env); err != nil {
+			return f, fmt.Errorf("invalid ERB_FLAGS %q: %w", env, err)
+		}
+	}
+	return f, f.validate()
+}
+
+// apply sets flags from a comma-separated list of name or name=value
+func (f *FeatureFlags) apply(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(item), "=")
+		if name == "" {
+			continue
+		}
+		var target *bool
+		switch name {
+		case "locale":
+			f.Locale = value
+			continue
+		case "three_valued_logic":
+			target = &f.ThreeValuedLogic
+		case "probabilistic":
+			target = &f.Probabilistic
+		default:
+			return fmt.Errorf("unknown flag %q (flags: three_valued_logic, probabilistic, locale)", name)
+		}
+		on := true
+		if hasValue {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("flag %s: %w", name, err)
+			}
+			on = b
+		}
+		*target = on
+	}
+	return nil
+}
+
+// writeFlagsMeta records non-canonical flags next to an output file
+func writeFlagsMeta(path string) error {
+	if activeFlags.Canonical() {
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]any{"canonical": false, "experimental_flags": activeFlags}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".meta.json", append(data, '\n'), 0o644)
+}
+
+// =============================================================================
+// EXPERIMENTAL SEMANTICS
+// =============================================================================
+
+// flags returns the evaluator's flags, or the active ones
+func (e *FormulaEvaluator) flags() FeatureFlags {
+	if e.Flags != nil {
+		return *e.Flags
+	}
+	return activeFlags
+}
+
+// probability returns v as a probability in probabilistic mode: numbers in
+// [0, 1], with booleans as 1 and 0
+func (e *FormulaEvaluator) probability(v any) (float64, bool) {
+	if !e.flags().Probabilistic {
+		return 0, false
+	}
+	switch x := v.(type) {
+	case float64:
+		return x, x >= 0 && x <= 1
+	case int:
+		return float64(x), x == 0 || x == 1
+	case bool:
+		return float64(boolRank(x)), true
+	}
+	return 0, false
+}
+
+// truth is the truth value of a condition; in probabilistic mode a
+// probability is true from 0.5
+func (e *FormulaEvaluator) truth(v any) bool {
+	if x, ok := v.(float64); ok {
+		if p, ok := e.probability(x); ok {
+			return p >= 0.5
+		}
+	}
+	return formulaBool(v)
+}
+
+// not negates a value: unknown stays unknown, a probability p becomes 1-p
+func (e *FormulaEvaluator) not(v any) any {
+	if v == nil && e.flags().ThreeValuedLogic {
+		return nil
+	}
+	if x, ok := v.(float64); ok {
+		if p, ok := e.probability(x); ok {
+			return 1 - p
+		}
+	}
+	return !formulaBool(v)
+}
+
+// compare compares two values; with three-valued logic unknown operands
+// make the comparison unknown
+func (e *FormulaEvaluator) compare(op string, l, r any) any {
+	if (l == nil || r == nil) && e.flags().ThreeValuedLogic {
+		return nil
+	}
+	return compareFormulaValues(op, l, r)
+}
+
+// combine implements AND and OR
+func (e *FormulaEvaluator) combine(and bool, values []any) any {
+	if hasFloat(values) {
+		if p, ok := e.combineProbabilities(and, values); ok {
+			return p
+		}
+	}
+	unknown := false
+	for _, v := range values {
+		if v == nil && e.flags().ThreeValuedLogic {
+			unknown = true
+			continue
+		}
+		if formulaBool(v) != and {
+			return !and
+		}
+	}
+	if unknown {
+		return nil
+	}
+	return and
+}
+
+// combineProbabilities combines independent probabilities (ok is false
+// unless every value is one)
+func (e *FormulaEvaluator) combineProbabilities(and bool, values []any) (float64, bool) {
+	result := 1.0
+	for _, v := range values {
+		p, ok := e.probability(v)
+		if !ok {
+			return 0, false
+		}
+		if and {
+			result *= p
+		} else {
+			result *= 1 - p
+		}
+	}
+	if and {
+		return result, true
+	}
+	return 1 - result, true
+}
+
+func hasFloat(values []any) bool {
+	for _, v := range values {
+		if _, ok := v.(float64); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lower lower-cases text with the locale's case mapping
+func (e *FormulaEvaluator) lower(s string) string {
+	if c, ok := localeCases[e.flags().Locale]; ok {
+		return strings.ToLowerSpecial(c, s)
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,681 @@
+// ERB SDK - Formula Interpreter
+// =============================
+// A runtime parser and evaluator for the Excel-dialect rulebook formulas.
+// It mirrors orchestration/formula_parser.py (same grammar, same AST) and the
+// nil-handling of the generated Calc* methods, so formulas can be inspected
+// and evaluated without going through code generation.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// =============================================================================
+// AST NODE TYPES
+// =============================================================================
+
+// FormulaNode is a node of a parsed formula; String() renders it back as formula text
+type FormulaNode interface {
+	String() string
+}
+
+// LiteralBool is TRUE() or FALSE()
+type LiteralBool struct{ Value bool }
+
+// LiteralInt is an integer literal
+type LiteralInt struct{ Value int }
+
+// LiteralString is a "quoted" string literal
+type LiteralString struct{ Value string }
+
+// FieldRef is a {{FieldName}} reference
+type FieldRef struct{ Name string }
+
+// BinaryOp is a comparison: =, <>, <, <=, >, >=
+type BinaryOp struct {
+	Op          string
+	Left, Right FormulaNode
+}
+
+// UnaryOp is NOT(x)
+type UnaryOp struct {
+	Op      string
+	Operand FormulaNode
+}
+
+// FuncCall is a function call such as AND, OR, IF, LOWER, FIND, CAST
+type FuncCall struct {
+	Name string
+	Args []FormulaNode
+}
+
+// Concat is a & b & c
+type Concat struct{ Parts []FormulaNode }
+
+func (n LiteralBool) String() string {
+	if n.Value {
+		return "TRUE()"
+	}
+	return "FALSE()"
+}
+
+func (n LiteralInt) String() string    { return strconv.Itoa(n.Value) }
+func (n LiteralString) String() string { return `"` + n.Value + `"` }
+func (n FieldRef) String() string      { return "{{" + n.Name + "}}" }
+
+func (n BinaryOp) String() string {
+	return operandString(n.Left) + " " + n.Op + " " + operandString(n.Right)
+}
+
+func (n UnaryOp) String() string { return n.Op + "(" + n.Operand.String() + ")" }
+
+func (n FuncCall) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (n Concat) String() string {
+	parts := make([]string, len(n.Parts))
+	for i, p := range n.Parts {
+		parts[i] = operandString(p)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// operandString parenthesizes nested comparisons and concatenations
+func operandString(n FormulaNode) string {
+	switch n.(type) {
+	case BinaryOp, Concat:
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+// =============================================================================
+// LEXER
+// =============================================================================
+
+type formulaTokenType int
+
+const (
+	tokString formulaTokenType = iota
+	tokNumber
+	tokFieldRef
+	tokFuncName
+	tokLParen
+	tokRParen
+	tokComma
+	tokAmpersand
+	tokCompare
+	tokEOF
+)
+
+type formulaToken struct {
+	typ   formulaTokenType
+	value string
+	pos   int
+}
+
+// tokenizeFormula splits a formula (with or without the leading =) into tokens
+func tokenizeFormula(formula string) ([]formulaToken, error) {
+	formula = strings.TrimPrefix(formula, "=")
+
+	var tokens []formulaToken
+	for i := 0; i < len(formula); {
+		c := formula[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"':
+			j := i + 1
+			for j < len(formula) && formula[j] != '"' {
+				if formula[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(formula) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, formulaToken{tokString, formula[i+1 : j], i})
+			i = j + 1
+
+		case strings.HasPrefix(formula[i:], "{{"):
+			j := strings.Index(formula[i:], "}}")
+			if j == -1 {
+				return nil, fmt.Errorf("unterminated field reference at position %d", i)
+			}
+			tokens = append(tokens, formulaToken{tokFieldRef, formula[i+2 : i+j], i})
+			i += j + 2
+
+		case isDigit(c) || (c == '-' && i+1 < len(formula) && isDigit(formula[i+1])):
+			j := i + 1
+			for j < len(formula) && isDigit(formula[j]) {
+				j++
+			}
+			tokens = append(tokens, formulaToken{tokNumber, formula[i:j], i})
+			i = j
+
+		case strings.HasPrefix(formula[i:], "<>"), strings.HasPrefix(formula[i:], "<="), strings.HasPrefix(formula[i:], ">="):
+			tokens = append(tokens, formulaToken{tokCompare, formula[i : i+2], i})
+			i += 2
+
+		case c == '<' || c == '>' || c == '=':
+			tokens = append(tokens, formulaToken{tokCompare, string(c), i})
+			i++
+
+		case c == '&':
+			tokens = append(tokens, formulaToken{tokAmpersand, "&", i})
+			i++
+
+		case c == '(':
+			tokens = append(tokens, formulaToken{tokLParen, "(", i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, formulaToken{tokRParen, ")", i})
+			i++
+
+		case c == ',':
+			tokens = append(tokens, formulaToken{tokComma, ",", i})
+			i++
+
+		case unicode.IsLetter(rune(c)) || c == '_':
+			j := i
+			for j < len(formula) && (isDigit(formula[j]) || formula[j] == '_' || unicode.IsLetter(rune(formula[j]))) {
+				j++
+			}
+			tokens = append(tokens, formulaToken{tokFuncName, strings.ToUpper(formula[i:j]), i})
+			i = j
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, formulaToken{tokEOF, "", len(formula)}), nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// =============================================================================
+// PARSER
+// =============================================================================
+
+// formulaParser is a recursive descent parser over formula tokens
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+}
+
+// ParseFormula parses an Excel-dialect formula into an AST
+func ParseFormula(formula string) (FormulaNode, error) {
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens}
+	node, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.current(); tok.typ != tokEOF {
+		return nil, fmt.Errorf("unexpected %q after expression at position %d", tok.value, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *formulaParser) current() formulaToken {
+	return p.tokens[p.pos]
+}
+
+func (p *formulaParser) consume(expected formulaTokenType, what string) (formulaToken, error) {
+	tok := p.current()
+	if tok.typ != expected {
+		return tok, fmt.Errorf("expected %s at position %d", what, tok.pos)
+	}
+	p.pos++
+	return tok, nil
+}
+
+func (p *formulaParser) parseConcat() (FormulaNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	parts := []FormulaNode{left}
+	for p.current().typ == tokAmpersand {
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, right)
+	}
+	if len(parts) == 1 {
+		return left, nil
+	}
+	return Concat{Parts: parts}, nil
+}
+
+func (p *formulaParser) parseComparison() (FormulaNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.current(); tok.typ == tokCompare {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return BinaryOp{Op: tok.value, Left: left, Right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parsePrimary() (FormulaNode, error) {
+	tok := p.current()
+	switch tok.typ {
+	case tokString:
+		p.pos++
+		return LiteralString{Value: tok.value}, nil
+
+	case tokNumber:
+		p.pos++
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.value, tok.pos)
+		}
+		return LiteralInt{Value: n}, nil
+
+	case tokFieldRef:
+		p.pos++
+		return FieldRef{Name: tok.value}, nil
+
+	case tokFuncName:
+		p.pos++
+		if tok.value == "TRUE" || tok.value == "FALSE" {
+			if p.current().typ == tokLParen {
+				p.pos++
+				if _, err := p.consume(tokRParen, "')'"); err != nil {
+					return nil, err
+				}
+			}
+			return LiteralBool{Value: tok.value == "TRUE"}, nil
+		}
+
+		if _, err := p.consume(tokLParen, "'(' after "+tok.value); err != nil {
+			return nil, err
+		}
+		var args []FormulaNode
+		if p.current().typ != tokRParen {
+			for {
+				arg, err := p.parseConcat()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.current().typ != tokComma {
+					break
+				}
+				p.pos++
+			}
+		}
+		if _, err := p.consume(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+
+		if tok.value == "NOT" && len(args) == 1 {
+			return UnaryOp{Op: "NOT", Operand: args[0]}, nil
+		}
+		return FuncCall{Name: tok.value, Args: args}, nil
+
+	case tokLParen:
+		p.pos++
+		expr, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+
+	if tok.typ == tokEOF {
+		return nil, fmt.Errorf("unexpected end of formula")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.value, tok.pos)
+}
+
+// FormulaFieldRefs returns the fields referenced by a parsed formula, in order of first use
+func FormulaFieldRefs(node FormulaNode) []string {
+	var refs []string
+	seen := map[string]bool{}
+	walkFormula(node, func(n FormulaNode) {
+		if f, ok := n.(FieldRef); ok && !seen[f.Name] {
+			seen[f.Name] = true
+			refs = append(refs, f.Name)
+		}
+	})
+	return refs
+}
+
+// walkFormula calls visit for every node, parents before children
+func walkFormula(node FormulaNode, visit func(FormulaNode)) {
+	visit(node)
+	switch n := node.(type) {
+	case BinaryOp:
+		walkFormula(n.Left, visit)
+		walkFormula(n.Right, visit)
+	case UnaryOp:
+		walkFormula(n.Operand, visit)
+	case FuncCall:
+		for _, a := range n.Args {
+			walkFormula(a, visit)
+		}
+	case Concat:
+		for _, p := range n.Parts {
+			walkFormula(p, visit)
+		}
+	}
+}
+
+// =============================================================================
+// EVALUATION
+// =============================================================================
+
+// FormulaEvaluator evaluates parsed formulas. Values are bool, int, string or
+// nil; nil behaves like the generated code's optGet defaults (false / "")
+// unless experimental flags say otherwise.
+type FormulaEvaluator struct {
+	// Lookup returns the value of a referenced field
+	Lookup func(name string) any
+
+	// Trace, if set, is called with every evaluated operator or function
+	// node and its result (children before parents)
+	Trace func(node FormulaNode, value any)
+
+	// Flags are the experimental semantics to evaluate with (nil: the
+	// active flags, see erb_flags.go)
+	Flags *FeatureFlags
+}
+
+// Eval evaluates a parsed formula
+func (e *FormulaEvaluator) Eval(node FormulaNode) (any, error) {
+	switch n := node.(type) {
+	case LiteralBool:
+		return n.Value, nil
+	case LiteralInt:
+		return n.Value, nil
+	case LiteralString:
+		return n.Value, nil
+	case FieldRef:
+		if e.Lookup == nil {
+			return nil, nil
+		}
+		return e.Lookup(n.Name), nil
+	}
+
+	value, err := e.evalOperator(node)
+	if err != nil {
+		return nil, err
+	}
+	if e.Trace != nil {
+		e.Trace(node, value)
+	}
+	return value, nil
+}
+
+func (e *FormulaEvaluator) evalOperator(node FormulaNode) (any, error) {
+	switch n := node.(type) {
+	case UnaryOp:
+		v, err := e.Eval(n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return e.not(v), nil
+
+	case BinaryOp:
+		l, err := e.Eval(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := e.Eval(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		return e.compare(n.Op, l, r), nil
+
+	case Concat:
+		var b strings.Builder
+		for _, p := range n.Parts {
+			v, err := e.Eval(p)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(formulaText(v))
+		}
+		return b.String(), nil
+
+	case FuncCall:
+		return e.evalFunc(n)
+	}
+	return nil, fmt.Errorf("unknown formula node %T", node)
+}
+
+func (e *FormulaEvaluator) evalFunc(n FuncCall) (any, error) {
+	args := func() ([]any, error) {
+		values := make([]any, len(n.Args))
+		for i, a := range n.Args {
+			v, err := e.Eval(a)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	arity := func(min, max int) error {
+		if len(n.Args) < min || len(n.Args) > max {
+			return fmt.Errorf("%s expects %s", n.Name, arityText(min, max))
+		}
+		return nil
+	}
+
+	switch n.Name {
+	case "AND", "OR":
+		values, err := args()
+		if err != nil {
+			return nil, err
+		}
+		return e.combine(n.Name == "AND", values), nil
+
+	case "IF":
+		if err := arity(2, 3); err != nil {
+			return nil, err
+		}
+		cond, err := e.Eval(n.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if e.truth(cond) {
+			return e.Eval(n.Args[1])
+		}
+		if len(n.Args) == 3 {
+			return e.Eval(n.Args[2])
+		}
+		return "", nil
+
+	case "NOT":
+		if err := arity(1, 1); err != nil {
+			return nil, err
+		}
+		v, err := e.Eval(n.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return e.not(v), nil
+
+	case "LOWER":
+		if err := arity(1, 1); err != nil {
+			return nil, err
+		}
+		values, err := args()
+		if err != nil {
+			return nil, err
+		}
+		return e.lower(formulaText(values[0])), nil
+
+	case "FIND":
+		if err := arity(2, 2); err != nil {
+			return nil, err
+		}
+		values, err := args()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Contains(formulaText(values[1]), formulaText(values[0])), nil
+
+	case "CAST":
+		if err := arity(1, 2); err != nil {
+			return nil, err
+		}
+		values, err := args()
+		if err != nil {
+			return nil, err
+		}
+		return formulaText(values[0]), nil
+	}
+
+	if f, ok := customFunctions[n.Name]; ok {
+		if len(n.Args) < f.MinArgs || (f.MaxArgs >= 0 && len(n.Args) > f.MaxArgs) {
+			return nil, fmt.Errorf("%s expects %s", n.Name, arityText(f.MinArgs, f.MaxArgs))
+		}
+		values, err := args()
+		if err != nil {
+			return nil, err
+		}
+		v, err := f.impl(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.Name, err)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", n.Name)
+}
+
+// FormulaFunction describes a function the formula engine supports
+type FormulaFunction struct {
+	Name        string `json:"name"`
+	MinArgs     int    `json:"min_args"`
+	MaxArgs     int    `json:"max_args"` // -1: any number
+	Description string `json:"description"`
+	Returns     string `json:"returns,omitempty"` // result datatype, if fixed
+}
+
+// FormulaFunctions lists the functions evalFunc implements: the built-ins,
+// then those added with RegisterFormulaFunction
+var FormulaFunctions = []FormulaFunction{
+	{Name: "AND", MinArgs: 0, MaxArgs: -1, Description: "true if every argument is true", Returns: "boolean"},
+	{Name: "OR", MinArgs: 0, MaxArgs: -1, Description: "true if any argument is true", Returns: "boolean"},
+	{Name: "IF", MinArgs: 2, MaxArgs: 3, Description: "the second argument if the first is true, else the third (or empty)"},
+	{Name: "NOT", MinArgs: 1, MaxArgs: 1, Description: "the negation of the argument", Returns: "boolean"},
+	{Name: "LOWER", MinArgs: 1, MaxArgs: 1, Description: "the argument's text in lower case", Returns: "string"},
+	{Name: "FIND", MinArgs: 2, MaxArgs: 2, Description: "whether the first argument's text occurs in the second's", Returns: "boolean"},
+	{Name: "CAST", MinArgs: 1, MaxArgs: 2, Description: "the argument as text (the type argument is ignored)", Returns: "string"},
+}
+
+func arityText(min, max int) string {
+	switch {
+	case max < 0 && min == 1:
+		return "at least 1 argument"
+	case max < 0:
+		return fmt.Sprintf("at least %d arguments", min)
+	case min == max && min == 1:
+		return "1 argument"
+	case min == max:
+		return fmt.Sprintf("%d arguments", min)
+	}
+	return fmt.Sprintf("%d to %d arguments", min, max)
+}
+
+// formulaBool is the truth value of a formula value (nil is false)
+func formulaBool(v any) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case int:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return false
+}
+
+// formulaText is the string value of a formula value (nil is "")
+func formulaText(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// compareFormulaValues compares like the generated code: integers compared
+// with nil are unequal (and never ordered), booleans treat nil as false, and
+// everything else compares as text
+func compareFormulaValues(op string, l, r any) bool {
+	li, lInt := l.(int)
+	ri, rInt := r.(int)
+	if lInt || rInt {
+		if !lInt || !rInt {
+			return op == "<>"
+		}
+		return compareOrdered(op, li, ri)
+	}
+
+	_, lBool := l.(bool)
+	_, rBool := r.(bool)
+	if lBool || rBool || (l == nil && r == nil) {
+		return compareOrdered(op, boolRank(formulaBool(l)), boolRank(formulaBool(r)))
+	}
+	return compareOrdered(op, formulaText(l), formulaText(r))
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareOrdered[T int | string](op string, l, r T) bool {
+	switch op {
+	case "=":
+		return l == r
+	case "<>":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// ERB SDK - Formula Function Library
+// ==================================
+// Functions beyond the built-ins can be registered with the formula engine,
+// so rulebook formulas that use them evaluate at runtime (Table.Compute,
+// eval, explain, lint) without changing the interpreter. Arguments are
+// evaluated before the function is called:
+//
+//	err := RegisterFormulaFunction(FormulaFunction{Name: "UPPER", MinArgs: 1, MaxArgs: 1,
+//		Description: "the argument's text in upper case", Returns: "string"},
+//		func(args []any) (any, error) { return strings.ToUpper(formulaText(args[0])), nil })
+//
+// The CLI registers the extended library below (LEN, REGEX_MATCH, SWITCH).
+// Generated code (gen) and the SQL and transpiled substrates only know the
+// built-ins, and report registered functions as unknown.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// FormulaImpl computes a registered function from its evaluated arguments
+type FormulaImpl func(args []any) (any, error)
+
+type customFunction struct {
+	FormulaFunction
+	impl FormulaImpl
+}
+
+// customFunctions holds the registered functions by name
+var customFunctions = map[string]customFunction{}
+
+// functionName matches the names the formula tokenizer reads as functions
+var functionName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// RegisterFormulaFunction adds a function to the formula engine, replacing a
+// registered function of the same name; built-ins cannot be replaced. Like
+// the importer and exporter registries it is meant to be called during
+// initialization, before formulas are evaluated.
+func RegisterFormulaFunction(f FormulaFunction, impl FormulaImpl) error {
+	switch {
+	case !functionName.MatchString(f.Name) || f.Name == "TRUE" || f.Name == "FALSE":
+		return fmt.Errorf("invalid formula function name %q (use upper-case letters, digits and _)", f.Name)
+	case impl == nil:
+		return fmt.Errorf("formula function %s has no implementation", f.Name)
+	case f.MinArgs < 0 || (f.MaxArgs >= 0 && f.MaxArgs < f.MinArgs):
+		return fmt.Errorf("formula function %s: invalid arity %d to %d", f.Name, f.MinArgs, f.MaxArgs)
+	}
+
+	for i, known := range FormulaFunctions {
+		if known.Name != f.Name {
+			continue
+		}
+		if _, ok := customFunctions[f.Name]; !ok {
+			return fmt.Errorf("%s is a built-in formula function", f.Name)
+		}
+		FormulaFunctions = append(FormulaFunctions[:i:i], FormulaFunctions[i+1:]...)
+		break
+	}
+	customFunctions[f.Name] = customFunction{FormulaFunction: f, impl: impl}
+	FormulaFunctions = append(FormulaFunctions, f)
+	return nil
+}
+
+// LookupFormulaFunction returns a built-in or registered function
+func LookupFormulaFunction(name string) (FormulaFunction, bool) {
+	for _, f := range FormulaFunctions {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FormulaFunction{}, false
+}
+
+// =============================================================================
+// EXTENDED LIBRARY
+// =============================================================================
+
+// RegisterExtendedFormulaFunctions registers LEN, REGEX_MATCH and SWITCH
+func RegisterExtendedFormulaFunctions() error {
+	library := []struct {
+		FormulaFunction
+		impl FormulaImpl
+	}{
+		{FormulaFunction{Name: "LEN", MinArgs: 1, MaxArgs: 1, Description: "the number of characters in the argument's text", Returns: "integer"}, formulaLen},
+		{FormulaFunction{Name: "REGEX_MATCH", MinArgs: 2, MaxArgs: 2, Description: "whether the first argument's text matches the regular expression in the second (Go syntax)", Returns: "boolean"}, formulaRegexMatch},
+		{FormulaFunction{Name: "SWITCH", MinArgs: 3, MaxArgs: -1, Description: "the result paired with the first value equal to the expression: SWITCH(expr, value, result, ..., [default])"}, formulaSwitch},
+	}
+	for _, f := range library {
+		if err := RegisterFormulaFunction(f.FormulaFunction, f.impl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formulaLen(args []any) (any, error) {
+	return utf8.RuneCountInString(formulaText(args[0])), nil
+}
+
+// compiledPatterns caches REGEX_MATCH patterns, which are usually literals
+var compiledPatterns sync.Map // pattern -> *regexp.Regexp
+
+func formulaRegexMatch(args []any) (any, error) {
+	pattern := formulaText(args[1])
+	re, ok := compiledPatterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		re, _ = compiledPatterns.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(formulaText(args[0])), nil
+}
+
+// formulaSwitch matches values of the same type and value, and an empty
+// field matches ""; with no match and no default the result is empty, as
+// IF without an else
+func formulaSwitch(args []any) (any, error) {
+	expr, cases := args[0], args[1:]
+	if expr == nil {
+		expr = ""
+	}
+	for len(cases) >= 2 {
+		if valuesEqual(expr, cases[0]) {
+			return cases[1], nil
+		}
+		cases = cases[2:]
+	}
+	if len(cases) == 1 {
+		return cases[0], nil
+	}
+	return "", nil
+}
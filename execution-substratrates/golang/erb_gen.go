@@ -0,0 +1,714 @@
+// ERB SDK - Code Generator
+// ========================
+// The generator behind erb_sdk.go, erb_runner.go and erb_golden_test.go,
+// ported from inject-into-golang.py so regenerating needs only Go:
+//
+//	go generate main.go
+//	go run $(ls *.go | grep -v _test.go) gen --rulebook ../../effortless-rulebook/effortless-rulebook.json --dir .
+//
+// --dir DIR writes all three files into DIR (the current directory when
+// neither flag is given); --out FILE writes only the SDK, to FILE, so it
+// can be generated elsewhere without touching the runner or golden test.
+//
+// Formulas compile exactly as compile_to_go in orchestration/formula_parser.py
+// does, so both generators write the same bytes. The file layouts live in
+// templates embedded from this directory: sdk.go.tmpl (text/template, fed a
+// genData), runner.go.tmpl and golden_test.go.tmpl (${name} placeholders,
+// shared with the Python generator). --templates DIR replaces any of them
+// with a file of the same name in DIR.
+//
+// Smaller changes need no copy of sdk.go.tmpl: every other *.tmpl file in
+// DIR is a partial whose {{define}}s replace the template's blocks:
+//
+//	license    text before the header, e.g. a license comment (default empty)
+//	imports    extra import lines, each starting with a newline (default none)
+//	structTag  a struct field's tag, given the field (default json:"snake_name")
+//	methods    code after each table's section, given the table (default empty)
+//
+// For example, a partial adding db tags:
+//
+//	{{define "structTag"}}json:"{{.JSONTag}}" db:"{{.JSONTag}}"{{end}}
+//
+// Like the Python generator, gen stops before writing anything if a formula
+// references an unknown field, returns a different datatype than its field,
+// or takes part in a dependency cycle.
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf16"
+)
+
+//go:embed sdk.go.tmpl runner.go.tmpl golden_test.go.tmpl
+var genTemplates embed.FS
+
+// GenOptions configures Generate; zero values use the defaults of inject-into-golang.py
+type GenOptions struct {
+	TemplateDir string // templates replacing the embedded ones, and partials overriding blocks
+	TestingDir  string // runner: blank tests, relative to the output (default ../../testing)
+	AnswersDir  string // runner: where answers are written (default .)
+	Label       string // runner: prefix of its messages (default "Golang substrate")
+	GoldenDir   string // golden tests: the golden files (default testdata/golden)
+}
+
+func (o GenOptions) withDefaults() GenOptions {
+	if o.TestingDir == "" {
+		o.TestingDir = "../../testing"
+	}
+	if o.AnswersDir == "" {
+		o.AnswersDir = "."
+	}
+	if o.Label == "" {
+		o.Label = "Golang substrate"
+	}
+	if o.GoldenDir == "" {
+		o.GoldenDir = "testdata/golden"
+	}
+	return o
+}
+
+// GeneratedFile is one file written by Generate
+type GeneratedFile struct {
+	Name    string // e.g. erb_sdk.go
+	Content []byte
+}
+
+// genData is what sdk.go.tmpl renders
+type genData struct {
+	Source  string // the rulebook, as dir/file
+	Tables  []genTable
+	Primary *genTable // the first table with calculated fields, if any
+}
+
+// genTable is a table of genData
+type genTable struct {
+	Name       string
+	Struct     string
+	Schema     []genField   // every field, in rulebook order
+	Fields     []genField   // the struct's fields: raw, then calculated
+	Raw        []genField   // raw fields
+	Calculated []genField   // calculated fields with a formula
+	Levels     [][]genField // calculated fields by DAG level, in rulebook order
+}
+
+// genField is a field of genTable; the formula parts are set for calculated fields
+type genField struct {
+	Name     string
+	Datatype string
+	GoType   string // the struct field's type, e.g. *bool
+	JSONTag  string
+	Hash     string // fieldDefinitionHash
+
+	QueryType string // the type of its QueryField: int or string
+	QueryRef  string // a pointer to the field of r
+
+	ReturnType     string // the Calc function's return type
+	FormulaLiteral string // the formula as a Go string literal
+	FormulaLine    string // the formula on one line
+	CalcExpr       string // the formula compiled against tc
+	ComputeExpr    string // the same, reading earlier levels from local variables
+	Var            string // ComputeAll's local variable
+	Wrap           string // turns Var back into the struct field: optPtr or optNilIfZero
+
+	Equal string // compares the field of tc and other, for RawEqual
+}
+
+// Generate renders erb_sdk.go, erb_runner.go and erb_golden_test.go for a
+// rulebook; source names it in the header of erb_sdk.go
+func (rb *Rulebook) Generate(source string, opts GenOptions) ([]GeneratedFile, error) {
+	opts = opts.withDefaults()
+	if err := rb.checkGeneratable(); err != nil {
+		return nil, err
+	}
+	data, err := rb.genData(source)
+	if err != nil {
+		return nil, err
+	}
+
+	sdkText, err := readGenTemplate(opts.TemplateDir, "sdk.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("sdk.go.tmpl").Funcs(template.FuncMap{
+		"upper": strings.ToUpper,
+		"inc":   func(i int) int { return i + 1 },
+	}).Parse(sdkText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sdk.go.tmpl: %w", err)
+	}
+	partials, err := genPartials(opts.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range partials {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read partial: %w", err)
+		}
+		if _, err := tmpl.New(filepath.Base(path)).Parse(string(text)); err != nil {
+			return nil, fmt.Errorf("failed to parse partial %s: %w", filepath.Base(path), err)
+		}
+	}
+	var sdk bytes.Buffer
+	if err := tmpl.Execute(&sdk, data); err != nil {
+		return nil, fmt.Errorf("failed to render sdk.go.tmpl: %w", err)
+	}
+
+	var entries strings.Builder
+	for _, t := range data.Tables {
+		if len(t.Calculated) == 0 {
+			continue
+		}
+		primary := entries.Len() == 0
+		suffix := "." + toSnakeCase(t.Name)
+		if primary {
+			suffix = ""
+		}
+		fmt.Fprintf(&entries, "\t{\n"+
+			"\t\tTable:    %q,\n"+
+			"\t\tInput:    \"blank-test%s.json\",\n"+
+			"\t\tOutput:   \"test-answers%s.json\",\n"+
+			"\t\tSuffix:   \"%s\",\n"+
+			"\t\tRequired: %t,\n"+
+			"\t\tFormulas: %sFormulas,\n"+
+			"\t\tcompute: func(data []byte, opts []RecordOption) ([]Record, error) {\n"+
+			"\t\t\treturn computeRecords(data, (*%s).ComputeAll, opts)\n"+
+			"\t\t},\n"+
+			"\t},\n", t.Name, suffix, suffix, suffix, primary, t.Struct, t.Struct)
+	}
+	runner, err := expandGenTemplate(opts.TemplateDir, "runner.go.tmpl", map[string]string{
+		"testing_dir": opts.TestingDir,
+		"answers_dir": opts.AnswersDir,
+		"label":       opts.Label,
+		"tables":      entries.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	golden, err := expandGenTemplate(opts.TemplateDir, "golden_test.go.tmpl", map[string]string{
+		"golden_dir": opts.GoldenDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []GeneratedFile{
+		{Name: "erb_sdk.go", Content: sdk.Bytes()},
+		{Name: "erb_runner.go", Content: []byte(runner)},
+		{Name: "erb_golden_test.go", Content: []byte(golden)},
+	}, nil
+}
+
+// checkGeneratable returns the problems that would generate Go that does
+// not compile: unknown references, formulas of the wrong datatype, cycles
+func (rb *Rulebook) checkGeneratable() error {
+	problems := rb.CheckReferences()
+	for _, t := range rb.Tables {
+		types := map[string]string{}
+		for _, f := range t.Schema {
+			types[f.Name] = f.Datatype
+		}
+		for _, f := range t.Schema {
+			if !f.IsCalculated() {
+				continue
+			}
+			ast, err := ParseFormula(f.Formula)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err))
+				continue
+			}
+			if got := FormulaType(ast, types); got != "" && got != f.Datatype {
+				problems = append(problems, fmt.Errorf("%s.%s is %s but its formula returns %s: %s", t.Name, f.Name, f.Datatype, got, f.Formula))
+			}
+		}
+	}
+	if err := rb.CheckCycles(); err != nil {
+		problems = append(problems, err)
+	}
+	return errors.Join(problems...)
+}
+
+// genData collects what sdk.go.tmpl needs from the rulebook
+func (rb *Rulebook) genData(source string) (*genData, error) {
+	data := &genData{Source: source}
+	for _, t := range rb.Tables {
+		gt := genTable{Name: t.Name, Struct: structName(t.Name)}
+		calculated := map[string]bool{}
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				calculated[f.Name] = true
+			}
+		}
+
+		for _, f := range t.Schema {
+			gf := genField{
+				Name:      f.Name,
+				Datatype:  f.Datatype,
+				GoType:    goFieldType(f.Datatype, f.Nullable),
+				JSONTag:   toSnakeCase(f.Name),
+				Hash:      fieldDefinitionHash(t.Name, f),
+				QueryType: "string",
+				QueryRef:  "r." + f.Name,
+			}
+			if strings.ToLower(f.Datatype) == "integer" {
+				gf.QueryType = "int"
+			}
+			gf.Equal = "optEqual(tc." + f.Name + ", other." + f.Name + ")"
+			if !f.Nullable {
+				gf.QueryRef = "&r." + f.Name
+				gf.Equal = "tc." + f.Name + " == other." + f.Name
+			}
+			gt.Schema = append(gt.Schema, gf)
+
+			switch {
+			case f.IsCalculated():
+				expr, err := compileGoFormula(f.Formula, nil)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+				}
+				gf.ReturnType = strings.TrimPrefix(goFieldType(f.Datatype, false), "*")
+				gf.FormulaLiteral = pythonJSONString(f.Formula)
+				gf.FormulaLine = strings.TrimSpace(strings.ReplaceAll(f.Formula, "\n", " "))
+				gf.CalcExpr = expr
+				gf.Var = strings.ToLower(f.Name[:1]) + f.Name[1:]
+				gf.Wrap = "optNilIfZero"
+				if f.Datatype == "boolean" || f.Datatype == "integer" {
+					gf.Wrap = "optPtr"
+				}
+				gt.Calculated = append(gt.Calculated, gf)
+			case f.Type == "raw":
+				gt.Raw = append(gt.Raw, gf)
+			}
+		}
+		for _, f := range gt.Raw {
+			if !calculated[f.Name] {
+				gt.Fields = append(gt.Fields, f)
+			}
+		}
+		gt.Fields = append(gt.Fields, gt.Calculated...)
+
+		// ComputeAll reads calculated fields of earlier levels from their
+		// local variables, in the order they were computed
+		levels := t.Levels()
+		var computed []genField
+		for level := 1; len(computed) < len(gt.Calculated); level++ {
+			var fields []genField
+			for _, f := range gt.Calculated {
+				if levels[f.Name] != level {
+					continue
+				}
+				expr, err := compileGoFormula(t.fieldFormula(f.Name), computed)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+				}
+				f.ComputeExpr = expr
+				fields = append(fields, f)
+			}
+			computed = append(computed, fields...)
+			gt.Levels = append(gt.Levels, fields)
+		}
+		data.Tables = append(data.Tables, gt)
+	}
+
+	for i := range data.Tables {
+		if len(data.Tables[i].Calculated) > 0 {
+			data.Primary = &data.Tables[i]
+			break
+		}
+	}
+	return data, nil
+}
+
+// fieldFormula is the formula of a schema field
+func (t *Table) fieldFormula(name string) string {
+	f, _ := t.Field(name)
+	return f.Formula
+}
+
+// goFieldType maps a rulebook datatype to a struct field type
+// (datatype_to_go in inject-into-golang.py)
+func goFieldType(datatype string, nullable bool) string {
+	goType := "string"
+	switch strings.ToLower(datatype) {
+	case "boolean":
+		goType = "bool"
+	case "integer":
+		goType = "int"
+	}
+	if nullable {
+		return "*" + goType
+	}
+	return goType
+}
+
+// =============================================================================
+// FORMULA COMPILER
+// =============================================================================
+
+// goCompareOps maps formula comparison operators to Go
+var goCompareOps = map[string]string{"=": "==", "<>": "!=", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+// ifFieldCondition matches an IF whose condition is a bare pointer field
+var ifFieldCondition = regexp.MustCompile(`if (tc\.\w+) \{`)
+
+// compileGoFormula compiles a formula to a Go expression over tc; fields in
+// computed are read from their local variables instead (compile_formula_to_go
+// in inject-into-golang.py)
+func compileGoFormula(formula string, computed []genField) (string, error) {
+	ast, err := ParseFormula(formula)
+	if err != nil {
+		return "", err
+	}
+	expr, err := compileGoNode(ast)
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range computed {
+		ref := "tc." + f.Name
+		expr = strings.ReplaceAll(expr, "optGet("+ref+", false)", f.Var)
+		expr = strings.ReplaceAll(expr, "optGet("+ref+`, "")`, f.Var)
+		eq := regexp.MustCompile(`\(` + regexp.QuoteMeta(ref) + ` != nil && \*` + regexp.QuoteMeta(ref) + ` == ([^)]+)\)`)
+		expr = eq.ReplaceAllStringFunc(expr, func(m string) string {
+			rhs := eq.FindStringSubmatch(m)[1]
+			if strings.HasPrefix(rhs, "tc.") {
+				return "(" + f.Var + " == optGet(" + rhs + ", false))"
+			}
+			return "(" + f.Var + " == " + rhs + ")"
+		})
+		expr = strings.ReplaceAll(expr, ref+" != nil && *"+ref, f.Var)
+		expr = strings.ReplaceAll(expr, ref, f.Var)
+	}
+	return ifFieldCondition.ReplaceAllString(expr, "if optGet(${1}, false) {"), nil
+}
+
+// compileGoNode compiles one formula node (compile_to_go in
+// orchestration/formula_parser.py); fields are pointers on tc
+func compileGoNode(node FormulaNode) (string, error) {
+	switch n := node.(type) {
+	case LiteralBool:
+		return fmt.Sprint(n.Value), nil
+	case LiteralInt:
+		return fmt.Sprint(n.Value), nil
+	case LiteralString:
+		return goStringLiteral(n.Value), nil
+	case FieldRef:
+		return "tc." + n.Name, nil
+	case UnaryOp:
+		return compileGoNot(n.Operand)
+	case BinaryOp:
+		return compileGoCompare(n)
+	case Concat:
+		parts := make([]string, len(n.Parts))
+		for i, part := range n.Parts {
+			expr, err := compileGoNode(part)
+			if err != nil {
+				return "", err
+			}
+			if _, ok := part.(FieldRef); ok {
+				expr = `optGet(` + expr + `, "")`
+			}
+			parts[i] = expr
+		}
+		return strings.Join(parts, " + "), nil
+	case FuncCall:
+		return compileGoCall(n)
+	}
+	return "", fmt.Errorf("unknown formula node %T", node)
+}
+
+// compileGoNot compiles NOT(operand)
+func compileGoNot(operand FormulaNode) (string, error) {
+	expr, err := compileGoNode(operand)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := operand.(FieldRef); ok {
+		return "!optGet(" + expr + ", false)", nil
+	}
+	return "!(" + expr + ")", nil
+}
+
+// compileGoCompare compiles a comparison; pointer fields are nil-checked
+func compileGoCompare(n BinaryOp) (string, error) {
+	op, ok := goCompareOps[n.Op]
+	if !ok {
+		return "", fmt.Errorf("unknown operator %s", n.Op)
+	}
+	left, err := compileGoNode(n.Left)
+	if err != nil {
+		return "", err
+	}
+	right, err := compileGoNode(n.Right)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := n.Left.(FieldRef); ok {
+		switch n.Right.(type) {
+		case FieldRef:
+			return "(optGet(" + left + ", false) " + op + " optGet(" + right + ", false))", nil
+		case LiteralInt:
+			switch n.Op {
+			case "=":
+				return "(" + left + " != nil && *" + left + " == " + right + ")", nil
+			case "<>":
+				return "(" + left + " == nil || *" + left + " != " + right + ")", nil
+			}
+			return "(" + left + " != nil && *" + left + " " + op + " " + right + ")", nil
+		case LiteralBool:
+			return "(optGet(" + left + ", false) " + op + " " + right + ")", nil
+		}
+	}
+	return "(" + left + " " + op + " " + right + ")", nil
+}
+
+// compileGoCall compiles a function call
+func compileGoCall(n FuncCall) (string, error) {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		expr, err := compileGoNode(arg)
+		if err != nil {
+			return "", err
+		}
+		args[i] = expr
+	}
+	arity := func(want int) error {
+		if len(args) != want {
+			return fmt.Errorf("%s requires %d argument(s)", n.Name, want)
+		}
+		return nil
+	}
+
+	switch n.Name {
+	case "AND", "OR":
+		parts := make([]string, len(args))
+		for i, arg := range n.Args {
+			parts[i] = args[i]
+			if _, ok := arg.(FieldRef); ok {
+				parts[i] = "optGet(" + args[i] + ", false)"
+			}
+		}
+		sep := " && "
+		if n.Name == "OR" {
+			sep = " || "
+		}
+		return "(" + strings.Join(parts, sep) + ")", nil
+	case "IF":
+		if len(args) < 2 {
+			return "", fmt.Errorf("IF requires at least 2 arguments")
+		}
+		otherwise := `""`
+		if len(args) > 2 {
+			otherwise = args[2]
+		}
+		return "func() string { if " + args[0] + " { return " + args[1] + " }; return " + otherwise + " }()", nil
+	case "NOT":
+		if err := arity(1); err != nil {
+			return "", err
+		}
+		return compileGoNot(n.Args[0])
+	case "LOWER":
+		if err := arity(1); err != nil {
+			return "", err
+		}
+		return `strings.ToLower(optGet(` + args[0] + `, ""))`, nil
+	case "FIND":
+		if err := arity(2); err != nil {
+			return "", err
+		}
+		return `strings.Contains(optGet(` + args[1] + `, ""), ` + args[0] + `)`, nil
+	case "CAST":
+		if len(args) < 1 {
+			return "", fmt.Errorf("CAST requires at least 1 argument")
+		}
+		if _, ok := n.Args[0].(FieldRef); ok {
+			return "fmt.Sprint(optGet(" + args[0] + ", false))", nil
+		}
+		return `fmt.Sprintf("%v", ` + args[0] + `)`, nil
+	}
+	return "", fmt.Errorf("unknown function %s", n.Name)
+}
+
+// goStringLiteral quotes a formula string, escaping only \ and "
+func goStringLiteral(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// pythonJSONString quotes s as Python's json.dumps does: non-ASCII as \uXXXX,
+// so the generated Formulas maps match byte for byte
+func pythonJSONString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			switch {
+			case r < 0x20 || (r > 0x7f && r < 0x10000):
+				fmt.Fprintf(&b, `\u%04x`, r)
+			case r >= 0x10000:
+				hi, lo := utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04x\u%04x`, hi, lo)
+			default:
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// =============================================================================
+// TEMPLATES
+// =============================================================================
+
+// readGenTemplate returns a template from dir, or the embedded one if dir
+// is empty or has no file of that name
+func readGenTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read template: %w", err)
+		}
+	}
+	data, err := genTemplates.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(data), nil
+}
+
+// genPartials lists the partials in dir: its *.tmpl files other than the
+// templates it replaces
+func genPartials(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	var partials []string
+	for _, path := range paths {
+		switch filepath.Base(path) {
+		case "sdk.go.tmpl", "runner.go.tmpl", "golden_test.go.tmpl":
+		default:
+			partials = append(partials, path)
+		}
+	}
+	return partials, nil
+}
+
+// expandGenTemplate renders a template with ${name} placeholders ($$ is a
+// literal $), as Python's string.Template.substitute does
+func expandGenTemplate(dir, name string, values map[string]string) (string, error) {
+	text, err := readGenTemplate(dir, name)
+	if err != nil {
+		return "", err
+	}
+	var unknown []string
+	out := os.Expand(text, func(key string) string {
+		if key == "$" {
+			return "$"
+		}
+		v, ok := values[key]
+		if !ok {
+			unknown = append(unknown, key)
+		}
+		return v
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("%s: unknown placeholders %s", name, strings.Join(unknown, ", "))
+	}
+	return out, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runGen implements `gen [--rulebook PATH] [--dir DIR | --out FILE]
+// [--templates DIR] [--check]`: writes erb_sdk.go, erb_runner.go and
+// erb_golden_test.go into --dir, or only erb_sdk.go to --out
+func runGen(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	out := fs.String("out", "", "write only the SDK, to this file")
+	dir := fs.String("dir", "", "write the SDK, runner and golden test into this directory (default \".\" unless --out is given)")
+	templates := fs.String("templates", "", "directory of templates replacing the embedded ones (sdk.go.tmpl, runner.go.tmpl, golden_test.go.tmpl) and partials overriding their blocks")
+	check := fs.Bool("check", false, "only report files that differ from what would be generated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(*rulebookPath)
+	if err != nil {
+		return err
+	}
+	source := filepath.ToSlash(filepath.Join(filepath.Base(filepath.Dir(abs)), filepath.Base(abs)))
+
+	files, err := rb.Generate(source, GenOptions{TemplateDir: *templates})
+	if err != nil {
+		return fmt.Errorf("not generating: %w", err)
+	}
+
+	if *out == "" && *dir == "" {
+		*dir = "."
+	}
+	var stale []string
+	for _, f := range files {
+		var path string
+		switch {
+		case f.Name == "erb_sdk.go" && *out != "":
+			path = *out
+		case *dir != "":
+			path = filepath.Join(*dir, f.Name)
+		default:
+			continue // --out alone writes only the SDK
+		}
+		if *check {
+			if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, f.Content) {
+				stale = append(stale, path)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote: %s (%d bytes)\n", path, len(f.Content))
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("regenerate needed: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
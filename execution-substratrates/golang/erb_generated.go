@@ -0,0 +1,89 @@
+// ERB SDK - Generated Code Drift
+// ==============================
+// erb_sdk.go embeds a hash of every field definition it was generated from
+// (GeneratedFieldHashes). Comparing those with the current rulebook tells
+// whether the generated code is stale and which fields changed.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FieldDrift is a field whose definition differs from what erb_sdk.go was generated from
+type FieldDrift struct {
+	Field string // "Table.Field"
+	Kind  string // "changed", "added", or "removed"
+}
+
+// fieldDefinitionHash hashes the parts of a field that affect generated code.
+// Must match field_definition_hash in inject-into-golang.py.
+func fieldDefinitionHash(table string, f Field) string {
+	nullable := "false"
+	if f.Nullable {
+		nullable = "true"
+	}
+	parts := []string{table + "." + f.Name, f.Datatype, f.Type, nullable, f.Formula}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// GeneratedDrift compares the rulebook with the field hashes embedded in the
+// generated code; an empty result means the generated code is current
+func (rb *Rulebook) GeneratedDrift(generated map[string]string) []FieldDrift {
+	var drift []FieldDrift
+	current := map[string]bool{}
+	for _, t := range rb.Tables {
+		for _, f := range t.Schema {
+			key := t.Name + "." + f.Name
+			current[key] = true
+			switch hash, ok := generated[key]; {
+			case !ok:
+				drift = append(drift, FieldDrift{Field: key, Kind: "added"})
+			case hash != fieldDefinitionHash(t.Name, f):
+				drift = append(drift, FieldDrift{Field: key, Kind: "changed"})
+			}
+		}
+	}
+	for _, key := range sortedKeys(generated) {
+		if !current[key] {
+			drift = append(drift, FieldDrift{Field: key, Kind: "removed"})
+		}
+	}
+	return drift
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runCheckGenerated implements `check-generated [--rulebook PATH]`
+func runCheckGenerated(args []string) error {
+	fs := flag.NewFlagSet("check-generated", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	drift := rb.GeneratedDrift(GeneratedFieldHashes)
+	if len(drift) == 0 {
+		fmt.Println("erb_sdk.go is up to date with the rulebook")
+		return nil
+	}
+
+	fmt.Println("Regenerate needed (go generate main.go or python3 inject-into-golang.py); drifted fields:")
+	for _, d := range drift {
+		fmt.Printf("  %-8s %s\n", d.Kind, d.Field)
+	}
+	return fmt.Errorf("erb_sdk.go is stale: %d field(s) drifted", len(drift))
+}
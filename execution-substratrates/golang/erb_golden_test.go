@@ -0,0 +1,62 @@
+// ERB SDK - Golden Answer Tests (GENERATED - DO NOT EDIT)
+// =======================================================
+// Rendered by inject-into-golang.py or `gen` from golden_test.go.tmpl. For
+// every table in RunnerTables, computes the blank test the way take-test does
+// and compares the answers field by field with the checked-in golden file:
+//
+//	go test $(ls *.go)            # compare with testdata/golden/
+//	go test $(ls *.go) -update    # rewrite the golden files after an intended change
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// goldenDir holds one golden answers file per RunnerTables Output
+const goldenDir = "testdata/golden"
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden answer files from the computed answers")
+
+func TestGoldenAnswers(t *testing.T) {
+	for _, table := range RunnerTables {
+		t.Run(table.Table, func(t *testing.T) {
+			input := filepath.Join(DefaultTestingDir, table.Input)
+			data, err := os.ReadFile(input)
+			if errors.Is(err, fs.ErrNotExist) && !table.Required {
+				t.Skipf("no blank test for %s (%s)", table.Table, input)
+			}
+			if err != nil {
+				t.Fatalf("failed to load blank test: %v", err)
+			}
+			records, err := table.compute(data, []RecordOption{WithStrictFields()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(goldenDir, table.Output)
+			if *updateGolden {
+				if err := os.MkdirAll(goldenDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := WriteOutput(OutputTarget{Format: "json", Path: golden}, table.Table, records); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := readAnswers(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if report := CompareRecords(want, records); !report.OK() {
+				t.Errorf("%s answers differ from %s:\n%s", table.Table, golden, report)
+			}
+		})
+	}
+}
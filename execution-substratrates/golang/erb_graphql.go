@@ -0,0 +1,833 @@
+// ERB SDK - GraphQL
+// =================
+// A GraphQL layer over the rulebook's computed views, so a client asks for
+// exactly the raw and calculated fields it needs and follows the
+// candidate <-> argument step relationship in one round trip:
+//
+//	{
+//	  languageCandidate(id: "english") {
+//	    name
+//	    hasGrammar
+//	    argumentSteps { argumentName statement }
+//	  }
+//	  argumentSteps(stepType: "Conclusion") { name candidate { name isOpenWorld } }
+//	}
+//
+// Every table field is exposed under its camelCase name with the type of its
+// schema datatype (the primary key is an ID). List fields take any scalar
+// field as an equality filter. The SDK has no dependencies, so this is a
+// small executor for the query subset clients need: selections, aliases,
+// arguments, variables and __typename. Fragments, directives, mutations and
+// introspection queries are rejected (GraphQLSchema prints the SDL instead).
+// A query is validated before it runs and returns no data if it has errors.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GraphQLRequest is a query in the standard POST body shape
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQLResponse is the result of a query: data in selection order, or
+// errors (and null data)
+type GraphQLResponse struct {
+	Data   *Record        `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError is one problem with a query
+type GraphQLError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// graphqlRelation links two tables by a field holding the other's primary key
+type graphqlRelation struct {
+	from, field, to string // from.field references to's primary key
+	one, many       string // field names on from (to one) and on to (to many)
+}
+
+// graphqlTables names the object type and query fields of each table; other
+// tables are exposed with names derived from the table name
+var graphqlTables = map[string][3]string{ // table -> type, list field, single field
+	"LanguageCandidates":    {"LanguageCandidate", "languageCandidates", "languageCandidate"},
+	"IsEverythingALanguage": {"IsEverythingALanguage", "argumentSteps", "argumentStep"},
+}
+
+var graphqlRelations = []graphqlRelation{
+	{from: "IsEverythingALanguage", field: "RelatedCandidateId", to: "LanguageCandidates", one: "candidate", many: "argumentSteps"},
+}
+
+// =============================================================================
+// SCHEMA
+// =============================================================================
+
+// gqlSchema is the GraphQL view of one rulebook
+type gqlSchema struct {
+	query   *gqlObject
+	objects []*gqlObject // table types, in rulebook order
+}
+
+// gqlObject is an object type; field values are resolved from a parent value
+// (a Record for table types, nil for Query)
+type gqlObject struct {
+	name   string
+	fields []*gqlField
+}
+
+func (o *gqlObject) field(name string) *gqlField {
+	for _, f := range o.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// gqlField is one field of an object type
+type gqlField struct {
+	name    string
+	typ     string     // SDL type, e.g. "[LanguageCandidate!]!"
+	object  *gqlObject // element type of object-valued fields; nil for scalars
+	args    []gqlArg
+	resolve func(parent any, args map[string]any) any
+}
+
+// gqlArg is a field argument; datatype is the schema datatype it must match
+type gqlArg struct {
+	name, typ, datatype string
+	required            bool
+}
+
+// gqlTable is a table's computed records and the GraphQL names of its fields
+type gqlTable struct {
+	object  *gqlObject
+	records []Record
+	keys    map[string]string // GraphQL field -> record key
+	byID    map[string]Record
+	filters []gqlArg // equality filters a list of the table's records takes
+}
+
+// newGraphQLSchema computes every table's views and builds the schema over them
+func newGraphQLSchema(rb *Rulebook, includeInternal bool) (*gqlSchema, error) {
+	s := &gqlSchema{query: &gqlObject{name: "Query"}}
+	tables := map[string]*gqlTable{}
+	for _, t := range rb.Tables {
+		views, err := rb.TableViews(t.Name, includeInternal)
+		if err != nil {
+			return nil, err
+		}
+		names, ok := graphqlTables[t.Name]
+		if !ok {
+			typ := toPascalCase(t.Name)
+			names = [3]string{typ, graphqlName(typ) + "List", graphqlName(typ)}
+		}
+
+		gt := &gqlTable{object: &gqlObject{name: names[0]}, records: views.Records, keys: map[string]string{}, byID: map[string]Record{}}
+		for _, rec := range views.Records {
+			gt.byID[recordID(rec)] = rec
+		}
+		for i, f := range t.Schema {
+			if f.IsInternal() && !includeInternal {
+				continue
+			}
+			key := toSnakeCase(f.Name)
+			if len(views.Records) > 0 {
+				if _, ok := views.Records[0].Get(key); !ok {
+					key = f.Name
+				}
+			}
+			name, typ := graphqlName(f.Name), graphqlScalar(f.Datatype)
+			if i == 0 {
+				typ = "ID"
+			}
+			gt.keys[name] = key
+			gt.filters = append(gt.filters, gqlArg{name: name, typ: typ, datatype: f.Datatype})
+			if !f.Nullable || i == 0 {
+				typ += "!"
+			}
+			gt.object.fields = append(gt.object.fields, &gqlField{name: name, typ: typ, resolve: func(parent any, _ map[string]any) any {
+				v, _ := parent.(Record).Get(key)
+				return v
+			}})
+		}
+		tables[t.Name] = gt
+		s.objects = append(s.objects, gt.object)
+
+		s.query.fields = append(s.query.fields,
+			&gqlField{name: names[1], typ: "[" + names[0] + "!]!", object: gt.object, args: gt.filters,
+				resolve: func(_ any, args map[string]any) any { return gt.filter(gt.records, args) }},
+			&gqlField{name: names[2], typ: names[0], object: gt.object,
+				args: []gqlArg{{name: "id", typ: "ID!", datatype: "string", required: true}},
+				resolve: func(_ any, args map[string]any) any {
+					if rec, ok := gt.byID[args["id"].(string)]; ok {
+						return rec
+					}
+					return nil
+				}})
+	}
+
+	for _, rel := range graphqlRelations {
+		from, to := tables[rel.from], tables[rel.to]
+		if from == nil || to == nil {
+			continue
+		}
+		ref, ok := from.keys[graphqlName(rel.field)]
+		if !ok {
+			continue // the reference field is internal
+		}
+		from.object.fields = append(from.object.fields, &gqlField{name: rel.one, typ: to.object.name, object: to.object,
+			resolve: func(parent any, _ map[string]any) any {
+				v, _ := parent.(Record).Get(ref)
+				if rec, ok := to.byID[formulaText(v)]; ok && v != nil {
+					return rec
+				}
+				return nil
+			}})
+		to.object.fields = append(to.object.fields, &gqlField{name: rel.many, typ: "[" + from.object.name + "!]!", object: from.object,
+			args: from.filters, resolve: func(parent any, args map[string]any) any {
+				var refs []Record
+				id := recordID(parent.(Record))
+				for _, rec := range from.records {
+					if v, _ := rec.Get(ref); v != nil && formulaText(v) == id {
+						refs = append(refs, rec)
+					}
+				}
+				return from.filter(refs, args)
+			}})
+	}
+	return s, nil
+}
+
+// filter returns the records whose fields equal every argument given
+func (gt *gqlTable) filter(records []Record, args map[string]any) []Record {
+	out := []Record{}
+	for _, rec := range records {
+		match := true
+		for name, want := range args {
+			if v, _ := rec.Get(gt.keys[name]); formulaText(v) != formulaText(want) || (v == nil) != (want == nil) {
+				match = false
+				break
+			}
+		}
+		if match {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// graphqlName is a schema field or type name in GraphQL field case (camelCase)
+func graphqlName(name string) string {
+	name = toPascalCase(name)
+	for i, r := range name {
+		return string(unicode.ToLower(r)) + name[i+len(string(r)):]
+	}
+	return name
+}
+
+// graphqlScalar maps a schema datatype to a GraphQL scalar
+func graphqlScalar(datatype string) string {
+	switch datatype {
+	case "boolean":
+		return "Boolean"
+	case "integer":
+		return "Int"
+	default:
+		return "String"
+	}
+}
+
+// GraphQLSchema returns the schema clients query, in SDL, without internal
+// fields unless includeInternal is set
+func (rb *Rulebook) GraphQLSchema(includeInternal bool) (string, error) {
+	s, err := newGraphQLSchema(rb, includeInternal)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, obj := range append([]*gqlObject{s.query}, s.objects...) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "type %s {\n", obj.name)
+		for _, f := range obj.fields {
+			args := ""
+			if len(f.args) > 0 {
+				parts := make([]string, len(f.args))
+				for i, a := range f.args {
+					parts[i] = a.name + ": " + a.typ
+				}
+				args = "(" + strings.Join(parts, ", ") + ")"
+				if len(f.args) > 2 {
+					args = "(\n    " + strings.Join(parts, "\n    ") + "\n  )"
+				}
+			}
+			fmt.Fprintf(&b, "  %s%s: %s\n", f.name, args, f.typ)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String(), nil
+}
+
+// =============================================================================
+// EXECUTION
+// =============================================================================
+
+// GraphQL runs a query against the rulebook's computed views, without internal
+// fields unless includeInternal is set
+func (rb *Rulebook) GraphQL(req GraphQLRequest, includeInternal bool) GraphQLResponse {
+	fail := func(err error) GraphQLResponse {
+		return GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}
+	}
+	op, err := parseGraphQL(req.Query, req.OperationName)
+	if err != nil {
+		return fail(err)
+	}
+	s, err := newGraphQLSchema(rb, includeInternal)
+	if err != nil {
+		return fail(err)
+	}
+
+	vars := map[string]any{}
+	for name, def := range op.defaults {
+		vars[name] = def
+	}
+	for name, v := range req.Variables {
+		vars[name] = v
+	}
+	e := &gqlExecutor{vars: vars, declared: op.declared}
+	if e.validate(s.query, op.selections, nil); len(e.errors) > 0 {
+		return GraphQLResponse{Errors: e.errors}
+	}
+	data := e.selectFields(s.query, nil, op.selections)
+	return GraphQLResponse{Data: &data}
+}
+
+// gqlExecutor resolves one operation, collecting errors with their path
+type gqlExecutor struct {
+	vars     map[string]any
+	declared map[string]bool
+	errors   []GraphQLError
+}
+
+func (e *gqlExecutor) errorf(path []any, format string, args ...any) {
+	e.errors = append(e.errors, GraphQLError{Message: fmt.Sprintf(format, args...), Path: append([]any(nil), path...)})
+}
+
+// validate checks a selection set on obj before anything is resolved, so a
+// query's errors do not depend on the data
+func (e *gqlExecutor) validate(obj *gqlObject, selections []gqlSelection, path []any) {
+	seen := map[string]bool{}
+	for _, sel := range selections {
+		key := sel.responseKey()
+		fieldPath := append(path[:len(path):len(path)], key)
+		if seen[key] {
+			e.errorf(fieldPath, "field %q is selected more than once; use an alias", key)
+			continue
+		}
+		seen[key] = true
+		if sel.name == "__typename" {
+			continue
+		}
+
+		f := obj.field(sel.name)
+		if f == nil {
+			e.errorf(fieldPath, "%s has no field %q", obj.name, sel.name)
+			continue
+		}
+		e.arguments(f, sel, fieldPath)
+		switch {
+		case f.object == nil && sel.selections != nil:
+			e.errorf(fieldPath, "%s.%s is a %s and takes no selection", obj.name, f.name, f.typ)
+		case f.object != nil && sel.selections == nil:
+			e.errorf(fieldPath, "%s.%s is a %s and needs a selection of its fields", obj.name, f.name, f.typ)
+		case f.object != nil:
+			e.validate(f.object, sel.selections, fieldPath)
+		}
+	}
+}
+
+// selectFields resolves a validated selection set on parent, which has type obj
+func (e *gqlExecutor) selectFields(obj *gqlObject, parent any, selections []gqlSelection) Record {
+	out := Record{Values: map[string]any{}}
+	for _, sel := range selections {
+		key := sel.responseKey()
+		out.Keys = append(out.Keys, key)
+		if sel.name == "__typename" {
+			out.Values[key] = obj.name
+			continue
+		}
+
+		f := obj.field(sel.name)
+		args, _ := e.arguments(f, sel, nil)
+		value := f.resolve(parent, args)
+		switch v := value.(type) {
+		case Record:
+			value = e.selectFields(f.object, v, sel.selections)
+		case []Record:
+			list := make([]Record, len(v))
+			for i, rec := range v {
+				list[i] = e.selectFields(f.object, rec, sel.selections)
+			}
+			value = list
+		}
+		out.Values[key] = value
+	}
+	return out
+}
+
+// arguments resolves a selection's arguments (and variables) against f's,
+// checking each value's type
+func (e *gqlExecutor) arguments(f *gqlField, sel gqlSelection, path []any) (map[string]any, bool) {
+	args := map[string]any{}
+	ok := true
+	for _, name := range sortedKeys(sel.args) {
+		var def *gqlArg
+		for i := range f.args {
+			if f.args[i].name == name {
+				def = &f.args[i]
+			}
+		}
+		if def == nil {
+			e.errorf(path, "%s has no argument %q", f.name, name)
+			ok = false
+			continue
+		}
+
+		v := sel.args[name]
+		if ref, isVar := v.(gqlVariable); isVar {
+			if !e.declared[string(ref)] {
+				e.errorf(path, "variable $%s is not declared", ref)
+				ok = false
+				continue
+			}
+			if v, isVar = e.vars[string(ref)]; !isVar {
+				continue // an unset variable omits the argument
+			}
+		}
+		if n, isFloat := v.(float64); isFloat && n == float64(int(n)) {
+			v = int(n) // JSON variables
+		}
+		if v != nil {
+			if FormulaType(graphqlLiteral(v), nil) != def.datatype {
+				e.errorf(path, "argument %q of %s expects %s, got %s", name, f.name, def.typ, pgDiffValue(v))
+				ok = false
+				continue
+			}
+		}
+		args[name] = v
+	}
+	for _, def := range f.args {
+		if _, given := args[def.name]; def.required && (!given || args[def.name] == nil) {
+			e.errorf(path, "%s needs argument %q of type %s", f.name, def.name, def.typ)
+			ok = false
+		}
+	}
+	return args, ok
+}
+
+// graphqlLiteral is the formula literal for an argument value, for typing it
+func graphqlLiteral(v any) FormulaNode {
+	switch x := v.(type) {
+	case bool:
+		return LiteralBool{Value: x}
+	case int:
+		return LiteralInt{Value: x}
+	case string:
+		return LiteralString{Value: x}
+	}
+	return nil
+}
+
+// =============================================================================
+// PARSER
+// =============================================================================
+
+// gqlSelection is one field in a selection set; selections is nil for a leaf
+type gqlSelection struct {
+	alias, name string
+	args        map[string]any // literal values or gqlVariable
+	selections  []gqlSelection
+}
+
+// responseKey is the key the field's value has in the response
+func (sel gqlSelection) responseKey() string {
+	if sel.alias != "" {
+		return sel.alias
+	}
+	return sel.name
+}
+
+// gqlVariable is a $name reference in an argument
+type gqlVariable string
+
+// gqlOperation is the query to run: its selections and declared variables
+type gqlOperation struct {
+	name       string
+	selections []gqlSelection
+	declared   map[string]bool
+	defaults   map[string]any
+}
+
+// parseGraphQL parses a document and returns the operation named operationName
+// (which may be empty when the document has one operation)
+func parseGraphQL(query, operationName string) (*gqlOperation, error) {
+	p := &gqlParser{src: query}
+	p.next()
+	var ops []*gqlOperation
+	for p.tok.kind != gqlEOF {
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("the query has no operation")
+	}
+	for _, op := range ops {
+		if op.name == operationName || (operationName == "" && len(ops) == 1) {
+			return op, nil
+		}
+	}
+	if operationName == "" {
+		return nil, fmt.Errorf("the query has %d operations; choose one with operationName", len(ops))
+	}
+	return nil, fmt.Errorf("the query has no operation %q", operationName)
+}
+
+// Token kinds
+const (
+	gqlEOF = iota
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+	gqlPunct
+)
+
+type gqlToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// gqlParser is a recursive descent parser over the query subset
+type gqlParser struct {
+	src string
+	pos int
+	tok gqlToken
+	err error
+}
+
+// next advances to the next token, skipping whitespace, commas and comments
+func (p *gqlParser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = gqlToken{kind: gqlEOF, pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		p.tok = gqlToken{kind: gqlName, text: p.src[start:p.pos], pos: start}
+	case c == '-' || unicode.IsDigit(rune(c)):
+		p.pos++
+		kind := gqlInt
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", rune(p.src[p.pos])) {
+			if !unicode.IsDigit(rune(p.src[p.pos])) {
+				kind = gqlFloat
+			}
+			p.pos++
+		}
+		p.tok = gqlToken{kind: kind, text: p.src[start:p.pos], pos: start}
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' && p.src[p.pos] != '\n' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			p.fail(start, "unterminated string")
+			p.tok = gqlToken{kind: gqlEOF, pos: start}
+			return
+		}
+		p.pos++
+		p.tok = gqlToken{kind: gqlString, text: p.src[start:p.pos], pos: start}
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = gqlToken{kind: gqlPunct, text: "...", pos: start}
+	default:
+		p.pos++
+		p.tok = gqlToken{kind: gqlPunct, text: string(c), pos: start}
+	}
+}
+
+// fail records the first syntax error, with its line and column
+func (p *gqlParser) fail(pos int, format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	line := strings.Count(p.src[:pos], "\n") + 1
+	col := pos - strings.LastIndex(p.src[:pos], "\n")
+	p.err = fmt.Errorf("syntax error at %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+// expect consumes a punctuator
+func (p *gqlParser) expect(punct string) bool {
+	if p.tok.kind != gqlPunct || p.tok.text != punct {
+		p.fail(p.tok.pos, "expected %q, found %s", punct, p.describe())
+		return false
+	}
+	p.next()
+	return true
+}
+
+// name consumes a name token
+func (p *gqlParser) name() string {
+	if p.tok.kind != gqlName {
+		p.fail(p.tok.pos, "expected a name, found %s", p.describe())
+		return ""
+	}
+	name := p.tok.text
+	p.next()
+	return name
+}
+
+func (p *gqlParser) describe() string {
+	if p.tok.kind == gqlEOF {
+		return "end of query"
+	}
+	return strconv.Quote(p.tok.text)
+}
+
+func (p *gqlParser) is(punct string) bool {
+	return p.tok.kind == gqlPunct && p.tok.text == punct
+}
+
+// operation parses `{...}` or `query [Name] [($var: Type = default, ...)] {...}`
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	op := &gqlOperation{declared: map[string]bool{}, defaults: map[string]any{}}
+	if p.tok.kind == gqlName {
+		switch p.tok.text {
+		case "query":
+			p.next()
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported; the rulebook is read-only", p.tok.text)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			p.fail(p.tok.pos, "expected an operation, found %s", p.describe())
+			return nil, p.err
+		}
+		if p.tok.kind == gqlName {
+			op.name = p.name()
+		}
+		if p.is("(") {
+			p.next()
+			for p.err == nil && !p.is(")") {
+				p.expect("$")
+				name := p.name()
+				p.expect(":")
+				p.varType()
+				op.declared[name] = true
+				if p.is("=") {
+					p.next()
+					op.defaults[name] = p.value(false)
+				}
+			}
+			p.expect(")")
+		}
+	}
+	if p.is("@") {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+	op.selections = p.selectionSet()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return op, nil
+}
+
+// varType skips a variable's type (Name, [Type], with optional !); values
+// are checked against the argument they are used for
+func (p *gqlParser) varType() {
+	if p.is("[") {
+		p.next()
+		p.varType()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	if p.is("!") {
+		p.next()
+	}
+}
+
+// selectionSet parses `{ field ... }`
+func (p *gqlParser) selectionSet() []gqlSelection {
+	if !p.expect("{") {
+		return nil
+	}
+	selections := []gqlSelection{}
+	for p.err == nil && !p.is("}") {
+		if p.is("...") {
+			p.fail(p.tok.pos, "fragments are not supported")
+			return nil
+		}
+		sel := gqlSelection{name: p.name()}
+		if p.is(":") {
+			p.next()
+			sel.alias, sel.name = sel.name, p.name()
+		}
+		if p.is("(") {
+			p.next()
+			sel.args = map[string]any{}
+			for p.err == nil && !p.is(")") {
+				name := p.name()
+				p.expect(":")
+				if _, dup := sel.args[name]; dup {
+					p.fail(p.tok.pos, "argument %q is given more than once", name)
+				}
+				sel.args[name] = p.value(true)
+			}
+			p.expect(")")
+		}
+		if p.is("@") {
+			p.fail(p.tok.pos, "directives are not supported")
+		}
+		if p.is("{") {
+			sel.selections = p.selectionSet()
+		}
+		selections = append(selections, sel)
+	}
+	p.expect("}")
+	if len(selections) == 0 {
+		p.fail(p.tok.pos, "a selection set needs at least one field")
+	}
+	return selections
+}
+
+// value parses a scalar literal, null, or (where allowed) a $variable
+func (p *gqlParser) value(variables bool) any {
+	tok := p.tok
+	switch {
+	case tok.kind == gqlPunct && tok.text == "$" && variables:
+		p.next()
+		return gqlVariable(p.name())
+	case tok.kind == gqlString:
+		p.next()
+		s, err := strconv.Unquote(tok.text)
+		if err != nil {
+			p.fail(tok.pos, "invalid string %s", tok.text)
+		}
+		return s
+	case tok.kind == gqlInt:
+		p.next()
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			p.fail(tok.pos, "invalid integer %s", tok.text)
+		}
+		return n
+	case tok.kind == gqlFloat:
+		p.next()
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			p.fail(tok.pos, "invalid number %s", tok.text)
+		}
+		return n
+	case tok.kind == gqlName && (tok.text == "true" || tok.text == "false"):
+		p.next()
+		return tok.text == "true"
+	case tok.kind == gqlName && tok.text == "null":
+		p.next()
+		return nil
+	}
+	p.fail(tok.pos, "expected a value, found %s", p.describe())
+	return nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runGraphQL implements `graphql [--rulebook PATH] [--variables JSON]
+// [--operation NAME] [--include-internal] QUERY` and `graphql --schema`
+func runGraphQL(args []string) error {
+	fs := flag.NewFlagSet("graphql", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	schema := fs.Bool("schema", false, "print the GraphQL schema (SDL) instead of running a query")
+	variables := fs.String("variables", "", "query variables as a JSON object")
+	operation := fs.String("operation", "", "operation to run when the query has several")
+	includeInternal := fs.Bool("include-internal", false, "expose fields marked internal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	if *schema {
+		sdl, err := rb.GraphQLSchema(*includeInternal)
+		if err != nil {
+			return err
+		}
+		fmt.Print(sdl)
+		return nil
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: graphql [--rulebook PATH] [--variables JSON] [--operation NAME] QUERY, or graphql --schema")
+	}
+
+	req := GraphQLRequest{Query: fs.Arg(0), OperationName: *operation}
+	if *variables != "" {
+		if err := json.Unmarshal([]byte(*variables), &req.Variables); err != nil {
+			return fmt.Errorf("failed to parse --variables: %w", err)
+		}
+	}
+	resp := rb.GraphQL(req, *includeInternal)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("query failed with %d errors", len(resp.Errors))
+	}
+	return nil
+}
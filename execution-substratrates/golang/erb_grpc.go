@@ -0,0 +1,209 @@
+// ERB SDK - gRPC Compute Service
+// ==============================
+// Serves the Compute service of the generated .proto (see erb_proto.go) so
+// substrates in other languages can have records computed by the generated
+// Go code instead of reimplementing it. gRPC is HTTP/2 with length-prefixed
+// protobuf messages and a grpc-status trailer; net/http speaks unencrypted
+// HTTP/2, so the server needs no gRPC library:
+//
+//	go run $(ls *.go | grep -v _test.go) grpc --addr :50051
+//	grpcurl -plaintext -proto erb.proto -d '{"name": "English"}' \
+//	    localhost:50051 erb.Compute/ComputeLanguageCandidate
+//
+// Every method is unary. Requests may be gzip-compressed; responses are not.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gRPC status codes the server returns
+const (
+	grpcOK              = 0
+	grpcInvalidArgument = 3
+	grpcUnimplemented   = 12
+	grpcInternal        = 13
+)
+
+// grpcMethod is one RPC of the Compute service
+type grpcMethod struct {
+	table  *Table
+	runner RunnerTable
+	list   bool // takes and returns a <Message>List
+}
+
+// GRPCServer serves the Compute service for a rulebook's tables
+type GRPCServer struct {
+	methods map[string]grpcMethod // "/erb.Compute/ComputeLanguageCandidate" -> method
+}
+
+// NewGRPCServer creates the Compute service for the tables of rb that the
+// conformance runner computes
+func NewGRPCServer(rb *Rulebook) *GRPCServer {
+	s := &GRPCServer{methods: map[string]grpcMethod{}}
+	for _, rt := range RunnerTables {
+		t := rb.Table(rt.Table)
+		if t == nil {
+			continue
+		}
+		path := "/" + ProtoPackage + ".Compute/Compute" + structName(t.Name)
+		s.methods[path] = grpcMethod{table: t, runner: rt}
+		s.methods[path+"List"] = grpcMethod{table: t, runner: rt, list: true}
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *GRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+
+	method, ok := s.methods[r.URL.Path]
+	if !ok {
+		writeGRPCStatus(w, grpcUnimplemented, fmt.Sprintf("unknown method %s", r.URL.Path))
+		return
+	}
+	msg, err := readGRPCMessage(r)
+	if err != nil {
+		writeGRPCStatus(w, grpcInvalidArgument, err.Error())
+		return
+	}
+	out, code, err := method.call(r.Context(), msg)
+	if err != nil {
+		writeGRPCStatus(w, code, err.Error())
+		return
+	}
+
+	frame := make([]byte, 5, 5+len(out))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(out)))
+	w.Write(append(frame, out...))
+	writeGRPCStatus(w, grpcOK, "")
+}
+
+// call decodes a request message, computes its records with the generated
+// code, giving up once ctx is done, and encodes the response message
+func (m grpcMethod) call(ctx context.Context, msg []byte) ([]byte, int, error) {
+	var records []Record
+	if m.list {
+		var err error
+		if records, err = m.table.DecodeProtoList(msg); err != nil {
+			return nil, grpcInvalidArgument, err
+		}
+	} else {
+		rec, err := m.table.DecodeProtoRecord(msg)
+		if err != nil {
+			return nil, grpcInvalidArgument, err
+		}
+		records = []Record{rec}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, grpcInternal, err
+	}
+	computed, err := m.runner.compute(data, []RecordOption{withContext(ctx)})
+	if err != nil {
+		return nil, grpcInvalidArgument, err
+	}
+
+	var out []byte
+	if m.list {
+		out, err = m.table.EncodeProtoList(computed)
+	} else {
+		out, err = m.table.EncodeProtoRecord(computed[0])
+	}
+	if err != nil {
+		return nil, grpcInternal, err
+	}
+	return out, grpcOK, nil
+}
+
+// readGRPCMessage reads the single length-prefixed message of a unary call
+func readGRPCMessage(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	if len(body) < 5 {
+		return nil, fmt.Errorf("request has no message")
+	}
+	compressed, size := body[0], binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) != uint64(size) {
+		return nil, fmt.Errorf("a unary call takes exactly one message")
+	}
+	msg := body[5:]
+	if compressed == 0 {
+		return msg, nil
+	}
+	if enc := r.Header.Get("Grpc-Encoding"); enc != "gzip" {
+		return nil, fmt.Errorf("unsupported message encoding %q", enc)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress request: %w", err)
+	}
+	return io.ReadAll(zr)
+}
+
+// writeGRPCStatus sends the grpc-status and grpc-message trailers (or, before
+// any message, a trailers-only response)
+func writeGRPCStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcPercentEncode(message))
+	}
+}
+
+// grpcPercentEncode escapes a grpc-message: everything but printable ASCII
+// other than '%'
+func grpcPercentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runGRPC implements `grpc [--addr :50051] [--rulebook PATH]`
+func runGRPC(args []string) error {
+	fs := flag.NewFlagSet("grpc", flag.ContinueOnError)
+	addr := fs.String("addr", ":50051", "listen address")
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite); its schema defines the messages")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{Addr: *addr, Handler: NewGRPCServer(rb), Protocols: &protocols}
+	fmt.Printf("Serving the Compute gRPC service on %s\n", *addr)
+	return server.ListenAndServe()
+}
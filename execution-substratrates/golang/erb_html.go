@@ -0,0 +1,135 @@
+// ERB SDK - HTML Dashboard Export
+// ===============================
+// Writes computed records as a static, self-contained HTML page: one table
+// sortable by clicking a column header, rows with a FamilyFeudMismatch
+// highlighted in red, and for LanguageCandidates a drill-down per candidate
+// with the Explain trace of every calculated field:
+//
+//	export --format html --out dashboard.html
+//	curl -H 'Accept: text/html' localhost:8080/candidates > dashboard.html
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// htmlExporter writes a sortable HTML dashboard
+type htmlExporter struct{}
+
+func (htmlExporter) Name() string         { return "html" }
+func (htmlExporter) Extensions() []string { return []string{".html", ".htm"} }
+func (htmlExporter) ContentType() string  { return "text/html; charset=utf-8" }
+
+func (htmlExporter) Write(views ExportViews, w io.Writer) error {
+	keys := recordKeys(views.Records)
+	mismatchKey := ""
+	for _, k := range keys {
+		if nameKey(k) == nameKey("FamilyFeudMismatch") {
+			mismatchKey = k
+		}
+	}
+
+	var b strings.Builder
+	title := html.EscapeString(views.Table)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", title, dashboardCSS)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<p>%d records", title, len(views.Records))
+	if mismatchKey != "" {
+		mismatches := 0
+		for _, rec := range views.Records {
+			if exportText(rec.Values[mismatchKey]) != "" {
+				mismatches++
+			}
+		}
+		fmt.Fprintf(&b, ", <span class=\"mismatch-count\">%d mismatches</span>", mismatches)
+	}
+	b.WriteString(". Click a column header to sort.</p>\n")
+
+	b.WriteString("<table id=\"views\">\n<thead><tr>")
+	for i, k := range keys {
+		fmt.Fprintf(&b, "<th data-column=\"%d\">%s</th>", i, html.EscapeString(k))
+	}
+	if views.Table == "LanguageCandidates" {
+		b.WriteString("<th>Trace</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, rec := range views.Records {
+		if mismatchKey != "" && exportText(rec.Values[mismatchKey]) != "" {
+			b.WriteString("<tr class=\"mismatch\">")
+		} else {
+			b.WriteString("<tr>")
+		}
+		for _, k := range keys {
+			fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(exportText(rec.Values[k])))
+		}
+		if views.Table == "LanguageCandidates" {
+			trace, err := candidateTrace(rec)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "<td><details><summary>trace</summary><pre>%s</pre></details></td>", html.EscapeString(trace))
+		}
+		b.WriteString("</tr>\n")
+	}
+	fmt.Fprintf(&b, "</tbody>\n</table>\n<script>%s</script>\n</body>\n</html>\n", dashboardJS)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// candidateTrace explains every calculated field of a LanguageCandidates record
+func candidateTrace(rec Record) (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	var lc LanguageCandidate
+	if err := json.Unmarshal(data, &lc); err != nil {
+		return "", fmt.Errorf("failed to decode candidate: %w", err)
+	}
+	var b strings.Builder
+	for _, field := range sortedKeys(LanguageCandidateFormulas) {
+		exp, err := lc.Explain(field)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", lc.LanguageCandidateId, err)
+		}
+		b.WriteString(exp.String())
+	}
+	return b.String(), nil
+}
+
+// dashboardCSS styles the dashboard
+const dashboardCSS = `
+body { font-family: system-ui, sans-serif; margin: 1.5em; }
+table { border-collapse: collapse; font-size: 0.85em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.5em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; cursor: pointer; position: sticky; top: 0; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr.mismatch td { background: #fdd; color: #900; }
+.mismatch-count { color: #900; font-weight: bold; }
+pre { font-size: 0.9em; margin: 0.3em 0; }
+`
+
+// dashboardJS sorts the table by the clicked column (numbers numerically)
+const dashboardJS = `
+document.querySelectorAll("#views th[data-column]").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var column = +th.dataset.column, asc = !th.classList.contains("asc");
+    document.querySelectorAll("#views th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+    th.classList.add(asc ? "asc" : "desc");
+    var body = document.querySelector("#views tbody");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[column].textContent, y = b.cells[column].textContent;
+      var c = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y);
+      return asc ? c : -c;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+`
@@ -0,0 +1,95 @@
+// ERB SDK - Record IDs
+// ====================
+// Records added in code need IDs that read like the ones already in the
+// rulebook and never collide with them. Candidates are keyed by a slug of
+// their Name, numbered when taken ("a-hammer", "a-hammer-2"); argument steps
+// by a prefixed sequence continuing the table's own ("neial-016" is followed
+// by "neial-017"). Store.InsertCandidate and Store.AddArgumentStep assign
+// them when the ID is left blank:
+//
+//	id, err := store.InsertCandidate(LanguageCandidate{Name: optPtr("A Hammer")})
+//	// id == "a-hammer"
+//	stepID, err := store.AddArgumentStep(IsEverythingALanguage{
+//		Name:               optPtr("NEIAL-017"),
+//		RelatedCandidateId: optPtr(id), // must name an existing candidate
+//	})
+//	// stepID == "neial-017"
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// recordSlug derives a record ID from a name: "OWL/RDF - Editing" -> "owl-rdf-editing"
+func recordSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// uniqueSlugID returns the slug of name (fallback if it has none), with a
+// numeric suffix from 2 up if taken reports it in use
+func uniqueSlugID(name, fallback string, taken func(id string) bool) string {
+	base := recordSlug(name)
+	if base == "" {
+		base = fallback
+	}
+	id := base
+	for n := 2; taken(id); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return id
+}
+
+// nextSequenceID continues the "<prefix>-<number>" IDs in ids: the prefix
+// most of them share, one past its highest number, zero-padded to the same
+// width. fallback is the prefix when no ID has a number.
+func nextSequenceID(ids []string, fallback string) string {
+	type sequence struct {
+		prefix             string
+		count, last, width int
+	}
+	var sequences []*sequence
+	byPrefix := map[string]*sequence{}
+	for _, id := range ids {
+		i := strings.LastIndexByte(id, '-')
+		if i <= 0 {
+			continue
+		}
+		n, err := strconv.Atoi(id[i+1:])
+		if err != nil || n < 0 {
+			continue
+		}
+		s := byPrefix[id[:i]]
+		if s == nil {
+			s = &sequence{prefix: id[:i]}
+			byPrefix[s.prefix] = s
+			sequences = append(sequences, s)
+		}
+		s.count++
+		s.last = max(s.last, n)
+		s.width = max(s.width, len(id[i+1:]))
+	}
+
+	best := &sequence{prefix: fallback, width: 3}
+	for _, s := range sequences {
+		if s.count > best.count {
+			best = s
+		}
+	}
+	return fmt.Sprintf("%s-%0*d", best.prefix, best.width, best.last+1)
+}
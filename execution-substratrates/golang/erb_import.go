@@ -0,0 +1,458 @@
+// ERB SDK - Importers
+// ===================
+// The counterpart of the exporters: an Importer reads records from one
+// source format, and ImportRecords converts them into validated records of a
+// table (snake_case keys, typed values, unknown columns rejected).
+//
+//	imp, ok := ImporterForPath("candidates.csv")
+//	raw, err := imp.Read(f)
+//	records, err := ImportRecords("LanguageCandidates", raw)
+//
+// Built-in formats: json, ndjson, csv, xlsx, airtable (API list-records
+// JSON), sheets (Google Sheets API values JSON).
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Importer reads records from one source format
+type Importer interface {
+	// Name is the format name used by --format (e.g. "csv")
+	Name() string
+	// Extensions are the file extensions the format is picked by (may be empty)
+	Extensions() []string
+	// Read reads every record from r, with the source's keys and values
+	Read(r io.Reader) ([]Record, error)
+}
+
+// importers holds the registered importers by name
+var importers = map[string]Importer{}
+
+func init() {
+	for _, i := range []Importer{jsonImporter{}, ndjsonImporter{}, csvImporter{}, xlsxImporter{}, airtableImporter{}, sheetsImporter{}} {
+		RegisterImporter(i)
+	}
+}
+
+// RegisterImporter adds an importer, replacing any registered under the same name
+func RegisterImporter(i Importer) {
+	importers[i.Name()] = i
+}
+
+// LookupImporter returns the importer registered under name
+func LookupImporter(name string) (Importer, bool) {
+	i, ok := importers[strings.ToLower(name)]
+	return i, ok
+}
+
+// Importers returns the registered importers sorted by name
+func Importers() []Importer {
+	list := make([]Importer, 0, len(importers))
+	for _, name := range sortedKeys(importers) {
+		list = append(list, importers[name])
+	}
+	return list
+}
+
+// ImporterForPath returns the importer whose extensions include the path's extension
+func ImporterForPath(path string) (Importer, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, i := range Importers() {
+		for _, x := range i.Extensions() {
+			if x == ext {
+				return i, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// =============================================================================
+// VALIDATION
+// =============================================================================
+
+// ImportError reports the problems found in one imported record
+type ImportError struct {
+	Row      int // 1-based position in the source
+	Problems []string
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("record %d: %s", e.Row, strings.Join(e.Problems, "; "))
+}
+
+// ImportRecords converts raw records into records of table: keys are
+// normalized to snake_case (PascalCase and "Display Names" are accepted),
+// text values are parsed to the field's type, and empty cells become null.
+// Every invalid record is reported as an *ImportError, joined.
+func ImportRecords(table string, raw []Record) ([]Record, error) {
+	var t *schemaTable
+	for i := range schemaTables {
+		if schemaTables[i].name == table {
+			t = &schemaTables[i]
+		}
+	}
+	if t == nil {
+		return nil, unknownTable(table)
+	}
+
+	fields := map[string]reflect.StructField{}
+	var keys []string
+	for i := 0; i < t.record.NumField(); i++ {
+		sf := t.record.Field(i)
+		if key, _ := jsonKey(sf); key != "" && sf.IsExported() {
+			fields[key] = sf
+			keys = append(keys, key)
+		}
+	}
+
+	records := make([]Record, 0, len(raw))
+	var errs []error
+	for n, in := range raw {
+		out := Record{Keys: keys, Values: make(map[string]any, len(keys))}
+		var problems []string
+		for _, k := range in.Keys {
+			key := importKey(k)
+			sf, ok := fields[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown field %q", k))
+				continue
+			}
+			v, err := importValue(in.Values[k], sf.Type)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+				continue
+			}
+			out.Values[key] = v
+		}
+		for _, key := range keys {
+			if fields[key].Type.Kind() != reflect.Pointer && out.Values[key] == nil {
+				problems = append(problems, fmt.Sprintf("%s: required", key))
+			}
+		}
+		if len(problems) > 0 {
+			errs = append(errs, &ImportError{Row: n + 1, Problems: problems})
+			continue
+		}
+		records = append(records, out)
+	}
+	return records, errors.Join(errs...)
+}
+
+// importKey normalizes a source column name to a snake_case JSON key
+func importKey(name string) string {
+	return toSnakeCase(strings.ReplaceAll(strings.TrimSpace(name), " ", ""))
+}
+
+// importValue converts a source value to the Go type of a field; empty text is null
+func importValue(v any, t reflect.Type) (any, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if s, ok := v.(string); ok {
+		text := strings.TrimSpace(s)
+		if text == "" {
+			return nil, nil
+		}
+		switch t.Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", s)
+			}
+			return b, nil
+		case reflect.Int:
+			i, err := strconv.Atoi(text)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", s)
+			}
+			return i, nil
+		}
+		return s, nil
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if t.Kind() == reflect.Bool {
+			return v, nil
+		}
+	case int:
+		if t.Kind() == reflect.Int {
+			return v, nil
+		}
+	case float64:
+		if t.Kind() == reflect.Int && v == float64(int(v)) {
+			return int(v), nil
+		}
+		if t.Kind() == reflect.String {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not a %s", v, jsonSchemaType(t))
+}
+
+// =============================================================================
+// JSON, NDJSON, CSV, AIRTABLE, SHEETS
+// =============================================================================
+
+// jsonImporter reads a JSON array of objects (the blank-test.json layout)
+type jsonImporter struct{}
+
+func (jsonImporter) Name() string         { return "json" }
+func (jsonImporter) Extensions() []string { return []string{".json"} }
+
+func (jsonImporter) Read(r io.Reader) ([]Record, error) {
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	records := make([]Record, 0, len(rows))
+	for i, row := range rows {
+		rec, err := decodeRecord(row)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// ndjsonImporter reads one JSON object per line
+type ndjsonImporter struct{}
+
+func (ndjsonImporter) Name() string         { return "ndjson" }
+func (ndjsonImporter) Extensions() []string { return []string{".ndjson", ".jsonl"} }
+
+func (ndjsonImporter) Read(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		rec, err := decodeRecord([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// csvImporter reads a header row of column names and one record per row
+type csvImporter struct{}
+
+func (csvImporter) Name() string         { return "csv" }
+func (csvImporter) Extensions() []string { return []string{".csv"} }
+
+func (csvImporter) Read(r io.Reader) ([]Record, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	cells := make([][]any, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]any, len(row))
+		for j, c := range row {
+			cells[i][j] = c
+		}
+	}
+	return tableRecords(cells), nil
+}
+
+// airtableImporter reads an Airtable list-records response: {"records": [{"id", "fields"}]}
+type airtableImporter struct{}
+
+func (airtableImporter) Name() string         { return "airtable" }
+func (airtableImporter) Extensions() []string { return nil }
+
+func (airtableImporter) Read(r io.Reader) ([]Record, error) {
+	var page struct {
+		Records []struct {
+			Fields json.RawMessage `json:"fields"`
+		} `json:"records"`
+	}
+	if err := json.NewDecoder(r).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to parse Airtable records: %w", err)
+	}
+	records := make([]Record, 0, len(page.Records))
+	for i, row := range page.Records {
+		rec, err := decodeRecord(row.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// sheetsImporter reads a Google Sheets values response: {"values": [[header...], [row...]]}
+type sheetsImporter struct{}
+
+func (sheetsImporter) Name() string         { return "sheets" }
+func (sheetsImporter) Extensions() []string { return nil }
+
+func (sheetsImporter) Read(r io.Reader) ([]Record, error) {
+	var values struct {
+		Values [][]any `json:"values"`
+	}
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to parse Sheets values: %w", err)
+	}
+	return tableRecords(values.Values), nil
+}
+
+// decodeRecord decodes a JSON object into a record, keeping key order
+func decodeRecord(data []byte) (Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Record{}, fmt.Errorf("expected a JSON object")
+	}
+
+	rec := Record{Values: map[string]any{}}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Record{}, err
+		}
+		key := tok.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return Record{}, err
+		}
+		rec.Keys = append(rec.Keys, key)
+		rec.Values[key] = v
+	}
+	return rec, nil
+}
+
+// wholeNumbersToInt converts decoded JSON numbers (float64) that are whole
+// to int, the SDK's numeric type
+func wholeNumbersToInt(records []Record) {
+	for _, rec := range records {
+		for k, v := range rec.Values {
+			if n, ok := v.(float64); ok && n == float64(int(n)) {
+				rec.Values[k] = int(n)
+			}
+		}
+	}
+}
+
+// tableRecords turns a header row plus data rows into records; short rows
+// leave the remaining columns empty
+func tableRecords(rows [][]any) []Record {
+	if len(rows) == 0 {
+		return nil
+	}
+	header := make([]string, len(rows[0]))
+	for i, h := range rows[0] {
+		header[i] = exportText(h)
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := Record{Values: map[string]any{}}
+		for i, name := range header {
+			if name == "" {
+				continue
+			}
+			var v any = ""
+			if i < len(row) {
+				v = row[i]
+			}
+			rec.Keys = append(rec.Keys, name)
+			rec.Values[name] = v
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runImport implements `import [--format NAME] [--table NAME] [--out FILE] [--list] FILE`:
+// reads FILE with the importer named by --format (else picked by extension),
+// validates the records against the table, and writes them as a blank-test
+// style JSON array (or in the export format of --out's extension)
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "source format (default: from the file extension)")
+	table := fs.String("table", "LanguageCandidates", "table the records belong to")
+	out := fs.String("out", "", "file to write the validated records to (default: stdout as JSON)")
+	list := fs.Bool("list", false, "list the registered source formats and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, i := range Importers() {
+			fmt.Printf("%-9s %s\n", i.Name(), strings.Join(i.Extensions(), ","))
+		}
+		return nil
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [--format NAME] [--table NAME] [--out FILE] FILE")
+	}
+	path := fs.Arg(0)
+
+	imp, ok := ImporterForPath(path)
+	if *format != "" {
+		imp, ok = LookupImporter(*format)
+	}
+	if !ok {
+		names := sortedKeys(importers)
+		return fmt.Errorf("no importer for format %q or file %q (supported: %s)", *format, path, strings.Join(names, ", "))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	raw, err := imp.Read(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	records, err := ImportRecords(*table, raw)
+	if err != nil {
+		return fmt.Errorf("invalid records in %s:\n%w", path, err)
+	}
+
+	if *out == "" {
+		e, _ := LookupExporter("json")
+		if err := e.Write(ExportViews{Table: *table, Records: records}, os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	}
+	e, ok := ExporterForPath(*out)
+	if !ok {
+		return fmt.Errorf("no exporter for %s (supported: %s)", *out, strings.Join(ExportFormats(), ", "))
+	}
+	if err := WriteOutput(OutputTarget{Format: e.Name(), Path: *out}, *table, records); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d %s records from %s to %s\n", len(records), *table, path, *out)
+	return nil
+}
@@ -0,0 +1,321 @@
+// ERB SDK - Rulebook Scaffolding
+// ==============================
+// `init DIR [--table T]` starts a new effortless rulebook without copying this
+// repo's semiotics data. DIR gets one example table (an id, two raw fields,
+// and two calculated fields, one reading the other) and the files the
+// conformance flow expects:
+//
+//	rulebook.json      schema + three example rows
+//	blank-test.json    the rows with calculated fields set to null
+//	answer-key.json    the rows as this SDK computes them
+//	sdk.go             a Go SDK stub in the generated style; `go run sdk.go`
+//	                   fills blank-test.json into test-answers.json
+//
+// `compare-answers answer-key.json test-answers.json` then checks the stub.
+// Every other command accepts the new rulebook with --rulebook.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultScaffoldTable is the table `init` creates unless --table is given
+const DefaultScaffoldTable = "Items"
+
+// tableNamePattern matches table names that are also valid Go identifiers
+var tableNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// ScaffoldRulebook returns the example rulebook `init` writes, with one table
+func ScaffoldRulebook(modelName, table string) (*Rulebook, error) {
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("table name %q must be PascalCase letters and digits", table)
+	}
+
+	rb := NewRulebook(modelName)
+	rb.Description = "Effortless rulebook scaffolded by `init`."
+	t := rb.AddTable(table,
+		Field{Name: structName(table) + "Id", Datatype: "string", Type: "raw", Nullable: false},
+		RawField("Name", "string"),
+		RawField("Score", "integer"),
+		CalculatedField("IsPassing", "boolean", "={{Score}} >= 50"),
+		CalculatedField("Summary", "string", `={{Name}} & IF({{IsPassing}}, " passes", " does not pass")`),
+	)
+	t.Schema[1].Description = "Display name of the row."
+	t.Schema[2].Description = "Example raw input; rows pass at 50 or more."
+	t.Schema[3].Description = "Example calculated field reading a raw field."
+	t.Schema[4].Description = "Example calculated field reading another calculated field."
+
+	id := t.IDField()
+	t.AddRows(
+		map[string]any{id: "example-1", "Name": "First example", "Score": 72},
+		map[string]any{id: "example-2", "Name": "Second example", "Score": 35},
+		map[string]any{id: "example-3", "Name": "Third example", "Score": nil},
+	)
+	if err := rb.Prepare(); err != nil {
+		return nil, err
+	}
+
+	// Store the computed values in the rulebook rows, like a rulebook
+	// exported from its source spreadsheet
+	records, err := t.Compute()
+	if err != nil {
+		return nil, err
+	}
+	for i, rec := range records {
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				t.Data[i][f.Name] = rec.Values[toSnakeCase(f.Name)]
+			}
+		}
+	}
+	return rb, nil
+}
+
+// Scaffold writes a new rulebook project to dir and returns the files it
+// wrote; it never overwrites an existing file
+func Scaffold(dir, table string) ([]string, error) {
+	rb, err := ScaffoldRulebook(filepath.Base(dir), table)
+	if err != nil {
+		return nil, err
+	}
+	t := rb.Tables[0]
+	sdk, err := scaffoldSDK(t)
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := t.Compute()
+	if err != nil {
+		return nil, err
+	}
+	blank := make([]Record, len(answers))
+	for i, rec := range answers {
+		blank[i] = Record{Keys: rec.Keys, Values: map[string]any{}}
+		for _, f := range t.Schema {
+			key := toSnakeCase(f.Name)
+			if !f.IsCalculated() {
+				blank[i].Values[key] = rec.Values[key]
+			} else {
+				blank[i].Values[key] = nil
+			}
+		}
+	}
+
+	files := []struct {
+		name  string
+		value any
+	}{
+		{"rulebook.json", rb},
+		{"blank-test.json", blank},
+		{"answer-key.json", answers},
+		{"sdk.go", sdk},
+	}
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(dir, f.name)); err == nil {
+			return nil, fmt.Errorf("%s already exists", filepath.Join(dir, f.name))
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var written []string
+	for _, f := range files {
+		data, ok := f.value.(string)
+		if !ok {
+			encoded, err := json.MarshalIndent(f.value, "", "  ")
+			if err != nil {
+				return written, err
+			}
+			data = string(encoded) + "\n"
+		}
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// structName is the Go struct for one row of a table (LanguageCandidates ->
+// LanguageCandidate), as table_name_to_struct_name in inject-into-golang.py
+func structName(table string) string {
+	if strings.HasSuffix(table, "s") && !strings.HasSuffix(table, "ss") {
+		return strings.TrimSuffix(table, "s")
+	}
+	return table
+}
+
+// scaffoldSDK renders the gofmt'd Go SDK stub for the scaffolded table. The
+// calculation bodies are the scaffold formulas written by hand the way the
+// generator compiles them; regenerate once the rulebook grows.
+func scaffoldSDK(t *Table) (string, error) {
+	src := strings.NewReplacer(
+		"{{Table}}", t.Name,
+		"{{TABLE}}", strings.ToUpper(t.Name),
+		"{{Struct}}", structName(t.Name),
+		"{{Id}}", t.IDField(),
+		"{{id}}", toSnakeCase(t.IDField()),
+	).Replace(scaffoldSDKTemplate)
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", fmt.Errorf("failed to format sdk.go: %w", err)
+	}
+	return string(formatted), nil
+}
+
+const scaffoldSDKTemplate = `// ERB SDK - Go Implementation (scaffolded stub)
+// ==============================================
+// Scaffolded from: rulebook.json
+//
+// Structs and calculation functions for the {{Table}} table, in the layout
+// the effortless rulebook generator produces. Run it to take the test:
+//
+//	go run sdk.go    # blank-test.json -> test-answers.json
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// =============================================================================
+// HELPER FUNCTIONS
+// =============================================================================
+
+// optGet dereferences an optional value, returning def if it is nil
+func optGet[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// optPtr returns a pointer to a copy of v
+func optPtr[T any](v T) *T {
+	return &v
+}
+
+// optNilIfZero returns nil for the zero value (e.g. ""), otherwise a pointer to v
+func optNilIfZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+// =============================================================================
+// {{TABLE}} TABLE
+// =============================================================================
+
+// {{Struct}} represents a row in the {{Table}} table
+type {{Struct}} struct {
+	{{Id}} string ` + "`json:\"{{id}}\"`" + `
+	Name *string ` + "`json:\"name\"`" + `
+	Score *int ` + "`json:\"score\"`" + `
+	IsPassing *bool ` + "`json:\"is_passing\"`" + `
+	Summary *string ` + "`json:\"summary\"`" + `
+}
+
+// --- Individual Calculation Functions ---
+
+// CalcIsPassing computes the IsPassing calculated field
+// Formula: ={{Score}} >= 50
+func (tc *{{Struct}}) CalcIsPassing() bool {
+	return (tc.Score != nil && *tc.Score >= 50)
+}
+
+// CalcSummary computes the Summary calculated field
+// Formula: ={{Name}} & IF({{IsPassing}}, " passes", " does not pass")
+func (tc *{{Struct}}) CalcSummary() string {
+	return optGet(tc.Name, "") + func() string { if optGet(tc.IsPassing, false) { return " passes" }; return " does not pass" }()
+}
+
+// --- Compute All Calculated Fields ---
+
+// ComputeAll computes all calculated fields and returns an updated struct
+func (tc *{{Struct}}) ComputeAll() *{{Struct}} {
+	// Level 1 calculations
+	isPassing := (tc.Score != nil && *tc.Score >= 50)
+
+	// Level 2 calculations
+	summary := optGet(tc.Name, "") + func() string { if isPassing { return " passes" }; return " does not pass" }()
+
+	return &{{Struct}}{
+		{{Id}}: tc.{{Id}},
+		Name: tc.Name,
+		Score: tc.Score,
+		IsPassing: optPtr(isPassing),
+		Summary: optNilIfZero(summary),
+	}
+}
+
+// =============================================================================
+// TEST RUNNER
+// =============================================================================
+
+func main() {
+	data, err := os.ReadFile("blank-test.json")
+	if err != nil {
+		fmt.Println("failed to load blank test:", err)
+		os.Exit(1)
+	}
+	var rows []{{Struct}}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		fmt.Println("failed to parse blank test:", err)
+		os.Exit(1)
+	}
+
+	answers := make([]{{Struct}}, 0, len(rows))
+	for i := range rows {
+		answers = append(answers, *rows[i].ComputeAll())
+	}
+	out, err := json.MarshalIndent(answers, "", "  ")
+	if err != nil {
+		fmt.Println("failed to encode answers:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile("test-answers.json", append(out, '\n'), 0644); err != nil {
+		fmt.Println("failed to write answers:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Computed %d {{Table}} records, saved results to test-answers.json\n", len(answers))
+}
+`
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runInit implements `init [--table T] DIR`: scaffolds a new rulebook project
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	table := fs.String("table", DefaultScaffoldTable, "name of the example table (PascalCase, e.g. Orders)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: init [--table T] DIR")
+	}
+
+	files, err := Scaffold(fs.Arg(0), *table)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Println("Created", f)
+	}
+	return nil
+}
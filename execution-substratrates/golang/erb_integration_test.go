@@ -0,0 +1,244 @@
+// ERB SDK - Integration Tests
+// ===========================
+// End-to-end tests across the CLI binary, the server, the watcher and the
+// rulebook file: a temp copy of the rulebook is served and watched, edited
+// with the CLI (built with `go build` and run with exec), and the test checks
+// the server recomputes, the edit persists, the event bus reports it, and the
+// exported artifacts include it:
+//
+//	go test $(ls *.go) -run Integration
+//	go test $(ls *.go) -short            # skips them (they build the CLI)
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// integrationWait bounds every wait for the watcher
+const integrationWait = 10 * time.Second
+
+func TestIntegrationEditServeAndExport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration test builds the CLI")
+	}
+	bin := buildCLI(t)
+	dir := t.TempDir()
+	rulebookPath := filepath.Join(dir, "effortless-rulebook.json")
+	copyFile(t, DefaultRulebookPath, rulebookPath)
+	copyFile(t, CandidateTemplatesPath(DefaultRulebookPath), CandidateTemplatesPath(rulebookPath))
+
+	// Serve the temp rulebook, reloading it through the event bus
+	rb, err := LoadFromRulebook(rulebookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates := len(rb.LanguageCandidates)
+	server := NewServer(rb, nil)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	bus := NewBus()
+	loaded := make(chan RulebookLoaded, 8)
+	changed := make(chan RecordChanged, 64)
+	violated := make(chan InvariantViolated, 64)
+	defer Subscribe(bus, func(e RulebookLoaded) {
+		server.SetRulebook(e.Rulebook)
+		loaded <- e
+	})()
+	defer Subscribe(bus, func(e RecordChanged) { changed <- e })()
+	defer Subscribe(bus, func(e InvariantViolated) { violated <- e })()
+
+	w, err := Watch(rulebookPath, nil, WithPollInterval(20*time.Millisecond), WithDebounce(60*time.Millisecond), WithBus(bus), WithReloadErrors(func(error) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if got := len(getRecords(t, ts.URL+"/candidates")); got != candidates {
+		t.Fatalf("GET /candidates: %d records, want %d", got, candidates)
+	}
+
+	// Edit with the CLI; the watcher reloads and the server recomputes
+	runCLI(t, bin, "derive", "physical-artifact", "a-hammer", "--set", "Name=A Hammer", "--set", "SortOrder=26", "--rulebook", rulebookPath)
+	select {
+	case e := <-loaded:
+		if e.Source != rulebookPath {
+			t.Errorf("RulebookLoaded source %q, want %q", e.Source, rulebookPath)
+		}
+	case <-time.After(integrationWait):
+		t.Fatal("no RulebookLoaded after derive")
+	}
+	select {
+	case e := <-changed:
+		if e.Table != "LanguageCandidates" || e.RecordID != "a-hammer" || e.Kind != "added" {
+			t.Errorf("RecordChanged = %s, want LanguageCandidates a-hammer added", e)
+		}
+	case <-time.After(integrationWait):
+		t.Fatal("no RecordChanged after derive")
+	}
+
+	if got := len(getRecords(t, ts.URL+"/candidates")); got != candidates+1 {
+		t.Errorf("GET /candidates after derive: %d records, want %d", got, candidates+1)
+	}
+	var view map[string]any
+	getJSON(t, ts.URL+"/candidates/a-hammer/view", &view)
+	if view["name"] != "A Hammer" {
+		t.Errorf("a-hammer name = %v, want A Hammer", view["name"])
+	}
+	if view["top_family_feud_answer"] != false {
+		t.Errorf("a-hammer top_family_feud_answer = %v, want false (a physical artifact)", view["top_family_feud_answer"])
+	}
+
+	// The edit persisted, to the rulebook and the templates
+	saved, err := LoadFromRulebook(rulebookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.LanguageCandidates) != candidates+1 {
+		t.Errorf("saved rulebook has %d candidates, want %d", len(saved.LanguageCandidates), candidates+1)
+	}
+	templates, err := LoadCandidateTemplates(CandidateTemplatesPath(rulebookPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl, ok := LookupTemplate(templates, "physical-artifact"); !ok || !containsString(tmpl.Members, "a-hammer") {
+		t.Error("physical-artifact template does not list a-hammer as a member")
+	}
+
+	// Artifacts written by the CLI include it
+	runCLI(t, bin, "validate", "--rulebook", rulebookPath)
+	csvPath := filepath.Join(dir, "candidates.csv")
+	runCLI(t, bin, "export", "--rulebook", rulebookPath, "--out", csvPath)
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != candidates+2 {
+		t.Errorf("exported CSV has %d rows, want a header and %d records", len(rows), candidates+1)
+	}
+	if dashboard := runCLI(t, bin, "export", "--rulebook", rulebookPath, "--format", "html"); !strings.Contains(dashboard, "A Hammer") {
+		t.Error("html export does not include A Hammer")
+	}
+	runCLI(t, bin, "roundtrip", rulebookPath)
+
+	// A broken edit is rejected: an InvariantViolated, and the server keeps
+	// the previous rulebook
+	data, err := os.ReadFile(rulebookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := strings.Replace(string(data), `"LanguageCandidateId": "a-hammer",`, `"LanguageCandidateId": "a-hammer", "NotAField": true,`, 1)
+	if broken == string(data) {
+		t.Fatal("a-hammer not found in the saved rulebook")
+	}
+	if err := os.WriteFile(rulebookPath, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-violated:
+		if e.Issue.Rule != "schema" || e.Issue.ID != "a-hammer" {
+			t.Errorf("InvariantViolated = %s, want a schema issue for a-hammer", e.Issue)
+		}
+	case <-time.After(integrationWait):
+		t.Fatal("no InvariantViolated after a broken edit")
+	}
+	select {
+	case e := <-loaded:
+		t.Errorf("broken rulebook was loaded from %s", e.Source)
+	default:
+	}
+	if got := len(getRecords(t, ts.URL+"/candidates")); got != candidates+1 {
+		t.Errorf("GET /candidates after a broken edit: %d records, want %d", got, candidates+1)
+	}
+}
+
+// buildCLI builds the runner binary from the package's non-test files
+func buildCLI(t *testing.T) string {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not on PATH")
+	}
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+	bin := filepath.Join(t.TempDir(), "erb")
+	out, err := exec.Command(goTool, append([]string{"build", "-o", bin}, sources...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runCLI runs the binary and returns its stdout, failing the test if it fails
+func runCLI(t *testing.T, bin string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(bin, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("erb %s: %v\n%s%s", strings.Join(args, " "), err, out, stderr.String())
+	}
+	return string(out)
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	data, err := os.ReadFile(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s\n%s", url, resp.Status, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+}
+
+func getRecords(t *testing.T, url string) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	getJSON(t, url, &records)
+	return records
+}
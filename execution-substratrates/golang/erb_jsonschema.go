@@ -0,0 +1,196 @@
+// ERB SDK - JSON Schema
+// =====================
+// JSON Schemas (draft 2020-12) derived from the generated structs, so other
+// tools can validate files before they reach the Go loader:
+//
+//	SchemaFor("LanguageCandidates") // blank-test.json / test-answers.json: an array of snake_case records
+//	RulebookSchema()                // an authored effortless-rulebook.json
+//
+// Records reject unknown keys (additionalProperties: false), so a typo such
+// as has_sytax fails validation. Calculated fields are readOnly.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaDraft is the dialect of the generated schemas
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema the SDK generates
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"` // a type name, or a list of them
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	ReadOnly             bool                   `json:"readOnly,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// schemaTable describes a generated table type
+type schemaTable struct {
+	name     string
+	record   reflect.Type
+	formulas map[string]string // calculated fields, by struct field name
+}
+
+var schemaTables = []schemaTable{
+	{"LanguageCandidates", reflect.TypeFor[LanguageCandidate](), LanguageCandidateFormulas},
+	{"IsEverythingALanguage", reflect.TypeFor[IsEverythingALanguage](), nil},
+}
+
+// SchemaFor returns the schema of a table's record files (blank-test.json,
+// test-answers.json): an array of records keyed by snake_case JSON names
+func SchemaFor(table string) (*JSONSchema, error) {
+	for _, t := range schemaTables {
+		if t.name != table {
+			continue
+		}
+		def := t.record.Name()
+		return &JSONSchema{
+			Schema:      JSONSchemaDraft,
+			Title:       t.name,
+			Description: fmt.Sprintf("%s records, keyed by snake_case field names", t.name),
+			Type:        "array",
+			Items:       &JSONSchema{Ref: "#/$defs/" + def},
+			Defs:        map[string]*JSONSchema{def: recordSchema(t, jsonKey)},
+		}, nil
+	}
+	return nil, unknownTable(table)
+}
+
+// RulebookSchema returns the schema of an authored rulebook file: metadata,
+// then each table's Description, field definitions, and PascalCase data rows
+func RulebookSchema() *JSONSchema {
+	s := &JSONSchema{
+		Schema:      JSONSchemaDraft,
+		Title:       "Effortless Rulebook",
+		Type:        "object",
+		Required:    []string{"model_name"},
+		Properties:  map[string]*JSONSchema{},
+		Defs:        map[string]*JSONSchema{"Field": structSchema(reflect.TypeFor[Field](), jsonKey, nil)},
+		Description: "An effortless-rulebook.json file; data rows are keyed by PascalCase field names",
+	}
+	s.Properties["$schema"] = &JSONSchema{Type: "string"}
+	s.Properties["model_name"] = &JSONSchema{Type: "string"}
+	s.Properties["Description"] = &JSONSchema{Type: "string"}
+	s.Properties["_meta"] = &JSONSchema{Type: "object"}
+
+	for _, t := range schemaTables {
+		def := t.record.Name()
+		s.Defs[def] = recordSchema(t, goFieldKey)
+		s.Properties[t.name] = &JSONSchema{
+			Type:     "object",
+			Required: []string{"schema", "data"},
+			Properties: map[string]*JSONSchema{
+				"Description": {Type: "string"},
+				"schema":      {Type: "array", Items: &JSONSchema{Ref: "#/$defs/Field"}},
+				"data":        {Type: "array", Items: &JSONSchema{Ref: "#/$defs/" + def}},
+			},
+			AdditionalProperties: optPtr(false),
+		}
+	}
+	return s
+}
+
+// recordSchema describes one record of a table; calculated fields are readOnly
+func recordSchema(t schemaTable, key func(reflect.StructField) (string, bool)) *JSONSchema {
+	s := structSchema(t.record, key, t.formulas)
+	s.Title = t.record.Name()
+	return s
+}
+
+// structSchema describes a struct as a closed object. Pointer fields may be
+// null; the others are required unless their JSON tag has omitempty.
+func structSchema(t reflect.Type, key func(reflect.StructField) (string, bool), formulas map[string]string) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: optPtr(false)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, omitempty := key(sf)
+		if name == "" || !sf.IsExported() {
+			continue
+		}
+
+		ft, nullable := sf.Type, false
+		if ft.Kind() == reflect.Pointer {
+			ft, nullable = ft.Elem(), true
+		}
+		var typ any = jsonSchemaType(ft)
+		if nullable {
+			typ = []string{typ.(string), "null"}
+		} else if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+
+		_, calculated := formulas[sf.Name]
+		s.Properties[name] = &JSONSchema{Type: typ, ReadOnly: calculated}
+	}
+	return s
+}
+
+// jsonSchemaType maps a Go type to its JSON Schema type name
+func jsonSchemaType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "integer"
+	case reflect.Float64, reflect.Float32:
+		return "number"
+	}
+	return "string"
+}
+
+// jsonKey names a field by its JSON tag
+func jsonKey(sf reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// goFieldKey names a field by its Go (rulebook PascalCase) name
+func goFieldKey(sf reflect.StructField) (string, bool) {
+	if sf.Tag.Get("json") == "-" {
+		return "", false
+	}
+	return sf.Name, false
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runJSONSchema implements `json-schema [TABLE]`: prints the JSON Schema of a
+// table's record files, or of the rulebook file when no table is given
+func runJSONSchema(args []string) error {
+	fs := flag.NewFlagSet("json-schema", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schema := RulebookSchema()
+	if fs.NArg() > 0 {
+		var err error
+		if schema, err = SchemaFor(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
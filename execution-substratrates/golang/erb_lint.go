@@ -0,0 +1,282 @@
+// ERB SDK - Formula Lint
+// ======================
+// Static checks over parsed formulas, using the schema's field datatypes to
+// infer what each expression returns. Errors are formulas whose result cannot
+// be what the author meant (comparing an integer with text, a string formula
+// in a boolean field); warnings are formulas that work but read badly or
+// hide a mistake (`= TRUE()`, constant conditions, duplicated conjuncts).
+//
+//	for _, issue := range rb.Lint() {
+//		fmt.Println(issue) // LanguageCandidates.HasGrammar: warning redundant-bool-compare: ...
+//	}
+
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Lint severities
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is one finding in a calculated field's formula
+type LintIssue struct {
+	Table    string `json:"table"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s.%s: %s %s: %s", i.Table, i.Field, i.Severity, i.Rule, i.Message)
+}
+
+// Lint checks every calculated field's formula; formulas that do not parse
+// are reported as parse errors
+func (rb *Rulebook) Lint() []LintIssue {
+	var issues []LintIssue
+	for _, t := range rb.Tables {
+		types := map[string]string{}
+		for _, f := range t.Schema {
+			types[f.Name] = f.Datatype
+		}
+		for _, f := range t.Schema {
+			if !f.IsCalculated() {
+				continue
+			}
+			l := &formulaLinter{types: types}
+			ast, err := ParseFormula(f.Formula)
+			if err != nil {
+				l.report(LintError, "parse", err.Error())
+			} else {
+				l.lint(ast)
+				if got := l.typeOf(ast); got != "" && got != f.Datatype {
+					l.report(LintError, "result-type", fmt.Sprintf("formula returns %s but the field is %s", got, f.Datatype))
+				}
+			}
+			for _, issue := range l.issues {
+				issue.Table, issue.Field = t.Name, f.Name
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+// FormulaType infers the datatype a formula returns from the datatypes of
+// the fields it reads (name -> datatype): boolean, integer, string, or "" when
+// it cannot be known (an unknown field, IF branches of different types). An
+// IF without an else returns "" when false, so it is a string (mirrors
+// infer_formula_type in orchestration/formula_parser.py).
+func FormulaType(node FormulaNode, fieldTypes map[string]string) string {
+	switch n := node.(type) {
+	case LiteralBool, BinaryOp, UnaryOp:
+		return "boolean"
+	case LiteralInt:
+		return "integer"
+	case LiteralString, Concat:
+		return "string"
+	case FieldRef:
+		return fieldTypes[n.Name]
+	case FuncCall:
+		switch n.Name {
+		case "AND", "OR", "NOT", "FIND":
+			return "boolean"
+		case "LOWER", "CAST":
+			return "string"
+		case "IF":
+			if len(n.Args) < 2 {
+				return ""
+			}
+			then, otherwise := FormulaType(n.Args[1], fieldTypes), "string"
+			if len(n.Args) == 3 {
+				otherwise = FormulaType(n.Args[2], fieldTypes)
+			}
+			if then != otherwise {
+				return ""
+			}
+			return then
+		}
+		if f, ok := customFunctions[n.Name]; ok {
+			return f.Returns
+		}
+	}
+	return ""
+}
+
+// formulaLinter collects issues for one formula
+type formulaLinter struct {
+	types  map[string]string // field name -> datatype
+	issues []LintIssue
+}
+
+func (l *formulaLinter) report(severity, rule, message string) {
+	l.issues = append(l.issues, LintIssue{Severity: severity, Rule: rule, Message: message})
+}
+
+// typeOf is the datatype node returns (see FormulaType)
+func (l *formulaLinter) typeOf(node FormulaNode) string {
+	return FormulaType(node, l.types)
+}
+
+// lint checks node and everything below it
+func (l *formulaLinter) lint(node FormulaNode) {
+	walkFormula(node, func(n FormulaNode) {
+		switch n := n.(type) {
+		case BinaryOp:
+			l.lintCompare(n)
+		case UnaryOp:
+			if inner, ok := n.Operand.(UnaryOp); ok {
+				l.report(LintWarning, "double-negation", fmt.Sprintf("%s is just %s", n, inner.Operand))
+			}
+			if lit, ok := n.Operand.(LiteralBool); ok {
+				l.report(LintWarning, "constant-condition", fmt.Sprintf("%s is always %v", n, !lit.Value))
+			}
+		case Concat:
+			for _, part := range n.Parts {
+				if cmp, ok := part.(BinaryOp); ok {
+					l.report(LintWarning, "concat-comparison", fmt.Sprintf("%s is concatenated as \"true\"/\"false\"; & binds looser than %s here (unlike Excel), so parenthesize the text it should compare", cmp, cmp.Op))
+				}
+			}
+		case FuncCall:
+			switch n.Name {
+			case "IF":
+				l.lintIf(n)
+			case "AND", "OR":
+				l.lintLogical(n)
+			default:
+				if _, ok := LookupFormulaFunction(n.Name); !ok {
+					l.report(LintError, "unknown-function", fmt.Sprintf("%s is neither a built-in nor a registered function", n.Name))
+				}
+			}
+		}
+	})
+}
+
+func (l *formulaLinter) lintCompare(n BinaryOp) {
+	lt, rt := l.typeOf(n.Left), l.typeOf(n.Right)
+	if lt != "" && rt != "" && lt != rt {
+		msg := fmt.Sprintf("%s compares %s with %s", n, lt, rt)
+		if lt == "integer" || rt == "integer" {
+			msg += fmt.Sprintf("; an integer never equals another type, so it is always %v", n.Op == "<>")
+		}
+		l.report(LintError, "type-mismatch", msg)
+		return
+	}
+
+	for _, pair := range [][2]FormulaNode{{n.Left, n.Right}, {n.Right, n.Left}} {
+		lit, ok := pair[1].(LiteralBool)
+		if !ok || l.typeOf(pair[0]) != "boolean" || (n.Op != "=" && n.Op != "<>") {
+			continue
+		}
+		if lit.Value == (n.Op == "=") {
+			l.report(LintWarning, "redundant-bool-compare", fmt.Sprintf("%s is just %s", n, pair[0]))
+		} else {
+			l.report(LintWarning, "redundant-bool-compare", fmt.Sprintf("%s is just NOT(%s)", n, pair[0]))
+		}
+		return
+	}
+}
+
+func (l *formulaLinter) lintIf(n FuncCall) {
+	if len(n.Args) < 2 || len(n.Args) > 3 {
+		l.report(LintError, "arity", "IF expects 2 to 3 arguments")
+		return
+	}
+	if lit, ok := n.Args[0].(LiteralBool); ok {
+		dead := "else"
+		if !lit.Value {
+			dead = "then"
+		}
+		l.report(LintWarning, "constant-condition", fmt.Sprintf("IF(%s, ...) never takes its %s branch", lit, dead))
+	}
+
+	then := l.typeOf(n.Args[1])
+	if len(n.Args) == 2 {
+		if then != "" && then != "string" {
+			l.report(LintWarning, "mixed-if-types", fmt.Sprintf("IF without an else branch returns \"\" when false, but its then branch is %s", then))
+		}
+		return
+	}
+	if otherwise := l.typeOf(n.Args[2]); then != "" && otherwise != "" && then != otherwise {
+		l.report(LintError, "mixed-if-types", fmt.Sprintf("IF branches return %s and %s", then, otherwise))
+	}
+}
+
+func (l *formulaLinter) lintLogical(n FuncCall) {
+	neutral := n.Name == "AND" // TRUE() does nothing in AND, FALSE() in OR
+	seen := map[string]bool{}
+	for _, arg := range n.Args {
+		if lit, ok := arg.(LiteralBool); ok {
+			if lit.Value == neutral {
+				l.report(LintWarning, "constant-conjunct", fmt.Sprintf("%s has no effect in %s", lit, n.Name))
+			} else {
+				l.report(LintWarning, "constant-conjunct", fmt.Sprintf("%s makes %s always %v", lit, n.Name, lit.Value))
+			}
+		}
+		text := arg.String()
+		if seen[text] {
+			l.report(LintWarning, "duplicate-conjunct", fmt.Sprintf("%s appears more than once in %s", text, n.Name))
+		}
+		seen[text] = true
+	}
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runLint implements `lint [--rulebook PATH] [--strict] [--min-quality N]`:
+// prints every formula issue and fails on errors (or on any issue with
+// --strict), or when a table's quality score is below --min-quality
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	minQuality := fs.Float64("min-quality", 0, "fail when a table's data quality score (0-1, see stats) is below this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	issues := rb.Lint()
+	errors := 0
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Severity == LintError {
+			errors++
+		}
+	}
+	fmt.Printf("%d errors, %d warnings\n", errors, len(issues)-errors)
+	if errors > 0 || (*strict && len(issues) > 0) {
+		return fmt.Errorf("formula lint found %d issues", len(issues))
+	}
+
+	if *minQuality > 0 {
+		quality, err := rb.Quality()
+		if err != nil {
+			return err
+		}
+		below := 0
+		for _, q := range quality {
+			if q.Score < *minQuality {
+				fmt.Printf("%s is below --min-quality %.2f\n", q, *minQuality)
+				below++
+			}
+		}
+		if below > 0 {
+			return fmt.Errorf("%d tables are below the minimum quality", below)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// ERB SDK - Logging
+// =================
+// The loader, the compute pipeline and the Store log what they do through
+// log/slog, so substrate runs can be followed in CI logs. Each takes an
+// optional logger and falls back to Logger, which discards everything until
+// it is set; the CLI sets it with --log-level (or $ERB_LOG_LEVEL), writing
+// text to stderr:
+//
+//	erb --log-level info take-test
+//	erb --log-level debug compute --scenario scenarios/no-syntax.json
+//
+//	rb, err := LoadFromRulebook(path, WithLogger(logger))
+//	answers := ComputeAllRecords(records, WithComputeLogger(logger))
+//	store.SetLogger(logger)
+//
+// What is logged:
+//
+//	info   rulebook loaded (tables, records, warnings, duration), records
+//	       loaded and computed (count, workers, duration), records written
+//	       (path, count), store commits (version, edits)
+//	debug  each rulebook table's record count, and each DAG level's compute
+//	       time in the formula interpreter (Table.Compute)
+//	warn   formula reference warnings, and validation findings (findings
+//	       of error severity are logged as errors)
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Logger is the default logger of the loader, the compute pipeline and the
+// Store (see WithLogger, WithComputeLogger and Store.SetLogger)
+var Logger = slog.New(slog.DiscardHandler)
+
+// WithLogger logs the rulebook load to l instead of Logger
+func WithLogger(l *slog.Logger) LoadOption {
+	return func(c *loadConfig) {
+		c.logger = l
+	}
+}
+
+// WithComputeLogger logs loading, computing and saving records to l instead of Logger
+func WithComputeLogger(l *slog.Logger) RecordOption {
+	return func(c *recordConfig) {
+		c.logger = l
+	}
+}
+
+// loggerOr returns l, or Logger if l is nil
+func loggerOr(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return Logger
+	}
+	return l
+}
+
+// logValidationIssue logs a validation finding at its severity
+func logValidationIssue(l *slog.Logger, source string, issue ValidationIssue) {
+	level := slog.LevelWarn
+	if issue.Severity == SeverityError {
+		level = slog.LevelError
+	}
+	attrs := []any{"rule", issue.Rule, "table", issue.Table}
+	if source != "" {
+		attrs = append(attrs, "source", source)
+	}
+	if issue.ID != "" {
+		attrs = append(attrs, "id", issue.ID)
+	}
+	if issue.Field != "" {
+		attrs = append(attrs, "field", issue.Field)
+	}
+	l.Log(commandContext(), level, "validation finding", append(attrs, "message", issue.Message)...)
+}
+
+// setLogLevel makes Logger write text to stderr from level up (debug, info,
+// warn or error); "" leaves logging off
+func setLogLevel(level string) error {
+	if level == "" {
+		return nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q (want debug, info, warn or error)", level)
+	}
+	Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l}))
+	return nil
+}
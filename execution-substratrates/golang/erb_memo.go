@@ -0,0 +1,119 @@
+// ERB SDK - Memoized Views
+// ========================
+// ToView computes every calculated field on each call. When the same records
+// are viewed again and again (a server answering requests, a UI re-rendering
+// one candidate), a ViewCache keeps each record's view by ID and recomputes
+// it only once a raw field has changed (the generated RawEqual):
+//
+//	cache := NewViewCache()
+//	view := candidate.ToView(WithViewCache(cache)) // computed
+//	view = candidate.ToView(WithViewCache(cache))  // cached
+//	*candidate.HasSyntax = false
+//	view = candidate.ToView(WithViewCache(cache))  // recomputed
+//
+// Cached views share their values with the cache; do not modify them
+// through their pointer fields.
+//
+// The cache is unbounded: it keeps one view for every ID it has seen until
+// Forget drops that ID (e.g. a deleted record) or Reset drops them all, so
+// a long-lived cache over records that come and go should do one or the other.
+//
+// Memoizing calculated fields one by one would not help the generated code:
+// each Calc* method reads stored fields only and no Calc* calls another,
+// and ComputeAll evaluates each calculated field once, level by level,
+// passing results on in locals, so no field is computed twice within one
+// ToView. The work repeated is the whole
+// view across calls, which is why the cache sits at the ToView level.
+
+package main
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ViewCache holds the computed views of LanguageCandidates by ID; safe for
+// concurrent use
+type ViewCache struct {
+	mu      sync.Mutex
+	entries map[string]viewCacheEntry
+	hits    int
+	misses  int
+}
+
+// viewCacheEntry is a view and a private copy of the raw values it was computed from
+type viewCacheEntry struct {
+	raw  LanguageCandidate
+	view LanguageCandidateView
+}
+
+// NewViewCache returns an empty cache
+func NewViewCache() *ViewCache {
+	return &ViewCache{entries: map[string]viewCacheEntry{}}
+}
+
+// WithViewCache makes ToView reuse the views in c while the raw fields are unchanged
+func WithViewCache(c *ViewCache) RecordOption {
+	return func(cfg *recordConfig) {
+		cfg.views = c
+	}
+}
+
+// View returns the candidate's view, computing it only if the candidate is
+// new to the cache or a raw field changed since it was computed
+func (c *ViewCache) View(tc *LanguageCandidate) LanguageCandidateView {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[tc.LanguageCandidateId]; ok && e.raw.RawEqual(tc) {
+		c.hits++
+		return e.view
+	}
+	c.misses++
+	raw := cloneRecord(*tc)
+	view := *raw.ComputeAll()
+	c.entries[tc.LanguageCandidateId] = viewCacheEntry{raw: raw, view: view}
+	return view
+}
+
+// Forget drops the view of a record, e.g. once it is deleted
+func (c *ViewCache) Forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
+
+// Reset drops every cached view and zeroes the Stats counts
+func (c *ViewCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.hits, c.misses = 0, 0
+}
+
+// Len returns the number of cached views
+func (c *ViewCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Stats returns how many View calls were served from the cache and how many computed
+func (c *ViewCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// cloneRecord copies a struct record, giving each pointer field its own copy
+// of the value, so later edits through the original's pointers do not reach it
+func cloneRecord[T any](rec T) T {
+	v := reflect.ValueOf(&rec).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Pointer && !f.IsNil() && f.CanSet() {
+			p := reflect.New(f.Type().Elem())
+			p.Elem().Set(f.Elem())
+			f.Set(p)
+		}
+	}
+	return rec
+}
@@ -0,0 +1,191 @@
+// ERB SDK - Datatype Migration
+// ============================
+// A field's datatype in the rulebook schema is canonical. Record files written
+// by an SDK that disagreed with it keep the old representation: HasGrammar
+// was a string ("true"/"") in the Python SDK and the CSV/YAML schemas while
+// the rulebook, the generated Go, and the answer key use a boolean.
+// `migrate` rewrites record files (blank tests, answer keys, test answers,
+// golden files) so every value has its field's canonical JSON form:
+//
+//	migrate --check ../../testing/*.json ../*/test-answers.json
+//	migrate ../python/test-answers.json
+//
+//	boolean  true/false; "true"/"false" (any case), "yes"/"no", "1"/"0", "",
+//	         0 and 1 convert
+//	integer  whole numbers; "2" and 2.0 convert
+//	string   booleans and numbers become their text
+//
+// Nulls stay null, and a file is rewritten only when a value changes (records
+// and keys keep their order). Stored values in the rulebook itself are
+// checked by `validate`.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Migration is one value MigrateRecords converted to its canonical form
+type Migration struct {
+	Record int // zero-based position in the file
+	Key    string
+	From   any
+	To     any
+}
+
+func (c Migration) String() string {
+	return fmt.Sprintf("record %d: %s %s -> %s", c.Record, c.Key, pgDiffValue(c.From), pgDiffValue(c.To))
+}
+
+// CanonicalValue converts v to the canonical JSON form of a schema datatype;
+// it fails when v has no meaning as that datatype (e.g. "maybe" as boolean)
+func CanonicalValue(datatype string, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if n, ok := v.(float64); ok && n == math.Trunc(n) {
+		v = int(n)
+	}
+
+	switch datatype {
+	case "boolean":
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int:
+			if x == 0 || x == 1 {
+				return x == 1, nil
+			}
+		case string:
+			switch strings.ToLower(strings.TrimSpace(x)) {
+			case "true", "yes", "1":
+				return true, nil
+			case "false", "no", "0", "":
+				return false, nil
+			}
+		}
+	case "integer":
+		switch x := v.(type) {
+		case int:
+			return x, nil
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(x)); err == nil {
+				return n, nil
+			}
+		}
+	case "string":
+		return formulaText(v), nil
+	default:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%s is not a valid %s", pgDiffValue(v), datatype)
+}
+
+// MigrateRecords converts, in place, every value of records whose key is one
+// of t's fields (by snake_case JSON key or field name) to its canonical form.
+// Whole JSON numbers should already be ints (see wholeNumbersToInt).
+func (t *Table) MigrateRecords(records []Record) ([]Migration, error) {
+	datatypes := map[string]string{}
+	for _, f := range t.Schema {
+		datatypes[f.Name] = f.Datatype
+		datatypes[toSnakeCase(f.Name)] = f.Datatype
+	}
+
+	var changes []Migration
+	for i, rec := range records {
+		for _, key := range rec.Keys {
+			datatype, ok := datatypes[key]
+			if !ok {
+				continue
+			}
+			from := rec.Values[key]
+			to, err := CanonicalValue(datatype, from)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %s: %w", i, key, err)
+			}
+			if !reflect.DeepEqual(from, to) {
+				changes = append(changes, Migration{Record: i, Key: key, From: from, To: to})
+				rec.Values[key] = to
+			}
+		}
+	}
+	return changes, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runMigrate implements `migrate [--rulebook PATH] [--table T] [--check] FILE...`
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	table := fs.String("table", "", "table the records belong to (default: the first table)")
+	check := fs.Bool("check", false, "report values that need migrating and fail instead of rewriting")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: migrate [--rulebook PATH] [--table T] [--check] FILE...")
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+	t := rb.Tables[0]
+	if *table != "" {
+		if t = rb.Table(*table); t == nil {
+			return unknownTable(*table)
+		}
+	}
+
+	pending := 0
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		records, err := jsonImporter{}.Read(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		wholeNumbersToInt(records)
+		changes, err := t.MigrateRecords(records)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(changes) == 0 {
+			fmt.Printf("%s: already canonical\n", path)
+			continue
+		}
+		for _, c := range changes {
+			fmt.Printf("%s: %s\n", path, c)
+		}
+		if *check {
+			pending += len(changes)
+			continue
+		}
+
+		out, err := MarshalCanonical(records)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("%s: migrated %d values\n", path, len(changes))
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d values need migrating", pending)
+	}
+	return nil
+}
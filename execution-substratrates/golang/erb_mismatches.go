@@ -0,0 +1,106 @@
+// ERB SDK - Family Feud Mismatches
+// ================================
+// A candidate is a mismatch when the Family Feud test (TopFamilyFeudAnswer)
+// and the curated ChosenLanguageCandidate flag disagree. FamilyFeudMismatches
+// reports them as data instead of the prose in the FamilyFeudMismatch field,
+// including which of the test's criteria failed.
+
+package main
+
+import "fmt"
+
+// Mismatch kinds
+const (
+	// MismatchUnchosenLanguage: passes the Family Feud test but is not a chosen candidate
+	MismatchUnchosenLanguage = "unchosen-language"
+	// MismatchChosenNonLanguage: a chosen candidate that fails the Family Feud test
+	MismatchChosenNonLanguage = "chosen-non-language"
+)
+
+// CandidateMismatch is one candidate whose Family Feud answer disagrees with its curation
+type CandidateMismatch struct {
+	CandidateId             string `json:"candidate_id"`
+	Name                    string `json:"name"`
+	Kind                    string `json:"kind"`
+	TopFamilyFeudAnswer     bool   `json:"top_family_feud_answer"`
+	ChosenLanguageCandidate bool   `json:"chosen_language_candidate"`
+
+	// FailedCriteria are the TopFamilyFeudAnswer conditions that do not hold
+	// (empty for MismatchUnchosenLanguage, where they all hold)
+	FailedCriteria []string `json:"failed_criteria"`
+
+	// OpenClosedWorldConflict is set when the candidate is both open and closed world
+	OpenClosedWorldConflict bool `json:"open_closed_world_conflict"`
+
+	// Message is the computed FamilyFeudMismatch text
+	Message string `json:"message"`
+}
+
+// MismatchReport summarizes the mismatches across the rulebook
+type MismatchReport struct {
+	Candidates         int                 `json:"candidates"`
+	Mismatches         []CandidateMismatch `json:"mismatches"`
+	UnchosenLanguages  int                 `json:"unchosen_languages"`
+	ChosenNonLanguages int                 `json:"chosen_non_languages"`
+}
+
+// FamilyFeudMismatches reports every candidate whose TopFamilyFeudAnswer
+// disagrees with ChosenLanguageCandidate, in rulebook order
+func (rb *Rulebook) FamilyFeudMismatches() (*MismatchReport, error) {
+	criteria, err := topAnswerCriteria()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MismatchReport{Mismatches: []CandidateMismatch{}}
+	for _, v := range rb.CandidateViews() {
+		report.Candidates++
+		top, chosen := optGet(v.TopFamilyFeudAnswer, false), optGet(v.ChosenLanguageCandidate, false)
+		if top == chosen {
+			continue
+		}
+
+		m := CandidateMismatch{
+			CandidateId:             v.LanguageCandidateId,
+			Name:                    optGet(v.Name, ""),
+			TopFamilyFeudAnswer:     top,
+			ChosenLanguageCandidate: chosen,
+			FailedCriteria:          []string{},
+			OpenClosedWorldConflict: optGet(v.IsOpenClosedWorldConflicted, false),
+			Message:                 optGet(v.FamilyFeudMismatch, ""),
+		}
+		if top {
+			m.Kind = MismatchUnchosenLanguage
+			report.UnchosenLanguages++
+		} else {
+			m.Kind = MismatchChosenNonLanguage
+			report.ChosenNonLanguages++
+		}
+
+		eval := &FormulaEvaluator{Lookup: func(name string) any { return recordField(&v, name) }}
+		for _, c := range criteria {
+			value, err := eval.Eval(c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate TopFamilyFeudAnswer criterion %s: %w", c, err)
+			}
+			if !formulaBool(value) {
+				m.FailedCriteria = append(m.FailedCriteria, c.String())
+			}
+		}
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	return report, nil
+}
+
+// topAnswerCriteria returns the conditions of the TopFamilyFeudAnswer formula:
+// the arguments of its top-level AND, or the whole formula otherwise
+func topAnswerCriteria() ([]FormulaNode, error) {
+	ast, err := ParseFormula(LanguageCandidateFormulas["TopFamilyFeudAnswer"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TopFamilyFeudAnswer: %w", err)
+	}
+	if and, ok := ast.(FuncCall); ok && and.Name == "AND" {
+		return and.Args, nil
+	}
+	return []FormulaNode{ast}, nil
+}
@@ -0,0 +1,218 @@
+// ERB SDK - Naming Map
+// ====================
+// Every substrate spells a field differently: the rulebook and Airtable use
+// the authored PascalCase name, record files snake_case JSON keys, GraphQL
+// camelCase, PostgreSQL snake_case columns of a table (raw fields) or its
+// vw_ view (calculated fields, each with a calc_ function). Naming() derives
+// all of them from the rulebook, and Lookup resolves a name in any of those
+// casings, so tools bridging substrates need no name tables of their own:
+//
+//	m := rb.Naming()
+//	f, _ := m.Field("LanguageCandidates", "resolves_to_an_ast")
+//	f.GoField   // ResolvesToAnAST
+//	f.PGColumn  // resolves_to_an_ast
+//	f.CamelKey  // resolvesToAnAST
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// TableNames is a table's name in every substrate
+type TableNames struct {
+	Table    string `json:"table"`     // rulebook name, also the Airtable table
+	GoStruct string `json:"go_struct"` // struct in erb_sdk.go
+	JSONKey  string `json:"json_key"`  // snake_case, e.g. the blank-test file suffix
+	PGTable  string `json:"pg_table"`
+	PGView   string `json:"pg_view"`
+	Airtable string `json:"airtable"`
+}
+
+// FieldNames is a field's name in every substrate
+type FieldNames struct {
+	Table      string `json:"table"`
+	Field      string `json:"field"`                 // rulebook name (PascalCase)
+	Calculated bool   `json:"calculated"`            // computed, so only in the view
+	GoField    string `json:"go_field"`              // struct field in erb_sdk.go
+	JSONKey    string `json:"json_key"`              // record files (snake_case)
+	CamelKey   string `json:"camel_key"`             // GraphQL and JavaScript (camelCase)
+	KebabKey   string `json:"kebab_key"`             // URLs and file names
+	PGColumn   string `json:"pg_column"`             // column of PGRelation
+	PGRelation string `json:"pg_relation"`           // the table for raw fields, its view for calculated ones
+	PGFunction string `json:"pg_function,omitempty"` // calc_ function of a calculated field
+	Airtable   string `json:"airtable"`              // Airtable column
+}
+
+// Names lists every spelling of the field, rulebook name first
+func (f FieldNames) Names() []string {
+	names := []string{f.Field}
+	for _, n := range []string{f.GoField, f.JSONKey, f.CamelKey, f.KebabKey, f.PGColumn, f.Airtable} {
+		if !containsString(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// NamingMap holds the names of a rulebook's tables and fields
+type NamingMap struct {
+	Tables []TableNames `json:"tables"`
+	Fields []FieldNames `json:"fields"` // in table and schema order
+
+	byKey map[string][]int // nameKey -> indexes into Fields
+}
+
+// Naming returns the naming map of the rulebook
+func (rb *Rulebook) Naming() *NamingMap {
+	m := &NamingMap{byKey: map[string][]int{}}
+	for _, t := range rb.Tables {
+		snake := toSnakeCase(t.Name)
+		m.Tables = append(m.Tables, TableNames{
+			Table:    t.Name,
+			GoStruct: structName(t.Name),
+			JSONKey:  snake,
+			PGTable:  snake,
+			PGView:   "vw_" + snake,
+			Airtable: t.Name,
+		})
+		for _, f := range t.Schema {
+			fn := FieldNames{
+				Table:      t.Name,
+				Field:      f.Name,
+				Calculated: f.IsCalculated(),
+				GoField:    f.Name,
+				JSONKey:    toSnakeCase(f.Name),
+				CamelKey:   graphqlName(f.Name),
+				KebabKey:   kebabCase(f.Name),
+				PGColumn:   toSnakeCase(f.Name),
+				PGRelation: snake,
+				Airtable:   f.Name,
+			}
+			if fn.Calculated {
+				fn.PGRelation = "vw_" + snake
+				fn.PGFunction = sqlFunctionName(t, f.Name)
+			}
+			key := nameKey(f.Name)
+			m.byKey[key] = append(m.byKey[key], len(m.Fields))
+			m.Fields = append(m.Fields, fn)
+		}
+	}
+	return m
+}
+
+// nameKey folds a name in any casing (PascalCase, snake_case, camelCase,
+// kebab-case, or with spaces) to the snake_case key it stands for
+func nameKey(name string) string {
+	name = strings.NewReplacer(" ", "", "-", "_").Replace(strings.TrimSpace(name))
+	return toSnakeCase(name)
+}
+
+// Lookup returns the fields, of any table, that name spells in any casing
+func (m *NamingMap) Lookup(name string) []FieldNames {
+	var fields []FieldNames
+	for _, i := range m.byKey[nameKey(name)] {
+		fields = append(fields, m.Fields[i])
+	}
+	return fields
+}
+
+// Field returns the names of a table's field; both may be spelled in any casing
+func (m *NamingMap) Field(table, name string) (FieldNames, bool) {
+	tableKey := nameKey(table)
+	for _, f := range m.Lookup(name) {
+		if nameKey(f.Table) == tableKey {
+			return f, true
+		}
+	}
+	return FieldNames{}, false
+}
+
+// Table returns the names of a table spelled in any casing
+func (m *NamingMap) Table(name string) (TableNames, bool) {
+	key := nameKey(name)
+	for _, t := range m.Tables {
+		if nameKey(t.Table) == key || t.PGView == key || nameKey(t.GoStruct) == key {
+			return t, true
+		}
+	}
+	return TableNames{}, false
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runNames implements `names [--rulebook PATH] [--table T] [--json] [NAME]`:
+// prints the naming map, or the fields NAME spells
+func runNames(args []string) error {
+	fs := flag.NewFlagSet("names", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	table := fs.String("table", "", "only list this table's fields")
+	asJSON := fs.Bool("json", false, "print the naming map as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	m := rb.Naming()
+	fields := m.Fields
+	if name := fs.Arg(0); name != "" {
+		if fields = m.Lookup(name); len(fields) == 0 {
+			return fmt.Errorf("no field is named %q in any casing", name)
+		}
+	}
+	if *table != "" {
+		t, ok := m.Table(*table)
+		if !ok {
+			return unknownTable(*table)
+		}
+		var kept []FieldNames
+		for _, f := range fields {
+			if f.Table == t.Table {
+				kept = append(kept, f)
+			}
+		}
+		fields = kept
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if fs.Arg(0) == "" && *table == "" {
+			return enc.Encode(m)
+		}
+		return enc.Encode(append([]FieldNames{}, fields...))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tFIELD (GO, AIRTABLE)\tJSON\tCAMEL\tPOSTGRES")
+	for _, f := range fields {
+		pg := f.PGRelation + "." + f.PGColumn
+		if f.PGFunction != "" {
+			pg += " = " + f.PGFunction + "()"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Table, f.Field, f.JSONKey, f.CamelKey, pg)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,217 @@
+// ERB SDK - Outlier Detection
+// ===========================
+// Heuristics that pick out records a curator should look at again. The
+// criteria are a table's raw boolean fields (nil counts as false, as in the
+// formulas), and a record is flagged when:
+//
+//	contradicts-correlation  the other records establish a strong rule
+//	                         (RequiresParsing holds for at least --confidence
+//	                         of at least --min-support records with
+//	                         ResolvesToAnAST) and this record breaks it
+//	isolated-combination     no other record is within --min-distance - 1
+//	                         criteria of this one
+//
+// Rules are learned leaving the record out, so an outlier cannot hide the
+// rule it breaks. Flags are review candidates, not errors: unusual records
+// are often the interesting ones.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Outlier rules
+const (
+	OutlierCorrelation = "contradicts-correlation"
+	OutlierIsolated    = "isolated-combination"
+)
+
+// OutlierOptions configures Outliers; zero values use the defaults
+type OutlierOptions struct {
+	Confidence  float64 // share of the premise's records the conclusion must hold for (default 0.9)
+	MinSupport  int     // records the premise must hold for (default 5)
+	MinDistance int     // criteria the nearest record must differ in to be isolated (default 2)
+}
+
+func (o OutlierOptions) withDefaults() OutlierOptions {
+	if o.Confidence <= 0 {
+		o.Confidence = 0.9
+	}
+	if o.MinSupport <= 0 {
+		o.MinSupport = 5
+	}
+	if o.MinDistance <= 0 {
+		o.MinDistance = 2
+	}
+	return o
+}
+
+// Outlier is one record flagged for review
+type Outlier struct {
+	Table   string `json:"table"`
+	ID      string `json:"id"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (o Outlier) String() string {
+	return fmt.Sprintf("%s %s: %s: %s", o.Table, o.ID, o.Rule, o.Message)
+}
+
+// Outliers flags records of every table with at least two boolean criteria
+func (rb *Rulebook) Outliers(opts OutlierOptions) []Outlier {
+	opts = opts.withDefaults()
+	var outliers []Outlier
+	for _, t := range rb.Tables {
+		outliers = append(outliers, t.Outliers(opts)...)
+	}
+	return outliers
+}
+
+// Outliers flags the table's records (see the file comment)
+func (t *Table) Outliers(opts OutlierOptions) []Outlier {
+	opts = opts.withDefaults()
+	var criteria []string
+	for _, f := range t.Schema {
+		if !f.IsCalculated() && f.Datatype == "boolean" {
+			criteria = append(criteria, f.Name)
+		}
+	}
+	if len(criteria) < 2 {
+		return nil
+	}
+
+	rows := make([][]bool, len(t.Data))
+	for i, row := range t.Data {
+		rows[i] = make([]bool, len(criteria))
+		for j, name := range criteria {
+			rows[i][j], _ = runtimeValue(row[name]).(bool)
+		}
+	}
+	ids := make([]string, len(t.Data))
+	for i, row := range t.Data {
+		ids[i] = fmt.Sprint(row[t.IDField()])
+	}
+
+	var outliers []Outlier
+	for i := range rows {
+		for _, msg := range brokenRules(rows, i, criteria, opts) {
+			outliers = append(outliers, Outlier{Table: t.Name, ID: ids[i], Rule: OutlierCorrelation, Message: msg})
+		}
+		if msg := isolation(rows, i, ids, opts.MinDistance); msg != "" {
+			outliers = append(outliers, Outlier{Table: t.Name, ID: ids[i], Rule: OutlierIsolated, Message: msg})
+		}
+	}
+	return outliers
+}
+
+// brokenRules describes every strong rule "a -> b = y", learned from the
+// rows other than i, that row i breaks. Only criteria that hold are
+// premises: what a record lacks says less about it than what it has.
+func brokenRules(rows [][]bool, i int, criteria []string, opts OutlierOptions) []string {
+	var broken []string
+	for a := range criteria {
+		for b := range criteria {
+			if a == b || !rows[i][a] {
+				continue
+			}
+			conclusion := !rows[i][b] // the rule row i would break
+			support, holds := 0, 0
+			for k, row := range rows {
+				if k == i || !row[a] {
+					continue
+				}
+				support++
+				if row[b] == conclusion {
+					holds++
+				}
+			}
+			if support < opts.MinSupport || float64(holds) < opts.Confidence*float64(support) {
+				continue
+			}
+			with, have := "without", "have"
+			if !conclusion {
+				with, have = "with", "lack"
+			}
+			broken = append(broken, fmt.Sprintf("%s %s %s, but %d of the %d other records with %s %s it",
+				criteria[a], with, criteria[b], holds, support, criteria[a], have))
+		}
+	}
+	return broken
+}
+
+// isolation describes row i when every other row differs from it in at
+// least minDistance criteria, naming the nearest ones
+func isolation(rows [][]bool, i int, ids []string, minDistance int) string {
+	nearest, distance := []string{}, len(rows[i])+1
+	for k, row := range rows {
+		if k == i {
+			continue
+		}
+		d := 0
+		for j := range row {
+			if row[j] != rows[i][j] {
+				d++
+			}
+		}
+		switch {
+		case d < distance:
+			nearest, distance = []string{ids[k]}, d
+		case d == distance:
+			nearest = append(nearest, ids[k])
+		}
+	}
+	if len(nearest) == 0 || distance < minDistance {
+		return ""
+	}
+	sort.Strings(nearest)
+	return fmt.Sprintf("no other record shares its criteria; the nearest (%s) differ in %d", strings.Join(nearest, ", "), distance)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runOutliers implements `outliers [--rulebook PATH] [--table T]
+// [--confidence N] [--min-support N] [--min-distance N]`
+func runOutliers(args []string) error {
+	fs := flag.NewFlagSet("outliers", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	table := fs.String("table", "", "only check this table")
+	var opts OutlierOptions
+	fs.Float64Var(&opts.Confidence, "confidence", 0.9, "share of records a correlation must hold for to count as a rule")
+	fs.IntVar(&opts.MinSupport, "min-support", 5, "records a rule's premise must hold for")
+	fs.IntVar(&opts.MinDistance, "min-distance", 2, "criteria the nearest record must differ in for a combination to be isolated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var outliers []Outlier
+	if *table != "" {
+		t := rb.Table(*table)
+		if t == nil {
+			return unknownTable(*table)
+		}
+		outliers = t.Outliers(opts)
+	} else {
+		outliers = rb.Outliers(opts)
+	}
+
+	flagged := map[string]bool{}
+	for _, o := range outliers {
+		fmt.Println(o)
+		flagged[o.Table+"\x00"+o.ID] = true
+	}
+	fmt.Printf("%d records flagged for review\n", len(flagged))
+	return nil
+}
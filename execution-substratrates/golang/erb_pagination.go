@@ -0,0 +1,229 @@
+// ERB SDK - Pagination
+// ====================
+// Large candidate sets are read a page at a time. Page takes a page size and
+// the previous page's NextCursor (empty for the first page); cursors are
+// opaque, and NextCursor is empty on the last page:
+//
+//	q := rb.Candidates().SortBy(SortOrder.NilsLast())
+//	for cursor := ""; ; {
+//		page, err := q.Page(10, cursor)
+//		if err != nil {
+//			return err
+//		}
+//		process(page.Items)
+//		if cursor = page.NextCursor; cursor == "" {
+//			break
+//		}
+//	}
+//
+// A cursor is a keyset, not an offset: it holds the last row's SortBy keys
+// and its id (see KeyedBy), and the next page starts at the first row that
+// sorts after them. Pages are ordered by the SortBy keys, then by id, so
+// rows added or removed before the cursor between two fetches neither
+// repeat nor skip a row; an edited row is read where it now sorts. Every
+// SortBy key must be a QueryField, or Desc or NilsLast of one, for its
+// value to be held in a cursor.
+//
+// PageAt(size, offset) reads any page directly. The server pages
+// /candidates and /arguments with ?limit= and ?offset= or ?cursor=, in id
+// order, reporting X-Total-Count and a Link header to the next page.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Page is one page of a query's rows
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`  // rows in the whole query
+	Offset     int    `json:"offset"` // position of Items[0] in the query, as of this read
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Page evaluates the query and returns size rows (all that remain if size
+// is zero or less) following the row the cursor was taken at
+func (q *Query[T]) Page(size int, cursor string) (Page[T], error) {
+	rows, keys, err := q.pageRows()
+	if err != nil {
+		return Page[T]{}, err
+	}
+	start := 0
+	if cursor != "" {
+		after, err := decodeCursorKeys(cursor, keys)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		start = sort.Search(len(rows), func(i int) bool {
+			return compareToCursor(&rows[i], keys, after) > 0
+		})
+	}
+	return pageOf(rows, keys, start, size)
+}
+
+// PageAt evaluates the query and returns size rows (all that remain if size
+// is zero or less) starting at offset, in Page's order
+func (q *Query[T]) PageAt(size, offset int) (Page[T], error) {
+	rows, keys, err := q.pageRows()
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return pageOf(rows, keys, min(max(offset, 0), len(rows)), size)
+}
+
+// pageRows evaluates the query ordered by its sort keys, then by id, and
+// returns the rows with the keys a cursor holds
+func (q *Query[T]) pageRows() ([]T, []cursorKey[T], error) {
+	if q.id == nil {
+		return nil, nil, errors.New("paging needs a query keyed by row id (see KeyedBy)")
+	}
+	ordered := q.SortBy(QueryField[T, string]{Name: "id", Get: func(r *T) *string {
+		id := q.id(r)
+		return &id
+	}})
+	keys := make([]cursorKey[T], len(ordered.order))
+	for i, key := range ordered.order {
+		ck, ok := asCursorKey(key)
+		if !ok {
+			return nil, nil, fmt.Errorf("cannot page by sort key %T: a cursor cannot hold its value", key)
+		}
+		keys[i] = ck
+	}
+	return ordered.All(), keys, nil
+}
+
+// pageOf returns the page of size rows (the rest when size <= 0) at start,
+// with the cursor of the next page if any row follows it
+func pageOf[T any](rows []T, keys []cursorKey[T], start, size int) (Page[T], error) {
+	end := len(rows)
+	if size > 0 {
+		end = min(start+size, len(rows))
+	}
+	page := Page[T]{Items: rows[start:end], Total: len(rows), Offset: start}
+	if end < len(rows) && end > start {
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = key.cursorValue(&rows[end-1])
+		}
+		var err error
+		if page.NextCursor, err = EncodeCursor(values); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	return page, nil
+}
+
+// EncodeCursor returns the cursor holding a row's sort key values
+func EncodeCursor(values []any) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor returns the sort key values a cursor holds, as JSON
+func DecodeCursor(cursor string) ([]json.RawMessage, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return values, nil
+}
+
+// decodeCursorKeys reads a cursor's values for the keys
+func decodeCursorKeys[T any](cursor string, keys []cursorKey[T]) ([]any, error) {
+	raw, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != len(keys) {
+		return nil, fmt.Errorf("invalid cursor %q: it was not taken from this query's sort order", cursor)
+	}
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		if values[i], err = key.readCursorValue(raw[i]); err != nil {
+			return nil, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+	}
+	return values, nil
+}
+
+// compareToCursor orders a row against a cursor's values, as CompareRows
+// would order it against the row the cursor was taken at
+func compareToCursor[T any](row *T, keys []cursorKey[T], values []any) int {
+	for i, key := range keys {
+		if c := key.compareToValue(row, values[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// =============================================================================
+// CURSOR KEYS
+// =============================================================================
+
+// cursorKey is a sort key whose value a cursor can hold
+type cursorKey[T any] interface {
+	SortKey[T]
+	cursorValue(row *T) any                            // the row's value, for the cursor
+	readCursorValue(data json.RawMessage) (any, error) // a value read back from a cursor
+	compareToValue(row *T, value any) int              // orders a row against a read-back value
+}
+
+// asCursorKey returns key as a cursorKey, if a cursor can hold its value
+func asCursorKey[T any](key SortKey[T]) (cursorKey[T], bool) {
+	if d, ok := key.(descending[T]); ok {
+		if _, ok := asCursorKey(d.key); !ok {
+			return nil, false
+		}
+	}
+	ck, ok := key.(cursorKey[T])
+	return ck, ok
+}
+
+func (f QueryField[T, V]) cursorValue(row *T) any { return f.Get(row) }
+
+func (f QueryField[T, V]) readCursorValue(data json.RawMessage) (any, error) {
+	var v *V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+func (f QueryField[T, V]) compareToValue(row *T, value any) int {
+	return compareNullable(f.Get(row), value.(*V))
+}
+
+func (n nilsLast[T, V]) cursorValue(row *T) any { return n.f.cursorValue(row) }
+
+func (n nilsLast[T, V]) readCursorValue(data json.RawMessage) (any, error) {
+	return n.f.readCursorValue(data)
+}
+
+func (n nilsLast[T, V]) compareToValue(row *T, value any) int {
+	c := n.f.compareToValue(row, value)
+	if (n.f.Get(row) == nil) != (value.(*V) == nil) {
+		return -c
+	}
+	return c
+}
+
+func (d descending[T]) cursorValue(row *T) any { return d.key.(cursorKey[T]).cursorValue(row) }
+
+func (d descending[T]) readCursorValue(data json.RawMessage) (any, error) {
+	return d.key.(cursorKey[T]).readCursorValue(data)
+}
+
+func (d descending[T]) compareToValue(row *T, value any) int {
+	return -d.key.(cursorKey[T]).compareToValue(row, value)
+}
@@ -0,0 +1,96 @@
+// ERB SDK - Pagination Tests
+// ==========================
+// Unit tests for Query.Page: a cursor is a keyset, so rows added or removed
+// ahead of it between two fetches neither repeat nor skip a row:
+//
+//	go test $(ls *.go) -run Page
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPageCursorSurvivesEdits(t *testing.T) {
+	_, store := storeForTest(t)
+	q := NewQuery(store.Candidates).
+		KeyedBy(func(lc *LanguageCandidate) string { return lc.LanguageCandidateId }).
+		SortBy(SortOrder.NilsLast(), Name.Desc())
+
+	all, err := q.PageAt(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := q.Page(5, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("first page has no NextCursor")
+	}
+
+	// Add two rows that sort before the cursor and remove one on the first page
+	err = store.Transact(func(tx *Tx) error {
+		for _, id := range []string{"aaa-first", "aab-second"} {
+			if err := tx.AddCandidate(LanguageCandidate{LanguageCandidateId: id, Name: optPtr(id), SortOrder: optPtr(-1)}); err != nil {
+				return err
+			}
+		}
+		return tx.DeleteCandidate(unreferencedCandidate(t, store, first.Items))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := q.Page(5, first.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := candidateIDs(all.Items[5:10])
+	if got := candidateIDs(second.Items); !slices.Equal(got, want) {
+		t.Errorf("second page after edits = %v, want %v", got, want)
+	}
+	if second.Offset != 6 {
+		t.Errorf("second page Offset = %d, want 6 (two rows added, one removed ahead of it)", second.Offset)
+	}
+}
+
+func TestPageRejectsForeignCursor(t *testing.T) {
+	_, store := storeForTest(t)
+	byID := NewQuery(store.Candidates).KeyedBy(func(lc *LanguageCandidate) string { return lc.LanguageCandidateId })
+	page, err := byID.Page(3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := byID.SortBy(Name).Page(3, page.NextCursor); err == nil {
+		t.Error("a cursor from another sort order was accepted")
+	}
+	if _, err := byID.Page(3, "not-a-cursor"); err == nil {
+		t.Error("an invalid cursor was accepted")
+	}
+}
+
+// unreferencedCandidate returns the id of a candidate among rows that no
+// argument step names, so it can be deleted
+func unreferencedCandidate(t *testing.T, store *Store, rows []LanguageCandidate) string {
+	t.Helper()
+	for _, lc := range rows {
+		referenced := slices.ContainsFunc(store.ArgumentSteps(), func(step IsEverythingALanguage) bool {
+			return optGet(step.RelatedCandidateId, "") == lc.LanguageCandidateId
+		})
+		if !referenced {
+			return lc.LanguageCandidateId
+		}
+	}
+	t.Fatal("every row is referenced by an argument step")
+	return ""
+}
+
+func candidateIDs(rows []LanguageCandidate) []string {
+	out := make([]string, len(rows))
+	for i, lc := range rows {
+		out[i] = lc.LanguageCandidateId
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+// ERB SDK - Parallel Compute
+// ==========================
+// ComputeAll only reads the record it is called on, so the records of a large
+// blank test can be computed by a pool of goroutines. Results keep the input
+// order, and take-test uses the same pool (`take-test --workers N`):
+//
+//	answers := ComputeAllRecords(records, WithWorkers(8))
+//
+// ComputeAllRecordsContext stops handing out records once its context is
+// done, so a large batch can be cancelled or bounded by a deadline:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	answers, err := ComputeAllRecordsContext(ctx, records)
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// computeChunk is how many consecutive records a worker takes at a time
+const computeChunk = 256
+
+// WithWorkers sets how many goroutines compute records; n <= 0 (the
+// default) uses GOMAXPROCS and 1 computes on the calling goroutine
+func WithWorkers(n int) RecordOption {
+	return func(c *recordConfig) {
+		c.workers = n
+	}
+}
+
+// ComputeAllRecords computes the calculated fields of every candidate in
+// parallel; the result is in the same order as records
+func ComputeAllRecords(records []LanguageCandidate, opts ...RecordOption) []LanguageCandidate {
+	computed, _ := computeAllParallel(records, (*LanguageCandidate).ComputeAll, opts) // fails only when cancelled
+	return computed
+}
+
+// ComputeAllRecordsContext is ComputeAllRecords, failing with ctx's error if
+// ctx is done before every record is computed
+func ComputeAllRecordsContext(ctx context.Context, records []LanguageCandidate, opts ...RecordOption) ([]LanguageCandidate, error) {
+	return computeAllParallel(records, (*LanguageCandidate).ComputeAll, append(opts, withContext(ctx)))
+}
+
+// withContext bounds computing records by ctx; it is unexported so that only
+// the functions that can report cancellation take it
+func withContext(ctx context.Context) RecordOption {
+	return func(c *recordConfig) {
+		c.ctx = ctx
+	}
+}
+
+// computeAllParallel calls compute on every record, fanning chunks of records
+// out to the configured number of workers, and applies WithEmptyStrings; it
+// stops at the next chunk once the withContext context is done
+func computeAllParallel[T any](records []T, compute func(*T) *T, opts []RecordOption) ([]T, error) {
+	cfg := recordConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	workers := cfg.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (len(records) + computeChunk - 1) / computeChunk; workers > chunks {
+		workers = max(chunks, 1)
+	}
+
+	typeName := reflect.TypeFor[T]().Name()
+	_, span := startSpan(cfg.traceContext(), "erb.compute_all",
+		slog.String("erb.type", typeName), slog.Int("erb.records", len(records)), slog.Int("erb.workers", workers))
+	start := time.Now()
+	computed, err := computeChunks(records, compute, cfg, workers)
+	if err == nil {
+		loggerOr(cfg.logger).Info("records computed", "type", typeName, "records", len(computed), "workers", workers, "duration", time.Since(start))
+		if _, noop := DefaultTracer.(noopTracer); !noop {
+			span.SetAttributes(mismatchAttrs(computed)...)
+		}
+	}
+	endSpan(span, err)
+	return computed, err
+}
+
+// computeChunks computes the records on the calling goroutine (workers == 1)
+// or on a pool of workers taking computeChunk records at a time
+func computeChunks[T any](records []T, compute func(*T) *T, cfg recordConfig, workers int) ([]T, error) {
+	ctx := cfg.ctx
+	computed := make([]T, len(records))
+	if workers == 1 {
+		for i := range records {
+			if i%computeChunk == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			computed[i] = *compute(&records[i])
+			applyEmptyStrings(&computed[i], cfg.emptyStrings)
+		}
+		return computed, nil
+	}
+
+	starts := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range starts {
+				end := min(start+computeChunk, len(records))
+				for i := start; i < end; i++ {
+					computed[i] = *compute(&records[i])
+					applyEmptyStrings(&computed[i], cfg.emptyStrings)
+				}
+			}
+		}()
+	}
+	var err error
+dispatch:
+	for start := 0; start < len(records); start += computeChunk {
+		select {
+		case starts <- start:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		}
+	}
+	close(starts)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return computed, nil
+}
@@ -0,0 +1,290 @@
+// ERB SDK - Parquet Export
+// ========================
+// Writes computed records as an uncompressed Apache Parquet file: one row
+// group, one PLAIN-encoded data page per column, every column OPTIONAL.
+// Column types come from the values: booleans are BOOLEAN, integers INT64,
+// everything else a UTF8 BYTE_ARRAY. The footer is Thrift compact encoded.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// parquetExporter writes an Apache Parquet file
+type parquetExporter struct{}
+
+func (parquetExporter) Name() string         { return "parquet" }
+func (parquetExporter) Extensions() []string { return []string{".parquet"} }
+func (parquetExporter) ContentType() string  { return "application/vnd.apache.parquet" }
+
+// Parquet format constants (parquet.thrift)
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetOptional = 1
+	parquetUTF8     = 0
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn is one column chunk as written to the file
+type parquetColumn struct {
+	name       string
+	kind       int
+	pageOffset int64
+	size       int64
+}
+
+func (parquetExporter) Write(views ExportViews, w io.Writer) error {
+	keys := recordKeys(views.Records)
+	rows := len(views.Records)
+
+	var file bytes.Buffer
+	file.Write(parquetMagic)
+
+	columns := make([]parquetColumn, len(keys))
+	for i, k := range keys {
+		values := make([]any, rows)
+		for r, rec := range views.Records {
+			values[r] = rec.Values[k]
+		}
+		kind := parquetKind(values)
+		page := parquetDataPage(kind, values)
+
+		var header thriftCompact
+		header.begin()
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5)
+		header.i32(1, int32(rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.end()
+
+		columns[i] = parquetColumn{name: k, kind: kind, pageOffset: int64(file.Len()), size: int64(header.buf.Len() + len(page))}
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	var meta thriftCompact
+	meta.begin()
+	meta.i32(1, 1) // version
+	meta.listField(2, thriftStruct, len(columns)+1)
+	meta.begin() // root
+	meta.binary(4, []byte("schema"))
+	meta.i32(5, int32(len(columns)))
+	meta.end()
+	for _, c := range columns {
+		meta.begin()
+		meta.i32(1, int32(c.kind))
+		meta.i32(3, parquetOptional)
+		meta.binary(4, []byte(c.name))
+		if c.kind == parquetByteArray {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(rows))
+	meta.listField(4, thriftStruct, 1)
+	meta.begin() // row group
+	meta.listField(1, thriftStruct, len(columns))
+	var total int64
+	for _, c := range columns {
+		meta.begin() // column chunk
+		meta.i64(2, c.pageOffset)
+		meta.structField(3)
+		meta.i32(1, int32(c.kind))
+		meta.listField(2, thriftI32, 2)
+		meta.listI32(parquetPlain)
+		meta.listI32(parquetRLE)
+		meta.listField(3, thriftBinary, 1)
+		meta.listBinary([]byte(c.name))
+		meta.i32(4, 0) // UNCOMPRESSED
+		meta.i64(5, int64(rows))
+		meta.i64(6, c.size)
+		meta.i64(7, c.size)
+		meta.i64(9, c.pageOffset)
+		meta.end()
+		meta.end()
+		total += c.size
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(rows))
+	meta.end()
+	meta.binary(6, []byte("erb golang substrate"))
+	meta.end()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.Write(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetKind picks a column's physical type from its first non-nil value
+func parquetKind(values []any) int {
+	for _, v := range values {
+		switch v.(type) {
+		case nil:
+			continue
+		case bool:
+			return parquetBoolean
+		case int, int64:
+			return parquetInt64
+		}
+		return parquetByteArray
+	}
+	return parquetByteArray
+}
+
+// parquetDataPage encodes a column's definition levels (1 = present) and its
+// non-null values
+func parquetDataPage(kind int, values []any) []byte {
+	defined := make([]bool, len(values))
+	for i, v := range values {
+		defined[i] = v != nil
+	}
+	levels := rleBitPacked(defined)
+
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+	page.Write(levels)
+
+	var bits []bool
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+		case bool:
+			bits = append(bits, v)
+		case int:
+			binary.Write(&page, binary.LittleEndian, int64(v))
+		case int64:
+			binary.Write(&page, binary.LittleEndian, v)
+		default:
+			s := fmt.Sprint(v)
+			binary.Write(&page, binary.LittleEndian, uint32(len(s)))
+			page.WriteString(s)
+		}
+	}
+	if kind == parquetBoolean {
+		page.Write(packBits(bits))
+	}
+	return page.Bytes()
+}
+
+// rleBitPacked encodes 1-bit values as a single bit-packed run of the
+// RLE/bit-packing hybrid encoding
+func rleBitPacked(bits []bool) []byte {
+	if len(bits) == 0 {
+		return nil
+	}
+	groups := (len(bits) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	return append(out, packBits(bits)...)
+}
+
+// packBits packs booleans LSB first, padding the last byte with zeros
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+// =============================================================================
+// THRIFT COMPACT PROTOCOL
+// =============================================================================
+
+// Thrift compact type ids
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftCompact writes Thrift compact protocol structs; begin/end bracket
+// each struct, including list elements
+type thriftCompact struct {
+	buf  bytes.Buffer
+	last []int16 // previous field id of each open struct
+}
+
+func (t *thriftCompact) begin() { t.last = append(t.last, 0) }
+
+func (t *thriftCompact) end() {
+	t.buf.WriteByte(0) // stop
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftCompact) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes a zigzag-encoded signed varint
+func (t *thriftCompact) varint(v int64) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(v<<1)^uint64(v>>63)))
+}
+
+func (t *thriftCompact) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftCompact) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftCompact) binary(id int16, b []byte) {
+	t.field(id, thriftBinary)
+	t.listBinary(b)
+}
+
+// structField starts a struct-valued field; close it with end()
+func (t *thriftCompact) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// listField starts a list field of n elements of type elem
+func (t *thriftCompact) listField(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	t.buf.WriteByte(0xF0 | elem)
+	t.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+func (t *thriftCompact) listI32(v int32) { t.varint(int64(v)) }
+
+func (t *thriftCompact) listBinary(b []byte) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	t.buf.Write(b)
+}
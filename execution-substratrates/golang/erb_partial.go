@@ -0,0 +1,52 @@
+// ERB SDK - Partial Loads
+// =======================
+// By default one malformed record fails the whole load. Batch pipelines that
+// would rather process what they can use WithPartialLoad: the records that
+// decode are returned together with a *MultiError holding one
+// *MalformedRecordError per record that did not (with WithStrictFields, a
+// record with unknown or missing keys is skipped the same way):
+//
+//	records, err := LoadRecords("answers.json", WithPartialLoad())
+//	var skipped *MultiError
+//	if errors.As(err, &skipped) {
+//		for _, e := range skipped.Errors {
+//			log.Printf("skipped %v", e) // record 3: field has_syntax: ...
+//		}
+//	} else if err != nil {
+//		return err // unreadable file or invalid JSON
+//	}
+//
+//	take-test --skip-bad-records
+//
+// A file that is not valid JSON still fails as a whole.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithPartialLoad skips records that do not decode instead of failing the
+// load, returning the others along with a *MultiError
+func WithPartialLoad() RecordOption {
+	return func(c *recordConfig) {
+		c.partial = true
+	}
+}
+
+// MultiError collects the errors of the records a partial load skipped
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%d records skipped:\n  %s", len(e.Errors), strings.Join(lines, "\n  "))
+}
+
+// Unwrap lets errors.Is and errors.As match any of the collected errors
+func (e *MultiError) Unwrap() []error { return e.Errors }
@@ -0,0 +1,285 @@
+// ERB SDK - Patches
+// =================
+// External editors submit changes to LanguageCandidates in one of the two
+// standard JSON formats, and every record they touch is recomputed:
+//
+// An RFC 7386 merge patch maps candidate ids to the raw fields to set (null
+// clears a field); a null record deletes the candidate and a new id adds one.
+// A record may repeat its own LanguageCandidateId, but not change it:
+//
+//	{"english": {"CanBeHeld": true, "Category": null}, "falsifier-a": null}
+//
+// An RFC 6902 JSON Patch is a list of operations on "/<id>/<field>" paths
+// (field names in any casing). "/<id>" alone names a whole record: add
+// creates it, or replaces it if it exists, replace replaces an existing one
+// (raw fields the value leaves out are cleared) and remove deletes it.
+// "test" fails the whole patch with ErrPatchTestFailed if the field holds a
+// different value, so an editor can guard against concurrent edits:
+//
+//	[{"op": "test", "path": "/english/CanBeHeld", "value": false},
+//	 {"op": "replace", "path": "/english/CanBeHeld", "value": true},
+//	 {"op": "copy", "from": "/english/Category", "path": "/latin/Category"}]
+//
+// Either applies in one transaction, all or nothing, and returns every
+// field it changed, raw or calculated:
+//
+//	changes, err := store.ApplyJSONPatch(ops)
+//	changes, err := store.MergePatch(map[string]any{"english": map[string]any{"CanBeHeld": true}})
+//
+// `serve --writable` accepts both at PATCH /candidates and PATCH
+// /candidates/{id}, where paths and keys are relative to the candidate.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// ErrPatchTestFailed is returned when a JSON Patch "test" operation does not match
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// PatchOperation is one RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string `json:"op"` // add, remove, replace, move, copy or test
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"` // for move and copy
+	Value any    `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies a JSON Patch to the store's candidates and returns
+// the fields it changed
+func (s *Store) ApplyJSONPatch(ops []PatchOperation) ([]FieldChanged, error) {
+	var changes []FieldChanged
+	err := s.Transact(func(tx *Tx) error {
+		if err := tx.ApplyJSONPatch(ops); err != nil {
+			return err
+		}
+		changes = tx.changes()
+		return nil
+	})
+	return changes, err
+}
+
+// MergePatch applies a merge patch, keyed by candidate id, to the store's
+// candidates and returns the fields it changed
+func (s *Store) MergePatch(patch map[string]any) ([]FieldChanged, error) {
+	var changes []FieldChanged
+	err := s.Transact(func(tx *Tx) error {
+		if err := tx.MergePatch(patch); err != nil {
+			return err
+		}
+		changes = tx.changes()
+		return nil
+	})
+	return changes, err
+}
+
+// ApplyJSONPatch applies the operations in order; the transaction should be
+// rolled back if it fails part way
+func (tx *Tx) ApplyJSONPatch(ops []PatchOperation) error {
+	for i, op := range ops {
+		if err := tx.applyOperation(op); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) applyOperation(op PatchOperation) error {
+	id, field, err := candidatePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	if field == "" {
+		switch op.Op {
+		case "add":
+			if _, ok := tx.Candidate(id); ok {
+				return tx.replacePatchedCandidate(id, op.Value)
+			}
+			return tx.addPatchedCandidate(id, op.Value)
+		case "replace":
+			return tx.replacePatchedCandidate(id, op.Value)
+		case "remove":
+			return tx.DeleteCandidate(id)
+		}
+		return fmt.Errorf("%s needs a field path (/%s/<field>)", op.Op, id)
+	}
+
+	switch op.Op {
+	case "add", "replace":
+		return tx.UpdateCandidate(id, map[string]any{field: op.Value})
+	case "remove":
+		return tx.UpdateCandidate(id, map[string]any{field: nil})
+	case "test":
+		current, err := tx.candidateValue(id, field)
+		if err != nil {
+			return err
+		}
+		sf, _ := candidateStructField(field)
+		want, err := importValue(op.Value, sf.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sf.Name, err)
+		}
+		if !valuesEqual(current, want) {
+			return fmt.Errorf("%s is %s, not %s: %w", sf.Name, formatValue(current), formatValue(want), ErrPatchTestFailed)
+		}
+		return nil
+	case "move", "copy":
+		fromID, fromField, err := candidatePointer(op.From)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		if fromField == "" {
+			return fmt.Errorf("from needs a field path (/%s/<field>)", fromID)
+		}
+		value, err := tx.candidateValue(fromID, fromField)
+		if err != nil {
+			return err
+		}
+		if op.Op == "move" {
+			if err := tx.UpdateCandidate(fromID, map[string]any{fromField: nil}); err != nil {
+				return err
+			}
+		}
+		return tx.UpdateCandidate(id, map[string]any{field: value})
+	}
+	return fmt.Errorf("unknown op %q", op.Op)
+}
+
+// MergePatch applies a merge patch keyed by candidate id, in id order: an
+// object updates the candidate's raw fields (adding the candidate if the id
+// is new) and null deletes it
+func (tx *Tx) MergePatch(patch map[string]any) error {
+	for _, id := range sortedKeys(patch) {
+		var err error
+		switch fields := patch[id].(type) {
+		case nil:
+			err = tx.DeleteCandidate(id)
+		case map[string]any:
+			if _, ok := tx.Candidate(id); !ok {
+				err = tx.addPatchedCandidate(id, fields)
+			} else if fields, err = withoutPatchID(id, fields); err == nil {
+				err = tx.UpdateCandidate(id, fields)
+			}
+		default:
+			err = fmt.Errorf("%s: a merge patch record must be an object or null", id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPatchedCandidate adds the candidate with the id and the raw fields of
+// value, which must be an object
+func (tx *Tx) addPatchedCandidate(id string, value any) error {
+	lc, err := patchedCandidate(id, value)
+	if err != nil {
+		return err
+	}
+	return tx.AddCandidate(lc)
+}
+
+// replacePatchedCandidate replaces the candidate with the id by one with the
+// raw fields of value, which must be an object; fields it leaves out are cleared
+func (tx *Tx) replacePatchedCandidate(id string, value any) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if _, ok := tx.Candidate(id); !ok {
+		return fmt.Errorf("no LanguageCandidates record %q", id)
+	}
+	lc, err := patchedCandidate(id, value)
+	if err != nil {
+		return err
+	}
+	tx.edit(id, lc.ComputeAll())
+	return nil
+}
+
+// patchedCandidate returns the candidate with the id and the raw fields of
+// value, which must be an object
+func patchedCandidate(id string, value any) (LanguageCandidate, error) {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return LanguageCandidate{}, fmt.Errorf("%s: a record must be an object", id)
+	}
+	fields, err := withoutPatchID(id, fields)
+	if err != nil {
+		return LanguageCandidate{}, err
+	}
+	lc := LanguageCandidate{LanguageCandidateId: id}
+	for _, field := range sortedKeys(fields) {
+		if err := setCandidateField(&lc, field, fields[field]); err != nil {
+			return LanguageCandidate{}, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return lc, nil
+}
+
+// withoutPatchID returns a patch record's fields without its
+// LanguageCandidateId, which must match the id of its path
+func withoutPatchID(id string, fields map[string]any) (map[string]any, error) {
+	for field, value := range fields {
+		if sf, ok := candidateStructField(field); ok && sf.Name == "LanguageCandidateId" {
+			if value != id {
+				return nil, fmt.Errorf("%s: LanguageCandidateId %v does not match the path", id, value)
+			}
+			rest := maps.Clone(fields)
+			delete(rest, field)
+			return rest, nil
+		}
+	}
+	return fields, nil
+}
+
+// candidateValue returns a field of a candidate, as the transaction sees it
+func (tx *Tx) candidateValue(id, field string) (any, error) {
+	lc, ok := tx.Candidate(id)
+	if !ok {
+		return nil, fmt.Errorf("no LanguageCandidates record %q", id)
+	}
+	sf, ok := candidateStructField(field)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+	return recordField(&lc, sf.Name), nil
+}
+
+// candidatePointer splits a "/<id>[/<field>]" JSON Pointer, unescaping ~1 and ~0
+func candidatePointer(pointer string) (id, field string, err error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return "", "", fmt.Errorf("path %q is not a JSON Pointer", pointer)
+	}
+	parts := strings.Split(pointer[1:], "/")
+	if len(parts) > 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("path %q is not /<id> or /<id>/<field>", pointer)
+	}
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	id = unescape.Replace(parts[0])
+	if len(parts) == 2 {
+		field = unescape.Replace(parts[1])
+	}
+	return id, field, nil
+}
+
+// escapePointer escapes a JSON Pointer segment
+func escapePointer(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
+
+// candidateStructField finds the LanguageCandidate field with the name, in any casing
+func candidateStructField(name string) (reflect.StructField, bool) {
+	t := reflect.TypeFor[LanguageCandidate]()
+	for i := 0; i < t.NumField(); i++ {
+		if nameKey(t.Field(i).Name) == nameKey(name) {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,42 @@
+// ERB SDK - Patch Tests
+// =====================
+// Unit tests for merge patches and JSON Patches: a record may repeat its own
+// id, but not change it, and "/<id>" alone adds or replaces a whole record:
+//
+//	go test $(ls *.go) -run Patch
+
+package main
+
+import "testing"
+
+func TestMergePatchKeepsOwnID(t *testing.T) {
+	_, store := storeForTest(t)
+	if _, err := store.MergePatch(map[string]any{"english": map[string]any{"LanguageCandidateId": "english", "CanBeHeld": true}}); err != nil {
+		t.Fatalf("MergePatch with the record's own id: %v", err)
+	}
+	if lc, _ := store.Candidate("english"); !optGet(lc.CanBeHeld, false) {
+		t.Error("MergePatch did not set CanBeHeld")
+	}
+	if _, err := store.MergePatch(map[string]any{"english": map[string]any{"LanguageCandidateId": "latin"}}); err == nil {
+		t.Error("MergePatch changing the id succeeded, want an error")
+	}
+}
+
+func TestJSONPatchReplacesRecord(t *testing.T) {
+	_, store := storeForTest(t)
+	for _, op := range []string{"replace", "add"} {
+		name := "English " + op
+		ops := []PatchOperation{{Op: op, Path: "/english", Value: map[string]any{"Name": name, "CanBeHeld": true}}}
+		if _, err := store.ApplyJSONPatch(ops); err != nil {
+			t.Fatalf("%s /english: %v", op, err)
+		}
+		lc, _ := store.Candidate("english")
+		if optGet(lc.Name, "") != name || !optGet(lc.CanBeHeld, false) || lc.Category != nil {
+			t.Errorf("%s /english left Name %s, CanBeHeld %s, Category %s; want %q, true, null", op,
+				formatValue(recordField(&lc, "Name")), formatValue(recordField(&lc, "CanBeHeld")), formatValue(recordField(&lc, "Category")), name)
+		}
+	}
+	if _, err := store.ApplyJSONPatch([]PatchOperation{{Op: "replace", Path: "/no-such-candidate", Value: map[string]any{}}}); err == nil {
+		t.Error("replace of a missing record succeeded, want an error")
+	}
+}
@@ -0,0 +1,354 @@
+// ERB SDK - Postgres Sync
+// =======================
+// Pushes a loaded rulebook into the postgres substrate's database and pulls
+// the computed vw_* rows back, so the SQL and Go substrates can be compared
+// programmatically. The substrate has no database driver dependency; it runs
+// the psql client, the same tool postgres/init-db.sh uses:
+//
+//	sync := NewPGSync("postgresql://postgres@localhost:5432/erb")
+//	err := sync.Push(ctx, rb, PushOptions{Schema: true, Prune: true})
+//	rows, err := sync.Pull(ctx, "LanguageCandidates")
+//	diffs, err := sync.Compare(ctx, rb)
+//
+// Push runs in one transaction: optional schema (tables, calc functions,
+// views from Rulebook.SQL), then an upsert of every raw row, then (with
+// Prune) a delete of rows no longer in the rulebook. Views are recreated
+// afterwards so they reflect the current functions.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultPGConn is the postgres substrate's default database (see postgres/init-db.sh)
+const DefaultPGConn = "postgresql://postgres@localhost:5432/wikidata-language-candidates"
+
+// PGSync syncs rulebooks with a Postgres database through psql
+type PGSync struct {
+	// Conn is a libpq connection string or URL
+	Conn string
+	// PSQL is the psql executable (default "psql" on PATH)
+	PSQL string
+}
+
+// NewPGSync returns a sync for the database at conn
+func NewPGSync(conn string) *PGSync {
+	return &PGSync{Conn: conn, PSQL: "psql"}
+}
+
+// PushOptions configures Push
+type PushOptions struct {
+	Schema bool // (re)create tables and calc functions first; existing rows are dropped
+	Prune  bool // delete rows whose id is not in the rulebook
+}
+
+// PushScript returns the SQL Push runs
+func (s *PGSync) PushScript(rb *Rulebook, opts PushOptions) (string, error) {
+	scripts, err := rb.SQL()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if opts.Schema {
+		b.WriteString(scripts.Tables)
+		b.WriteString(scripts.Functions)
+	}
+	for _, t := range rb.Tables {
+		upsert, ids := pgUpsert(t)
+		b.WriteString(upsert)
+		if opts.Prune {
+			table, id := toSnakeCase(t.Name), toSnakeCase(t.IDField())
+			if len(ids) == 0 {
+				fmt.Fprintf(&b, "DELETE FROM %s;\n", table)
+			} else {
+				fmt.Fprintf(&b, "DELETE FROM %s WHERE %s NOT IN (%s);\n", table, id, strings.Join(ids, ", "))
+			}
+		}
+	}
+	b.WriteString(scripts.Views)
+	return b.String(), nil
+}
+
+// Push upserts the rulebook's raw rows and refreshes the views, in one transaction
+func (s *PGSync) Push(ctx context.Context, rb *Rulebook, opts PushOptions) error {
+	script, err := s.PushScript(rb, opts)
+	if err != nil {
+		return err
+	}
+	_, err = s.psql(ctx, script, "--single-transaction", "-f", "-")
+	return err
+}
+
+// Pull returns the rows of a table's view (vw_<table>), ordered by id, with
+// the view's column order
+func (s *PGSync) Pull(ctx context.Context, table string) ([]Record, error) {
+	view, id := "vw_"+toSnakeCase(table), ""
+	for _, t := range schemaTables {
+		if t.name == table {
+			id, _ = jsonKey(t.record.Field(0))
+		}
+	}
+	if id == "" {
+		return nil, unknownTable(table)
+	}
+
+	query := fmt.Sprintf("SELECT COALESCE(json_agg(v ORDER BY v.%s), '[]') FROM %s v", id, view)
+	out, err := s.psql(ctx, "", "-A", "-t", "-c", query)
+	if err != nil {
+		return nil, err
+	}
+	records, err := jsonImporter{}.Read(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", view, err)
+	}
+	wholeNumbersToInt(records)
+	return records, nil
+}
+
+// PGDiff is a field whose Postgres view value differs from the Go value
+type PGDiff struct {
+	Table    string `json:"table"`
+	ID       string `json:"id"`
+	Field    string `json:"field"`
+	Go       any    `json:"go"`
+	Postgres any    `json:"postgres"`
+}
+
+func (d PGDiff) String() string {
+	return fmt.Sprintf("%s %s.%s: go=%v postgres=%v", d.Table, d.ID, d.Field, pgDiffValue(d.Go), pgDiffValue(d.Postgres))
+}
+
+func pgDiffValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// Compare pulls every table's view and compares it field by field with the
+// Go-computed records; rows missing on either side are reported on the id
+// field
+func (s *PGSync) Compare(ctx context.Context, rb *Rulebook) ([]PGDiff, error) {
+	var diffs []PGDiff
+	for _, t := range schemaTables {
+		views, err := rb.TableViews(t.name, true)
+		if err != nil {
+			return nil, err
+		}
+		pulled, err := s.Pull(ctx, t.name)
+		if err != nil {
+			return nil, err
+		}
+
+		// vw_* views leave out internal calculated fields
+		skip := map[string]bool{}
+		if rt := rb.Table(t.name); rt != nil {
+			for _, f := range rt.Schema {
+				if f.IsCalculated() && f.IsInternal() {
+					skip[toSnakeCase(f.Name)] = true
+				}
+			}
+		}
+
+		id, _ := jsonKey(t.record.Field(0))
+		byID := map[any]Record{}
+		for _, rec := range pulled {
+			byID[rec.Values[id]] = rec
+		}
+		for _, rec := range views.Records {
+			key := rec.Values[id]
+			pg, ok := byID[key]
+			if !ok {
+				diffs = append(diffs, PGDiff{Table: t.name, ID: fmt.Sprint(key), Field: id, Go: key})
+				continue
+			}
+			delete(byID, key)
+			for _, k := range rec.Keys {
+				if !skip[k] && !reflect.DeepEqual(rec.Values[k], pg.Values[k]) {
+					diffs = append(diffs, PGDiff{Table: t.name, ID: fmt.Sprint(key), Field: k, Go: rec.Values[k], Postgres: pg.Values[k]})
+				}
+			}
+		}
+		for _, key := range sortedKeys(pgStringKeys(byID)) {
+			diffs = append(diffs, PGDiff{Table: t.name, ID: key, Field: id, Postgres: key})
+		}
+	}
+	return diffs, nil
+}
+
+// pgStringKeys re-keys pulled rows that have no Go counterpart by their id text
+func pgStringKeys(rows map[any]Record) map[string]bool {
+	keys := make(map[string]bool, len(rows))
+	for k := range rows {
+		keys[fmt.Sprint(k)] = true
+	}
+	return keys
+}
+
+// psql runs psql against the database, with script on stdin, and returns stdout
+func (s *PGSync) psql(ctx context.Context, script string, args ...string) ([]byte, error) {
+	bin := s.PSQL
+	if bin == "" {
+		bin = "psql"
+	}
+	cmd := subprocess(ctx, bin, append([]string{s.Conn, "-X", "-q", "-v", "ON_ERROR_STOP=1"}, args...)...)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, subprocessError(ctx, "psql", stderr.String(), err)
+	}
+	return out, nil
+}
+
+// pgUpsert returns an INSERT ... ON CONFLICT DO UPDATE of a table's raw rows
+// and the quoted ids it wrote
+func pgUpsert(t *Table) (string, []string) {
+	var columns, fields []string
+	for _, f := range t.Schema {
+		if !f.IsCalculated() {
+			columns = append(columns, toSnakeCase(f.Name))
+			fields = append(fields, f.Name)
+		}
+	}
+	if len(t.Data) == 0 || len(columns) == 0 {
+		return "", nil
+	}
+
+	var rows, ids []string
+	for _, row := range t.Data {
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = pgLiteral(row[f])
+		}
+		rows = append(rows, "  ("+strings.Join(values, ", ")+")")
+		ids = append(ids, values[0])
+	}
+	var updates []string
+	for _, c := range columns[1:] {
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+	}
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES\n%s\nON CONFLICT (%s) %s;\n",
+		toSnakeCase(t.Name), strings.Join(columns, ", "), strings.Join(rows, ",\n"), columns[0], action), ids
+}
+
+// pgLiteral renders a rulebook value as a SQL literal
+func pgLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return sqlString(v)
+	}
+	return sqlString(fmt.Sprint(v))
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runPGSync implements `pgsync push|pull|compare [--conn URL] [--rulebook PATH] ...`
+func runPGSync(args []string) error {
+	const usage = "usage: pgsync push|pull|compare [flags]"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("pgsync "+action, flag.ContinueOnError)
+	conn := fs.String("conn", "", "Postgres connection string (default: $DATABASE_URL, else "+DefaultPGConn+")")
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	psql := fs.String("psql", "psql", "psql executable")
+	schema := fs.Bool("schema", false, "push: recreate the tables and calc functions first")
+	prune := fs.Bool("prune", false, "push: delete rows that are not in the rulebook")
+	dryRun := fs.Bool("dry-run", false, "push: print the SQL instead of running it")
+	table := fs.String("table", "LanguageCandidates", "pull: table whose view to pull")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+
+	sync := NewPGSync(*conn)
+	if sync.Conn == "" {
+		sync.Conn = os.Getenv("DATABASE_URL")
+	}
+	if sync.Conn == "" {
+		sync.Conn = DefaultPGConn
+	}
+	sync.PSQL = *psql
+	ctx := commandContext()
+
+	switch action {
+	case "push", "compare":
+		rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+		if err != nil {
+			return err
+		}
+		printWarnings(rb)
+
+		if action == "compare" {
+			diffs, err := sync.Compare(ctx, rb)
+			if err != nil {
+				return err
+			}
+			for _, d := range diffs {
+				fmt.Println(d)
+			}
+			if len(diffs) > 0 {
+				return fmt.Errorf("%d values differ between the Go and Postgres substrates", len(diffs))
+			}
+			fmt.Println("Go and Postgres substrates agree")
+			return nil
+		}
+
+		opts := PushOptions{Schema: *schema, Prune: *prune}
+		if *dryRun {
+			script, err := sync.PushScript(rb, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		}
+		if err := sync.Push(ctx, rb, opts); err != nil {
+			return err
+		}
+		fmt.Printf("Pushed %d tables to %s\n", len(rb.Tables), sync.Conn)
+		return nil
+
+	case "pull":
+		records, err := sync.Pull(ctx, *table)
+		if err != nil {
+			return err
+		}
+		return jsonExporter{}.Write(ExportViews{Table: *table, Records: records}, os.Stdout)
+	}
+	return fmt.Errorf(usage)
+}
@@ -0,0 +1,609 @@
+// ERB SDK - Record Pipelines
+// ==========================
+// A pipeline file (YAML or JSON) lists the steps that turn source records
+// into answers, so a flow is declared once instead of hand-written per
+// directory:
+//
+//	name: conformance
+//	table: LanguageCandidates
+//	cache: .erb-cache   # optional; see erb_pipeline_cache.go
+//	steps:
+//	  - import: ../../testing/blank-test.json
+//	  - normalize
+//	  - overlay: scenarios/no-syntax.yaml
+//	  - compute
+//	  - validate
+//	  - export: test-answers.json
+//	  - export: summary.md
+//	    format: md
+//
+// Each step reads the records of its input step and produces a record set:
+//
+//	import PATH     read records with the importer for format (default: by extension)
+//	normalize       convert keys and values to the table's fields (ImportRecords)
+//	overlay PATH    merge a scenario file's fields into the records with the same id
+//	compute         compute the calculated fields
+//	validate        fail on unknown, missing, mistyped, or null required fields
+//	export PATH     write the records with the exporter for format (default: by extension)
+//
+// A step's input is the step before it, except that an export passes on its
+// own input, so consecutive exports share one. A step can instead name any
+// earlier step with input: ID, given id: ID on that step. Steps whose inputs
+// are ready run concurrently (up to Jobs at a time), so both exports below
+// start as soon as compute finishes; validate still gates the export after it:
+//
+//	  - compute:
+//	    id: computed
+//	  - export: answers.csv
+//	  - export: answers.ttl
+//	  - validate:
+//	    input: computed
+//	  - export: test-answers.json
+//
+// Relative paths are resolved against the pipeline file's directory.
+// Import and overlay files ending in .age are decrypted with the age key file
+// given as identity: (or `pipeline run --identity`; see erb_age.go).
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pipelineSteps are the step kinds a pipeline may use
+var pipelineSteps = []string{"import", "normalize", "overlay", "compute", "validate", "export"}
+
+// Pipeline is a parsed pipeline file
+type Pipeline struct {
+	Name  string
+	Table string // default table of every step
+	Steps []PipelineStep
+
+	// CacheDir holds cached step outputs; empty disables caching
+	CacheDir string
+
+	// Jobs limits how many steps run at once; 0 means no limit
+	Jobs int
+
+	// Identity is the age key file that decrypts .age import and overlay files
+	Identity string
+
+	dir string // base directory of relative paths
+}
+
+// PipelineStep is one step of a pipeline
+type PipelineStep struct {
+	Kind   string
+	Path   string // import, overlay, export
+	Format string // import, overlay, export; default: by the path's extension
+	Table  string // overrides the pipeline's table
+	ID     string // names the step for input
+	Input  string // ID of the step whose records this step reads
+
+	input int // index of the input step; -1 for import
+}
+
+func (s PipelineStep) String() string {
+	if s.Path == "" {
+		return s.Kind
+	}
+	return s.Kind + " " + s.Path
+}
+
+// LoadPipeline reads a pipeline file; .json files are JSON, anything else YAML
+func LoadPipeline(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+		}
+	}
+	p, err := ParsePipeline(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.dir = filepath.Dir(path)
+	if p.CacheDir != "" {
+		p.CacheDir = p.path(p.CacheDir)
+	}
+	if p.Identity != "" {
+		p.Identity = p.path(p.Identity)
+	}
+	return p, nil
+}
+
+// ParsePipeline parses pipeline JSON. A step is either its kind ("compute")
+// or an object whose step key holds the path ({"import": "blank-test.json"})
+// next to optional format, table, id, and input keys.
+func ParsePipeline(data []byte) (*Pipeline, error) {
+	var spec struct {
+		Name     string            `json:"name"`
+		Table    string            `json:"table"`
+		Cache    string            `json:"cache"`
+		Identity string            `json:"identity"`
+		Steps    []json.RawMessage `json:"steps"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+	if len(spec.Steps) == 0 {
+		return nil, fmt.Errorf("pipeline has no steps")
+	}
+
+	p := &Pipeline{Name: spec.Name, Table: spec.Table, CacheDir: spec.Cache, Identity: spec.Identity}
+	if p.Table == "" {
+		p.Table = "LanguageCandidates"
+	}
+	for i, raw := range spec.Steps {
+		step, err := parsePipelineStep(raw)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	if err := p.resolveInputs(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// resolveInputs links every step to the earlier step it reads
+func (p *Pipeline) resolveInputs() error {
+	ids := map[string]int{}
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		switch {
+		case step.Kind == "import":
+			if step.Input != "" {
+				return fmt.Errorf("step %d: import takes no input", i+1)
+			}
+			step.input = -1
+		case step.Input != "":
+			in, ok := ids[step.Input]
+			if !ok {
+				return fmt.Errorf("step %d: input %q is not the id of an earlier step", i+1, step.Input)
+			}
+			step.input = in
+		case i == 0:
+			return fmt.Errorf("step 1 (%s): no records yet; start with an import step", step)
+		case p.Steps[i-1].Kind == "export":
+			step.input = p.Steps[i-1].input
+		default:
+			step.input = i - 1
+		}
+
+		if step.ID != "" {
+			if _, dup := ids[step.ID]; dup {
+				return fmt.Errorf("step %d: duplicate id %q", i+1, step.ID)
+			}
+			ids[step.ID] = i
+		}
+	}
+	return nil
+}
+
+func parsePipelineStep(raw json.RawMessage) (PipelineStep, error) {
+	var step PipelineStep
+	if json.Unmarshal(raw, &step.Kind) != nil {
+		var fields map[string]any
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return step, fmt.Errorf("want a step name or an object")
+		}
+		for _, k := range sortedKeys(fields) {
+			v := fields[k]
+			switch {
+			case k == "format" || k == "table" || k == "id" || k == "input":
+				s, ok := v.(string)
+				if !ok {
+					return step, fmt.Errorf("%s must be a string", k)
+				}
+				switch k {
+				case "format":
+					step.Format = s
+				case "table":
+					step.Table = s
+				case "id":
+					step.ID = s
+				case "input":
+					step.Input = s
+				}
+			case isPipelineStep(k):
+				if step.Kind != "" {
+					return step, fmt.Errorf("both %s and %s in one step", step.Kind, k)
+				}
+				step.Kind = k
+				switch v := v.(type) {
+				case string:
+					step.Path = v
+				case nil, bool:
+				default:
+					return step, fmt.Errorf("%s must be a path", k)
+				}
+			default:
+				return step, fmt.Errorf("unknown key %q", k)
+			}
+		}
+	}
+
+	switch {
+	case step.Kind == "":
+		return step, fmt.Errorf("no step kind (want one of %s)", strings.Join(pipelineSteps, ", "))
+	case !isPipelineStep(step.Kind):
+		return step, fmt.Errorf("unknown step %q (want one of %s)", step.Kind, strings.Join(pipelineSteps, ", "))
+	case step.Path == "" && (step.Kind == "import" || step.Kind == "overlay" || step.Kind == "export"):
+		return step, fmt.Errorf("%s needs a path", step.Kind)
+	}
+	return step, nil
+}
+
+func isPipelineStep(kind string) bool {
+	for _, k := range pipelineSteps {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// RUNNING
+// =============================================================================
+
+// Run executes the steps, each as soon as its input step has finished, and
+// logs one line per step to log as it completes. With a CacheDir, steps whose
+// inputs are unchanged since an earlier run reuse its results (see
+// erb_pipeline_cache.go); pipelines reading .age files are not cached, so
+// decrypted records never reach the disk. The first failing step's error is
+// returned; steps downstream of it do not run.
+func (p *Pipeline) Run(log io.Writer) error {
+	var cache *pipelineCache
+	if p.CacheDir != "" && !p.readsEncrypted() {
+		cache = &pipelineCache{dir: p.CacheDir}
+	}
+	jobs := p.Jobs
+	if jobs <= 0 {
+		jobs = len(p.Steps)
+	}
+	sem := make(chan struct{}, jobs)
+
+	type result struct {
+		key     string
+		records []Record
+		err     error
+		done    chan struct{}
+	}
+	results := make([]*result, len(p.Steps))
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+
+	width := 0
+	for i := range p.Steps {
+		width = max(width, len(p.stepLabel(i)))
+	}
+	var logMu sync.Mutex
+	for i, step := range p.Steps {
+		go func() {
+			res := results[i]
+			defer close(res.done)
+
+			var in result
+			if step.input >= 0 {
+				<-results[step.input].done
+				in = *results[step.input]
+				if in.err != nil {
+					res.err = errPipelineSkipped
+					return
+				}
+			}
+			table := step.Table
+			if table == "" {
+				table = p.Table
+			}
+
+			sem <- struct{}{}
+			start := time.Now()
+			var cached bool
+			res.key, res.records, cached, res.err = p.runStep(cache, in.key, in.records, step, table)
+			<-sem
+			if res.err != nil {
+				res.err = fmt.Errorf("step %d (%s): %w", i+1, step, res.err)
+				return
+			}
+
+			note := ""
+			if cached {
+				note = ", cached"
+			}
+			logMu.Lock()
+			fmt.Fprintf(log, "%s: %-*s %-9s %d %s records (%s%s)\n", p.label(), width, p.stepLabel(i), step.Kind, len(res.records), table, time.Since(start).Round(time.Millisecond), note)
+			logMu.Unlock()
+		}()
+	}
+
+	for _, res := range results {
+		<-res.done
+	}
+	for _, res := range results {
+		if res.err != nil && res.err != errPipelineSkipped {
+			return res.err
+		}
+	}
+	return nil
+}
+
+// errPipelineSkipped marks a step that did not run because its input failed
+var errPipelineSkipped = errors.New("input step failed")
+
+// stepLabel names a step in the log: its id, else its position
+func (p *Pipeline) stepLabel(i int) string {
+	if id := p.Steps[i].ID; id != "" {
+		return id
+	}
+	return "#" + strconv.Itoa(i+1)
+}
+
+// runStep runs one step on its input's records, or reuses the cached result
+// when nothing upstream changed; it returns the step's key and records
+// (validate and export pass their input through)
+func (p *Pipeline) runStep(cache *pipelineCache, inKey string, in []Record, step PipelineStep, table string) (key string, out []Record, cached bool, err error) {
+	if key, err = p.stepKey(inKey, step, table); err != nil {
+		return "", nil, false, err
+	}
+
+	switch step.Kind {
+	case "validate":
+		if cache.done(key, "ok") {
+			return key, in, true, nil
+		}
+		if err := validateRecords(table, in); err != nil {
+			return "", nil, false, err
+		}
+		return key, in, false, cache.markDone(key, "ok")
+
+	case "export":
+		path := p.path(step.Path)
+		if hash := fileHash(path); hash != "" && cache.done(key, hash) {
+			return key, in, true, nil
+		}
+		if err := p.export(table, in, step); err != nil {
+			return "", nil, false, err
+		}
+		return key, in, false, cache.markDone(key, fileHash(path))
+	}
+
+	if out, ok := cache.load(key); ok {
+		return key, out, true, nil
+	}
+	switch step.Kind {
+	case "import":
+		out, err = readRecordFile(p.path(step.Path), step.Format, p.Identity)
+	case "normalize":
+		out, err = ImportRecords(table, in)
+	case "overlay":
+		out = append([]Record(nil), in...)
+		err = p.overlay(table, out, step)
+	case "compute":
+		out, err = computeTable(table, in)
+	}
+	if err != nil {
+		return "", nil, false, err
+	}
+	return key, out, false, cache.store(key, out)
+}
+
+// readsEncrypted reports whether an import or overlay step reads an .age file
+func (p *Pipeline) readsEncrypted() bool {
+	for _, step := range p.Steps {
+		if (step.Kind == "import" || step.Kind == "overlay") && IsEncryptedPath(step.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Pipeline) label() string {
+	if p.Name == "" {
+		return "pipeline"
+	}
+	return "pipeline " + p.Name
+}
+
+// path resolves a step path against the pipeline file's directory
+func (p *Pipeline) path(path string) string {
+	return resolvePath(p.dir, path)
+}
+
+// readRecordFile reads a record file with the named importer, else the one
+// for its extension; .yaml/.yml files are read as a JSON array, and .age
+// files are decrypted with identity first (the extension before .age counts)
+func readRecordFile(path, format, identity string) ([]Record, error) {
+	data, err := ReadFileDecrypted(path, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	imp, ok := LookupImporter(format)
+	if format == "" {
+		plain := PlainPath(path)
+		if ext := strings.ToLower(filepath.Ext(plain)); ext == ".yaml" || ext == ".yml" {
+			if data, err = yamlToJSON(data); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			imp, ok = jsonImporter{}, true
+		} else {
+			imp, ok = ImporterForPath(plain)
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("no importer for format %q or file %q", format, path)
+	}
+	return imp.Read(bytes.NewReader(data))
+}
+
+// overlay merges a scenario file into the records (see OverlayRecords)
+func (p *Pipeline) overlay(table string, records []Record, step PipelineStep) error {
+	raw, err := readRecordFile(p.path(step.Path), step.Format, p.Identity)
+	if err != nil {
+		return err
+	}
+	return OverlayRecords(table, records, raw)
+}
+
+// OverlayRecords merges, in place, each scenario record's fields into the
+// record with the same id; empty scenario cells clear the field. Scenario
+// records are validated with ImportRecords.
+func OverlayRecords(table string, records []Record, scenario []Record) error {
+	changes, err := ImportRecords(table, scenario)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 || len(changes) == 0 {
+		return nil
+	}
+
+	idKey := changes[0].Keys[0]
+	byID := make(map[any]int, len(records))
+	for i, rec := range records {
+		byID[rec.Values[idKey]] = i
+	}
+	for _, change := range changes {
+		i, ok := byID[change.Values[idKey]]
+		if !ok {
+			return fmt.Errorf("no %s record with %s %v", table, idKey, change.Values[idKey])
+		}
+		rec := records[i]
+		values := make(map[string]any, len(rec.Values))
+		for k, v := range rec.Values {
+			values[k] = v
+		}
+		for _, k := range change.Keys {
+			if v, ok := change.Values[k]; ok {
+				if _, known := values[k]; !known {
+					rec.Keys = append(rec.Keys, k)
+				}
+				values[k] = v
+			}
+		}
+		records[i] = Record{Keys: rec.Keys, Values: values}
+	}
+	return nil
+}
+
+func (p *Pipeline) export(table string, records []Record, step PipelineStep) error {
+	format := step.Format
+	if format == "" {
+		e, ok := ExporterForPath(step.Path)
+		if !ok {
+			return fmt.Errorf("no exporter for %q (use format)", step.Path)
+		}
+		format = e.Name()
+	}
+	return WriteOutput(OutputTarget{Format: format, Path: p.path(step.Path)}, table, records)
+}
+
+// computeTable computes the calculated fields of a table's records
+func computeTable(table string, records []Record) ([]Record, error) {
+	for _, t := range RunnerTables {
+		if t.Table != table {
+			continue
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return nil, err
+		}
+		return t.compute(data, nil)
+	}
+	return nil, fmt.Errorf("table %q has no calculated fields", table)
+}
+
+// validateRecords checks every record has exactly the table's fields, with
+// values of the right types
+func validateRecords(table string, records []Record) error {
+	for _, t := range schemaTables {
+		if t.name != table {
+			continue
+		}
+		data, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		reports, err := checkRecordFields(t.record, data)
+		if err != nil {
+			return err
+		}
+		if len(reports) > 0 {
+			return &FieldError{Reports: reports}
+		}
+		if err := json.Unmarshal(data, reflect.New(reflect.SliceOf(t.record)).Interface()); err != nil {
+			return err
+		}
+		_, err = ImportRecords(table, records) // required fields
+		return err
+	}
+	return unknownTable(table)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runPipeline implements `pipeline run [--no-cache] [--jobs N] [--identity KEY] FILE...`: runs each
+// pipeline file in turn, caching step outputs in the file's cache directory
+// (default .erb-cache next to it)
+func runPipeline(args []string) error {
+	const usage = "usage: pipeline run [--no-cache] [--jobs N] [--identity KEY] FILE..."
+	if len(args) == 0 || args[0] != "run" {
+		return fmt.Errorf(usage)
+	}
+	fs := flag.NewFlagSet("pipeline run", flag.ContinueOnError)
+	noCache := fs.Bool("no-cache", false, "run every step, ignoring and not writing the cache")
+	jobs := fs.Int("jobs", 0, "maximum steps to run at once (0: no limit)")
+	identity := fs.String("identity", "", "age key file for .age inputs, overriding the pipeline's identity (default: $ERB_AGE_IDENTITY)")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	if fs.NArg() == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	for _, path := range fs.Args() {
+		p, err := LoadPipeline(path)
+		if err != nil {
+			return err
+		}
+		p.Jobs = *jobs
+		if *identity != "" || p.Identity == "" {
+			p.Identity = AgeIdentity(*identity)
+		}
+		switch {
+		case *noCache:
+			p.CacheDir = ""
+		case p.CacheDir == "":
+			p.CacheDir = p.path(DefaultPipelineCache)
+		}
+		if err := p.Run(os.Stdout); err != nil {
+			return fmt.Errorf("%s: %w", p.label(), err)
+		}
+	}
+	return nil
+}
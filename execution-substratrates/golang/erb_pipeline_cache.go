@@ -0,0 +1,136 @@
+// ERB SDK - Pipeline Cache
+// ========================
+// Pipelines cache each step's output like a tiny build system. A step's key
+// hashes the previous step's key, the step itself, and everything else it
+// reads: the contents of import/overlay files and, for compute, the table's
+// formulas. Changing any input changes every downstream key, so only the
+// steps after the change run again:
+//
+//	<cache>/<key>.json   records produced by import, normalize, overlay, compute
+//	<cache>/<key>.done   validate passed / export wrote a file with this hash
+//
+// Entries are never updated in place; delete the directory to reclaim space.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPipelineCache is the cache directory `pipeline run` uses, relative
+// to the pipeline file
+const DefaultPipelineCache = ".erb-cache"
+
+// pipelineCache stores step outputs by key; a nil cache stores nothing
+type pipelineCache struct {
+	dir string
+}
+
+// stepKey hashes a step with its upstream key and the contents of its inputs
+func (p *Pipeline) stepKey(prev string, step PipelineStep, table string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00", prev, step.Kind, table, step.Path, step.Format)
+	switch step.Kind {
+	case "import", "overlay":
+		data, err := os.ReadFile(p.path(step.Path))
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+	case "compute":
+		for _, t := range schemaTables {
+			if t.name == table {
+				for _, field := range sortedKeys(t.formulas) {
+					fmt.Fprintf(h, "%s=%s\x00", field, t.formulas[field])
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// load returns the records cached under key
+func (c *pipelineCache) load(key string) ([]Record, bool) {
+	if c == nil {
+		return nil, false
+	}
+	f, err := os.Open(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	records, err := jsonImporter{}.Read(f)
+	if err != nil {
+		return nil, false
+	}
+	wholeNumbersToInt(records)
+	return records, true
+}
+
+// store caches records under key
+func (c *pipelineCache) store(key string, records []Record) error {
+	if c == nil {
+		return nil
+	}
+	if records == nil {
+		records = []Record{}
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return c.write(key+".json", data)
+}
+
+// done reports whether key was marked with the given contents
+func (c *pipelineCache) done(key, contents string) bool {
+	if c == nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".done"))
+	return err == nil && string(data) == contents
+}
+
+// markDone records that the step with key finished, with contents to check later
+func (c *pipelineCache) markDone(key, contents string) error {
+	if c == nil {
+		return nil
+	}
+	return c.write(key+".done", []byte(contents))
+}
+
+// write creates a cache file atomically, so an interrupted run never leaves
+// a truncated entry behind
+func (c *pipelineCache) write(name string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, name))
+}
+
+// fileHash returns the hex SHA-256 of a file, or "" if it cannot be read
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return sha256Hex(data)
+}
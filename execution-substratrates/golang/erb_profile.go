@@ -0,0 +1,187 @@
+// ERB SDK - Compute Profiler
+// ==========================
+// The generated ComputeAll costs the same for every record, but the formula
+// interpreter (Table.Compute) walks each field's parsed formula, so its cost
+// depends on the formulas and on the data. ComputeProfile computes a table
+// with every field's evaluations timed, and reports the hottest first:
+//
+//	records, profile, err := table.ComputeProfile()
+//	for _, f := range profile.Hot(3) {
+//		fmt.Println(f) // FamilyFeudMismatch (level 3): 412µs, 16.5µs/record, 38.2%
+//	}
+//
+// `profile` prints the same for every table of a rulebook, repeating the
+// batch to smooth out small tables:
+//
+//	profile --table LanguageCandidates --repeat 100 --top 5
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// FieldProfile is the time spent evaluating one calculated field over a batch
+type FieldProfile struct {
+	Field     string        `json:"field"`
+	Level     int           `json:"level"`
+	Evals     int           `json:"evals"`
+	Total     time.Duration `json:"total_ns"`
+	PerRecord time.Duration `json:"per_record_ns"`
+	Share     float64       `json:"share"` // fraction of the time spent on all calculated fields
+}
+
+func (f FieldProfile) String() string {
+	return fmt.Sprintf("%s (level %d): %v, %v/record, %.1f%%", f.Field, f.Level, f.Total, f.PerRecord, f.Share*100)
+}
+
+// ComputeProfile is where the time of computing a table went
+type ComputeProfile struct {
+	Table   string         `json:"table"`
+	Records int            `json:"records"`
+	Total   time.Duration  `json:"total_ns"` // the whole batch, including reading raw fields and building records
+	Fields  []FieldProfile `json:"fields"`   // hottest first
+}
+
+// Hot returns the n hottest fields (all of them if n <= 0)
+func (p *ComputeProfile) Hot(n int) []FieldProfile {
+	if n <= 0 || n > len(p.Fields) {
+		return p.Fields
+	}
+	return p.Fields[:n]
+}
+
+func (p *ComputeProfile) String() string {
+	return fmt.Sprintf("%s: %d records in %v", p.Table, p.Records, p.Total)
+}
+
+// ComputeProfile is Compute, timing the evaluation of every calculated field
+func (t *Table) ComputeProfile() ([]Record, *ComputeProfile, error) {
+	profile := &ComputeProfile{Table: t.Name}
+	records, err := t.profile(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile.finish()
+	return records, profile, nil
+}
+
+// profile computes the table once, adding its field times and record count
+// to p (call p.finish once every batch is in)
+func (t *Table) profile(p *ComputeProfile) ([]Record, error) {
+	c, err := t.compiler()
+	if err != nil {
+		return nil, err
+	}
+	c.fieldTimes = map[string]time.Duration{}
+	records, took, err := c.computeRows()
+	if err != nil {
+		return nil, err
+	}
+	if p.Fields == nil {
+		for _, f := range c.order {
+			p.Fields = append(p.Fields, FieldProfile{Field: f.Name, Level: c.levels[f.Name]})
+		}
+	}
+	for i := range p.Fields {
+		p.Fields[i].Evals += len(records)
+		p.Fields[i].Total += c.fieldTimes[p.Fields[i].Field]
+	}
+	p.Records += len(records)
+	p.Total += took
+	return records, nil
+}
+
+// finish fills in the per-record times and shares and sorts the hottest first
+func (p *ComputeProfile) finish() {
+	var fields time.Duration
+	for _, f := range p.Fields {
+		fields += f.Total
+	}
+	for i := range p.Fields {
+		f := &p.Fields[i]
+		if f.Evals > 0 {
+			f.PerRecord = f.Total / time.Duration(f.Evals)
+		}
+		if fields > 0 {
+			f.Share = float64(f.Total) / float64(fields)
+		}
+	}
+	sort.SliceStable(p.Fields, func(i, j int) bool { return p.Fields[i].Total > p.Fields[j].Total })
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runProfile implements `profile [--rulebook PATH] [--table NAME] [--repeat N]
+// [--top N] [--json]`
+func runProfile(args []string) error {
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	table := fs.String("table", "", "profile only this table (default: every table with calculated fields)")
+	repeat := fs.Int("repeat", 1, "compute each table's rows this many times")
+	top := fs.Int("top", 0, "list only the N hottest fields of each table (default: all)")
+	asJSON := fs.Bool("json", false, "print the profiles as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || *repeat < 1 {
+		return fmt.Errorf("usage: profile [--rulebook PATH] [--table NAME] [--repeat N] [--top N] [--json]")
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var tables []*Table
+	if *table != "" {
+		t := rb.Table(*table)
+		if t == nil {
+			return unknownTable(*table)
+		}
+		tables = append(tables, t)
+	} else {
+		tables = rb.Tables
+	}
+
+	var profiles []*ComputeProfile
+	for _, t := range tables {
+		if !slices.ContainsFunc(t.Schema, Field.IsCalculated) {
+			continue
+		}
+		p := &ComputeProfile{Table: t.Name}
+		for i := 0; i < *repeat; i++ {
+			if err := commandContext().Err(); err != nil {
+				return err
+			}
+			if _, err := t.profile(p); err != nil {
+				return err
+			}
+		}
+		p.finish()
+		p.Fields = p.Hot(*top)
+		profiles = append(profiles, p)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profiles)
+	}
+	for _, p := range profiles {
+		fmt.Println(p)
+		for _, f := range p.Fields {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+	return nil
+}
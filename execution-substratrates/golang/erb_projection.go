@@ -0,0 +1,85 @@
+// ERB SDK - Field Projection
+// ==========================
+// A view has every raw and calculated field, more than most consumers want.
+// A projection keeps only the requested fields, in the requested order, so
+// API responses and exports carry just those columns. Field names are
+// matched in any casing:
+//
+//	rec, err := candidate.ToViewFields("name", "has_grammar", "family_feud_mismatch")
+//	data, _ := json.Marshal(rec) // {"name":"English","has_grammar":true,"family_feud_mismatch":null}
+//
+//	views, err := rb.TableViews("LanguageCandidates", false)
+//	views, err = views.Select("Name", "TopFamilyFeudAnswer")
+//
+// The server takes ?fields=name,has_grammar on /candidates, /arguments and
+// /candidates/{id}/view, and `export` takes --fields.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToViewFields returns the record's view (see ToView) with only the named
+// fields, in the order given
+func (tc *LanguageCandidate) ToViewFields(fields ...string) (Record, error) {
+	view := tc.ToView()
+	return RecordsOf([]LanguageCandidateView{view})[0].Select(fields...)
+}
+
+// Select returns a copy of the record with only the named keys, in the
+// order given; names match keys in any casing, and an unknown name fails
+func (r Record) Select(fields ...string) (Record, error) {
+	out := Record{Values: map[string]any{}}
+	for _, field := range fields {
+		key, ok := r.keyFor(field)
+		if !ok {
+			return Record{}, fmt.Errorf("unknown field %q", field)
+		}
+		if _, dup := out.Values[key]; dup {
+			continue
+		}
+		out.Keys = append(out.Keys, key)
+		out.Values[key] = r.Values[key]
+	}
+	return out, nil
+}
+
+// keyFor returns the record's key for a field name in any casing
+func (r Record) keyFor(field string) (string, bool) {
+	if _, ok := r.Values[field]; ok {
+		return field, true
+	}
+	for _, k := range r.Keys {
+		if nameKey(k) == nameKey(field) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// Select returns the views with only the named fields of every record
+func (v ExportViews) Select(fields ...string) (ExportViews, error) {
+	records := make([]Record, len(v.Records))
+	for i, rec := range v.Records {
+		selected, err := rec.Select(fields...)
+		if err != nil {
+			return ExportViews{}, fmt.Errorf("%s: %w", v.Table, err)
+		}
+		records[i] = selected
+	}
+	v.Records = records
+	return v, nil
+}
+
+// fieldList splits a comma-separated list of field names, dropping blanks
+func fieldList(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,178 @@
+// ERB SDK - Calculated Field Properties
+// =====================================
+// Property tests for the generated LanguageCandidate calculations: random
+// raw inputs (every field independently nil or set) must satisfy invariants
+// that follow from the rulebook formulas, and every computed value must agree
+// with the runtime formula evaluator.
+//
+//	go test $(ls *.go) -run Properties
+//	go test $(ls *.go) -run '^$' -fuzz FuzzComputeAll -fuzztime 30s
+
+package main
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// propertyRuns is the number of random candidates TestComputeAllProperties checks
+const propertyRuns = 5000
+
+func TestComputeAllProperties(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < propertyRuns; i++ {
+		in := randomCandidate(func(n int) int { return r.IntN(n) }, randomWords[r.IntN(len(randomWords))])
+		checkCandidateProperties(t, in)
+		if t.Failed() {
+			t.Fatalf("run %d failed for input %s", i, describeCandidate(in))
+		}
+	}
+}
+
+// FuzzComputeAll derives a candidate from the fuzzer's inputs: choice picks,
+// field by field, nil or a value, and name is used for every text field
+func FuzzComputeAll(f *testing.F) {
+	f.Add(uint64(0), "")
+	f.Add(uint64(0xffffffffffffffff), "Music")
+	f.Add(uint64(0x5555555555555555), "A Coffee Mug")
+	f.Fuzz(func(t *testing.T, choice uint64, name string) {
+		next := func(n int) int {
+			v := int(choice % uint64(n))
+			choice = choice/uint64(n) ^ choice<<7
+			return v
+		}
+		in := randomCandidate(next, name)
+		checkCandidateProperties(t, in)
+		if t.Failed() {
+			t.Logf("input %s", describeCandidate(in))
+		}
+	})
+}
+
+// checkCandidateProperties computes in and checks every invariant
+func checkCandidateProperties(t *testing.T, in LanguageCandidate) {
+	t.Helper()
+	out := in.ComputeAll()
+	b := func(p *bool) bool { return optGet(p, false) }
+	s := func(p *string) string { return optGet(p, "") }
+
+	// Raw fields pass through unchanged
+	for _, name := range rawCandidateFields() {
+		if !reflect.DeepEqual(recordField(&in, name), recordField(out, name)) {
+			t.Errorf("raw field %s changed: %v -> %v", name, recordField(&in, name), recordField(out, name))
+		}
+	}
+
+	// TopFamilyFeudAnswer is an AND that includes IsDescriptionOf, HasSyntax
+	// and NOT(CanBeHeld)
+	if b(out.TopFamilyFeudAnswer) {
+		if !b(out.IsDescriptionOf) {
+			t.Errorf("TopFamilyFeudAnswer without IsDescriptionOf")
+		}
+		if !b(out.HasSyntax) || b(out.CanBeHeld) {
+			t.Errorf("TopFamilyFeudAnswer with HasSyntax=%v CanBeHeld=%v", b(out.HasSyntax), b(out.CanBeHeld))
+		}
+	}
+
+	// FamilyFeudMismatch is empty exactly when the Family Feud answer agrees
+	// with the curation and there is no open/closed world conflict (the
+	// conflict suffix is appended even when the answers agree)
+	agree := b(out.TopFamilyFeudAnswer) == b(out.ChosenLanguageCandidate)
+	if empty := s(out.FamilyFeudMismatch) == ""; empty != (agree && !b(out.IsOpenClosedWorldConflicted)) {
+		t.Errorf("FamilyFeudMismatch = %q with TopFamilyFeudAnswer=%v ChosenLanguageCandidate=%v IsOpenClosedWorldConflicted=%v",
+			s(out.FamilyFeudMismatch), b(out.TopFamilyFeudAnswer), b(out.ChosenLanguageCandidate), b(out.IsOpenClosedWorldConflicted))
+	}
+	if !agree && !strings.HasPrefix(s(out.FamilyFeudMismatch), s(out.Name)+" ") {
+		t.Errorf("FamilyFeudMismatch %q does not start with the name %q", s(out.FamilyFeudMismatch), s(out.Name))
+	}
+
+	if b(out.HasGrammar) != b(out.HasSyntax) {
+		t.Errorf("HasGrammar=%v but HasSyntax=%v", b(out.HasGrammar), b(out.HasSyntax))
+	}
+	if b(out.IsOpenClosedWorldConflicted) != (b(out.IsOpenWorld) && b(out.IsClosedWorld)) {
+		t.Errorf("IsOpenClosedWorldConflicted=%v with IsOpenWorld=%v IsClosedWorld=%v", b(out.IsOpenClosedWorldConflicted), b(out.IsOpenWorld), b(out.IsClosedWorld))
+	}
+	if out.DistanceFromConcept == nil && (b(out.IsDescriptionOf) || s(out.RelationshipToConcept) != "IsDescriptionOf") {
+		t.Errorf("nil DistanceFromConcept gave IsDescriptionOf=%v RelationshipToConcept=%q", b(out.IsDescriptionOf), s(out.RelationshipToConcept))
+	}
+	if b(out.IsDescriptionOf) && s(out.RelationshipToConcept) != "IsDescriptionOf" {
+		t.Errorf("IsDescriptionOf with RelationshipToConcept=%q", s(out.RelationshipToConcept))
+	}
+	if q := s(out.FamilyFuedQuestion); q != "Is "+s(out.Name)+" a language?" {
+		t.Errorf("FamilyFuedQuestion = %q", q)
+	}
+
+	// Computing again changes nothing
+	if again := out.ComputeAll(); !reflect.DeepEqual(again, out) {
+		t.Errorf("ComputeAll is not idempotent: %s -> %s", describeCandidate(*out), describeCandidate(*again))
+	}
+
+	// Every calculated field matches the runtime evaluation of its formula
+	for _, field := range sortedKeys(LanguageCandidateFormulas) {
+		exp, err := explainRecord(out, LanguageCandidateFormulas, field)
+		if err != nil {
+			t.Fatalf("%s: %v", field, err)
+		}
+		if got := recordField(out, field); !reflect.DeepEqual(got, exp.Value) {
+			t.Errorf("%s = %v, formula evaluates to %v", field, got, exp.Value)
+		}
+	}
+}
+
+// randomCandidate fills every raw field of a LanguageCandidate: next(n)
+// picks 0..n-1 and decides, field by field, whether the value is nil
+func randomCandidate(next func(n int) int, text string) LanguageCandidate {
+	var c LanguageCandidate
+	v := reflect.ValueOf(&c).Elem()
+	for _, name := range rawCandidateFields() {
+		f := v.FieldByName(name)
+		if f.Kind() == reflect.String {
+			f.SetString("id-" + text) // the primary key is never nil
+			continue
+		}
+		if next(4) == 0 {
+			continue // nil
+		}
+		p := reflect.New(f.Type().Elem())
+		switch p.Elem().Kind() {
+		case reflect.Bool:
+			p.Elem().SetBool(next(2) == 1)
+		case reflect.Int:
+			p.Elem().SetInt(int64(next(5) - 1))
+		case reflect.String:
+			if next(3) > 0 {
+				p.Elem().SetString(text)
+			}
+		}
+		f.Set(p)
+	}
+	return c
+}
+
+// rawCandidateFields lists the LanguageCandidate fields that are not calculated
+func rawCandidateFields() []string {
+	var names []string
+	t := reflect.TypeOf(LanguageCandidate{})
+	for i := 0; i < t.NumField(); i++ {
+		if _, calculated := LanguageCandidateFormulas[t.Field(i).Name]; !calculated {
+			names = append(names, t.Field(i).Name)
+		}
+	}
+	return names
+}
+
+// describeCandidate renders a candidate's set fields for failure messages
+func describeCandidate(c LanguageCandidate) string {
+	rec := RecordsOf([]LanguageCandidate{c})[0]
+	var parts []string
+	for _, k := range rec.Keys {
+		if v := rec.Values[k]; v != nil {
+			parts = append(parts, k+"="+pgDiffValue(v))
+		}
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+var randomWords = []string{"", "Music", "A Coffee Mug", "SQL", "Äpfel & Birnen", "\"quoted\""}
@@ -0,0 +1,294 @@
+// ERB SDK - Protocol Buffers
+// ==========================
+// .proto definitions generated from the rulebook, so substrates in other
+// languages can call the Go calculation engine over gRPC (see erb_grpc.go):
+// one message per table (fields in schema order, numbered from 1, named by
+// their snake_case JSON key), a <Message>List wrapper, and a Compute service
+// with a unary RPC for one record and one for a list of every table that has
+// calculated fields.
+//
+//	go run $(ls *.go | grep -v _test.go) proto --out erb.proto
+//	protoc --python_out=. --grpc_python_out=. erb.proto
+//
+// The SDK has no dependencies, so records are encoded and decoded here with
+// the protobuf wire format directly: string -> string, boolean -> bool,
+// integer -> int64, number -> double, and nullable fields are proto3
+// `optional` so nil survives the round trip.
+
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// ProtoPackage is the package of the generated .proto
+const ProtoPackage = "erb"
+
+// protoType maps a schema datatype to a protobuf scalar type
+func protoType(datatype string) string {
+	switch datatype {
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int64"
+	case "number":
+		return "double"
+	default:
+		return "string"
+	}
+}
+
+// computeServiceTables lists the tables the Compute service calculates:
+// those with calculated fields, as in the conformance runner
+func computeServiceTables(rb *Rulebook) []*Table {
+	var tables []*Table
+	for _, rt := range RunnerTables {
+		if t := rb.Table(rt.Table); t != nil {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// ProtoSchema returns the .proto file for the rulebook's tables and the
+// Compute service
+func (rb *Rulebook) ProtoSchema() string {
+	var b strings.Builder
+	b.WriteString("// Generated from the rulebook by `erb proto` - do not edit\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n", ProtoPackage)
+
+	for _, t := range rb.Tables {
+		msg := structName(t.Name)
+		b.WriteString("\n")
+		if t.Description != "" {
+			fmt.Fprintf(&b, "// %s\n", strings.Join(strings.Fields(t.Description), " "))
+		}
+		fmt.Fprintf(&b, "message %s {\n", msg)
+		for i, f := range t.Schema {
+			label := ""
+			if i > 0 {
+				label = "optional "
+			}
+			comment := ""
+			if f.IsCalculated() {
+				comment = " // calculated"
+			}
+			fmt.Fprintf(&b, "  %s%s %s = %d;%s\n", label, protoType(f.Datatype), toSnakeCase(f.Name), i+1, comment)
+		}
+		b.WriteString("}\n\n")
+		fmt.Fprintf(&b, "message %sList {\n  repeated %s records = 1;\n}\n", msg, msg)
+	}
+
+	b.WriteString("\n// Compute fills in the calculated fields of records with the Go SDK\n")
+	b.WriteString("service Compute {\n")
+	for _, t := range computeServiceTables(rb) {
+		msg := structName(t.Name)
+		fmt.Fprintf(&b, "  rpc Compute%s(%s) returns (%s);\n", msg, msg, msg)
+		fmt.Fprintf(&b, "  rpc Compute%sList(%sList) returns (%sList);\n", msg, msg, msg)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// =============================================================================
+// WIRE FORMAT
+// =============================================================================
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// EncodeProtoRecord encodes a record (snake_case keys, as from RecordsOf) as
+// the table's message; nil values are left out
+func (t *Table) EncodeProtoRecord(rec Record) ([]byte, error) {
+	var buf []byte
+	for i, f := range t.Schema {
+		v, _ := rec.Get(toSnakeCase(f.Name))
+		if v == nil {
+			continue
+		}
+		num := uint64(i + 1)
+		switch x := runtimeValue(v).(type) {
+		case string:
+			if i == 0 && x == "" {
+				continue // proto3 default for the non-optional ID
+			}
+			buf = binary.AppendUvarint(buf, num<<3|protoBytes)
+			buf = binary.AppendUvarint(buf, uint64(len(x)))
+			buf = append(buf, x...)
+		case bool:
+			n := uint64(0)
+			if x {
+				n = 1
+			}
+			buf = binary.AppendUvarint(buf, num<<3|protoVarint)
+			buf = binary.AppendUvarint(buf, n)
+		case int:
+			buf = binary.AppendUvarint(buf, num<<3|protoVarint)
+			buf = binary.AppendUvarint(buf, uint64(int64(x)))
+		case float64:
+			buf = binary.AppendUvarint(buf, num<<3|protoFixed64)
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+		default:
+			return nil, fmt.Errorf("%s.%s: cannot encode %T", t.Name, f.Name, v)
+		}
+	}
+	return buf, nil
+}
+
+// DecodeProtoRecord decodes the table's message into a record with every
+// field (snake_case keys, schema order; unset optional fields are nil)
+func (t *Table) DecodeProtoRecord(data []byte) (Record, error) {
+	rec := Record{Values: map[string]any{}}
+	for i, f := range t.Schema {
+		key := toSnakeCase(f.Name)
+		rec.Keys = append(rec.Keys, key)
+		if i == 0 {
+			rec.Values[key] = "" // the ID is not optional
+		}
+	}
+
+	err := readProtoFields(data, func(num uint64, wire int, value []byte, n uint64) error {
+		if num == 0 || num > uint64(len(t.Schema)) {
+			return nil // unknown field
+		}
+		f := t.Schema[num-1]
+		key := toSnakeCase(f.Name)
+		want := map[string]int{"string": protoBytes, "bool": protoVarint, "int64": protoVarint, "double": protoFixed64}[protoType(f.Datatype)]
+		if wire != want {
+			return fmt.Errorf("%s.%s: wire type %d, want %d", t.Name, f.Name, wire, want)
+		}
+		switch protoType(f.Datatype) {
+		case "string":
+			rec.Values[key] = string(value)
+		case "bool":
+			rec.Values[key] = n != 0
+		case "int64":
+			rec.Values[key] = int(int64(n))
+		case "double":
+			rec.Values[key] = math.Float64frombits(n)
+		}
+		return nil
+	})
+	return rec, err
+}
+
+// readProtoFields calls fn for every field of a message: value holds the
+// bytes of a length-delimited field, n the number of any other
+func readProtoFields(data []byte, fn func(num uint64, wire int, value []byte, n uint64) error) error {
+	for len(data) > 0 {
+		tag, size := binary.Uvarint(data)
+		if size <= 0 {
+			return fmt.Errorf("invalid protobuf field tag")
+		}
+		data = data[size:]
+		num, wire := tag>>3, int(tag&7)
+
+		var value []byte
+		var n uint64
+		switch wire {
+		case protoVarint:
+			if n, size = binary.Uvarint(data); size <= 0 {
+				return fmt.Errorf("invalid protobuf varint in field %d", num)
+			}
+			data = data[size:]
+		case protoFixed64, protoFixed32:
+			width := 8
+			if wire == protoFixed32 {
+				width = 4
+			}
+			if len(data) < width {
+				return fmt.Errorf("truncated protobuf field %d", num)
+			}
+			if width == 8 {
+				n = binary.LittleEndian.Uint64(data)
+			} else {
+				n = uint64(binary.LittleEndian.Uint32(data))
+			}
+			data = data[width:]
+		case protoBytes:
+			length, size := binary.Uvarint(data)
+			if size <= 0 || uint64(len(data)-size) < length {
+				return fmt.Errorf("truncated protobuf field %d", num)
+			}
+			value, data = data[size:size+int(length)], data[size+int(length):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d in field %d", wire, num)
+		}
+		if err := fn(num, wire, value, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeProtoList encodes records as the table's <Message>List
+func (t *Table) EncodeProtoList(records []Record) ([]byte, error) {
+	var buf []byte
+	for _, rec := range records {
+		msg, err := t.EncodeProtoRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.AppendUvarint(buf, 1<<3|protoBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(msg)))
+		buf = append(buf, msg...)
+	}
+	return buf, nil
+}
+
+// DecodeProtoList decodes the table's <Message>List
+func (t *Table) DecodeProtoList(data []byte) ([]Record, error) {
+	records := []Record{}
+	err := readProtoFields(data, func(num uint64, wire int, value []byte, _ uint64) error {
+		if num != 1 || wire != protoBytes {
+			return nil
+		}
+		rec, err := t.DecodeProtoRecord(value)
+		records = append(records, rec)
+		return err
+	})
+	return records, err
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runProto implements `proto [--rulebook PATH] [--out FILE]`: writes the
+// .proto for the rulebook (stdout by default)
+func runProto(args []string) error {
+	fs := flag.NewFlagSet("proto", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	out := fs.String("out", "", "file to write (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	schema := rb.ProtoSchema()
+	if *out == "" {
+		fmt.Print(schema)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(schema), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Wrote %s\n", *out)
+	return nil
+}
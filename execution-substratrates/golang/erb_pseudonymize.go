@@ -0,0 +1,143 @@
+// ERB SDK - Pseudonymization
+// ==========================
+// Schema fields may be marked `"privacy": "identifier"` (contributor names,
+// handles, emails) or `"privacy": "free_text"` (notes). Pseudonymized()
+// replaces their values with keyed hashes so a bundle can be shared for
+// external analysis:
+//
+//	identifier  ->  anon-3f2a9c81d4e0   same input, same pseudonym (joins still work)
+//	free_text   ->  hash:9b1c0e77a2f45d10
+//
+// The salt is the secret: without it a pseudonym cannot be reversed by
+// hashing candidate names, and with the same salt pseudonyms are stable
+// across exports.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Privacy classes for schema fields
+const (
+	PrivacyIdentifier = "identifier"
+	PrivacyFreeText   = "free_text"
+)
+
+// PseudonymizeOptions configures Pseudonymized
+type PseudonymizeOptions struct {
+	// Salt keys the hashes; required
+	Salt string
+
+	// Identifiers and FreeText add fields (by schema name) on top of the
+	// schema's privacy tags, e.g. for rulebooks that are not tagged yet
+	Identifiers []string
+	FreeText    []string
+}
+
+// PrivacyFields returns the privacy class of each field to pseudonymize.
+// String calculated fields derived from a pseudonymized field are treated as
+// free text, since their stored values may embed the original.
+func (t *Table) PrivacyFields(opts PseudonymizeOptions) map[string]string {
+	classes := map[string]string{}
+	for _, f := range t.Schema {
+		switch strings.ToLower(f.Privacy) {
+		case PrivacyIdentifier:
+			classes[f.Name] = PrivacyIdentifier
+		case PrivacyFreeText:
+			classes[f.Name] = PrivacyFreeText
+		}
+	}
+	for _, name := range opts.Identifiers {
+		if _, ok := t.Field(name); ok {
+			classes[name] = PrivacyIdentifier
+		}
+	}
+	for _, name := range opts.FreeText {
+		if _, ok := t.Field(name); ok {
+			classes[name] = PrivacyFreeText
+		}
+	}
+
+	deps := t.Dependencies()
+	for changed := true; changed; {
+		changed = false
+		for _, f := range t.Schema {
+			if _, ok := classes[f.Name]; ok || !f.IsCalculated() || f.Datatype != "string" {
+				continue
+			}
+			for _, dep := range deps[f.Name] {
+				if _, ok := classes[dep]; ok {
+					classes[f.Name] = PrivacyFreeText
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// Pseudonymized returns a copy of the rulebook with identifier and free-text
+// fields replaced by stable keyed hashes
+func (rb *Rulebook) Pseudonymized(opts PseudonymizeOptions) (*Rulebook, error) {
+	if opts.Salt == "" {
+		return nil, fmt.Errorf("pseudonymization requires a salt")
+	}
+	key := []byte(opts.Salt)
+
+	out := *rb
+	out.Tables = make([]*Table, len(rb.Tables))
+
+	for i, t := range rb.Tables {
+		classes := t.PrivacyFields(opts)
+		for name := range classes {
+			if f, _ := t.Field(name); f.Datatype != "string" {
+				return nil, fmt.Errorf("cannot pseudonymize %s.%s: %s fields cannot hold a pseudonym", t.Name, name, f.Datatype)
+			}
+		}
+		pt := &Table{Name: t.Name, Description: t.Description, Schema: t.Schema}
+		for _, row := range t.Data {
+			r := make(map[string]any, len(row))
+			for k, v := range row {
+				if class, ok := classes[k]; ok {
+					v = pseudonymize(key, class, v)
+				}
+				r[k] = v
+			}
+			pt.Data = append(pt.Data, r)
+		}
+		out.Tables[i] = pt
+	}
+
+	out.LanguageCandidates = nil
+	out.IsEverythingALanguage = nil
+	if err := out.decodeTypedTables(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// pseudonymize replaces one value; nulls and empty strings are left as they are
+func pseudonymize(key []byte, class string, v any) any {
+	if v == nil {
+		return nil
+	}
+	s := fmt.Sprint(v)
+	if s == "" {
+		return v
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	sum := hex.EncodeToString(mac.Sum(nil))
+
+	if class == PrivacyIdentifier {
+		return "anon-" + sum[:12]
+	}
+	return "hash:" + sum[:16]
+}
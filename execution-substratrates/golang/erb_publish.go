@@ -0,0 +1,403 @@
+// ERB SDK - Snapshot Publishing
+// =============================
+// Writes immutable, content-addressed copies of the rulebook and everything
+// derived from it into a static-hostable directory:
+//
+//	dist/
+//	  index.json                       every published snapshot
+//	  latest.json                      pointer to the last snapshot published
+//	  <version>/manifest.json          files in the snapshot with their hashes
+//	  <version>/rulebook.<hash>.json
+//	  <version>/views/<table>.<hash>.json
+//	  <version>/schemas/<table>.<hash>.json
+//	  <version>/reports/summary.<hash>.md
+//
+// A version names a directory under dist/, so it must be a single path
+// element: not empty, "." or absolute, with no "/", "\" or "..". latest.json and
+// index.json's latest follow publish order, not version order: publishing an
+// older tag after a newer one makes the older tag latest.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fingerprintLength is the number of hex digits of the SHA-256 used in file names
+const fingerprintLength = 12
+
+// =============================================================================
+// PUBLISHED LAYOUT TYPES
+// =============================================================================
+
+// PublishedFile is one fingerprinted artifact inside a snapshot
+type PublishedFile struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Bytes  int    `json:"bytes"`
+}
+
+// SnapshotManifest describes a published snapshot (<version>/manifest.json)
+type SnapshotManifest struct {
+	Version     string          `json:"version"`
+	Fingerprint string          `json:"fingerprint"`
+	PublishedAt time.Time       `json:"published_at"`
+	Files       []PublishedFile `json:"files"`
+}
+
+// File returns the path of a logical artifact (e.g. "rulebook") in the snapshot
+func (m *SnapshotManifest) File(name string) (string, bool) {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return f.Path, true
+		}
+	}
+	return "", false
+}
+
+// SnapshotEntry is a snapshot's entry in index.json and latest.json
+type SnapshotEntry struct {
+	Version     string    `json:"version"`
+	Fingerprint string    `json:"fingerprint"`
+	PublishedAt time.Time `json:"published_at"`
+	Manifest    string    `json:"manifest"`
+}
+
+// SnapshotIndex is the top-level index.json of a publish destination
+type SnapshotIndex struct {
+	Latest    string          `json:"latest"`
+	Snapshots []SnapshotEntry `json:"snapshots"`
+}
+
+// =============================================================================
+// PUBLISHING
+// =============================================================================
+
+// PublishOptions configures a Publish run
+type PublishOptions struct {
+	RulebookPath string
+	Dest         string
+	Version      string
+
+	// IncludeInternal publishes fields marked "visibility": "internal"
+	IncludeInternal bool
+
+	// Pseudonymize, if set, replaces identifier and free-text fields with keyed hashes
+	Pseudonymize *PseudonymizeOptions
+}
+
+// Publish writes a snapshot of the rulebook to opts.Dest and updates index.json and latest.json.
+// Publishing the same version twice is a no-op; publishing different content under an existing version fails.
+func Publish(opts PublishOptions) (*SnapshotManifest, error) {
+	if err := checkVersion(opts.Version); err != nil {
+		return nil, err
+	}
+	raw, err := readRulebookJSON(opts.RulebookPath, FormatAuto)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := ParseRulebook(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Pseudonymize != nil {
+		if rb, err = rb.Pseudonymized(*opts.Pseudonymize); err != nil {
+			return nil, err
+		}
+		if raw, err = json.MarshalIndent(rb, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal pseudonymized rulebook: %w", err)
+		}
+	}
+
+	if !opts.IncludeInternal && rb.HasInternalFields() {
+		redacted, err := rb.Redacted()
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = json.MarshalIndent(redacted, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal redacted rulebook: %w", err)
+		}
+	}
+
+	fingerprint := sha256Hex(raw)
+	manifestPath := filepath.Join(opts.Dest, opts.Version, "manifest.json")
+
+	if existing, err := readManifest(manifestPath); err == nil {
+		if existing.Fingerprint != fingerprint {
+			return nil, fmt.Errorf("version %s is already published with different content (%s)", opts.Version, existing.Fingerprint[:fingerprintLength])
+		}
+		return existing, nil
+	}
+
+	manifest := &SnapshotManifest{
+		Version:     opts.Version,
+		Fingerprint: fingerprint,
+		PublishedAt: time.Now().UTC(),
+	}
+
+	artifacts, err := snapshotArtifacts(rb, raw, opts.Version, opts.IncludeInternal)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range artifacts {
+		f, err := writeFingerprinted(opts.Dest, opts.Version, a)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, f)
+	}
+
+	if err := writeJSONFile(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	if err := updateSnapshotIndex(opts.Dest, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// artifact is a logical snapshot file before it is fingerprinted
+type artifact struct {
+	name string // logical name, e.g. "views/LanguageCandidates"
+	dir  string // directory inside the snapshot
+	base string // file name without hash or extension
+	ext  string
+	data []byte
+}
+
+// snapshotArtifacts builds every file of a snapshot; raw is the (possibly redacted) rulebook to publish
+func snapshotArtifacts(rb *Rulebook, raw []byte, version string, includeInternal bool) ([]artifact, error) {
+	artifacts := []artifact{{name: "rulebook", base: "rulebook", ext: ".json", data: raw}}
+
+	views := map[string]any{
+		"LanguageCandidates":    rb.CandidateViews(),
+		"IsEverythingALanguage": rb.ArgumentViews(),
+	}
+	for _, t := range rb.Tables {
+		if v, ok := views[t.Name]; ok {
+			data, err := MarshalCanonical(rb.ExportRecords(t.Name, v, includeInternal))
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal %s view: %w", t.Name, err)
+			}
+			artifacts = append(artifacts, artifact{name: "views/" + t.Name, dir: "views", base: kebabCase(t.Name), ext: ".json", data: data})
+		}
+
+		schema := make([]Field, 0, len(t.Schema))
+		for _, f := range t.Schema {
+			if includeInternal || !f.IsInternal() {
+				schema = append(schema, f)
+			}
+		}
+		data, err := MarshalCanonical(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s schema: %w", t.Name, err)
+		}
+		artifacts = append(artifacts, artifact{name: "schemas/" + t.Name, dir: "schemas", base: kebabCase(t.Name), ext: ".json", data: data})
+	}
+
+	artifacts = append(artifacts, artifact{name: "reports/summary", dir: "reports", base: "summary", ext: ".md", data: []byte(snapshotSummary(rb, version))})
+	return artifacts, nil
+}
+
+// snapshotSummary renders the Markdown summary report for a snapshot
+func snapshotSummary(rb *Rulebook, version string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Rulebook Snapshot %s\n\n", version)
+	b.WriteString("| Table | Records | Calculated Fields |\n|-------|---------|-------------------|\n")
+	for _, t := range rb.Tables {
+		calculated := 0
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				calculated++
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", t.Name, len(t.Data), calculated)
+	}
+
+	var mismatches []string
+	for _, v := range rb.CandidateViews() {
+		if v.FamilyFeudMismatch != nil {
+			mismatches = append(mismatches, *v.FamilyFeudMismatch)
+		}
+	}
+	fmt.Fprintf(&b, "\n## Family Feud Mismatches (%d)\n\n", len(mismatches))
+	for _, m := range mismatches {
+		fmt.Fprintf(&b, "- %s\n", m)
+	}
+	return b.String()
+}
+
+func writeFingerprinted(dest, version string, a artifact) (PublishedFile, error) {
+	hash := sha256Hex(a.data)
+	rel := path.Join(version, a.dir, a.base+"."+hash[:fingerprintLength]+a.ext)
+
+	full := filepath.Join(dest, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return PublishedFile{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(full, a.data, 0644); err != nil {
+		return PublishedFile{}, fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return PublishedFile{Name: a.name, Path: rel, SHA256: hash, Bytes: len(a.data)}, nil
+}
+
+// checkVersion rejects a version that is not a single path element under the destination
+func checkVersion(version string) error {
+	switch {
+	case version == "" || version == ".":
+		return fmt.Errorf("snapshot version %q does not name a directory", version)
+	case filepath.IsAbs(version) || path.IsAbs(version):
+		return fmt.Errorf("snapshot version %q is an absolute path", version)
+	case strings.ContainsAny(version, `/\`) || strings.Contains(version, ".."):
+		return fmt.Errorf(`snapshot version %q must be a single path element (no "/", "\" or "..")`, version)
+	}
+	return nil
+}
+
+// updateSnapshotIndex adds the manifest to index.json and points latest.json at it,
+// whether or not its version sorts after the current latest
+func updateSnapshotIndex(dest string, m *SnapshotManifest) error {
+	indexPath := filepath.Join(dest, "index.json")
+
+	var index SnapshotIndex
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", indexPath, err)
+		}
+	}
+
+	entry := SnapshotEntry{
+		Version:     m.Version,
+		Fingerprint: m.Fingerprint,
+		PublishedAt: m.PublishedAt,
+		Manifest:    path.Join(m.Version, "manifest.json"),
+	}
+	index.Snapshots = append(index.Snapshots, entry)
+	index.Latest = m.Version
+
+	if err := writeJSONFile(indexPath, index); err != nil {
+		return err
+	}
+	return writeJSONFile(filepath.Join(dest, "latest.json"), entry)
+}
+
+func readManifest(p string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var m SnapshotManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	return &m, nil
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// kebabCase converts a PascalCase table name to a file-name slug
+func kebabCase(name string) string {
+	return strings.ReplaceAll(toSnakeCase(name), "_", "-")
+}
+
+// writeJSONFile writes v as canonical JSON, creating parent directories
+func writeJSONFile(p string, v any) error {
+	data, err := MarshalCanonical(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(p), err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", p, err)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// defaultVersion describes HEAD with git (nearest tag, else abbreviated hash)
+func defaultVersion() (string, error) {
+	out, err := subprocess(commandContext(), "git", "describe", "--tags", "--always").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to derive a version from git (pass --version): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runPublish implements `publish [--dest DIR] [--version V] [--rulebook PATH] [--include-internal]
+// [--pseudonymize [--salt S] [--identifiers A,B] [--free-text C,D]]`
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ContinueOnError)
+	dest := fs.String("dest", "dist", "destination directory")
+	version := fs.String("version", "", "snapshot version (default: git describe --tags --always)")
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	includeInternal := fs.Bool("include-internal", false, "publish fields marked internal (maintainers only)")
+	pseudonymizeFlag := fs.Bool("pseudonymize", false, "replace identifier and free-text fields with stable hashes")
+	salt := fs.String("salt", os.Getenv("ERB_PSEUDONYM_SALT"), "secret salt for --pseudonymize (default: $ERB_PSEUDONYM_SALT)")
+	identifiers := fs.String("identifiers", "", "comma-separated extra fields to pseudonymize as identifiers")
+	freeText := fs.String("free-text", "", "comma-separated extra fields to pseudonymize as free text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *version == "" {
+		v, err := defaultVersion()
+		if err != nil {
+			return err
+		}
+		*version = v
+	}
+
+	opts := PublishOptions{RulebookPath: *rulebookPath, Dest: *dest, Version: *version, IncludeInternal: *includeInternal}
+	if *pseudonymizeFlag {
+		opts.Pseudonymize = &PseudonymizeOptions{
+			Salt:        *salt,
+			Identifiers: splitList(*identifiers),
+			FreeText:    splitList(*freeText),
+		}
+	}
+
+	manifest, err := Publish(opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Published %s (%s) to %s\n", manifest.Version, manifest.Fingerprint[:fingerprintLength], *dest)
+	for _, f := range manifest.Files {
+		fmt.Printf("  %s\n", f.Path)
+	}
+	return nil
+}
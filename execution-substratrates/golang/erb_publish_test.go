@@ -0,0 +1,33 @@
+// ERB SDK - Publish Tests
+// =======================
+// Unit tests for Publish: a version that is not a single path element under
+// the destination is rejected before anything is written:
+//
+//	go test $(ls *.go) -run Publish
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPublishRejectsUnsafeVersions(t *testing.T) {
+	dest := t.TempDir()
+	for _, version := range []string{"", "/tmp/v1", "v1/v2", `v1\v2`, "..", "../v1", "v1..v2", "."} {
+		if _, err := Publish(PublishOptions{RulebookPath: DefaultRulebookPath, Dest: dest, Version: version}); err == nil {
+			t.Errorf("Publish(version %q) succeeded, want an error", version)
+		}
+	}
+	if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+		t.Errorf("rejected versions wrote %d entries to the destination", len(entries))
+	}
+
+	if _, err := Publish(PublishOptions{RulebookPath: DefaultRulebookPath, Dest: dest, Version: "v1.0.0"}); err != nil {
+		t.Fatalf("Publish(version %q): %v", "v1.0.0", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "v1.0.0", "manifest.json")); err != nil {
+		t.Error(err)
+	}
+}
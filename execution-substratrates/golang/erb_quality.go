@@ -0,0 +1,234 @@
+// ERB SDK - Data Quality
+// ======================
+// A quality score for every record and table of the rulebook, from three
+// components (each 0 to 1):
+//
+//	completeness  fraction of the raw fields (other than the ID) that are set
+//	rationale     whether argument steps reference the record (only for
+//	              tables something argues about: LanguageCandidates)
+//	invariants    fraction of fields that validate (required, datatype) and,
+//	              for stored calculated values, agree with the formula
+//
+// A record's score is the mean of its components; a table's components are
+// the means over its records. `stats` prints them with the compute
+// statistics, `serve` has GET /quality, and `lint --min-quality` fails when a
+// table scores below a threshold.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// RecordQuality is the quality of one rulebook row
+type RecordQuality struct {
+	ID           string   `json:"id"`
+	Completeness float64  `json:"completeness"`
+	Rationale    *float64 `json:"rationale,omitempty"`
+	Invariants   float64  `json:"invariants"`
+	Score        float64  `json:"score"`
+	Problems     []string `json:"problems,omitempty"` // failed invariants
+}
+
+// TableQuality aggregates RecordQuality over a table
+type TableQuality struct {
+	Table        string          `json:"table"`
+	Records      int             `json:"records"`
+	Completeness float64         `json:"completeness"`
+	Rationale    *float64        `json:"rationale,omitempty"`
+	Invariants   float64         `json:"invariants"`
+	Score        float64         `json:"score"`
+	Rows         []RecordQuality `json:"rows"`
+}
+
+func (q TableQuality) String() string {
+	rationale := ""
+	if q.Rationale != nil {
+		rationale = fmt.Sprintf(", rationale %.2f", *q.Rationale)
+	}
+	return fmt.Sprintf("%s: quality %.2f (completeness %.2f%s, invariants %.2f) over %d records",
+		q.Table, q.Score, q.Completeness, rationale, q.Invariants, q.Records)
+}
+
+// qualityRationale counts the records that argue about a row, for tables
+// that have a rationale component
+var qualityRationale = map[string]func(rb *Rulebook, id string) int{
+	"LanguageCandidates": func(rb *Rulebook, id string) int {
+		return len(rb.relations.steps[id])
+	},
+}
+
+// Quality scores every record of every table
+func (rb *Rulebook) Quality() ([]TableQuality, error) {
+	var tables []TableQuality
+	for _, t := range rb.Tables {
+		q, err := rb.tableQuality(t)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, q)
+	}
+	return tables, nil
+}
+
+func (rb *Rulebook) tableQuality(t *Table) (TableQuality, error) {
+	computed, err := t.Compute()
+	if err != nil {
+		return TableQuality{}, err
+	}
+	problems := map[int][]*RowError{} // row index -> failed invariants
+	for _, err := range t.Validate() {
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			problems[rowErr.Row-1] = append(problems[rowErr.Row-1], rowErr)
+		}
+	}
+
+	q := TableQuality{Table: t.Name, Records: len(t.Data), Rows: []RecordQuality{}}
+	rationale := qualityRationale[t.Name]
+	var rationaleSum float64
+	for i, row := range t.Data {
+		rq := RecordQuality{ID: recordID(computed[i])}
+
+		raw, set := 0, 0
+		for _, f := range t.Schema {
+			if f.IsCalculated() || f.Name == t.IDField() {
+				continue
+			}
+			raw++
+			if normalizeAnswer(row[f.Name]) != nil {
+				set++
+			}
+		}
+		rq.Completeness = ratio(set, raw)
+
+		failed := map[string]bool{}
+		for _, p := range problems[i] {
+			failed[p.Field] = true
+			rq.Problems = append(rq.Problems, p.Field+" "+p.Problem)
+		}
+		for _, f := range t.Schema {
+			stored, ok := row[f.Name]
+			if !f.IsCalculated() || !ok || failed[f.Name] {
+				continue
+			}
+			want, _ := computed[i].Get(toSnakeCase(f.Name))
+			if !reflect.DeepEqual(normalizeAnswer(runtimeValue(stored)), normalizeAnswer(want)) {
+				failed[f.Name] = true
+				rq.Problems = append(rq.Problems, fmt.Sprintf("%s is stored as %s but computes to %s", f.Name, pgDiffValue(stored), pgDiffValue(want)))
+			}
+		}
+		rq.Invariants = 1 - ratio(len(failed), len(t.Schema))
+
+		components := []float64{rq.Completeness, rq.Invariants}
+		if rationale != nil {
+			covered := 0.0
+			if rationale(rb, rq.ID) > 0 {
+				covered = 1
+			}
+			rq.Rationale = &covered
+			rationaleSum += covered
+			components = append(components, covered)
+		}
+		rq.Score = mean(components)
+
+		q.Completeness += rq.Completeness
+		q.Invariants += rq.Invariants
+		q.Rows = append(q.Rows, rq)
+	}
+
+	if len(t.Data) == 0 {
+		return q, nil
+	}
+	q.Completeness /= float64(len(t.Data))
+	q.Invariants /= float64(len(t.Data))
+	components := []float64{q.Completeness, q.Invariants}
+	if rationale != nil {
+		coverage := rationaleSum / float64(len(t.Data))
+		q.Rationale = &coverage
+		components = append(components, coverage)
+	}
+	q.Score = mean(components)
+	return q, nil
+}
+
+// ratio is n/of, or 1 when there is nothing to count
+func ratio(n, of int) float64 {
+	if of == 0 {
+		return 1
+	}
+	return float64(n) / float64(of)
+}
+
+// mean is the average of values, which must not be empty
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runStats implements `stats [--rulebook PATH] [--records] [--json]`: the
+// quality score and the calculated field statistics of every table
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	records := fs.Bool("records", false, "list every record's quality")
+	asJSON := fs.Bool("json", false, "print the quality report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	quality, err := rb.Quality()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(quality)
+	}
+
+	for i, t := range rb.Tables {
+		fmt.Println(quality[i])
+		if *records {
+			for _, rq := range quality[i].Rows {
+				fmt.Printf("  %s: %.2f\n", rq.ID, rq.Score)
+				for _, p := range rq.Problems {
+					fmt.Printf("    %s\n", p)
+				}
+			}
+		}
+
+		formulas := map[string]string{}
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				formulas[f.Name] = f.Formula
+			}
+		}
+		computed, err := t.Compute()
+		if err != nil {
+			return err
+		}
+		for _, s := range ComputeStats(computed, formulas) {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+	return nil
+}
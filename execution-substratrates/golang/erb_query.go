@@ -0,0 +1,270 @@
+// ERB SDK - Query Builder
+// =======================
+// A small fluent query API over computed views:
+//
+//	top5 := rb.Candidates().Where(HasSyntax(true)).SortBy(SortOrder).Limit(5).All()
+//	next5 := rb.Candidates().Where(HasSyntax(true)).SortBy(SortOrder).Offset(5).Limit(5).All()
+//
+// The generated SDK provides the field accessors: a predicate function per
+// boolean field (HasSyntax(true)) and a QueryField per string or integer
+// field (SortOrder, Name.Eq("English"), DistanceFromConcept.Gt(1)).
+//
+// A QueryField sorts nulls below every value: first ascending, last with
+// Desc, which reverses a key entirely. NilsLast sorts ascending with nulls
+// last, the order a sentinel like `if v == nil { return 999 }` is after.
+
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Predicate selects rows in a query
+type Predicate[T any] func(*T) bool
+
+// SortKey orders rows in a query; QueryFields and Desc(...) are sort keys
+type SortKey[T any] interface {
+	CompareRows(a, b *T) int
+}
+
+// QueryField is a (nullable) field of T usable in Where and SortBy
+type QueryField[T any, V cmp.Ordered] struct {
+	Name string
+	Get  func(*T) *V
+}
+
+// Eq matches rows whose field equals v
+func (f QueryField[T, V]) Eq(v V) Predicate[T] {
+	return func(r *T) bool {
+		p := f.Get(r)
+		return p != nil && *p == v
+	}
+}
+
+// Ne matches rows whose field is null or differs from v
+func (f QueryField[T, V]) Ne(v V) Predicate[T] {
+	return Not(f.Eq(v))
+}
+
+// Lt matches rows whose field is set and less than v
+func (f QueryField[T, V]) Lt(v V) Predicate[T] {
+	return f.compareTo(v, func(c int) bool { return c < 0 })
+}
+
+// Le matches rows whose field is set and at most v
+func (f QueryField[T, V]) Le(v V) Predicate[T] {
+	return f.compareTo(v, func(c int) bool { return c <= 0 })
+}
+
+// Gt matches rows whose field is set and greater than v
+func (f QueryField[T, V]) Gt(v V) Predicate[T] {
+	return f.compareTo(v, func(c int) bool { return c > 0 })
+}
+
+// Ge matches rows whose field is set and at least v
+func (f QueryField[T, V]) Ge(v V) Predicate[T] {
+	return f.compareTo(v, func(c int) bool { return c >= 0 })
+}
+
+func (f QueryField[T, V]) compareTo(v V, ok func(int) bool) Predicate[T] {
+	return func(r *T) bool {
+		p := f.Get(r)
+		return p != nil && ok(cmp.Compare(*p, v))
+	}
+}
+
+// In matches rows whose field equals any of vs
+func (f QueryField[T, V]) In(vs ...V) Predicate[T] {
+	return func(r *T) bool {
+		p := f.Get(r)
+		return p != nil && slices.Contains(vs, *p)
+	}
+}
+
+// IsNull matches rows whose field is null
+func (f QueryField[T, V]) IsNull() Predicate[T] {
+	return func(r *T) bool { return f.Get(r) == nil }
+}
+
+// NotNull matches rows whose field is set
+func (f QueryField[T, V]) NotNull() Predicate[T] {
+	return Not(f.IsNull())
+}
+
+// CompareRows orders rows by the field ascending, nulls first
+func (f QueryField[T, V]) CompareRows(a, b *T) int {
+	return compareNullable(f.Get(a), f.Get(b))
+}
+
+// compareNullable orders two nullable values ascending, nulls first
+func compareNullable[V cmp.Ordered](pa, pb *V) int {
+	switch {
+	case pa == nil && pb == nil:
+		return 0
+	case pa == nil:
+		return -1
+	case pb == nil:
+		return 1
+	}
+	return cmp.Compare(*pa, *pb)
+}
+
+// Desc orders rows by the field descending, nulls last
+func (f QueryField[T, V]) Desc() SortKey[T] {
+	return Desc[T](f)
+}
+
+// NilsLast orders rows by the field ascending, nulls last
+func (f QueryField[T, V]) NilsLast() SortKey[T] {
+	return nilsLast[T, V]{f}
+}
+
+type nilsLast[T any, V cmp.Ordered] struct{ f QueryField[T, V] }
+
+func (n nilsLast[T, V]) CompareRows(a, b *T) int {
+	if (n.f.Get(a) == nil) != (n.f.Get(b) == nil) {
+		return -n.f.CompareRows(a, b)
+	}
+	return n.f.CompareRows(a, b)
+}
+
+// Not negates a predicate
+func Not[T any](p Predicate[T]) Predicate[T] {
+	return func(r *T) bool { return !p(r) }
+}
+
+// Or matches rows that satisfy any of the predicates
+func Or[T any](preds ...Predicate[T]) Predicate[T] {
+	return func(r *T) bool {
+		for _, p := range preds {
+			if p(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Desc reverses a sort key
+func Desc[T any](key SortKey[T]) SortKey[T] {
+	return descending[T]{key}
+}
+
+type descending[T any] struct{ key SortKey[T] }
+
+func (d descending[T]) CompareRows(a, b *T) int { return -d.key.CompareRows(a, b) }
+
+// compareByKeys orders two rows by the keys, in priority order
+func compareByKeys[T any](keys []SortKey[T], a, b *T) int {
+	for _, key := range keys {
+		if c := key.CompareRows(a, b); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// =============================================================================
+// QUERIES
+// =============================================================================
+
+// Query is an immutable query over rows of T; each builder method returns a new query
+type Query[T any] struct {
+	source func() []T
+	where  []Predicate[T]
+	order  []SortKey[T]
+	id     func(*T) string // see KeyedBy
+	offset int
+	limit  int // negative: no limit
+}
+
+// NewQuery returns a query over the rows produced by source (called on every evaluation)
+func NewQuery[T any](source func() []T) *Query[T] {
+	return &Query[T]{source: source, limit: -1}
+}
+
+// Candidates queries the computed LanguageCandidate views
+func (rb *Rulebook) Candidates() *Query[LanguageCandidateView] {
+	return NewQuery(rb.CandidateViews).KeyedBy(func(r *LanguageCandidateView) string { return r.LanguageCandidateId })
+}
+
+// Where keeps rows matching every predicate (and any earlier Where)
+func (q *Query[T]) Where(preds ...Predicate[T]) *Query[T] {
+	out := *q
+	out.where = append(slices.Clip(q.where), preds...)
+	return &out
+}
+
+// SortBy orders rows by the keys, in priority order; the sort is stable
+func (q *Query[T]) SortBy(keys ...SortKey[T]) *Query[T] {
+	out := *q
+	out.order = append(slices.Clip(q.order), keys...)
+	return &out
+}
+
+// KeyedBy identifies each row by id, which Page needs to break ties and to
+// find its place again after the rows change
+func (q *Query[T]) KeyedBy(id func(*T) string) *Query[T] {
+	out := *q
+	out.id = id
+	return &out
+}
+
+// Limit keeps at most n rows
+func (q *Query[T]) Limit(n int) *Query[T] {
+	out := *q
+	out.limit = n
+	return &out
+}
+
+// Offset skips the first n rows (after sorting, before Limit)
+func (q *Query[T]) Offset(n int) *Query[T] {
+	out := *q
+	out.offset = max(n, 0)
+	return &out
+}
+
+// All evaluates the query
+func (q *Query[T]) All() []T {
+	var rows []T
+	for _, r := range q.source() {
+		if q.matches(&r) {
+			rows = append(rows, r)
+		}
+	}
+
+	if len(q.order) > 0 {
+		slices.SortStableFunc(rows, func(a, b T) int { return compareByKeys(q.order, &a, &b) })
+	}
+
+	rows = rows[min(q.offset, len(rows)):]
+	if q.limit >= 0 && len(rows) > q.limit {
+		rows = rows[:q.limit]
+	}
+	return rows
+}
+
+// First returns the first row of the query, if any
+func (q *Query[T]) First() (T, bool) {
+	rows := q.Limit(1).All()
+	if len(rows) == 0 {
+		var zero T
+		return zero, false
+	}
+	return rows[0], true
+}
+
+// Count returns the number of rows the query yields
+func (q *Query[T]) Count() int {
+	return len(q.All())
+}
+
+func (q *Query[T]) matches(r *T) bool {
+	for _, p := range q.where {
+		if !p(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+// ERB SDK - RDF Export
+// ====================
+// Writes computed records as RDF Turtle in the vocabulary of the rdf
+// substrate (execution-substratrates/rdf): each record is an individual
+// erb:<Table>_<n> of class erb:<Table>, with one camelCase property per
+// non-null field. Booleans and integers are typed literals.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// rdfExporter writes RDF Turtle
+type rdfExporter struct{}
+
+func (rdfExporter) Name() string         { return "rdf" }
+func (rdfExporter) Extensions() []string { return []string{".ttl"} }
+func (rdfExporter) ContentType() string  { return "text/turtle; charset=utf-8" }
+
+func (rdfExporter) Write(views ExportViews, w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .\n")
+	b.WriteString("@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .\n")
+	b.WriteString("@prefix erb: <http://example.org/erb#> .\n\n")
+	fmt.Fprintf(&b, "# === Individuals: %s ===\n", views.Table)
+
+	for i, rec := range views.Records {
+		fmt.Fprintf(&b, "\nerb:%s_%d a erb:%s", views.Table, i, views.Table)
+		for _, k := range rec.Keys {
+			v := rec.Values[k]
+			if v == nil {
+				continue
+			}
+			fmt.Fprintf(&b, " ;\n    erb:%s %s", rdfProperty(k), rdfLiteral(v))
+		}
+		b.WriteString(" .\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// rdfProperty converts a snake_case JSON key to the rdf substrate's camelCase property name
+func rdfProperty(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// rdfLiteral renders a value as a Turtle literal
+func rdfLiteral(v any) string {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return `"` + turtleString.Replace(fmt.Sprint(v)) + `"`
+}
+
+// turtleString escapes text inside a double-quoted Turtle literal
+var turtleString = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
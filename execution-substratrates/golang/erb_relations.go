@@ -0,0 +1,53 @@
+// ERB SDK - Relations
+// ===================
+// IsEverythingALanguage steps point at the candidate they discuss through
+// RelatedCandidateId. The rulebook indexes those links when it loads, so
+// both directions are map lookups:
+//
+//	lc := step.Candidate(rb)     // the step's candidate, or nil
+//	steps := lc.ArgumentSteps(rb) // every step that references lc
+
+package main
+
+// relationIndex maps candidate ids to positions in the typed table slices
+type relationIndex struct {
+	candidates map[string]int   // LanguageCandidateId -> index into LanguageCandidates
+	steps      map[string][]int // RelatedCandidateId -> indexes into IsEverythingALanguage, in table order
+}
+
+// indexRelations rebuilds the candidate/step index from the typed tables
+func (rb *Rulebook) indexRelations() {
+	idx := relationIndex{
+		candidates: make(map[string]int, len(rb.LanguageCandidates)),
+		steps:      map[string][]int{},
+	}
+	for i, lc := range rb.LanguageCandidates {
+		idx.candidates[lc.LanguageCandidateId] = i
+	}
+	for i, step := range rb.IsEverythingALanguage {
+		if id := optGet(step.RelatedCandidateId, ""); id != "" {
+			idx.steps[id] = append(idx.steps[id], i)
+		}
+	}
+	rb.relations = idx
+}
+
+// Candidate returns the LanguageCandidate this step references, or nil if
+// RelatedCandidateId is empty or names no candidate in rb
+func (step *IsEverythingALanguage) Candidate(rb *Rulebook) *LanguageCandidate {
+	i, ok := rb.relations.candidates[optGet(step.RelatedCandidateId, "")]
+	if !ok {
+		return nil
+	}
+	return &rb.LanguageCandidates[i]
+}
+
+// ArgumentSteps returns the IsEverythingALanguage steps that reference this
+// candidate, in rulebook order
+func (tc *LanguageCandidate) ArgumentSteps(rb *Rulebook) []*IsEverythingALanguage {
+	var steps []*IsEverythingALanguage
+	for _, i := range rb.relations.steps[tc.LanguageCandidateId] {
+		steps = append(steps, &rb.IsEverythingALanguage[i])
+	}
+	return steps
+}
@@ -0,0 +1,147 @@
+// ERB SDK - Remote Rulebooks
+// ==========================
+// Downloads a rulebook over HTTP(S) and keeps a local copy keyed by URL.
+// Revalidation uses ETag / If-Modified-Since, so an unchanged rulebook costs
+// a 304, and the cached copy is used if the server cannot be reached.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// cachedRulebook is the metadata stored next to a cached rulebook body
+type cachedRulebook struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// WithCacheDir sets where LoadFromURL caches downloads (default: the user cache dir)
+func WithCacheDir(dir string) LoadOption {
+	return func(c *loadConfig) {
+		c.cacheDir = dir
+	}
+}
+
+// WithHTTPClient sets the client LoadFromURL uses (default: 30s timeout)
+func WithHTTPClient(client *http.Client) LoadOption {
+	return func(c *loadConfig) {
+		c.httpClient = client
+	}
+}
+
+// LoadFromURL downloads and loads a rulebook, revalidating any cached copy
+// with If-None-Match / If-Modified-Since
+func LoadFromURL(ctx context.Context, rawURL string, opts ...LoadOption) (*Rulebook, error) {
+	cfg := loadConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	data, err := fetchRulebook(ctx, rawURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	return loadRulebookData(ctx, name, data, opts)
+}
+
+// fetchRulebook returns the rulebook body from the server or, when unchanged or unreachable, the cache
+func fetchRulebook(ctx context.Context, rawURL string, cfg loadConfig) ([]byte, error) {
+	client := cfg.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	cacheDir := cfg.cacheDir
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			userCache = os.TempDir()
+		}
+		cacheDir = filepath.Join(userCache, "erb", "rulebooks")
+	}
+	key := sha256Hex([]byte(rawURL))[:32]
+	bodyPath := filepath.Join(cacheDir, key+".body")
+	metaPath := filepath.Join(cacheDir, key+".meta.json")
+
+	var meta cachedRulebook
+	cached, cacheErr := os.ReadFile(bodyPath)
+	if cacheErr == nil {
+		if data, err := os.ReadFile(metaPath); err == nil {
+			json.Unmarshal(data, &meta)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rulebook URL: %w", err)
+	}
+	if cacheErr == nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if cacheErr == nil && ctx.Err() == nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("failed to fetch rulebook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cacheErr == nil:
+		return cached, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrRulebookNotFound, rawURL)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch rulebook: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rulebook response: %w", err)
+	}
+
+	meta = cachedRulebook{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now().UTC(),
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err == nil {
+		if err := os.WriteFile(bodyPath, data, 0644); err == nil {
+			writeJSONFile(metaPath, meta)
+		}
+	}
+	return data, nil
+}
+
+// loadRulebookLocation loads a rulebook from a file path or an http(s) URL
+func loadRulebookLocation(ctx context.Context, location string) (*Rulebook, error) {
+	if isURL(location) {
+		return LoadFromURL(ctx, location)
+	}
+	return LoadFromRulebookContext(ctx, location)
+}
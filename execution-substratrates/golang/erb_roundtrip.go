@@ -0,0 +1,402 @@
+// ERB SDK - Round-Trip Check
+// ==========================
+// `roundtrip FILE` loads a file, saves it in the same format, loads the
+// saved copy and compares the two, reporting every value that did not
+// survive. It is the check a new importer or exporter should pass:
+//
+//	roundtrip ../../effortless-rulebook/effortless-rulebook.json
+//	roundtrip rulebook.sqlite
+//	roundtrip ../../testing/blank-test.json
+//	roundtrip answers.xlsx --table LanguageCandidates
+//
+// Rulebooks (JSON, YAML, SQLite) are compared on metadata, table order,
+// field definitions and every stored value; record files on every record
+// and key, after ImportRecords. Values are compared in canonical form (see
+// CanonicalValue): 2 and 2.0 are equal, as are a missing key and null.
+// YAML rulebooks have no writer, so they are saved as JSON. The format
+// comes from the extension (or --format, for record files); a file with
+// any other extension is rejected rather than guessed at.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// RoundTripLoss is one value that changed in a round trip
+type RoundTripLoss struct {
+	Table  string // empty for rulebook metadata
+	Record int    // 1-based record, 0 for table and schema attributes
+	Field  string // field name, or field.attribute for schema changes
+	Before any
+	After  any
+}
+
+func (l RoundTripLoss) String() string {
+	where := l.Table
+	switch {
+	case where == "":
+		where = "rulebook"
+	case l.Record > 0:
+		where = fmt.Sprintf("%s record %d", l.Table, l.Record)
+	}
+	return fmt.Sprintf("%s: %s %s -> %s", where, l.Field, roundTripText(l.Before), roundTripText(l.After))
+}
+
+// roundTripText renders a value for a loss report
+func roundTripText(v any) string {
+	if raw, ok := v.(json.RawMessage); ok {
+		return string(raw)
+	}
+	return pgDiffValue(v)
+}
+
+// CompareRoundTripRulebooks reports what differs between a rulebook and its reloaded copy
+func CompareRoundTripRulebooks(before, after *Rulebook) []RoundTripLoss {
+	var losses []RoundTripLoss
+	add := func(table string, record int, field string, a, b any) {
+		losses = append(losses, RoundTripLoss{Table: table, Record: record, Field: field, Before: a, After: b})
+	}
+
+	if before.SchemaURI != after.SchemaURI {
+		add("", 0, "$schema", before.SchemaURI, after.SchemaURI)
+	}
+	if before.ModelName != after.ModelName {
+		add("", 0, "model_name", before.ModelName, after.ModelName)
+	}
+	if before.Description != after.Description {
+		add("", 0, "Description", before.Description, after.Description)
+	}
+	if !sameJSON(before.Meta, after.Meta) {
+		add("", 0, "_meta", before.Meta, after.Meta)
+	}
+
+	for i, t := range before.Tables {
+		a := after.Table(t.Name)
+		if a == nil {
+			add(t.Name, 0, "(table)", t.Name, nil)
+			continue
+		}
+		if i >= len(after.Tables) || after.Tables[i] != a {
+			add(t.Name, 0, "(position)", i+1, tablePosition(after, t.Name))
+		}
+		losses = append(losses, compareTables(t, a)...)
+	}
+	for _, t := range after.Tables {
+		if before.Table(t.Name) == nil {
+			add(t.Name, 0, "(table)", nil, t.Name)
+		}
+	}
+	return losses
+}
+
+// tablePosition returns the 1-based position of a table in the rulebook
+func tablePosition(rb *Rulebook, name string) int {
+	for i, t := range rb.Tables {
+		if t.Name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// compareTables compares the description, field definitions and stored values of a table
+func compareTables(before, after *Table) []RoundTripLoss {
+	var losses []RoundTripLoss
+	add := func(record int, field string, a, b any) {
+		losses = append(losses, RoundTripLoss{Table: before.Name, Record: record, Field: field, Before: a, After: b})
+	}
+
+	if before.Description != after.Description {
+		add(0, "Description", before.Description, after.Description)
+	}
+	for _, f := range before.Schema {
+		g, ok := after.Field(f.Name)
+		if !ok {
+			add(0, f.Name, f.Name, nil)
+			continue
+		}
+		for _, attr := range []struct {
+			name string
+			a, b any
+		}{
+			{"datatype", f.Datatype, g.Datatype},
+			{"type", f.Type, g.Type},
+			{"nullable", f.Nullable, g.Nullable},
+			{"Description", f.Description, g.Description},
+			{"formula", f.Formula, g.Formula},
+			{"visibility", f.Visibility, g.Visibility},
+			{"privacy", f.Privacy, g.Privacy},
+		} {
+			if attr.a != attr.b {
+				add(0, f.Name+"."+attr.name, attr.a, attr.b)
+			}
+		}
+	}
+	for _, g := range after.Schema {
+		if _, ok := before.Field(g.Name); !ok {
+			add(0, g.Name, nil, g.Name)
+		}
+	}
+
+	if len(before.Data) != len(after.Data) {
+		add(0, "(records)", len(before.Data), len(after.Data))
+	}
+	for i := 0; i < len(before.Data) && i < len(after.Data); i++ {
+		for _, f := range before.Schema {
+			a := canonicalRoundTripValue(f.Datatype, before.Data[i][f.Name])
+			b := canonicalRoundTripValue(f.Datatype, after.Data[i][f.Name])
+			if !reflect.DeepEqual(a, b) {
+				add(i+1, f.Name, a, b)
+			}
+		}
+	}
+	return losses
+}
+
+// CompareRoundTripRecords reports what differs between records and their reloaded copy
+func CompareRoundTripRecords(table string, before, after []Record) []RoundTripLoss {
+	var losses []RoundTripLoss
+	if len(before) != len(after) {
+		losses = append(losses, RoundTripLoss{Table: table, Field: "(records)", Before: len(before), After: len(after)})
+	}
+	for i := 0; i < len(before) && i < len(after); i++ {
+		keys := append([]string{}, before[i].Keys...)
+		for _, k := range after[i].Keys {
+			if !containsString(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			a := canonicalRoundTripValue("", before[i].Values[k])
+			b := canonicalRoundTripValue("", after[i].Values[k])
+			if !reflect.DeepEqual(a, b) {
+				losses = append(losses, RoundTripLoss{Table: table, Record: i + 1, Field: k, Before: a, After: b})
+			}
+		}
+	}
+	return losses
+}
+
+// canonicalRoundTripValue returns the canonical form of a value; values that
+// are not valid for the datatype are compared as they are
+func canonicalRoundTripValue(datatype string, v any) any {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			v = int(i)
+		} else if f, err := n.Float64(); err == nil {
+			v = f
+		}
+	}
+	if c, err := CanonicalValue(datatype, v); err == nil {
+		return c
+	}
+	return v
+}
+
+// sameJSON reports whether two JSON documents hold the same value; empty counts as null
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if len(bytes.TrimSpace(a)) > 0 && json.Unmarshal(a, &x) != nil {
+		return bytes.Equal(a, b)
+	}
+	if len(bytes.TrimSpace(b)) > 0 && json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// =============================================================================
+// ROUND TRIPS
+// =============================================================================
+
+// RoundTripRulebook loads the rulebook at path, saves it to dir in the same
+// format (JSON for YAML), reloads it and compares the two
+func RoundTripRulebook(ctx context.Context, path, dir string) (RulebookFormat, []RoundTripLoss, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, rulebookReadError(err)
+	}
+	format := DetectRulebookFormat(path, data)
+	before, err := loadRulebookData(ctx, path, data, nil)
+	if err != nil {
+		return format, nil, err
+	}
+
+	saved := filepath.Join(dir, "roundtrip.json")
+	switch format {
+	case FormatSQLite:
+		saved = filepath.Join(dir, "roundtrip.sqlite")
+		err = before.SaveSQLite(saved)
+	default:
+		var out []byte
+		if out, err = json.MarshalIndent(before, "", "  "); err == nil {
+			err = os.WriteFile(saved, out, 0644)
+		}
+	}
+	if err != nil {
+		return format, nil, fmt.Errorf("failed to save rulebook: %w", err)
+	}
+
+	after, err := LoadFromRulebookContext(ctx, saved)
+	if err != nil {
+		return format, nil, fmt.Errorf("failed to reload saved rulebook: %w", err)
+	}
+	return format, CompareRoundTripRulebooks(before, after), nil
+}
+
+// RoundTripRecords reads a record file of a table with the named importer,
+// writes it with the exporter of the same name, reads that back and
+// compares the two; both reads go through ImportRecords
+func RoundTripRecords(path, format, table string) ([]Record, []RoundTripLoss, error) {
+	imp, ok := LookupImporter(format)
+	if !ok {
+		return nil, nil, fmt.Errorf("no importer for format %q", format)
+	}
+	exp, ok := LookupExporter(format)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s files can be imported but not exported (exporters: %s)", format, strings.Join(ExportFormats(), ", "))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := imp.Read(f)
+	f.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	before, err := ImportRecords(table, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid records in %s:\n%w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := exp.Write(ExportViews{Table: table, Records: before}, &buf); err != nil {
+		return before, nil, fmt.Errorf("failed to write %s: %w", format, err)
+	}
+	raw, err = imp.Read(&buf)
+	if err != nil {
+		return before, nil, fmt.Errorf("failed to reread written %s: %w", format, err)
+	}
+	after, err := ImportRecords(table, raw)
+	if err != nil {
+		return before, nil, fmt.Errorf("invalid records in written %s:\n%w", format, err)
+	}
+	return before, CompareRoundTripRecords(table, before, after), nil
+}
+
+// isRecordFile reports whether path holds records rather than a rulebook:
+// a file an importer reads, except JSON objects (rulebooks)
+func isRecordFile(path string) bool {
+	imp, ok := ImporterForPath(path)
+	if !ok {
+		return false
+	}
+	if imp.Name() != "json" {
+		return true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// roundTripExtensions are the file extensions roundtrip recognizes, sorted:
+// the rulebook formats' and the importers'
+func roundTripExtensions() []string {
+	exts := []string{".json", ".yaml", ".yml", ".sqlite", ".sqlite3", ".db"}
+	for _, imp := range Importers() {
+		exts = append(exts, imp.Extensions()...)
+	}
+	slices.Sort(exts)
+	return slices.Compact(exts)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runRoundTrip implements `roundtrip FILE [--format NAME] [--table NAME]`
+func runRoundTrip(args []string) error {
+	fs := flag.NewFlagSet("roundtrip", flag.ContinueOnError)
+	format := fs.String("format", "", "record file format (default: from the file extension; rulebooks are detected)")
+	table := fs.String("table", "LanguageCandidates", "table a record file belongs to")
+
+	// FILE comes first; flags may follow it
+	var positional []string
+	rest := args
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: roundtrip FILE [--format NAME] [--table NAME]")
+	}
+	path := positional[0]
+
+	if e, ok := ExporterForPath(path); ok && *format == "" {
+		if _, ok := ImporterForPath(path); !ok {
+			return fmt.Errorf("%s files can be exported but not imported (importers: %s)", e.Name(), strings.Join(sortedKeys(importers), ", "))
+		}
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); *format == "" && !slices.Contains(roundTripExtensions(), ext) {
+		return fmt.Errorf("cannot tell the format of %s from its extension %q (supported: %s; pass --format for a record file)", path, ext, strings.Join(roundTripExtensions(), ", "))
+	}
+
+	var losses []RoundTripLoss
+	if *format != "" || isRecordFile(path) {
+		name := *format
+		if name == "" {
+			imp, _ := ImporterForPath(path)
+			name = imp.Name()
+		}
+		records, l, err := RoundTripRecords(path, name, *table)
+		if err != nil {
+			return err
+		}
+		losses = l
+		fmt.Printf("%s: %s records, %d %s records\n", path, name, len(records), *table)
+	} else {
+		dir, err := os.MkdirTemp("", "erb-roundtrip-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		format, l, err := RoundTripRulebook(commandContext(), path, dir)
+		if err != nil {
+			return err
+		}
+		losses = l
+		saved := format
+		if format == FormatYAML {
+			saved = FormatJSON
+		}
+		fmt.Printf("%s: %s rulebook, saved as %s\n", path, format, saved)
+	}
+
+	if len(losses) == 0 {
+		fmt.Println("No information lost")
+		return nil
+	}
+	for _, l := range losses {
+		fmt.Println("  " + l.String())
+	}
+	return fmt.Errorf("%d value(s) changed in the round trip", len(losses))
+}
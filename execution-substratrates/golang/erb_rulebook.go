@@ -0,0 +1,432 @@
+// ERB SDK - Rulebook Loader
+// =========================
+// Loads effortless-rulebook.json (schema + data for every table) and exposes
+// the LanguageCandidates and IsEverythingALanguage tables as SDK structs.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRulebookPath is the rulebook location relative to a substrate directory
+const DefaultRulebookPath = "../../effortless-rulebook/effortless-rulebook.json"
+
+// =============================================================================
+// RULEBOOK TYPES
+// =============================================================================
+
+// Field describes one column of a rulebook table schema
+type Field struct {
+	Name        string `json:"name"`
+	Datatype    string `json:"datatype"`
+	Type        string `json:"type"`
+	Nullable    bool   `json:"nullable"`
+	Description string `json:"Description,omitempty"`
+	Formula     string `json:"formula,omitempty"`
+	Visibility  string `json:"visibility,omitempty"`
+	Privacy     string `json:"privacy,omitempty"`
+
+	// Level is the calculated field's DAG level (1 = reads only raw fields); 0 for raw fields
+	Level int `json:"-"`
+}
+
+// IsCalculated reports whether the field is computed from a formula
+func (f Field) IsCalculated() bool {
+	return f.Type == "calculated" && f.Formula != ""
+}
+
+// Table is a rulebook table: its schema and its rows as authored (PascalCase keys)
+type Table struct {
+	Name        string           `json:"-"`
+	Description string           `json:"Description"`
+	Schema      []Field          `json:"schema"`
+	Data        []map[string]any `json:"data"`
+}
+
+// IDField returns the name of the table's primary key field (the first schema field)
+func (t *Table) IDField() string {
+	if len(t.Schema) == 0 {
+		return ""
+	}
+	return t.Schema[0].Name
+}
+
+// Field looks up a schema field by name
+func (t *Table) Field(name string) (Field, bool) {
+	for _, f := range t.Schema {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Rulebook is the parsed effortless-rulebook.json
+type Rulebook struct {
+	SchemaURI   string
+	ModelName   string
+	Description string
+	Meta        json.RawMessage
+
+	// Tables holds every table in rulebook order
+	Tables []*Table
+
+	LanguageCandidates    []LanguageCandidate
+	IsEverythingALanguage []IsEverythingALanguage
+
+	// Warnings are problems that do not stop loading, such as formulas
+	// referencing unknown fields (see CheckReferences)
+	Warnings []error
+
+	// relations indexes step -> candidate links (see erb_relations.go)
+	relations relationIndex
+}
+
+// Table looks up a table by name, returning nil if it does not exist
+func (rb *Rulebook) Table(name string) *Table {
+	for _, t := range rb.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// LOADING
+// =============================================================================
+
+// RulebookFormat identifies how a rulebook file is serialized
+type RulebookFormat string
+
+const (
+	FormatAuto   RulebookFormat = ""
+	FormatJSON   RulebookFormat = "json"
+	FormatYAML   RulebookFormat = "yaml"
+	FormatSQLite RulebookFormat = "sqlite" // a database written by SaveSQLite
+)
+
+// LoadOption configures LoadFromRulebook
+type LoadOption func(*loadConfig)
+
+type loadConfig struct {
+	format RulebookFormat
+	strict bool
+	logger *slog.Logger // see WithLogger
+
+	// LoadFromURL only
+	cacheDir   string
+	httpClient *http.Client
+}
+
+// WithFormat forces the rulebook format instead of detecting it from the file
+func WithFormat(format RulebookFormat) LoadOption {
+	return func(c *loadConfig) {
+		c.format = format
+	}
+}
+
+// WithStrictReferences fails loading when a formula references an unknown field,
+// instead of reporting it in Rulebook.Warnings
+func WithStrictReferences() LoadOption {
+	return func(c *loadConfig) {
+		c.strict = true
+	}
+}
+
+// LoadFromRulebook loads the rulebook file at path.
+// JSON, YAML (.yaml/.yml) and SQLite (see SaveSQLite) rulebooks are accepted.
+func LoadFromRulebook(path string, opts ...LoadOption) (*Rulebook, error) {
+	return LoadFromRulebookContext(context.Background(), path, opts...)
+}
+
+// LoadFromRulebookContext is LoadFromRulebook, giving up with ctx's error
+// once ctx is done: while the file is read, between tables while it is
+// parsed, and (for SQLite rulebooks) by killing sqlite3
+func LoadFromRulebookContext(ctx context.Context, path string, opts ...LoadOption) (*Rulebook, error) {
+	data, err := readFileContext(ctx, path)
+	if err != nil {
+		return nil, rulebookReadError(err)
+	}
+	return loadRulebookData(ctx, path, data, opts)
+}
+
+// rulebookReadError wraps a failure to read a rulebook file, as
+// ErrRulebookNotFound if the file does not exist
+func rulebookReadError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrRulebookNotFound, err)
+	}
+	return fmt.Errorf("failed to read rulebook: %w", err)
+}
+
+// readFileContext reads a file in chunks, stopping once ctx is done
+func readFileContext(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if info, err := f.Stat(); err == nil {
+		buf.Grow(int(info.Size()))
+	}
+	if _, err := buf.ReadFrom(contextReader{ctx, f}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// contextReader fails every Read with ctx's error once ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// LoadFromReader loads a rulebook from r (e.g. an HTTP response body).
+// The format is sniffed from the content unless WithFormat is given.
+func LoadFromReader(r io.Reader, opts ...LoadOption) (*Rulebook, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rulebook: %w", err)
+	}
+	return loadRulebookData(context.Background(), "", data, opts)
+}
+
+// LoadFromFS loads the named rulebook from a file system such as an embed.FS:
+//
+//	//go:embed effortless-rulebook.json
+//	var rulebookFS embed.FS
+//
+//	rb, err := LoadFromFS(rulebookFS, "effortless-rulebook.json")
+func LoadFromFS(fsys fs.FS, name string, opts ...LoadOption) (*Rulebook, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, rulebookReadError(err)
+	}
+	return loadRulebookData(context.Background(), name, data, opts)
+}
+
+func loadRulebookData(ctx context.Context, name string, data []byte, opts []LoadOption) (*Rulebook, error) {
+	cfg := loadConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	start := time.Now()
+
+	converted, err := rulebookJSON(ctx, name, data, cfg.format)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := parseRulebook(ctx, converted)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.strict && len(rb.Warnings) > 0 {
+		return nil, fmt.Errorf("rulebook has invalid formula references: %w", errors.Join(rb.Warnings...))
+	}
+	logRulebookLoaded(loggerOr(cfg.logger), name, rb, time.Since(start))
+	return rb, nil
+}
+
+// logRulebookLoaded logs a loaded rulebook's size and its warnings
+func logRulebookLoaded(l *slog.Logger, source string, rb *Rulebook, took time.Duration) {
+	records := 0
+	for _, t := range rb.Tables {
+		records += len(t.Data)
+		l.Debug("rulebook table", "source", source, "table", t.Name, "fields", len(t.Schema), "records", len(t.Data))
+	}
+	for _, w := range rb.Warnings {
+		l.Warn("rulebook warning", "source", source, "error", w)
+	}
+	l.Info("rulebook loaded", "source", source, "tables", len(rb.Tables), "records", records, "warnings", len(rb.Warnings), "duration", took)
+}
+
+// readRulebookJSON reads a rulebook file and returns it as JSON, converting YAML if needed
+func readRulebookJSON(path string, format RulebookFormat) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, rulebookReadError(err)
+	}
+	return rulebookJSON(context.Background(), path, data, format)
+}
+
+// rulebookJSON returns rulebook content as JSON; name (which may be empty) is used for format detection
+func rulebookJSON(ctx context.Context, name string, data []byte, format RulebookFormat) ([]byte, error) {
+	if format == FormatAuto {
+		format = DetectRulebookFormat(name, data)
+	}
+
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatYAML:
+		converted, err := yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML rulebook: %w", err)
+		}
+		return converted, nil
+	case FormatSQLite:
+		return sqliteRulebookJSON(ctx, data)
+	default:
+		return nil, fmt.Errorf("unsupported rulebook format %q", format)
+	}
+}
+
+// DetectRulebookFormat picks a format from the file extension (if any), falling back to sniffing the content
+func DetectRulebookFormat(path string, data []byte) RulebookFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	}
+	if isSQLitePath(path) || bytes.HasPrefix(data, []byte(sqliteMagic)) {
+		return FormatSQLite
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// ParseRulebook parses rulebook JSON, preserving the order of its tables; it fails
+// with a *CycleError if calculated fields depend on each other
+func ParseRulebook(data []byte) (*Rulebook, error) {
+	return parseRulebook(context.Background(), data)
+}
+
+// parseRulebook is ParseRulebook, checking ctx before each table
+func parseRulebook(ctx context.Context, data []byte) (*Rulebook, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("failed to parse rulebook: expected a JSON object")
+	}
+
+	rb := &Rulebook{}
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rulebook: %w", err)
+		}
+		key := tok.(string)
+
+		switch key {
+		case "$schema":
+			err = dec.Decode(&rb.SchemaURI)
+		case "model_name":
+			err = dec.Decode(&rb.ModelName)
+		case "Description":
+			err = dec.Decode(&rb.Description)
+		case "_meta":
+			err = dec.Decode(&rb.Meta)
+		default:
+			table := &Table{Name: key}
+			err = dec.Decode(table)
+			if err == nil {
+				rb.Tables = append(rb.Tables, table)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rulebook key %q: %w", key, err)
+		}
+	}
+
+	if err := rb.prepare(); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// prepare rejects dependency cycles, assigns DAG levels, collects reference
+// warnings and decodes the typed tables
+func (rb *Rulebook) prepare() error {
+	if err := rb.CheckCycles(); err != nil {
+		return err
+	}
+	for _, t := range rb.Tables {
+		levels := t.Levels()
+		for i := range t.Schema {
+			t.Schema[i].Level = levels[t.Schema[i].Name]
+		}
+	}
+	rb.Warnings = append(rb.CheckReferences(), rb.checkVisibility()...)
+	return rb.decodeTypedTables()
+}
+
+// decodeTypedTables fills the typed table slices from the generic table rows
+func (rb *Rulebook) decodeTypedTables() error {
+	if t := rb.Table("LanguageCandidates"); t != nil {
+		if err := decodeRows(t.Data, &rb.LanguageCandidates); err != nil {
+			return fmt.Errorf("failed to decode LanguageCandidates: %w", err)
+		}
+	}
+	if t := rb.Table("IsEverythingALanguage"); t != nil {
+		if err := decodeRows(t.Data, &rb.IsEverythingALanguage); err != nil {
+			return fmt.Errorf("failed to decode IsEverythingALanguage: %w", err)
+		}
+	}
+	rb.indexRelations()
+	return nil
+}
+
+// decodeRows converts PascalCase rulebook rows into structs with snake_case JSON tags
+func decodeRows(rows []map[string]any, out any) error {
+	converted := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		m := make(map[string]any, len(row))
+		for k, v := range row {
+			m[toSnakeCase(k)] = v
+		}
+		converted[i] = m
+	}
+
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return err
+	}
+	return decodeRecordArray(data, out, recordDecoding{})
+}
+
+// =============================================================================
+// NAMING HELPERS
+// =============================================================================
+
+var (
+	snakeWordBoundary = regexp.MustCompile(`(.)([A-Z][a-z]+)`)
+	snakeLowerToUpper = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// toSnakeCase converts a PascalCase field name to its snake_case JSON key
+// (mirrors to_snake_case in orchestration/formula_parser.py)
+func toSnakeCase(name string) string {
+	s := snakeWordBoundary.ReplaceAllString(name, "${1}_${2}")
+	s = snakeLowerToUpper.ReplaceAllString(s, "${1}_${2}")
+	return strings.ToLower(s)
+}
@@ -0,0 +1,190 @@
+// ERB SDK - Validation Rules
+// ==========================
+// Data rules beyond the schema: values that load and compute but make the
+// argument wrong or hard to read. Each rule has an ID and a severity, so CI
+// can fail on errors and report warnings (`validate` does, and
+// `validate --strict` fails on warnings too):
+//
+//	for _, issue := range rb.Validate() {
+//		fmt.Println(issue) // warning duplicate-sort-order: LanguageCandidates row 4 (english) SortOrder: ...
+//	}
+//
+// The schema check (Table.Validate) runs as the "schema" rule, and the
+// load-time warnings (Rulebook.Warnings) as "unknown-reference" and
+// "unknown-visibility". Rules are listed in ValidationRules; append to it
+// for project-specific checks.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validation severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue is one rule violation
+type ValidationIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Table    string `json:"table"`
+	Row      int    `json:"row,omitempty"` // 1-based; 0 for the whole table
+	ID       string `json:"id,omitempty"`  // primary key of the row
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	where := i.Table
+	if i.Row > 0 {
+		where += fmt.Sprintf(" row %d", i.Row)
+		if i.ID != "" {
+			where += " (" + i.ID + ")"
+		}
+	}
+	if i.Field != "" {
+		where += " " + i.Field
+	}
+	return fmt.Sprintf("%s %s: %s: %s", i.Severity, i.Rule, where, i.Message)
+}
+
+// ValidationRule is a named check over a loaded rulebook
+type ValidationRule struct {
+	ID          string
+	Severity    string
+	Description string
+	Check       func(rb *Rulebook) []ValidationIssue // Rule and Severity are filled in by Validate
+}
+
+// KnownCategories are the LanguageCandidates categories unknown-category accepts
+var KnownCategories = []string{
+	"Natural Language",
+	"Formal Language",
+	"Running Software",
+	"Physical Object",
+	"Physical event",
+	"MISSING: Have you seen this Language?",
+}
+
+// ValidationRules are the rules Validate runs, in order
+var ValidationRules = []ValidationRule{
+	{ID: "schema", Severity: SeverityError, Description: "values match the schema: known fields, required fields set, datatypes", Check: checkSchemaRule},
+	{ID: "unknown-reference", Severity: SeverityWarning, Description: "formula {{references}} name fields of their table (unknown ones read as null; see erb_dag.go)", Check: checkUnknownReferences},
+	{ID: "unknown-visibility", Severity: SeverityWarning, Description: "field visibility is public or internal (anything else is treated as public)", Check: checkUnknownVisibility},
+	{ID: "missing-name", Severity: SeverityError, Description: "every candidate and argument step has a Name", Check: checkMissingNames},
+	{ID: "dangling-related-candidate", Severity: SeverityError, Description: "RelatedCandidateId names an existing candidate", Check: checkRelatedCandidates},
+	{ID: "duplicate-name", Severity: SeverityWarning, Description: "no two candidates share a Name, ignoring case and whitespace (see erb_dedupe.go)", Check: checkDuplicateNames},
+	{ID: "duplicate-sort-order", Severity: SeverityWarning, Description: "no two candidates share a SortOrder", Check: checkDuplicateSortOrder},
+	{ID: "open-and-closed-world", Severity: SeverityWarning, Description: "a candidate is not both IsOpenWorld and IsClosedWorld", Check: checkOpenClosedWorld},
+	{ID: "unknown-category", Severity: SeverityWarning, Description: "Category is one of KnownCategories", Check: checkCategories},
+}
+
+// Validate runs every rule in ValidationRules and returns the issues, rule
+// by rule; each is also published on Events as an InvariantViolated
+func (rb *Rulebook) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	for _, rule := range ValidationRules {
+		for _, issue := range rule.Check(rb) {
+			issue.Rule, issue.Severity = rule.ID, rule.Severity
+			issues = append(issues, issue)
+			logValidationIssue(Logger, "", issue)
+			Events.Publish(InvariantViolated{Issue: issue})
+		}
+	}
+	return issues
+}
+
+// ValidationErrors counts the issues with error severity
+func ValidationErrors(issues []ValidationIssue) int {
+	n := 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// =============================================================================
+// RULES
+// =============================================================================
+
+func checkSchemaRule(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, t := range rb.Tables {
+		for _, err := range t.Validate() {
+			issues = append(issues, rowIssue(err.(*RowError)))
+		}
+	}
+	return issues
+}
+
+// rowIssue is a schema check failure as an issue of the "schema" rule
+func rowIssue(e *RowError) ValidationIssue {
+	return ValidationIssue{Rule: "schema", Severity: SeverityError, Table: e.Table, Row: e.Row, ID: e.ID, Field: e.Field, Message: e.Problem}
+}
+
+func checkMissingNames(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for i, lc := range rb.LanguageCandidates {
+		if strings.TrimSpace(optGet(lc.Name, "")) == "" {
+			issues = append(issues, ValidationIssue{Table: "LanguageCandidates", Row: i + 1, ID: lc.LanguageCandidateId, Field: "Name", Message: "is empty"})
+		}
+	}
+	for i, step := range rb.IsEverythingALanguage {
+		if strings.TrimSpace(optGet(step.Name, "")) == "" {
+			issues = append(issues, ValidationIssue{Table: "IsEverythingALanguage", Row: i + 1, ID: step.IsEverythingALanguageId, Field: "Name", Message: "is empty"})
+		}
+	}
+	return issues
+}
+
+func checkRelatedCandidates(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for i, step := range rb.IsEverythingALanguage {
+		if id := optGet(step.RelatedCandidateId, ""); id != "" && step.Candidate(rb) == nil {
+			issues = append(issues, ValidationIssue{Table: "IsEverythingALanguage", Row: i + 1, ID: step.IsEverythingALanguageId, Field: "RelatedCandidateId", Message: fmt.Sprintf("%q is not a LanguageCandidateId", id)})
+		}
+	}
+	return issues
+}
+
+func checkDuplicateSortOrder(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	first := map[int]string{}
+	for i, lc := range rb.LanguageCandidates {
+		if lc.SortOrder == nil {
+			continue
+		}
+		if other, dup := first[*lc.SortOrder]; dup {
+			issues = append(issues, ValidationIssue{Table: "LanguageCandidates", Row: i + 1, ID: lc.LanguageCandidateId, Field: "SortOrder", Message: fmt.Sprintf("%d is also the SortOrder of %s", *lc.SortOrder, other)})
+			continue
+		}
+		first[*lc.SortOrder] = lc.LanguageCandidateId
+	}
+	return issues
+}
+
+func checkOpenClosedWorld(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for i, lc := range rb.LanguageCandidates {
+		if optGet(lc.IsOpenWorld, false) && optGet(lc.IsClosedWorld, false) {
+			issues = append(issues, ValidationIssue{Table: "LanguageCandidates", Row: i + 1, ID: lc.LanguageCandidateId, Field: "IsOpenWorld", Message: "IsOpenWorld and IsClosedWorld are both true"})
+		}
+	}
+	return issues
+}
+
+func checkCategories(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for i, lc := range rb.LanguageCandidates {
+		if c := optGet(lc.Category, ""); c != "" && !containsString(KnownCategories, c) {
+			issues = append(issues, ValidationIssue{Table: "LanguageCandidates", Row: i + 1, ID: lc.LanguageCandidateId, Field: "Category", Message: fmt.Sprintf("%q is not a known category", c)})
+		}
+	}
+	return issues
+}
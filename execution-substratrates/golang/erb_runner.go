@@ -0,0 +1,125 @@
+// ERB SDK - Conformance Runner (GENERATED - DO NOT EDIT)
+// =====================================================
+// Rendered by inject-into-golang.py or `gen` from runner.go.tmpl with one
+// entry per table that has calculated fields. Change the template or the
+// generator's runner options, not this file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Default runner locations, relative to the substrate directory
+const (
+	DefaultTestingDir = "../../testing"
+	DefaultAnswersDir = "."
+)
+
+// RunnerTable is one table in the conformance flow: its blank test is read
+// from Input (under the testing directory) and its computed answers are
+// written to Output (under the answers directory)
+type RunnerTable struct {
+	Table    string
+	Input    string
+	Output   string
+	Suffix   string            // inserted before the extension of --outputs paths ("" for the primary table)
+	Required bool              // a missing Input fails the run; otherwise the table is skipped
+	Formulas map[string]string // calculated field -> formula, for ComputeStats
+	compute  func(data []byte, opts []RecordOption) ([]Record, error)
+}
+
+// RunnerTables lists the tables take-test computes, in rulebook order
+var RunnerTables = []RunnerTable{
+	{
+		Table:    "LanguageCandidates",
+		Input:    "blank-test.json",
+		Output:   "test-answers.json",
+		Suffix:   "",
+		Required: true,
+		Formulas: LanguageCandidateFormulas,
+		compute: func(data []byte, opts []RecordOption) ([]Record, error) {
+			return computeRecords(data, (*LanguageCandidate).ComputeAll, opts)
+		},
+	},
+}
+
+// RunConformance computes the answers for every table in RunnerTables once
+// and writes them to each output target; with no targets, JSON answers are
+// written to each table's Output under answersDir. opts apply to loading
+// and computing every blank test (e.g. WithStrictFields, WithWorkers).
+func RunConformance(testingDir, answersDir string, outputs []OutputTarget, opts ...RecordOption) error {
+	for _, t := range RunnerTables {
+		input := filepath.Join(testingDir, t.Input)
+		if _, err := os.Stat(input); errors.Is(err, fs.ErrNotExist) && !t.Required {
+			fmt.Printf("Golang substrate: No blank test for %s (%s), skipping\n", t.Table, input)
+			continue
+		}
+
+		ctx, span := startSpan(commandContext(), "erb.take_test", slog.String("erb.table", t.Table))
+		err := t.run(ctx, input, answersDir, outputs, opts)
+		endSpan(span, err)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.Table, err)
+		}
+	}
+	return nil
+}
+
+// run computes the table's blank test at input and writes the answers, in
+// spans under ctx
+func (t RunnerTable) run(ctx context.Context, input, answersDir string, outputs []OutputTarget, opts []RecordOption) error {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to load blank test: %w", err)
+	}
+	records, err := t.compute(data, append(opts, WithTraceContext(ctx)))
+	if err != nil {
+		return err
+	}
+
+	var targets []OutputTarget
+	for _, o := range outputs {
+		targets = append(targets, o.WithSuffix(t.Suffix))
+	}
+	if len(targets) == 0 {
+		targets = []OutputTarget{{Format: "json", Path: filepath.Join(answersDir, t.Output)}}
+	}
+	for _, target := range targets {
+		if err := writeOutputViews(ctx, target, ExportViews{Table: t.Table, Records: records}); err != nil {
+			return err
+		}
+		fmt.Printf("Golang substrate: Computed %d %s records, saved %s results to %s\n", len(records), t.Table, target.Format, target.Path)
+	}
+	for _, s := range ComputeStats(records, t.Formulas) {
+		fmt.Printf("Golang substrate: %s.%s\n", t.Table, s)
+	}
+	return nil
+}
+
+// computeRecords decodes a JSON array of records and computes their
+// calculated fields on WithWorkers goroutines, until the command's context
+// (see commandContext), or one given in opts, is done; records skipped by
+// WithPartialLoad are reported on stderr
+func computeRecords[T any](data []byte, compute func(*T) *T, opts []RecordOption) ([]Record, error) {
+	records, err := decodeRecords[T](data, opts)
+	var skipped *MultiError
+	if errors.As(err, &skipped) {
+		for _, e := range skipped.Errors {
+			fmt.Fprintf(os.Stderr, "Golang substrate: skipped %v\n", e)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse records: %w", err)
+	}
+	computed, err := computeAllParallel(records, compute, append([]RecordOption{withContext(commandContext())}, opts...))
+	if err != nil {
+		return nil, err
+	}
+	return RecordsOf(computed), nil
+}
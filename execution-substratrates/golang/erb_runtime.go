@@ -0,0 +1,383 @@
+// ERB SDK - Runtime Tables
+// ========================
+// The generated structs cover this repo's own tables, but nothing else in the
+// engine depends on them: formulas are parsed and evaluated at runtime in DAG
+// level order, so any rulebook (loaded from a file or built in code) can be
+// computed, validated and exported the same way:
+//
+//	rb := NewRulebook("Orders")
+//	orders := rb.AddTable("Orders",
+//		RawField("OrderId", "string"),
+//		RawField("Customer", "string"),
+//		RawField("Quantity", "integer"),
+//		CalculatedField("IsBulk", "boolean", "={{Quantity}} >= 100"),
+//		CalculatedField("Label", "string", `={{Customer}} & IF({{IsBulk}}, " (bulk)")`),
+//	)
+//	orders.AddRows(map[string]any{"OrderId": "o-1", "Customer": "Acme", "Quantity": 150})
+//	if err := rb.Prepare(); err != nil { ... }
+//	errs := orders.Validate()
+//	views, err := rb.TableViews("Orders", false)
+//	exporter, _ := LookupExporter("json")
+//	err = exporter.Write(views, os.Stdout)
+//
+// Like the rest of the substrate this is package main, not an importable
+// package: code defining its own tables lives in this directory, next to
+// the CLI, or copies the engine files.
+//
+// LanguageCandidates and IsEverythingALanguage keep using the generated
+// ComputeAll; every other table is computed by Table.Compute, which follows
+// the generated code's conventions (nil inputs read as false / "" / absent,
+// empty string and zero results are stored as nil).
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+)
+
+// =============================================================================
+// BUILDING RULEBOOKS
+// =============================================================================
+
+// NewRulebook returns an empty rulebook for tables defined in code
+func NewRulebook(modelName string) *Rulebook {
+	return &Rulebook{ModelName: modelName}
+}
+
+// AddTable appends a table with the given schema (the first field is its
+// primary key) and returns it for adding rows
+func (rb *Rulebook) AddTable(name string, fields ...Field) *Table {
+	t := &Table{Name: name, Description: "Table: " + name, Schema: fields}
+	rb.Tables = append(rb.Tables, t)
+	return t
+}
+
+// RawField returns a nullable raw (authored) schema field
+func RawField(name, datatype string) Field {
+	return Field{Name: name, Datatype: datatype, Type: "raw", Nullable: true}
+}
+
+// CalculatedField returns a schema field computed from a formula
+func CalculatedField(name, datatype, formula string) Field {
+	return Field{Name: name, Datatype: datatype, Type: "calculated", Nullable: true, Formula: formula}
+}
+
+// AddRows appends rows keyed by schema field name (PascalCase)
+func (t *Table) AddRows(rows ...map[string]any) {
+	t.Data = append(t.Data, rows...)
+}
+
+// Prepare checks a rulebook built in code the way loading checks a file:
+// duplicate tables and dependency cycles are errors, unknown references and
+// formulas that do not parse become Warnings, and DAG levels are assigned
+func (rb *Rulebook) Prepare() error {
+	seen := map[string]bool{}
+	for _, t := range rb.Tables {
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate table %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	if err := rb.prepare(); err != nil {
+		return err
+	}
+	for _, t := range rb.Tables {
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				if _, err := ParseFormula(f.Formula); err != nil {
+					rb.Warnings = append(rb.Warnings, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// COMPUTING
+// =============================================================================
+
+// Compute returns every row with its calculated fields evaluated, as records
+// with snake_case keys in schema order
+func (t *Table) Compute() ([]Record, error) {
+	c, err := t.compiler()
+	if err != nil {
+		return nil, err
+	}
+	if Logger.Enabled(commandContext(), slog.LevelDebug) {
+		c.fieldTimes = map[string]time.Duration{}
+	}
+	records, took, err := c.computeRows()
+	if err != nil {
+		return nil, err
+	}
+	levelTimes := map[int]time.Duration{}
+	for name, d := range c.fieldTimes {
+		levelTimes[c.levels[name]] += d
+	}
+	for level := 1; level <= len(levelTimes); level++ {
+		Logger.Debug("computed level", "table", t.Name, "level", level, "duration", levelTimes[level])
+	}
+	Logger.Info("records computed", "table", t.Name, "records", len(records), "duration", took)
+	Events.Publish(ComputeCompleted{Table: t.Name, Records: len(records), Duration: took})
+	return records, nil
+}
+
+// ComputeRow evaluates the calculated fields of one row keyed by schema field name
+func (t *Table) ComputeRow(row map[string]any) (Record, error) {
+	c, err := t.compiler()
+	if err != nil {
+		return Record{}, err
+	}
+	return c.compute(row)
+}
+
+// tableCompiler holds a table's parsed formulas in evaluation order
+type tableCompiler struct {
+	table    *Table
+	order    []Field // calculated fields by DAG level, then schema order
+	formulas map[string]FormulaNode
+	levels   map[string]int
+
+	// fieldTimes, when not nil, sums the time spent evaluating each field
+	fieldTimes map[string]time.Duration
+}
+
+func (t *Table) compiler() (*tableCompiler, error) {
+	if cycle := t.DependencyCycle(); cycle != nil {
+		return nil, &CycleError{Table: t.Name, Fields: cycle}
+	}
+	levels := t.Levels()
+	c := &tableCompiler{table: t, formulas: map[string]FormulaNode{}, levels: levels}
+	for level := 1; len(c.formulas) < len(levels); level++ {
+		for _, f := range t.Schema {
+			if f.IsCalculated() && levels[f.Name] == level {
+				ast, err := ParseFormula(f.Formula)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse formula for %s.%s: %w", t.Name, f.Name, err)
+				}
+				c.order = append(c.order, f)
+				c.formulas[f.Name] = ast
+			}
+		}
+	}
+	return c, nil
+}
+
+// computeRows computes every row of the table and returns how long it took
+func (c *tableCompiler) computeRows() ([]Record, time.Duration, error) {
+	start := time.Now()
+	records := make([]Record, len(c.table.Data))
+	for i, row := range c.table.Data {
+		var err error
+		if records[i], err = c.compute(row); err != nil {
+			return nil, 0, fmt.Errorf("%s row %d: %w", c.table.Name, i+1, err)
+		}
+	}
+	return records, time.Since(start), nil
+}
+
+func (c *tableCompiler) compute(row map[string]any) (Record, error) {
+	values := make(map[string]any, len(c.table.Schema))
+	for _, f := range c.table.Schema {
+		if !f.IsCalculated() {
+			values[f.Name] = runtimeValue(row[f.Name])
+		}
+	}
+
+	eval := &FormulaEvaluator{Lookup: func(name string) any { return values[name] }}
+	for _, f := range c.order {
+		var started time.Time
+		if c.fieldTimes != nil {
+			started = time.Now()
+		}
+		v, err := eval.Eval(c.formulas[f.Name])
+		if c.fieldTimes != nil {
+			c.fieldTimes[f.Name] += time.Since(started)
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("failed to evaluate %s: %w", f.Name, err)
+		}
+		if v == "" || v == 0 {
+			v = nil // like optNilIfZero in the generated ComputeAll
+		}
+		values[f.Name] = v
+	}
+
+	rec := Record{Values: make(map[string]any, len(values))}
+	for _, f := range c.table.Schema {
+		key := toSnakeCase(f.Name)
+		rec.Keys = append(rec.Keys, key)
+		rec.Values[key] = values[f.Name]
+	}
+	return rec, nil
+}
+
+// runtimeValue converts a rulebook value to the evaluator's bool/int/string,
+// keeping fractional numbers as float64
+func runtimeValue(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := x.Float64()
+		return f
+	case float64:
+		if x == math.Trunc(x) {
+			return int(x)
+		}
+	case int64:
+		return int(x)
+	}
+	return v
+}
+
+// =============================================================================
+// VALIDATION
+// =============================================================================
+
+// RowError is a raw value that does not match its schema field
+type RowError struct {
+	Table   string
+	Row     int    // 1-based
+	ID      string // primary key value, if any
+	Field   string
+	Problem string
+}
+
+func (e *RowError) Error() string {
+	row := fmt.Sprintf("row %d", e.Row)
+	if e.ID != "" {
+		row += " (" + e.ID + ")"
+	}
+	return fmt.Sprintf("%s %s: %s %s", e.Table, row, e.Field, e.Problem)
+}
+
+// Validate returns a *RowError for every unknown field, missing non-nullable
+// raw field, or value (raw or stored calculated) whose type does not match
+// the field's datatype
+func (t *Table) Validate() []error {
+	var problems []error
+	for i, row := range t.Data {
+		id := ""
+		if v := row[t.IDField()]; v != nil {
+			id = fmt.Sprint(v)
+		}
+		report := func(field, problem string) {
+			problems = append(problems, &RowError{Table: t.Name, Row: i + 1, ID: id, Field: field, Problem: problem})
+		}
+
+		for _, key := range sortedKeys(row) {
+			if _, ok := t.Field(key); !ok {
+				report(key, "is not in the schema")
+			}
+		}
+		for _, f := range t.Schema {
+			switch v := row[f.Name]; {
+			case v == nil && !f.Nullable && !f.IsCalculated():
+				report(f.Name, "is required")
+			case v != nil && !datatypeMatches(f.Datatype, runtimeValue(v)):
+				report(f.Name, fmt.Sprintf("must be %s, got %v", f.Datatype, v))
+			}
+		}
+	}
+	return problems
+}
+
+// datatypeMatches reports whether a runtime value fits a schema datatype;
+// unknown datatypes accept anything
+func datatypeMatches(datatype string, v any) bool {
+	switch datatype {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		_, ok := v.(int)
+		return ok
+	case "number":
+		switch v.(type) {
+		case int, float64:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	}
+	return true
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runValidate implements `validate [--rulebook PATH] [--table T] [--strict]
+// [--json]`: runs the validation rules (see erb_rules.go), including the
+// schema check, and checks that every table computes. Errors fail the
+// command; warnings only with --strict.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	table := fs.String("table", "", "table to validate (default: all)")
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	asJSON := fs.Bool("json", false, "print the issues as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// rb.Warnings are reported as unknown-reference and unknown-visibility issues
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+
+	issues := []ValidationIssue{}
+	for _, issue := range rb.Validate() {
+		if *table == "" || issue.Table == *table {
+			issues = append(issues, issue)
+		}
+	}
+	for _, t := range rb.Tables {
+		if *table != "" && t.Name != *table {
+			continue
+		}
+		if _, err := t.Compute(); err != nil {
+			issues = append(issues, ValidationIssue{Rule: "compute", Severity: SeverityError, Table: t.Name, Message: err.Error()})
+		}
+	}
+	errors := ValidationErrors(issues)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, t := range rb.Tables {
+			if *table != "" && t.Name != *table {
+				continue
+			}
+			n := 0
+			for _, issue := range issues {
+				if issue.Table == t.Name {
+					fmt.Println(issue)
+					n++
+				}
+			}
+			fmt.Printf("%s: %d rows, %d problems\n", t.Name, len(t.Data), n)
+		}
+		fmt.Printf("%d errors, %d warnings\n", errors, len(issues)-errors)
+	}
+	if errors > 0 || (*strict && len(issues) > 0) {
+		return fmt.Errorf("%d problems found", len(issues))
+	}
+	return nil
+}
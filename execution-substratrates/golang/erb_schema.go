@@ -0,0 +1,187 @@
+// ERB SDK - Schema Introspection
+// ==============================
+// Schema() describes a rulebook's tables and fields as plain values (names,
+// datatypes, nullability, raw or calculated, formula text, DAG level and
+// dependencies), so UIs, validators and doc generators can work from the
+// loaded rulebook instead of parsing its JSON. The result is a copy:
+// changing it does not change the rulebook.
+//
+//	for _, t := range rb.Schema().Tables {
+//		for _, f := range t.Fields {
+//			fmt.Println(t.Name, f.Name, f.Datatype, f.Calculated, f.Formula)
+//		}
+//	}
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// SchemaInfo describes every table of a rulebook, in rulebook order
+type SchemaInfo struct {
+	ModelName string        `json:"model_name,omitempty"`
+	Tables    []TableSchema `json:"tables"`
+}
+
+// TableSchema describes a table
+type TableSchema struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	IDField     string        `json:"id_field"`
+	Records     int           `json:"records"`
+	Fields      []FieldSchema `json:"fields"` // in schema order
+}
+
+// FieldSchema describes a field
+type FieldSchema struct {
+	Name         string   `json:"name"`
+	Datatype     string   `json:"datatype"`
+	Nullable     bool     `json:"nullable"`
+	Calculated   bool     `json:"calculated"`
+	Formula      string   `json:"formula,omitempty"`
+	Level        int      `json:"level,omitempty"`        // DAG level of a calculated field
+	Dependencies []string `json:"dependencies,omitempty"` // fields the formula reads
+	Description  string   `json:"description,omitempty"`
+	Visibility   string   `json:"visibility,omitempty"`
+}
+
+// Schema describes the rulebook's tables and fields
+func (rb *Rulebook) Schema() SchemaInfo {
+	s := SchemaInfo{ModelName: rb.ModelName, Tables: []TableSchema{}}
+	for _, t := range rb.Tables {
+		ts := TableSchema{Name: t.Name, Description: t.Description, IDField: t.IDField(), Records: len(t.Data), Fields: []FieldSchema{}}
+		for _, f := range t.Schema {
+			fs := FieldSchema{
+				Name:        f.Name,
+				Datatype:    f.Datatype,
+				Nullable:    f.Nullable,
+				Calculated:  f.IsCalculated(),
+				Formula:     f.Formula,
+				Description: f.Description,
+				Visibility:  f.Visibility,
+			}
+			if fs.Calculated {
+				fs.Level = f.Level
+				fs.Dependencies = FormulaDependencies(f.Formula)
+			}
+			ts.Fields = append(ts.Fields, fs)
+		}
+		s.Tables = append(s.Tables, ts)
+	}
+	return s
+}
+
+// Table looks up a table by name
+func (s SchemaInfo) Table(name string) (TableSchema, bool) {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return TableSchema{}, false
+}
+
+// Field looks up a field by name
+func (t TableSchema) Field(name string) (FieldSchema, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FieldSchema{}, false
+}
+
+// Raw returns the fields that are not calculated
+func (t TableSchema) Raw() []FieldSchema {
+	var raw []FieldSchema
+	for _, f := range t.Fields {
+		if !f.Calculated {
+			raw = append(raw, f)
+		}
+	}
+	return raw
+}
+
+// Calculated returns the calculated fields
+func (t TableSchema) Calculated() []FieldSchema {
+	var calculated []FieldSchema
+	for _, f := range t.Fields {
+		if f.Calculated {
+			calculated = append(calculated, f)
+		}
+	}
+	return calculated
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runSchemaShow implements `schema show [TABLE] [--rulebook PATH] [--json]`
+func runSchemaShow(args []string) error {
+	fs := flag.NewFlagSet("schema show", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	asJSON := fs.Bool("json", false, "print the schema as JSON")
+
+	// TABLE comes first; flags may follow it
+	var positional []string
+	rest := args
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) > 1 {
+		return fmt.Errorf("usage: schema show [TABLE] [--rulebook PATH] [--json]")
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	schema := rb.Schema()
+	if len(positional) == 1 {
+		t, ok := schema.Table(positional[0])
+		if !ok {
+			return unknownTable(positional[0])
+		}
+		schema.Tables = []TableSchema{t}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(schema)
+	}
+	for i, t := range schema.Tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%d records, ID %s)\n", t.Name, t.Records, t.IDField)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, f := range t.Fields {
+			kind, nullable := "raw", "required"
+			if f.Calculated {
+				kind = fmt.Sprintf("calculated (level %d): %s", f.Level, oneLine(f.Formula))
+			}
+			if f.Nullable {
+				nullable = "nullable"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", f.Name, f.Datatype, nullable, kind)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+// ERB SDK - Schema Editing
+// ========================
+// `schema add-field` and `schema add-calc` add a field to a table's schema in
+// the rulebook file:
+//
+//	schema add-field LanguageCandidates is_performative --type bool
+//	schema add-calc LanguageCandidates IsPerformativeLanguage --type bool \
+//	    --formula "=AND({{IsPerformative}}, {{TopFamilyFeudAnswer}})" --regenerate
+//
+// The field is checked against the loaded rulebook first (unique name, known
+// datatype, a formula that parses, returns the field's datatype, references
+// existing fields and does not close a cycle) and its DAG level is reported. The file is edited in place:
+// the new field definition is inserted after the table's last schema field
+// and the rest of the file is left byte for byte as it was, so the change
+// reviews as a small diff. Data rows are not touched.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// fieldDatatypes maps the accepted --type spellings to rulebook datatypes
+var fieldDatatypes = map[string]string{
+	"bool": "boolean", "boolean": "boolean",
+	"int": "integer", "integer": "integer",
+	"string": "string", "text": "string",
+}
+
+// toPascalCase converts a snake_case name to PascalCase (mirrors
+// to_pascal_case in orchestration/formula_parser.py); names without
+// underscores only get their first letter capitalized
+func toPascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// AddField adds a field to a table's schema after checking it against the
+// rest of the rulebook, and assigns DAG levels. Only the in-memory rulebook
+// changes; see InsertSchemaField for the file.
+func (rb *Rulebook) AddField(table string, field Field) error {
+	t := rb.Table(table)
+	if t == nil {
+		return unknownTable(table)
+	}
+	for _, f := range t.Schema {
+		if toSnakeCase(f.Name) == toSnakeCase(field.Name) {
+			return fmt.Errorf("%s already has a field %s", table, f.Name)
+		}
+	}
+	if !isDatatype(field.Datatype) {
+		return fmt.Errorf("unknown datatype %q (want boolean, integer or string)", field.Datatype)
+	}
+	if field.Type == "calculated" {
+		if field.Formula == "" {
+			return fmt.Errorf("calculated field %s needs a formula", field.Name)
+		}
+		ast, err := ParseFormula(field.Formula)
+		if err != nil {
+			return fmt.Errorf("invalid formula for %s: %w", field.Name, err)
+		}
+		types := map[string]string{}
+		for _, f := range t.Schema {
+			types[f.Name] = f.Datatype
+		}
+		if got := FormulaType(ast, types); got != "" && got != field.Datatype {
+			return fmt.Errorf("%s is %s but its formula returns %s", field.Name, field.Datatype, got)
+		}
+	}
+
+	t.Schema = append(t.Schema, field)
+	undo := func() { t.Schema = t.Schema[:len(t.Schema)-1] }
+	if cycle := t.DependencyCycle(); cycle != nil {
+		undo()
+		return &CycleError{Table: table, Fields: cycle}
+	}
+	for _, err := range rb.CheckReferences() {
+		if ref, ok := err.(*UnknownReference); ok && ref.Table == table && ref.Field == field.Name {
+			undo()
+			return err
+		}
+	}
+
+	levels := t.Levels()
+	for i := range t.Schema {
+		t.Schema[i].Level = levels[t.Schema[i].Name]
+	}
+	return nil
+}
+
+func isDatatype(datatype string) bool {
+	for _, dt := range fieldDatatypes {
+		if dt == datatype {
+			return true
+		}
+	}
+	return false
+}
+
+// InsertSchemaField returns a JSON rulebook with field appended to the
+// table's schema array. Only the inserted text differs from data; its
+// indentation copies the schema's existing entries.
+func InsertSchemaField(data []byte, table string, field Field) ([]byte, error) {
+	return appendTableEntry(data, table, "schema", field)
+}
+
+// appendTableEntry returns a JSON rulebook with entry appended to one of a
+// table's arrays ("schema" or "data"), indented like the existing entries
+func appendTableEntry(data []byte, table, key string, entry any) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	if err := seekKey(dec, table); err != nil {
+		return nil, err
+	}
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	if err := seekKey(dec, key); err != nil {
+		return nil, fmt.Errorf("table %s: %w", table, err)
+	}
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+
+	// Walk the entries, remembering where the last one starts and ends
+	start, end := -1, int(dec.InputOffset())
+	for dec.More() {
+		start = end + len(data[end:]) - len(bytes.TrimLeft(data[end:], ", \t\r\n"))
+		var existing json.RawMessage
+		if err := dec.Decode(&existing); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s: %w", table, key, err)
+		}
+		end = int(dec.InputOffset())
+	}
+
+	indent := "  "
+	if start >= 0 {
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		indent = string(data[lineStart:start])
+	}
+	encoded, err := json.MarshalIndent(entry, indent, "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:end])
+	if start >= 0 {
+		out.WriteByte(',')
+	}
+	out.WriteString("\n" + indent)
+	out.Write(encoded)
+	out.Write(data[end:])
+	return out.Bytes(), nil
+}
+
+// expectDelim reads the next token and checks it is the given delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse rulebook: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("failed to parse rulebook: expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// seekKey advances an object decoder to the value of key
+func seekKey(dec *json.Decoder, key string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse rulebook: %w", err)
+		}
+		if tok == key {
+			return nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return fmt.Errorf("failed to parse rulebook: %w", err)
+		}
+	}
+	return fmt.Errorf("no %q key", key)
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runSchema implements `schema add-field|add-calc TABLE FIELD --type T [...]`
+// and `schema show` (see erb_schema.go)
+func runSchema(args []string) error {
+	const usage = "usage: schema add-field|add-calc TABLE FIELD --type T [--formula F] [flags] | schema show [TABLE] [--json]"
+	if len(args) > 0 && args[0] == "show" {
+		return runSchemaShow(args[1:])
+	}
+	if len(args) == 0 || (args[0] != "add-field" && args[0] != "add-calc") {
+		return fmt.Errorf(usage)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("schema "+action, flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the JSON rulebook to edit")
+	datatype := fs.String("type", "", "datatype: bool, int or string")
+	formula := fs.String("formula", "", "add-calc: the formula, e.g. \"=AND({{HasSyntax}}, {{RequiresParsing}})\"")
+	description := fs.String("description", "", "field description")
+	required := fs.Bool("required", false, "add-field: the field is not nullable")
+	internal := fs.Bool("internal", false, `mark the field "visibility": "internal" (computed, but not published or served)`)
+	dryRun := fs.Bool("dry-run", false, "print the field definition instead of editing the rulebook")
+	regenerate := fs.Bool("regenerate", false, "run inject-into-golang.py after editing")
+
+	// TABLE and FIELD come first; flags may follow them
+	var positional []string
+	rest := args[1:]
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 2 || *datatype == "" {
+		return fmt.Errorf(usage)
+	}
+	if *regenerate && *rulebookPath != DefaultRulebookPath {
+		return fmt.Errorf("--regenerate only works with the default rulebook, which is what inject-into-golang.py reads")
+	}
+	table := positional[0]
+
+	field := Field{Name: toPascalCase(positional[1]), Datatype: fieldDatatypes[*datatype], Type: "raw", Nullable: !*required, Description: *description}
+	if *internal {
+		field.Visibility = VisibilityInternal
+	}
+	if field.Datatype == "" {
+		return fmt.Errorf("unknown --type %q (want bool, int or string)", *datatype)
+	}
+	if action == "add-calc" {
+		field.Type, field.Formula, field.Nullable = "calculated", *formula, true
+	} else if *formula != "" {
+		return fmt.Errorf("--formula is for add-calc")
+	}
+
+	data, err := os.ReadFile(*rulebookPath)
+	if err != nil {
+		return rulebookReadError(err)
+	}
+	if DetectRulebookFormat(*rulebookPath, data) != FormatJSON {
+		return fmt.Errorf("schema editing needs a JSON rulebook")
+	}
+	rb, err := ParseRulebook(data)
+	if err != nil {
+		return err
+	}
+	if err := rb.AddField(table, field); err != nil {
+		return err
+	}
+	if f, _ := rb.Table(table).Field(field.Name); f.IsCalculated() {
+		fmt.Printf("%s.%s: DAG level %d\n", table, f.Name, f.Level)
+	}
+
+	if *dryRun {
+		entry, err := json.MarshalIndent(field, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(entry))
+		return nil
+	}
+
+	edited, err := InsertSchemaField(data, table, field)
+	if err != nil {
+		return err
+	}
+	if _, err := ParseRulebook(edited); err != nil {
+		return fmt.Errorf("edited rulebook does not load: %w", err)
+	}
+	if err := os.WriteFile(*rulebookPath, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write rulebook: %w", err)
+	}
+	fmt.Printf("Added %s field %s.%s to %s\n", field.Type, table, field.Name, *rulebookPath)
+
+	if *regenerate {
+		cmd := exec.Command("python3", "inject-into-golang.py")
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("regeneration failed: %w", err)
+		}
+	}
+	return nil
+}
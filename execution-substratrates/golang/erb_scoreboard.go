@@ -0,0 +1,254 @@
+// ERB SDK - Family Feud Scoreboard
+// ================================
+// Aggregate statistics over the computed LanguageCandidates: how many pass
+// the Family Feud test, how often that answer agrees with the curated
+// ChosenLanguageCandidate flag, and the same counts per Category and per
+// DistanceFromConcept:
+//
+//	sb := rb.Scoreboard()
+//	fmt.Printf("%d/%d top answers, %.0f%% agreement\n", sb.TopAnswers, sb.Candidates, 100*sb.AgreementRate)
+//
+//	scoreboard                     a text summary
+//	scoreboard --format csv        one row per group (all, each category, each distance)
+//	scoreboard --out scores.json   the Scoreboard as JSON
+//	curl localhost:8080/scoreboard
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ScoreCounts are the Family Feud counts over a group of candidates
+type ScoreCounts struct {
+	Candidates int `json:"candidates"`
+	TopAnswers int `json:"top_answers"` // TopFamilyFeudAnswer set
+	Chosen     int `json:"chosen"`      // ChosenLanguageCandidate set
+	Agreements int `json:"agreements"`  // TopFamilyFeudAnswer equals ChosenLanguageCandidate
+
+	// AgreementRate is Agreements / Candidates (1 for no candidates)
+	AgreementRate float64 `json:"agreement_rate"`
+}
+
+// CategoryScore is the counts of the candidates in one Category
+type CategoryScore struct {
+	Category string `json:"category"` // "" for candidates without one
+	ScoreCounts
+}
+
+// DistanceScore is the counts of the candidates at one DistanceFromConcept
+type DistanceScore struct {
+	Distance *int `json:"distance"` // nil for candidates without one
+	ScoreCounts
+}
+
+// Scoreboard summarizes the Family Feud answers across the candidates
+type Scoreboard struct {
+	ScoreCounts
+	Categories []CategoryScore `json:"categories"` // by category name
+	Distances  []DistanceScore `json:"distances"`  // by distance, unset last
+}
+
+// Scoreboard computes the scoreboard of the rulebook's candidates
+func (rb *Rulebook) Scoreboard() *Scoreboard {
+	return NewScoreboard(rb.CandidateViews())
+}
+
+// NewScoreboard computes the scoreboard of computed candidate views
+func NewScoreboard(views []LanguageCandidateView) *Scoreboard {
+	sb := &Scoreboard{Categories: []CategoryScore{}, Distances: []DistanceScore{}}
+	categories := map[string]*ScoreCounts{}
+	distances := map[int]*ScoreCounts{}
+	var noDistance *ScoreCounts
+	for _, v := range views {
+		category := optGet(v.Category, "")
+		if categories[category] == nil {
+			categories[category] = &ScoreCounts{}
+		}
+		var distance *ScoreCounts
+		if d := v.DistanceFromConcept; d != nil {
+			if distances[*d] == nil {
+				distances[*d] = &ScoreCounts{}
+			}
+			distance = distances[*d]
+		} else {
+			if noDistance == nil {
+				noDistance = &ScoreCounts{}
+			}
+			distance = noDistance
+		}
+
+		top, chosen := optGet(v.TopFamilyFeudAnswer, false), optGet(v.ChosenLanguageCandidate, false)
+		for _, c := range []*ScoreCounts{&sb.ScoreCounts, categories[category], distance} {
+			c.add(top, chosen)
+		}
+	}
+
+	sb.finish()
+	for _, name := range sortedKeys(categories) {
+		categories[name].finish()
+		sb.Categories = append(sb.Categories, CategoryScore{Category: name, ScoreCounts: *categories[name]})
+	}
+	keys := make([]int, 0, len(distances))
+	for d := range distances {
+		keys = append(keys, d)
+	}
+	sort.Ints(keys)
+	for _, d := range keys {
+		distances[d].finish()
+		sb.Distances = append(sb.Distances, DistanceScore{Distance: optPtr(d), ScoreCounts: *distances[d]})
+	}
+	if noDistance != nil {
+		noDistance.finish()
+		sb.Distances = append(sb.Distances, DistanceScore{ScoreCounts: *noDistance})
+	}
+	return sb
+}
+
+func (c *ScoreCounts) add(top, chosen bool) {
+	c.Candidates++
+	if top {
+		c.TopAnswers++
+	}
+	if chosen {
+		c.Chosen++
+	}
+	if top == chosen {
+		c.Agreements++
+	}
+}
+
+func (c *ScoreCounts) finish() {
+	c.AgreementRate = ratio(c.Agreements, c.Candidates)
+}
+
+func (c ScoreCounts) String() string {
+	return fmt.Sprintf("%d candidates, %d top answers, %d chosen, %d agree (%.0f%%)", c.Candidates, c.TopAnswers, c.Chosen, c.Agreements, 100*c.AgreementRate)
+}
+
+// Records flattens the scoreboard to one record per group, for tabular
+// exporters: group "all", "category" or "distance", and the group's key
+func (sb *Scoreboard) Records() []Record {
+	keys := []string{"group", "key", "candidates", "top_answers", "chosen", "agreements", "agreement_rate"}
+	record := func(group string, key any, c ScoreCounts) Record {
+		return Record{Keys: keys, Values: map[string]any{
+			"group": group, "key": key,
+			"candidates": c.Candidates, "top_answers": c.TopAnswers, "chosen": c.Chosen,
+			"agreements": c.Agreements, "agreement_rate": c.AgreementRate,
+		}}
+	}
+
+	records := []Record{record("all", nil, sb.ScoreCounts)}
+	for _, c := range sb.Categories {
+		records = append(records, record("category", c.Category, c.ScoreCounts))
+	}
+	for _, d := range sb.Distances {
+		var key any
+		if d.Distance != nil {
+			key = *d.Distance
+		}
+		records = append(records, record("distance", key, d.ScoreCounts))
+	}
+	return records
+}
+
+// WriteText writes the scoreboard as an aligned text summary
+func (sb *Scoreboard) WriteText(w io.Writer) error {
+	var categories, distances []string
+	width := 0
+	for _, c := range sb.Categories {
+		categories = append(categories, headingText(c.Category, "(none)"))
+		width = max(width, utf8.RuneCountInString(categories[len(categories)-1]))
+	}
+	for _, d := range sb.Distances {
+		label := "(none)"
+		if d.Distance != nil {
+			label = fmt.Sprint(*d.Distance)
+		}
+		distances = append(distances, label)
+		width = max(width, len(label))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Family Feud scoreboard: %s\n", sb.ScoreCounts)
+	b.WriteString("\nBy category:\n")
+	for i, c := range sb.Categories {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, categories[i], c.ScoreCounts)
+	}
+	b.WriteString("\nBy distance from concept:\n")
+	for i, d := range sb.Distances {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, distances[i], d.ScoreCounts)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// write writes the scoreboard as text, json or csv
+func (sb *Scoreboard) write(w io.Writer, format string) error {
+	var err error
+	switch format {
+	case "text":
+		err = sb.WriteText(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(sb)
+	case "csv":
+		err = csvExporter{}.Write(ExportViews{Table: "Scoreboard", Records: sb.Records(), Calculated: []string{}}, w)
+	default:
+		return fmt.Errorf("unknown scoreboard format %q (supported: text, json, csv)", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the scoreboard: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runScoreboard implements `scoreboard [--rulebook PATH] [--format text|json|csv] [--out FILE]`
+func runScoreboard(args []string) error {
+	fs := flag.NewFlagSet("scoreboard", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path or URL of the rulebook")
+	format := fs.String("format", "", "text, json or csv (default: from --out's extension, else text)")
+	out := fs.String("out", "", "file to write (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = "text"
+		if ext := strings.TrimPrefix(filepath.Ext(*out), "."); ext == "json" || ext == "csv" {
+			*format = ext
+		}
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+	sb := rb.Scoreboard()
+
+	if *out == "" {
+		return sb.write(os.Stdout, *format)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *out, err)
+	}
+	if err := sb.write(f, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -8,7 +8,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 )
@@ -17,28 +16,34 @@ import (
 // HELPER FUNCTIONS
 // =============================================================================
 
-// boolVal safely dereferences a *bool, returning false if nil
-func boolVal(b *bool) bool {
-	if b == nil {
-		return false
+// optGet dereferences an optional value, returning def if it is nil
+func optGet[T any](p *T, def T) T {
+	if p == nil {
+		return def
 	}
-	return *b
+	return *p
 }
 
-// stringVal safely dereferences a *string, returning "" if nil
-func stringVal(s *string) string {
-	if s == nil {
-		return ""
-	}
-	return *s
+// optPtr returns a pointer to a copy of v
+func optPtr[T any](v T) *T {
+	return &v
 }
 
-// nilIfEmpty returns nil for empty strings, otherwise a pointer to the string
-func nilIfEmpty(s string) *string {
-	if s == "" {
+// optNilIfZero returns nil for the zero value (e.g. ""), otherwise a pointer to v
+func optNilIfZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
 		return nil
 	}
-	return &s
+	return &v
+}
+
+// optEqual reports whether two optional values are both nil, or both set and equal
+func optEqual[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 // =============================================================================
@@ -74,36 +79,50 @@ type LanguageCandidate struct {
 	RelationshipToConcept *string `json:"relationship_to_concept"`
 }
 
+// LanguageCandidatesTable loads, computes and saves LanguageCandidates records
+var LanguageCandidatesTable = NewTypedTable[LanguageCandidate]("LanguageCandidates", (*LanguageCandidate).ComputeAll)
+
+// LanguageCandidateFormulas maps each calculated field to its rulebook formula
+var LanguageCandidateFormulas = map[string]string{
+	"FamilyFuedQuestion": "=\"Is \" & {{Name}} & \" a language?\"",
+	"TopFamilyFeudAnswer": "=AND(\n  {{HasSyntax}},\n  {{RequiresParsing}},\n  {{IsDescriptionOf}},\n  {{HasLinearDecodingPressure}},\n  {{ResolvesToAnAST}},\n  {{IsStableOntologyReference}},\n  NOT({{CanBeHeld}}),\n  NOT({{HasIdentity}})\n)",
+	"FamilyFeudMismatch": "=IF(NOT({{TopFamilyFeudAnswer}} = {{ChosenLanguageCandidate}}),\n  {{Name}} & \" \" & IF({{TopFamilyFeudAnswer}}, \"Is\", \"Isn't\") & \" a Family Feud Language, but \" & \n  IF({{ChosenLanguageCandidate}}, \"Is\", \"Is Not\") & \" marked as a 'Language Candidate.'\") & IF({{IsOpenClosedWorldConflicted}}, \" - Open World vs. Closed World Conflict.\")",
+	"HasGrammar": "={{HasSyntax}} = TRUE()",
+	"IsOpenClosedWorldConflicted": "=AND({{IsOpenWorld}}, {{IsClosedWorld}})",
+	"IsDescriptionOf": "={{DistanceFromConcept}} > 1",
+	"RelationshipToConcept": "=IF({{DistanceFromConcept}} = 1, \"IsMirrorOf\", \"IsDescriptionOf\")",
+}
+
 // --- Individual Calculation Functions ---
 
 // CalcFamilyFuedQuestion computes the FamilyFuedQuestion calculated field
 // Formula: ="Is " & {{Name}} & " a language?"
 func (tc *LanguageCandidate) CalcFamilyFuedQuestion() string {
-	return "Is " + stringVal(tc.Name) + " a language?"
+	return "Is " + optGet(tc.Name, "") + " a language?"
 }
 
 // CalcTopFamilyFeudAnswer computes the TopFamilyFeudAnswer calculated field
 // Formula: =AND(   {{HasSyntax}},   {{RequiresParsing}},   {{IsDescriptionOf}},   {{HasLinearDecodingPressure}},   {{ResolvesToAnAST}},   {{IsStableOntologyReference}},   NOT({{CanBeHeld}}),   NOT({{HasIdentity}}) )
 func (tc *LanguageCandidate) CalcTopFamilyFeudAnswer() bool {
-	return (boolVal(tc.HasSyntax) && boolVal(tc.RequiresParsing) && boolVal(tc.IsDescriptionOf) && boolVal(tc.HasLinearDecodingPressure) && boolVal(tc.ResolvesToAnAST) && boolVal(tc.IsStableOntologyReference) && !boolVal(tc.CanBeHeld) && !boolVal(tc.HasIdentity))
+	return (optGet(tc.HasSyntax, false) && optGet(tc.RequiresParsing, false) && optGet(tc.IsDescriptionOf, false) && optGet(tc.HasLinearDecodingPressure, false) && optGet(tc.ResolvesToAnAST, false) && optGet(tc.IsStableOntologyReference, false) && !optGet(tc.CanBeHeld, false) && !optGet(tc.HasIdentity, false))
 }
 
 // CalcFamilyFeudMismatch computes the FamilyFeudMismatch calculated field
 // Formula: =IF(NOT({{TopFamilyFeudAnswer}} = {{ChosenLanguageCandidate}}),   {{Name}} & " " & IF({{TopFamilyFeudAnswer}}, "Is", "Isn't") & " a Family Feud Language, but " &    IF({{ChosenLanguageCandidate}}, "Is", "Is Not") & " marked as a 'Language Candidate.'") & IF({{IsOpenClosedWorldConflicted}}, " - Open World vs. Closed World Conflict.")
 func (tc *LanguageCandidate) CalcFamilyFeudMismatch() string {
-	return func() string { if !((boolVal(tc.TopFamilyFeudAnswer) == boolVal(tc.ChosenLanguageCandidate))) { return stringVal(tc.Name) + " " + func() string { if boolVal(tc.TopFamilyFeudAnswer) { return "Is" }; return "Isn't" }() + " a Family Feud Language, but " + func() string { if boolVal(tc.ChosenLanguageCandidate) { return "Is" }; return "Is Not" }() + " marked as a 'Language Candidate.'" }; return "" }() + func() string { if boolVal(tc.IsOpenClosedWorldConflicted) { return " - Open World vs. Closed World Conflict." }; return "" }()
+	return func() string { if !((optGet(tc.TopFamilyFeudAnswer, false) == optGet(tc.ChosenLanguageCandidate, false))) { return optGet(tc.Name, "") + " " + func() string { if optGet(tc.TopFamilyFeudAnswer, false) { return "Is" }; return "Isn't" }() + " a Family Feud Language, but " + func() string { if optGet(tc.ChosenLanguageCandidate, false) { return "Is" }; return "Is Not" }() + " marked as a 'Language Candidate.'" }; return "" }() + func() string { if optGet(tc.IsOpenClosedWorldConflicted, false) { return " - Open World vs. Closed World Conflict." }; return "" }()
 }
 
 // CalcHasGrammar computes the HasGrammar calculated field
 // Formula: ={{HasSyntax}} = TRUE()
 func (tc *LanguageCandidate) CalcHasGrammar() bool {
-	return (boolVal(tc.HasSyntax) == true)
+	return (optGet(tc.HasSyntax, false) == true)
 }
 
 // CalcIsOpenClosedWorldConflicted computes the IsOpenClosedWorldConflicted calculated field
 // Formula: =AND({{IsOpenWorld}}, {{IsClosedWorld}})
 func (tc *LanguageCandidate) CalcIsOpenClosedWorldConflicted() bool {
-	return (boolVal(tc.IsOpenWorld) && boolVal(tc.IsClosedWorld))
+	return (optGet(tc.IsOpenWorld, false) && optGet(tc.IsClosedWorld, false))
 }
 
 // CalcIsDescriptionOf computes the IsDescriptionOf calculated field
@@ -123,17 +142,17 @@ func (tc *LanguageCandidate) CalcRelationshipToConcept() string {
 // ComputeAll computes all calculated fields and returns an updated struct
 func (tc *LanguageCandidate) ComputeAll() *LanguageCandidate {
 	// Level 1 calculations
-	familyFuedQuestion := "Is " + stringVal(tc.Name) + " a language?"
-	hasGrammar := (boolVal(tc.HasSyntax) == true)
-	isOpenClosedWorldConflicted := (boolVal(tc.IsOpenWorld) && boolVal(tc.IsClosedWorld))
+	familyFuedQuestion := "Is " + optGet(tc.Name, "") + " a language?"
+	hasGrammar := (optGet(tc.HasSyntax, false) == true)
+	isOpenClosedWorldConflicted := (optGet(tc.IsOpenWorld, false) && optGet(tc.IsClosedWorld, false))
 	isDescriptionOf := (tc.DistanceFromConcept != nil && *tc.DistanceFromConcept > 1)
 	relationshipToConcept := func() string { if (tc.DistanceFromConcept != nil && *tc.DistanceFromConcept == 1) { return "IsMirrorOf" }; return "IsDescriptionOf" }()
 
 	// Level 2 calculations
-	topFamilyFeudAnswer := (boolVal(tc.HasSyntax) && boolVal(tc.RequiresParsing) && isDescriptionOf && boolVal(tc.HasLinearDecodingPressure) && boolVal(tc.ResolvesToAnAST) && boolVal(tc.IsStableOntologyReference) && !boolVal(tc.CanBeHeld) && !boolVal(tc.HasIdentity))
+	topFamilyFeudAnswer := (optGet(tc.HasSyntax, false) && optGet(tc.RequiresParsing, false) && isDescriptionOf && optGet(tc.HasLinearDecodingPressure, false) && optGet(tc.ResolvesToAnAST, false) && optGet(tc.IsStableOntologyReference, false) && !optGet(tc.CanBeHeld, false) && !optGet(tc.HasIdentity, false))
 
 	// Level 3 calculations
-	familyFeudMismatch := func() string { if !((topFamilyFeudAnswer == boolVal(tc.ChosenLanguageCandidate))) { return stringVal(tc.Name) + " " + func() string { if topFamilyFeudAnswer { return "Is" }; return "Isn't" }() + " a Family Feud Language, but " + func() string { if boolVal(tc.ChosenLanguageCandidate) { return "Is" }; return "Is Not" }() + " marked as a 'Language Candidate.'" }; return "" }() + func() string { if isOpenClosedWorldConflicted { return " - Open World vs. Closed World Conflict." }; return "" }()
+	familyFeudMismatch := func() string { if !((topFamilyFeudAnswer == optGet(tc.ChosenLanguageCandidate, false))) { return optGet(tc.Name, "") + " " + func() string { if topFamilyFeudAnswer { return "Is" }; return "Isn't" }() + " a Family Feud Language, but " + func() string { if optGet(tc.ChosenLanguageCandidate, false) { return "Is" }; return "Is Not" }() + " marked as a 'Language Candidate.'" }; return "" }() + func() string { if isOpenClosedWorldConflicted { return " - Open World vs. Closed World Conflict." }; return "" }()
 
 	return &LanguageCandidate{
 		LanguageCandidateId: tc.LanguageCandidateId,
@@ -154,16 +173,38 @@ func (tc *LanguageCandidate) ComputeAll() *LanguageCandidate {
 		DistanceFromConcept: tc.DistanceFromConcept,
 		ModelObjectFacilityLayer: tc.ModelObjectFacilityLayer,
 		SortOrder: tc.SortOrder,
-		FamilyFuedQuestion: nilIfEmpty(familyFuedQuestion),
-		TopFamilyFeudAnswer: &topFamilyFeudAnswer,
-		FamilyFeudMismatch: nilIfEmpty(familyFeudMismatch),
-		HasGrammar: &hasGrammar,
-		IsOpenClosedWorldConflicted: &isOpenClosedWorldConflicted,
-		IsDescriptionOf: &isDescriptionOf,
-		RelationshipToConcept: nilIfEmpty(relationshipToConcept),
+		FamilyFuedQuestion: optNilIfZero(familyFuedQuestion),
+		TopFamilyFeudAnswer: optPtr(topFamilyFeudAnswer),
+		FamilyFeudMismatch: optNilIfZero(familyFeudMismatch),
+		HasGrammar: optPtr(hasGrammar),
+		IsOpenClosedWorldConflicted: optPtr(isOpenClosedWorldConflicted),
+		IsDescriptionOf: optPtr(isDescriptionOf),
+		RelationshipToConcept: optNilIfZero(relationshipToConcept),
 	}
 }
 
+// RawEqual reports whether tc and other hold the same raw field values
+func (tc *LanguageCandidate) RawEqual(other *LanguageCandidate) bool {
+	return tc.LanguageCandidateId == other.LanguageCandidateId &&
+		optEqual(tc.Name, other.Name) &&
+		optEqual(tc.Category, other.Category) &&
+		optEqual(tc.ChosenLanguageCandidate, other.ChosenLanguageCandidate) &&
+		optEqual(tc.HasSyntax, other.HasSyntax) &&
+		optEqual(tc.HasIdentity, other.HasIdentity) &&
+		optEqual(tc.CanBeHeld, other.CanBeHeld) &&
+		optEqual(tc.RequiresParsing, other.RequiresParsing) &&
+		optEqual(tc.ResolvesToAnAST, other.ResolvesToAnAST) &&
+		optEqual(tc.HasLinearDecodingPressure, other.HasLinearDecodingPressure) &&
+		optEqual(tc.IsStableOntologyReference, other.IsStableOntologyReference) &&
+		optEqual(tc.IsLiveOntologyEditor, other.IsLiveOntologyEditor) &&
+		optEqual(tc.DimensionalityWhileEditing, other.DimensionalityWhileEditing) &&
+		optEqual(tc.IsOpenWorld, other.IsOpenWorld) &&
+		optEqual(tc.IsClosedWorld, other.IsClosedWorld) &&
+		optEqual(tc.DistanceFromConcept, other.DistanceFromConcept) &&
+		optEqual(tc.ModelObjectFacilityLayer, other.ModelObjectFacilityLayer) &&
+		optEqual(tc.SortOrder, other.SortOrder)
+}
+
 // =============================================================================
 // ISEVERYTHINGALANGUAGE TABLE
 // =============================================================================
@@ -183,35 +224,197 @@ type IsEverythingALanguage struct {
 	Notes *string `json:"notes"`
 }
 
+// IsEverythingALanguageTable loads, computes and saves IsEverythingALanguage records
+var IsEverythingALanguageTable = NewTypedTable[IsEverythingALanguage]("IsEverythingALanguage", nil)
+
+// =============================================================================
+// GENERATION METADATA
+// =============================================================================
+
+// GeneratedLevels maps table -> calculated field -> the DAG level ComputeAll computes it at
+var GeneratedLevels = map[string]map[string]int{
+	"LanguageCandidates": {
+		"FamilyFuedQuestion": 1,
+		"HasGrammar": 1,
+		"IsOpenClosedWorldConflicted": 1,
+		"IsDescriptionOf": 1,
+		"RelationshipToConcept": 1,
+		"TopFamilyFeudAnswer": 2,
+		"FamilyFeudMismatch": 3,
+	},
+}
+
+// GeneratedFieldHashes maps "Table.Field" to a hash of the field definition this file was generated from
+var GeneratedFieldHashes = map[string]string{
+	"LanguageCandidates.LanguageCandidateId": "396ffeb6f6d6",
+	"LanguageCandidates.Name": "08bf268f6f03",
+	"LanguageCandidates.Category": "f0b560f4decd",
+	"LanguageCandidates.FamilyFuedQuestion": "64e02c87a7d3",
+	"LanguageCandidates.TopFamilyFeudAnswer": "3e89bdcf2df4",
+	"LanguageCandidates.ChosenLanguageCandidate": "49d09d090584",
+	"LanguageCandidates.FamilyFeudMismatch": "f164c6e0344c",
+	"LanguageCandidates.HasSyntax": "4d9a3af3fde1",
+	"LanguageCandidates.HasIdentity": "ec077f370bb6",
+	"LanguageCandidates.CanBeHeld": "5092e06680e9",
+	"LanguageCandidates.HasGrammar": "5373c34c0849",
+	"LanguageCandidates.RequiresParsing": "2e06b820ef6c",
+	"LanguageCandidates.ResolvesToAnAST": "0c0da652a64e",
+	"LanguageCandidates.HasLinearDecodingPressure": "35077bdebe7c",
+	"LanguageCandidates.IsStableOntologyReference": "1b0227aee85d",
+	"LanguageCandidates.IsLiveOntologyEditor": "11a6018da11c",
+	"LanguageCandidates.DimensionalityWhileEditing": "fd1e253a1e95",
+	"LanguageCandidates.IsOpenWorld": "b04c6327f731",
+	"LanguageCandidates.IsClosedWorld": "f85ede7c4cb9",
+	"LanguageCandidates.IsOpenClosedWorldConflicted": "90cf4e131705",
+	"LanguageCandidates.DistanceFromConcept": "b5179b3a593b",
+	"LanguageCandidates.IsDescriptionOf": "4b0cc0f685f8",
+	"LanguageCandidates.RelationshipToConcept": "d75635bdab99",
+	"LanguageCandidates.ModelObjectFacilityLayer": "3b800ecf967d",
+	"LanguageCandidates.SortOrder": "a89c0cadfcb6",
+	"IsEverythingALanguage.IsEverythingALanguageId": "9f2564de9333",
+	"IsEverythingALanguage.Name": "da61e641c789",
+	"IsEverythingALanguage.ArgumentName": "ed1d5e7dc749",
+	"IsEverythingALanguage.ArgumentCategory": "afe17f71a89a",
+	"IsEverythingALanguage.StepType": "fb1327e07d42",
+	"IsEverythingALanguage.Statement": "90466f81a4cc",
+	"IsEverythingALanguage.Formalization": "965acaa8935d",
+	"IsEverythingALanguage.RelatedCandidateName": "8fdb31813a9a",
+	"IsEverythingALanguage.RelatedCandidateId": "eed14e4f0477",
+	"IsEverythingALanguage.EvidenceFromRulebook": "5fe91e4d9218",
+	"IsEverythingALanguage.Notes": "0e67c83eac49",
+}
+
+// =============================================================================
+// QUERY FIELDS (for LanguageCandidates)
+// =============================================================================
+
+// LanguageCandidateId is the LanguageCandidateId field, for Where and SortBy
+var LanguageCandidateId = QueryField[LanguageCandidate, string]{Name: "LanguageCandidateId", Get: func(r *LanguageCandidate) *string { return &r.LanguageCandidateId }}
+
+// Name is the Name field, for Where and SortBy
+var Name = QueryField[LanguageCandidate, string]{Name: "Name", Get: func(r *LanguageCandidate) *string { return r.Name }}
+
+// Category is the Category field, for Where and SortBy
+var Category = QueryField[LanguageCandidate, string]{Name: "Category", Get: func(r *LanguageCandidate) *string { return r.Category }}
+
+// FamilyFuedQuestion is the FamilyFuedQuestion field, for Where and SortBy
+var FamilyFuedQuestion = QueryField[LanguageCandidate, string]{Name: "FamilyFuedQuestion", Get: func(r *LanguageCandidate) *string { return r.FamilyFuedQuestion }}
+
+// TopFamilyFeudAnswer matches LanguageCandidates whose TopFamilyFeudAnswer is want (null counts as false)
+func TopFamilyFeudAnswer(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.TopFamilyFeudAnswer, false) == want }
+}
+
+// ChosenLanguageCandidate matches LanguageCandidates whose ChosenLanguageCandidate is want (null counts as false)
+func ChosenLanguageCandidate(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.ChosenLanguageCandidate, false) == want }
+}
+
+// FamilyFeudMismatch is the FamilyFeudMismatch field, for Where and SortBy
+var FamilyFeudMismatch = QueryField[LanguageCandidate, string]{Name: "FamilyFeudMismatch", Get: func(r *LanguageCandidate) *string { return r.FamilyFeudMismatch }}
+
+// HasSyntax matches LanguageCandidates whose HasSyntax is want (null counts as false)
+func HasSyntax(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.HasSyntax, false) == want }
+}
+
+// HasIdentity matches LanguageCandidates whose HasIdentity is want (null counts as false)
+func HasIdentity(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.HasIdentity, false) == want }
+}
+
+// CanBeHeld matches LanguageCandidates whose CanBeHeld is want (null counts as false)
+func CanBeHeld(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.CanBeHeld, false) == want }
+}
+
+// HasGrammar matches LanguageCandidates whose HasGrammar is want (null counts as false)
+func HasGrammar(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.HasGrammar, false) == want }
+}
+
+// RequiresParsing matches LanguageCandidates whose RequiresParsing is want (null counts as false)
+func RequiresParsing(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.RequiresParsing, false) == want }
+}
+
+// ResolvesToAnAST matches LanguageCandidates whose ResolvesToAnAST is want (null counts as false)
+func ResolvesToAnAST(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.ResolvesToAnAST, false) == want }
+}
+
+// HasLinearDecodingPressure matches LanguageCandidates whose HasLinearDecodingPressure is want (null counts as false)
+func HasLinearDecodingPressure(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.HasLinearDecodingPressure, false) == want }
+}
+
+// IsStableOntologyReference matches LanguageCandidates whose IsStableOntologyReference is want (null counts as false)
+func IsStableOntologyReference(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsStableOntologyReference, false) == want }
+}
+
+// IsLiveOntologyEditor matches LanguageCandidates whose IsLiveOntologyEditor is want (null counts as false)
+func IsLiveOntologyEditor(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsLiveOntologyEditor, false) == want }
+}
+
+// DimensionalityWhileEditing is the DimensionalityWhileEditing field, for Where and SortBy
+var DimensionalityWhileEditing = QueryField[LanguageCandidate, string]{Name: "DimensionalityWhileEditing", Get: func(r *LanguageCandidate) *string { return r.DimensionalityWhileEditing }}
+
+// IsOpenWorld matches LanguageCandidates whose IsOpenWorld is want (null counts as false)
+func IsOpenWorld(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsOpenWorld, false) == want }
+}
+
+// IsClosedWorld matches LanguageCandidates whose IsClosedWorld is want (null counts as false)
+func IsClosedWorld(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsClosedWorld, false) == want }
+}
+
+// IsOpenClosedWorldConflicted matches LanguageCandidates whose IsOpenClosedWorldConflicted is want (null counts as false)
+func IsOpenClosedWorldConflicted(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsOpenClosedWorldConflicted, false) == want }
+}
+
+// DistanceFromConcept is the DistanceFromConcept field, for Where and SortBy
+var DistanceFromConcept = QueryField[LanguageCandidate, int]{Name: "DistanceFromConcept", Get: func(r *LanguageCandidate) *int { return r.DistanceFromConcept }}
+
+// IsDescriptionOf matches LanguageCandidates whose IsDescriptionOf is want (null counts as false)
+func IsDescriptionOf(want bool) Predicate[LanguageCandidate] {
+	return func(r *LanguageCandidate) bool { return optGet(r.IsDescriptionOf, false) == want }
+}
+
+// RelationshipToConcept is the RelationshipToConcept field, for Where and SortBy
+var RelationshipToConcept = QueryField[LanguageCandidate, string]{Name: "RelationshipToConcept", Get: func(r *LanguageCandidate) *string { return r.RelationshipToConcept }}
+
+// ModelObjectFacilityLayer is the ModelObjectFacilityLayer field, for Where and SortBy
+var ModelObjectFacilityLayer = QueryField[LanguageCandidate, string]{Name: "ModelObjectFacilityLayer", Get: func(r *LanguageCandidate) *string { return r.ModelObjectFacilityLayer }}
+
+// SortOrder is the SortOrder field, for Where and SortBy
+var SortOrder = QueryField[LanguageCandidate, int]{Name: "SortOrder", Get: func(r *LanguageCandidate) *int { return r.SortOrder }}
+
 // =============================================================================
 // FILE I/O (for LanguageCandidates)
 // =============================================================================
 
-// LoadRecords loads records from a JSON file
-func LoadRecords(path string) ([]LanguageCandidate, error) {
+// LoadRecords loads records from a JSON file; WithStrictFields rejects unknown or missing keys
+// and WithPartialLoad returns the records that parsed along with a *MultiError
+func LoadRecords(path string, opts ...RecordOption) ([]LanguageCandidate, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var records []LanguageCandidate
-	if err := json.Unmarshal(data, &records); err != nil {
-		return nil, fmt.Errorf("failed to parse file: %w", err)
+	records, err := decodeRecords[LanguageCandidate](data, opts)
+	if err != nil {
+		return records, fmt.Errorf("failed to parse file: %w", err)
 	}
 
 	return records, nil
 }
 
-// SaveRecords saves computed records to a JSON file
-func SaveRecords(path string, records []LanguageCandidate) error {
-	data, err := json.MarshalIndent(records, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal records: %w", err)
-	}
-
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write records: %w", err)
-	}
-
-	return nil
+// SaveRecords saves computed records to a JSON file, as canonical JSON;
+// WithEmptyStrings picks how empty calculated strings are written
+func SaveRecords(path string, records []LanguageCandidate, opts ...RecordOption) error {
+	return saveRecords(path, records, opts)
 }
\ No newline at end of file
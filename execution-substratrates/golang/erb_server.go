@@ -0,0 +1,591 @@
+// ERB SDK - HTTP Server
+// =====================
+// Serves the rulebook and its computed views as JSON, so front-ends and other
+// substrates need no file access:
+//
+//	GET /rulebook                  the rulebook (internal fields removed)
+//	GET /candidates                computed LanguageCandidates views
+//	GET /candidates/{id}/view      one candidate's computed view
+//	GET /arguments                 computed IsEverythingALanguage views
+//	GET /mismatches                the Family Feud mismatch report
+//	GET /scoreboard                Family Feud counts overall, by category and by distance
+//	GET /quality                   per table and record data quality scores
+//	GET /snapshots                 published versions usable with ?as_of=
+//	POST /graphql                  a GraphQL query over the computed views
+//	GET /graphql?query=...         the same, for simple clients
+//	GET /graphql/schema            the GraphQL schema (SDL)
+//	GET /capabilities              formats, functions, tables and features supported
+//	PATCH /candidates              a JSON Patch or merge patch of candidates by id
+//	PATCH /candidates/{id}         the same, relative to one candidate
+//
+// /candidates, /candidates/{id}/view and /arguments take
+// ?fields=name,has_grammar to serve only those fields (see erb_projection.go),
+// and /candidates and /arguments are paged with ?limit=N and ?offset=N or
+// ?cursor= (see erb_pagination.go): the X-Total-Count header counts every
+// record, and Link has the URL of the next page.
+// With a snapshot index configured, every rulebook endpoint accepts
+// ?as_of=<snapshot>; with --watch, edits to a local rulebook are served
+// without a restart. PATCH needs a Store (ServeStore, or serve --writable);
+// the body's Content-Type picks the format (see erb_patch.go), and the
+// response lists every field changed, calculated ones included:
+//
+//	curl -X PATCH -H 'Content-Type: application/merge-patch+json' \
+//		-d '{"CanBeHeld": true}' localhost:8080/candidates/english
+//	[{"id": "english", "field": "CanBeHeld", "old": false, "new": true, "calculated": false}, ...]
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Server serves the rulebook over HTTP
+type Server struct {
+	rulebook  atomic.Pointer[Rulebook]
+	snapshots *SnapshotReader
+	store     *Store // accepts PATCH edits when set
+	mux       *http.ServeMux
+
+	// IncludeInternal serves fields marked "visibility": "internal"
+	IncludeInternal bool
+
+	// AllowOrigin, if set, is sent as Access-Control-Allow-Origin so browser
+	// front-ends on other origins can call the API
+	AllowOrigin string
+}
+
+// NewServer creates a server for rb; snapshots may be nil to disable as_of queries
+func NewServer(rb *Rulebook, snapshots *SnapshotReader) *Server {
+	s := &Server{snapshots: snapshots, mux: http.NewServeMux()}
+	s.rulebook.Store(rb)
+	s.mux.HandleFunc("GET /rulebook", s.handleRulebook)
+	s.mux.HandleFunc("GET /candidates", s.handleTable("LanguageCandidates"))
+	s.mux.HandleFunc("GET /candidates/{id}/view", s.handleCandidateView)
+	s.mux.HandleFunc("GET /arguments", s.handleTable("IsEverythingALanguage"))
+	s.mux.HandleFunc("GET /mismatches", s.handleMismatches)
+	s.mux.HandleFunc("GET /scoreboard", s.handleScoreboard)
+	s.mux.HandleFunc("GET /quality", s.handleQuality)
+	s.mux.HandleFunc("GET /snapshots", s.handleSnapshots)
+	s.mux.HandleFunc("POST /graphql", s.handleGraphQL)
+	s.mux.HandleFunc("GET /graphql", s.handleGraphQL)
+	s.mux.HandleFunc("GET /graphql/schema", s.handleGraphQLSchema)
+	s.mux.HandleFunc("GET /capabilities", s.handleCapabilities)
+	s.mux.HandleFunc("PATCH /candidates", s.handlePatch)
+	s.mux.HandleFunc("PATCH /candidates/{id}", s.handlePatch)
+	return s
+}
+
+// SetRulebook replaces the live rulebook; requests in flight finish with the old one
+func (s *Server) SetRulebook(rb *Rulebook) {
+	s.rulebook.Store(rb)
+}
+
+// ServeStore serves the store's current snapshot, then the snapshot of
+// every commit, applies PATCH requests to the store, and returns the
+// function that stops following it
+func (s *Server) ServeStore(store *Store) (func(), error) {
+	rb, err := store.Snapshot().Rulebook()
+	if err != nil {
+		return nil, err
+	}
+	s.SetRulebook(rb)
+	s.store = store
+	return store.OnCommit(func(snap *StoreSnapshot) {
+		if rb, err := snap.Rulebook(); err == nil {
+			s.SetRulebook(rb)
+		}
+	}), nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.AllowOrigin)
+		w.Header().Set("Access-Control-Expose-Headers", "Link, X-Total-Count")
+	}
+	if flags := ActiveFlags(); !flags.Canonical() {
+		w.Header().Set("X-ERB-Experimental", flags.String())
+	}
+
+	_, route := s.mux.Handler(r)
+	if route == "" {
+		route = r.Method + " (unmatched)"
+	}
+	ctx, span := startSpan(r.Context(), route, slog.String("http.method", r.Method), slog.String("url.path", r.URL.Path))
+	defer span.End()
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(sw, r.WithContext(ctx))
+	span.SetAttributes(slog.Int("http.status_code", sw.status))
+	if sw.status >= 500 {
+		span.RecordError(fmt.Errorf("%s", http.StatusText(sw.status)))
+	}
+}
+
+// statusWriter remembers the status code a handler wrote
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// rulebookFor returns the live rulebook, or the published snapshot named by ?as_of=
+func (s *Server) rulebookFor(r *http.Request) (*Rulebook, int, error) {
+	asOf := r.URL.Query().Get("as_of")
+	if asOf == "" {
+		return s.rulebook.Load(), http.StatusOK, nil
+	}
+	if s.snapshots == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("as_of requires the server to be started with a snapshot index")
+	}
+	if _, err := s.snapshots.Resolve(r.Context(), asOf); err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	rb, err := s.snapshots.LoadRulebook(r.Context(), asOf)
+	if err != nil {
+		return nil, http.StatusBadGateway, err
+	}
+	return rb, http.StatusOK, nil
+}
+
+// handleTable serves GET /<table>[?as_of=<snapshot>][&format=<exporter>][&fields=a,b][&limit=N][&offset=N|&cursor=C]:
+// a table's computed views in the format named by ?format= or negotiated
+// from the Accept header (JSON by default), with only the ?fields= listed,
+// one page at a time if ?limit=, ?offset= or ?cursor= is given
+func (s *Server) handleTable(table string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exporter, ok := LookupExporter("json")
+		if name := r.URL.Query().Get("format"); name != "" {
+			if exporter, ok = LookupExporter(name); !ok {
+				writeError(w, http.StatusNotAcceptable, fmt.Errorf("unknown format %q (supported: %s)", name, strings.Join(ExportFormats(), ", ")))
+				return
+			}
+		} else if e, ok := NegotiateExporter(r.Header.Get("Accept")); ok {
+			exporter = e
+		}
+
+		rb, status, err := s.rulebookFor(r)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		views, err := rb.TableViews(table, s.IncludeInternal)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if views, err = paginate(w, r, views); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if views, err = selectFields(views, r); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if exporter.Name() == "json" {
+			writeJSON(w, http.StatusOK, views.Records)
+			return
+		}
+		w.Header().Set("Content-Type", exporter.ContentType())
+		exporter.Write(views, w)
+	}
+}
+
+// handleCandidateView serves GET /candidates/{id}/view[?as_of=<snapshot>][&fields=a,b]
+func (s *Server) handleCandidateView(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	views, err := rb.TableViews("LanguageCandidates", s.IncludeInternal)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	id := r.PathValue("id")
+	for _, rec := range views.Records {
+		if recordID(rec) != id {
+			continue
+		}
+		if fields := fieldList(r.URL.Query().Get("fields")); len(fields) > 0 {
+			if rec, err = rec.Select(fields...); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, rec)
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no candidate %q", id))
+}
+
+// selectFields projects views onto the request's ?fields=, if given
+func selectFields(views ExportViews, r *http.Request) (ExportViews, error) {
+	fields := fieldList(r.URL.Query().Get("fields"))
+	if len(fields) == 0 {
+		return views, nil
+	}
+	return views.Select(fields...)
+}
+
+// paginate trims views to the page requested by ?limit=, ?offset= and
+// ?cursor=, if any, in id order (see Query.Page), setting X-Total-Count
+// and, unless it is the last page, a Link header to the next one
+func paginate(w http.ResponseWriter, r *http.Request, views ExportViews) (ExportViews, error) {
+	q := r.URL.Query()
+	if !q.Has("limit") && !q.Has("offset") && !q.Has("cursor") {
+		return views, nil
+	}
+	size := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return views, fmt.Errorf("limit must be a positive integer, not %q", v)
+		}
+		size = n
+	}
+	rows := NewQuery(func() []Record { return views.Records }).KeyedBy(func(rec *Record) string { return recordID(*rec) })
+	var page Page[Record]
+	var err error
+	switch {
+	case q.Has("offset") && q.Has("cursor"):
+		return views, fmt.Errorf("use offset or cursor, not both")
+	case q.Has("offset"):
+		offset, convErr := strconv.Atoi(q.Get("offset"))
+		if convErr != nil || offset < 0 {
+			return views, fmt.Errorf("offset must be a non-negative integer, not %q", q.Get("offset"))
+		}
+		page, err = rows.PageAt(size, offset)
+	default:
+		page, err = rows.Page(size, q.Get("cursor"))
+	}
+	if err != nil {
+		return views, err
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		q.Del("offset")
+		q.Set("cursor", page.NextCursor)
+		u := *r.URL
+		u.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", u.RequestURI()))
+	}
+	views.Records = page.Items
+	return views, nil
+}
+
+// handleMismatches serves GET /mismatches[?as_of=<snapshot>]
+func (s *Server) handleMismatches(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	report, err := rb.FamilyFeudMismatches()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleScoreboard serves GET /scoreboard[?as_of=<snapshot>]
+func (s *Server) handleScoreboard(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rb.Scoreboard())
+}
+
+// handleQuality serves GET /quality[?as_of=<snapshot>]
+func (s *Server) handleQuality(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	quality, err := rb.Quality()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, quality)
+}
+
+// handleCapabilities serves GET /capabilities[?as_of=<snapshot>]
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rb.Capabilities())
+}
+
+// handleRulebook serves GET /rulebook[?as_of=<snapshot>], without internal
+// fields unless the server includes them
+func (s *Server) handleRulebook(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	if !s.IncludeInternal && rb.HasInternalFields() {
+		if rb, err = rb.Redacted(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, rb)
+}
+
+// handleGraphQL serves POST /graphql (a JSON GraphQLRequest body) and GET
+// /graphql?query=...[&variables=<json>][&operationName=...], both with
+// ?as_of=<snapshot>; query errors are reported in the response with 200
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req GraphQLRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse GraphQL request: %w", err))
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse variables: %w", err))
+				return
+			}
+		}
+	}
+
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rb.GraphQL(req, s.IncludeInternal))
+}
+
+// handleGraphQLSchema serves GET /graphql/schema[?as_of=<snapshot>]
+func (s *Server) handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	rb, status, err := s.rulebookFor(r)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	sdl, err := rb.GraphQLSchema(s.IncludeInternal)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, sdl)
+}
+
+// handleSnapshots serves GET /snapshots, the versions usable with as_of
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if s.snapshots == nil {
+		writeJSON(w, http.StatusOK, SnapshotIndex{Snapshots: []SnapshotEntry{}})
+		return
+	}
+	index, err := s.snapshots.Index(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, index)
+}
+
+// Patch media types
+const (
+	JSONPatchType  = "application/json-patch+json"
+	MergePatchType = "application/merge-patch+json"
+)
+
+// patchChange is one field in a PATCH response
+type patchChange struct {
+	ID         string `json:"id"`
+	Field      string `json:"field"`
+	Old        any    `json:"old"`
+	New        any    `json:"new"`
+	Calculated bool   `json:"calculated"`
+}
+
+// handlePatch serves PATCH /candidates and PATCH /candidates/{id}: a JSON
+// Patch (application/json-patch+json) or a merge patch
+// (application/merge-patch+json or application/json) applied to the store
+// in one transaction
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("the server is read-only (serve --writable accepts patches)"))
+		return
+	}
+	id := r.PathValue("id")
+	if id != "" {
+		if _, ok := s.store.Candidate(id); !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no candidate %q", id))
+			return
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var changes []FieldChanged
+	var err error
+	switch mediaType {
+	case JSONPatchType:
+		var ops []PatchOperation
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse JSON Patch: %w", err))
+			return
+		}
+		if id != "" {
+			for i := range ops {
+				ops[i].Path = "/" + escapePointer(id) + ops[i].Path
+				if ops[i].From != "" {
+					ops[i].From = "/" + escapePointer(id) + ops[i].From
+				}
+			}
+		}
+		changes, err = s.store.ApplyJSONPatch(ops)
+	case MergePatchType, "application/json":
+		var patch map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to parse merge patch: %w", err))
+			return
+		}
+		if id != "" {
+			patch = map[string]any{id: patch}
+		}
+		changes, err = s.store.MergePatch(patch)
+	default:
+		w.Header().Set("Accept-Patch", JSONPatchType+", "+MergePatchType)
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported patch type %q", mediaType))
+		return
+	}
+	switch {
+	case errors.Is(err, ErrPatchTestFailed):
+		writeError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	response := []patchChange{}
+	for _, c := range changes {
+		_, calculated := LanguageCandidateFormulas[c.Field]
+		response = append(response, patchChange{ID: c.RecordID, Field: c.Field, Old: c.Old, New: c.New, Calculated: calculated})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError writes {"error": "..."} with the given status
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runServe implements `serve [--addr ADDR] [--rulebook PATH|URL] [--snapshots DIR|URL] [--include-internal] [--watch | --writable] [--allow-origin ORIGIN] [--timeout D]`
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path or http(s) URL of the rulebook (JSON or YAML)")
+	snapshots := fs.String("snapshots", "", "publish destination (directory or URL) enabling ?as_of=")
+	includeInternal := fs.Bool("include-internal", false, "serve fields marked internal (maintainers only)")
+	allowOrigin := fs.String("allow-origin", "", "Access-Control-Allow-Origin for browser front-ends (e.g. * or https://app.example)")
+	watch := fs.Bool("watch", false, "reload a local rulebook when it changes (validated before it is served)")
+	writable := fs.Bool("writable", false, "accept PATCH edits to candidates (kept in memory, not saved to the rulebook)")
+	timeout := fs.Duration("timeout", 0, "answer 503 to requests not served within this long, e.g. 10s (0: no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The server runs until stopped, so only its --timeout bounds the first load
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+	}
+	rb, err := loadRulebookLocation(ctx, *rulebookPath)
+	cancel()
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var reader *SnapshotReader
+	if *snapshots != "" {
+		reader = OpenSnapshots(*snapshots)
+	}
+
+	server := NewServer(rb, reader)
+	server.IncludeInternal = *includeInternal
+	server.AllowOrigin = *allowOrigin
+
+	if *watch && *writable {
+		return fmt.Errorf("--watch and --writable cannot be combined")
+	}
+	if *writable {
+		stop, err := server.ServeStore(NewStore(rb))
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if *watch {
+		if isURL(*rulebookPath) {
+			return fmt.Errorf("--watch needs a local rulebook file")
+		}
+		defer Subscribe(Events, func(e RulebookLoaded) {
+			printWarnings(e.Rulebook)
+			fmt.Printf("Reloaded %s\n", e.Source)
+			server.SetRulebook(e.Rulebook)
+		})()
+		defer Subscribe(Events, func(e RecordChanged) {
+			fmt.Printf("  %s\n", e)
+		})()
+		w, err := Watch(*rulebookPath, nil)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		server.SetRulebook(w.Rulebook())
+	}
+
+	var handler http.Handler = server
+	if *timeout > 0 {
+		handler = http.TimeoutHandler(server, *timeout, `{"error": "request timed out"}`)
+	}
+	fmt.Printf("Serving rulebook on %s\n", *addr)
+	return http.ListenAndServe(*addr, handler)
+}
@@ -0,0 +1,217 @@
+// ERB SDK - Published Snapshot Index
+// ==================================
+// Reads the layout written by `publish` from a local directory or an HTTP(S)
+// base URL: lists snapshots from index.json and loads any version on demand.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatestVersion resolves to whichever snapshot latest.json points at
+const LatestVersion = "latest"
+
+// SnapshotReader reads published snapshots from a directory or URL
+type SnapshotReader struct {
+	location string
+	client   *http.Client
+
+	mu        sync.Mutex
+	rulebooks map[string]*Rulebook
+}
+
+// OpenSnapshots returns a reader for a publish destination (directory path or http(s):// URL)
+func OpenSnapshots(location string) *SnapshotReader {
+	return &SnapshotReader{
+		location:  strings.TrimSuffix(location, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+		rulebooks: map[string]*Rulebook{},
+	}
+}
+
+// Location returns the directory or URL the reader was opened with
+func (r *SnapshotReader) Location() string {
+	return r.location
+}
+
+// Index loads index.json
+func (r *SnapshotReader) Index(ctx context.Context) (*SnapshotIndex, error) {
+	var index SnapshotIndex
+	if err := r.fetchJSON(ctx, "index.json", &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// List returns every published snapshot, oldest first
+func (r *SnapshotReader) List(ctx context.Context) ([]SnapshotEntry, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.Snapshots, nil
+}
+
+// Resolve finds the index entry for a version ("latest" is accepted)
+func (r *SnapshotReader) Resolve(ctx context.Context, version string) (SnapshotEntry, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return SnapshotEntry{}, err
+	}
+	if version == LatestVersion {
+		version = index.Latest
+	}
+	for _, e := range index.Snapshots {
+		if e.Version == version {
+			return e, nil
+		}
+	}
+	return SnapshotEntry{}, fmt.Errorf("snapshot %q not found in %s", version, r.location)
+}
+
+// Manifest loads the manifest of a published version
+func (r *SnapshotReader) Manifest(ctx context.Context, version string) (*SnapshotManifest, error) {
+	entry, err := r.Resolve(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	var m SnapshotManifest
+	if err := r.fetchJSON(ctx, entry.Manifest, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadRulebook loads (and caches) the rulebook of a published version,
+// verifying it against the fingerprint recorded in the manifest
+func (r *SnapshotReader) LoadRulebook(ctx context.Context, version string) (*Rulebook, error) {
+	m, err := r.Manifest(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	cached, ok := r.rulebooks[m.Version]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	rel, ok := m.File("rulebook")
+	if !ok {
+		return nil, fmt.Errorf("snapshot %s has no rulebook", m.Version)
+	}
+	data, err := r.fetch(ctx, rel)
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256Hex(data); got != m.Fingerprint {
+		return nil, fmt.Errorf("snapshot %s rulebook fingerprint mismatch: manifest %s, got %s", m.Version, m.Fingerprint[:fingerprintLength], got[:fingerprintLength])
+	}
+
+	rb, err := ParseRulebook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.rulebooks[m.Version] = rb
+	r.mu.Unlock()
+	return rb, nil
+}
+
+// fetch reads a file relative to the publish destination
+func (r *SnapshotReader) fetch(ctx context.Context, rel string) ([]byte, error) {
+	if !isURL(r.location) {
+		data, err := os.ReadFile(filepath.Join(r.location, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.location+"/"+rel, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rel, err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rel, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", rel, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	return data, nil
+}
+
+func (r *SnapshotReader) fetchJSON(ctx context.Context, rel string, v any) error {
+	data, err := r.fetch(ctx, rel)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", rel, err)
+	}
+	return nil
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runHistory implements `history [--from DIR|URL]`
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	from := fs.String("from", "dist", "publish destination (directory or http(s) URL)")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+
+	reader := OpenSnapshots(*from)
+	index, err := reader.Index(commandContext())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-20s %-12s %-20s %10s %12s %11s\n", "VERSION", "FINGERPRINT", "PUBLISHED", "CANDIDATES", "TOP ANSWERS", "MISMATCHES")
+	for i := len(index.Snapshots) - 1; i >= 0; i-- {
+		e := index.Snapshots[i]
+		rb, err := reader.LoadRulebook(commandContext(), e.Version)
+		if err != nil {
+			return err
+		}
+
+		top := rb.Candidates().Where(TopFamilyFeudAnswer(true)).Count()
+		mismatches := rb.Candidates().Where(FamilyFeudMismatch.NotNull()).Count()
+
+		version := e.Version
+		if e.Version == index.Latest {
+			version += " *"
+		}
+		fmt.Printf("%-20s %-12s %-20s %10d %12d %11d\n", version, e.Fingerprint[:fingerprintLength], e.PublishedAt.Format("2006-01-02 15:04"), len(rb.LanguageCandidates), top, mismatches)
+	}
+	return nil
+}
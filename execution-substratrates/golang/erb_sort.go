@@ -0,0 +1,121 @@
+// ERB SDK - Sorting Candidates
+// ============================
+// Sorting candidates by hand means deciding where a null SortOrder goes,
+// usually with a sentinel (`if lc.SortOrder == nil { return 999 }`) that
+// breaks as soon as a real SortOrder passes it. SortCandidates takes the
+// query builder's sort keys in priority order, so it sorts exactly as
+// SortBy does:
+//
+//	SortCandidates(candidates, SortOrder.NilsLast())
+//	SortCandidates(candidates, ByTopFamilyFeudAnswer.Desc(), Name)
+//
+// The generated QueryFields (SortOrder, Name, DistanceFromConcept,
+// FamilyFeudMismatch, ...) are the keys, with ByTopFamilyFeudAnswer and
+// ByChosenLanguageCandidate for the booleans, which sort false before true;
+// ParseCandidateSort reads any field from text ("-HasGrammar,name"). Nulls
+// follow the query builder's policy (see erb_query.go): first ascending,
+// last with Desc, and last ascending with NilsLast.
+//
+// Keys compare the candidates' computed values, so calculated fields sort
+// correctly on records that were never computed; the records themselves
+// are moved, not changed. The sort is stable.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Boolean candidate sort keys
+var (
+	ByTopFamilyFeudAnswer     = boolSortKey("TopFamilyFeudAnswer", func(r *LanguageCandidate) *bool { return r.TopFamilyFeudAnswer })
+	ByChosenLanguageCandidate = boolSortKey("ChosenLanguageCandidate", func(r *LanguageCandidate) *bool { return r.ChosenLanguageCandidate })
+)
+
+// boolSortKey is a boolean field as a QueryField that reads false as 0 and true as 1
+func boolSortKey(name string, get func(*LanguageCandidate) *bool) QueryField[LanguageCandidate, int] {
+	return QueryField[LanguageCandidate, int]{Name: name, Get: func(r *LanguageCandidate) *int {
+		b := get(r)
+		if b == nil {
+			return nil
+		}
+		n := 0
+		if *b {
+			n = 1
+		}
+		return &n
+	}}
+}
+
+// SortCandidates sorts candidates by the keys, in priority order, comparing
+// their computed values; the sort is stable
+func SortCandidates(candidates []LanguageCandidate, keys ...SortKey[LanguageCandidate]) {
+	computed := make([]*LanguageCandidate, len(candidates))
+	order := make([]int, len(candidates))
+	for i := range candidates {
+		computed[i] = candidates[i].ComputeAll()
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return compareByKeys(keys, computed[a], computed[b])
+	})
+	sorted := make([]LanguageCandidate, len(candidates))
+	for i, j := range order {
+		sorted[i] = candidates[j]
+	}
+	copy(candidates, sorted)
+}
+
+// ParseCandidateSort reads a comma-separated list of field names in any
+// casing, each prefixed with "-" for descending: "SortOrder,-name"
+func ParseCandidateSort(spec string) ([]SortKey[LanguageCandidate], error) {
+	var keys []SortKey[LanguageCandidate]
+	for _, item := range fieldList(spec) {
+		name, desc := strings.CutPrefix(item, "-")
+		key, err := candidateSortKey(name)
+		if err != nil {
+			return nil, err
+		}
+		if desc {
+			key = Desc(key)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// candidateSortKey returns the LanguageCandidate field with the name, in
+// any casing, as a QueryField
+func candidateSortKey(name string) (SortKey[LanguageCandidate], error) {
+	sf, ok := candidateStructField(name)
+	if !ok {
+		return nil, fmt.Errorf("cannot sort by %q: LanguageCandidates has no such field", name)
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return QueryField[LanguageCandidate, string]{Name: sf.Name, Get: func(r *LanguageCandidate) *string { return fieldPointer[string](r, sf) }}, nil
+	case reflect.Int:
+		return QueryField[LanguageCandidate, int]{Name: sf.Name, Get: func(r *LanguageCandidate) *int { return fieldPointer[int](r, sf) }}, nil
+	case reflect.Bool:
+		return boolSortKey(sf.Name, func(r *LanguageCandidate) *bool { return fieldPointer[bool](r, sf) }), nil
+	}
+	return nil, fmt.Errorf("cannot sort by %q: unsupported type %s", name, sf.Type)
+}
+
+// fieldPointer returns a pointer to the record's value of a nullable or
+// plain struct field, nil when the field is null
+func fieldPointer[V any](r *LanguageCandidate, sf reflect.StructField) *V {
+	f := reflect.ValueOf(r).Elem().FieldByIndex(sf.Index)
+	if f.Kind() != reflect.Pointer {
+		f = f.Addr()
+	}
+	p, _ := f.Interface().(*V)
+	return p
+}
@@ -0,0 +1,514 @@
+// ERB SDK - PostgreSQL DDL
+// ========================
+// Generates the postgres substrate's schema from the same rulebook the Go
+// code is generated from, split like the files in postgres/:
+//
+//	01-drop-and-create-tables.sql  CREATE TABLE per table (raw fields only)
+//	02-create-functions.sql        calc_<table>_<field>(id) per calculated field
+//	03-create-views.sql            vw_<table>: raw columns plus calculated ones
+//	                               (internal calculated fields only get a function)
+//
+// Calc function bodies are translated from the parsed formulas with the Go
+// nil-handling: null booleans are FALSE, null text is '', integer
+// comparisons with null are false (except <>), and calculated text fields
+// return NULL instead of ''. `sql --check DIR` reports calc functions in a
+// postgres/ directory that no longer match their translated formulas.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SQLScripts is a rulebook's PostgreSQL schema, one script per postgres/ file
+type SQLScripts struct {
+	Tables    string
+	Functions string
+	Views     string
+}
+
+// Files maps each postgres/ file name to its script
+func (s *SQLScripts) Files() map[string]string {
+	return map[string]string{
+		"01-drop-and-create-tables.sql": s.Tables,
+		"02-create-functions.sql":       s.Functions,
+		"03-create-views.sql":           s.Views,
+	}
+}
+
+// String returns all three scripts in the order they must run
+func (s *SQLScripts) String() string {
+	return s.Tables + "\n" + s.Functions + "\n" + s.Views
+}
+
+// SQL generates the PostgreSQL tables, calc functions and views
+func (rb *Rulebook) SQL() (*SQLScripts, error) {
+	var functions, views strings.Builder
+	var dropFunctions, dropViews, dropTables, tables []string
+
+	for _, t := range rb.Tables {
+		table := toSnakeCase(t.Name)
+		id := toSnakeCase(t.IDField())
+		dropViews = append(dropViews, fmt.Sprintf("DROP VIEW IF EXISTS vw_%s CASCADE;", table))
+		dropTables = append(dropTables, fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", table))
+
+		var columns []string
+		for i, f := range t.Schema {
+			if f.IsCalculated() {
+				continue
+			}
+			col := fmt.Sprintf("  %-35s %s", toSnakeCase(f.Name), sqlType(f.Datatype))
+			if i == 0 {
+				col += " PRIMARY KEY"
+			}
+			columns = append(columns, col)
+		}
+		tables = append(tables, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", table, strings.Join(columns, ",\n")))
+
+		var selected []string
+		for _, f := range t.Schema {
+			col := toSnakeCase(f.Name)
+			switch {
+			case f.IsCalculated() && f.IsInternal():
+				continue
+			case f.IsCalculated():
+				selected = append(selected, fmt.Sprintf("  %s(t.%s) AS %s", sqlFunctionName(t, f.Name), id, col))
+			default:
+				selected = append(selected, "  t."+col)
+			}
+		}
+		fmt.Fprintf(&views, "\nCREATE OR REPLACE VIEW vw_%s WITH (security_invoker = ON) AS\nSELECT\n", table)
+		fmt.Fprintf(&views, "%s\nFROM %s t;\n", strings.Join(selected, ",\n"), table)
+
+		for _, f := range sqlCalculatedFields(t) {
+			body, err := sqlFunctionBody(t, f)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+			}
+			name := sqlFunctionName(t, f.Name)
+			dropFunctions = append(dropFunctions, fmt.Sprintf("DROP FUNCTION IF EXISTS %s(TEXT) CASCADE;", name))
+			fmt.Fprintf(&functions, "\n-- %s\n-- Formula: %s\n", f.Name, strings.Join(strings.Fields(f.Formula), " "))
+			fmt.Fprintf(&functions, "CREATE OR REPLACE FUNCTION %s(p_%s TEXT)\nRETURNS %s AS $$\n", name, id, sqlType(f.Datatype))
+			fmt.Fprintf(&functions, "DECLARE\n  r %s%%ROWTYPE;\nBEGIN\n", table)
+			fmt.Fprintf(&functions, "  SELECT * INTO r FROM %s WHERE %s = p_%s;\n", table, id, id)
+			fmt.Fprintf(&functions, "  RETURN %s;\nEND;\n$$ LANGUAGE plpgsql STABLE SECURITY DEFINER;\n", body)
+		}
+	}
+	sort.Strings(dropFunctions)
+
+	header := func(title, detail string) string {
+		return "-- ============================================================================\n" +
+			"-- " + title + "\n" +
+			"-- ============================================================================\n" +
+			"-- Generated from the rulebook by the golang substrate (`sql` command)\n" +
+			detail +
+			"-- ============================================================================\n"
+	}
+
+	var s SQLScripts
+	s.Tables = header("DROP AND CREATE TABLES - Clean slate + Normalized schema", fmt.Sprintf("-- Total Tables: %d\n", len(rb.Tables))) +
+		"\n-- Drop functions (first, because views depend on them)\n" + strings.Join(dropFunctions, "\n") +
+		"\n\n-- Drop views (second, because they depend on tables)\n" + strings.Join(dropViews, "\n") +
+		"\n\n-- Drop tables (last, after dependent objects are removed)\n" + strings.Join(dropTables, "\n") +
+		"\n\n" + strings.Join(tables, "\n\n") + "\n"
+	s.Functions = header("CREATE FUNCTIONS - One per calculated field, in DAG level order", "") + functions.String()
+	s.Views = header("CREATE VIEWS - Combine raw data with calculated fields", fmt.Sprintf("-- Total Views: %d\n", len(rb.Tables))) + views.String()
+	return &s, nil
+}
+
+// sqlCalculatedFields returns a table's calculated fields by DAG level, so
+// every function is created after the ones it calls
+func sqlCalculatedFields(t *Table) []Field {
+	var fields []Field
+	for _, f := range t.Schema {
+		if f.IsCalculated() {
+			fields = append(fields, f)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Level < fields[j].Level })
+	return fields
+}
+
+// sqlFunctionName is the calc function of a calculated field
+func sqlFunctionName(t *Table, field string) string {
+	return "calc_" + toSnakeCase(t.Name) + "_" + toSnakeCase(field)
+}
+
+// sqlType maps a rulebook datatype to a PostgreSQL column type
+func sqlType(datatype string) string {
+	switch datatype {
+	case "boolean":
+		return "BOOLEAN"
+	case "integer":
+		return "INTEGER"
+	case "number":
+		return "NUMERIC"
+	}
+	return "TEXT"
+}
+
+// =============================================================================
+// FORMULA TRANSLATION
+// =============================================================================
+
+// sqlKind is the static type of a translated expression
+type sqlKind int
+
+const (
+	sqlText sqlKind = iota
+	sqlBoolean
+	sqlInteger
+)
+
+// sqlExpr is a translated expression; nullable ones may evaluate to NULL
+type sqlExpr struct {
+	sql      string
+	kind     sqlKind
+	nullable bool
+}
+
+// sqlFunctionBody translates a calculated field's formula to its RETURN expression
+func sqlFunctionBody(t *Table, f Field) (string, error) {
+	ast, err := ParseFormula(f.Formula)
+	if err != nil {
+		return "", err
+	}
+	e, err := sqlTranslate(t, ast)
+	if err != nil {
+		return "", err
+	}
+	switch f.Datatype {
+	case "boolean":
+		return sqlAsBool(e), nil
+	case "integer", "number":
+		if e.kind != sqlInteger {
+			return "", fmt.Errorf("formula is not numeric")
+		}
+		return "NULLIF(" + e.sql + ", 0)", nil
+	}
+	return "NULLIF(" + sqlAsText(e) + ", '')", nil
+}
+
+func sqlTranslate(t *Table, node FormulaNode) (sqlExpr, error) {
+	switch n := node.(type) {
+	case LiteralBool:
+		return sqlExpr{sql: strings.ToUpper(strconv.FormatBool(n.Value)), kind: sqlBoolean}, nil
+	case LiteralInt:
+		return sqlExpr{sql: strconv.Itoa(n.Value), kind: sqlInteger}, nil
+	case LiteralString:
+		return sqlExpr{sql: sqlString(n.Value), kind: sqlText}, nil
+
+	case FieldRef:
+		f, ok := t.Field(n.Name)
+		if !ok {
+			return sqlExpr{}, fmt.Errorf("unknown field %s", n.Name)
+		}
+		e := sqlExpr{sql: "r." + toSnakeCase(f.Name), kind: sqlFieldKind(f.Datatype), nullable: true}
+		if f.IsCalculated() {
+			e.sql = sqlFunctionName(t, f.Name) + "(r." + toSnakeCase(t.IDField()) + ")"
+		}
+		return e, nil
+
+	case UnaryOp:
+		operand, err := sqlTranslate(t, n.Operand)
+		if err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlExpr{sql: "NOT " + sqlAsBool(operand), kind: sqlBoolean}, nil
+
+	case BinaryOp:
+		l, err := sqlTranslate(t, n.Left)
+		if err != nil {
+			return sqlExpr{}, err
+		}
+		r, err := sqlTranslate(t, n.Right)
+		if err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlCompare(n.Op, l, r), nil
+
+	case Concat:
+		parts := make([]string, len(n.Parts))
+		for i, p := range n.Parts {
+			e, err := sqlTranslate(t, p)
+			if err != nil {
+				return sqlExpr{}, err
+			}
+			parts[i] = sqlAsText(e)
+		}
+		return sqlExpr{sql: "(" + strings.Join(parts, " || ") + ")", kind: sqlText}, nil
+
+	case FuncCall:
+		return sqlFunc(t, n)
+	}
+	return sqlExpr{}, fmt.Errorf("unknown formula node %T", node)
+}
+
+func sqlFunc(t *Table, n FuncCall) (sqlExpr, error) {
+	args := make([]sqlExpr, len(n.Args))
+	for i, a := range n.Args {
+		e, err := sqlTranslate(t, a)
+		if err != nil {
+			return sqlExpr{}, err
+		}
+		args[i] = e
+	}
+	arity := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("%s expects %s", n.Name, arityText(min, max))
+		}
+		return nil
+	}
+
+	switch n.Name {
+	case "AND", "OR":
+		if len(args) == 0 {
+			return sqlExpr{sql: strings.ToUpper(strconv.FormatBool(n.Name == "AND")), kind: sqlBoolean}, nil
+		}
+		terms := make([]string, len(args))
+		for i, a := range args {
+			terms[i] = sqlAsBool(a)
+		}
+		return sqlExpr{sql: "(" + strings.Join(terms, " "+n.Name+" ") + ")", kind: sqlBoolean}, nil
+
+	case "IF":
+		if err := arity(2, 3); err != nil {
+			return sqlExpr{}, err
+		}
+		then := args[1]
+		otherwise := sqlExpr{sql: "''", kind: sqlText}
+		if len(args) == 3 {
+			otherwise = args[2]
+		}
+		if then.kind != otherwise.kind {
+			then = sqlExpr{sql: sqlAsText(then), kind: sqlText}
+			otherwise = sqlExpr{sql: sqlAsText(otherwise), kind: sqlText}
+		}
+		return sqlExpr{
+			sql:      fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", sqlAsBool(args[0]), then.sql, otherwise.sql),
+			kind:     then.kind,
+			nullable: then.nullable || otherwise.nullable,
+		}, nil
+
+	case "NOT":
+		if err := arity(1, 1); err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlExpr{sql: "NOT " + sqlAsBool(args[0]), kind: sqlBoolean}, nil
+
+	case "LOWER":
+		if err := arity(1, 1); err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlExpr{sql: "LOWER(" + sqlAsText(args[0]) + ")", kind: sqlText}, nil
+
+	case "FIND":
+		if err := arity(2, 2); err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlExpr{sql: fmt.Sprintf("(POSITION(%s IN %s) > 0)", sqlAsText(args[0]), sqlAsText(args[1])), kind: sqlBoolean}, nil
+
+	case "CAST":
+		if err := arity(1, 2); err != nil {
+			return sqlExpr{}, err
+		}
+		return sqlExpr{sql: sqlAsText(args[0]), kind: sqlText}, nil
+	}
+	return sqlExpr{}, fmt.Errorf("unknown function %s", n.Name)
+}
+
+// sqlCompare compares like compareFormulaValues: integers compared with null
+// (or with a non-integer) are unequal, booleans treat null as FALSE, and
+// everything else compares as text, byte-wise
+func sqlCompare(op string, l, r sqlExpr) sqlExpr {
+	unequal := strings.ToUpper(strconv.FormatBool(op == "<>"))
+	switch {
+	case l.kind == sqlInteger && r.kind == sqlInteger:
+		cmp := fmt.Sprintf("%s %s %s", l.sql, op, r.sql)
+		if l.nullable || r.nullable {
+			return sqlExpr{sql: fmt.Sprintf("COALESCE(%s, %s)", cmp, unequal), kind: sqlBoolean}
+		}
+		return sqlExpr{sql: "(" + cmp + ")", kind: sqlBoolean}
+	case l.kind == sqlInteger || r.kind == sqlInteger:
+		return sqlExpr{sql: unequal, kind: sqlBoolean}
+	case l.kind == sqlBoolean || r.kind == sqlBoolean:
+		return sqlExpr{sql: fmt.Sprintf("(%s %s %s)", sqlAsBool(l), op, sqlAsBool(r)), kind: sqlBoolean}
+	}
+	collate := ""
+	if op != "=" && op != "<>" {
+		collate = ` COLLATE "C"`
+	}
+	return sqlExpr{sql: fmt.Sprintf("(%s%s %s %s%s)", sqlAsText(l), collate, op, sqlAsText(r), collate), kind: sqlBoolean}
+}
+
+// sqlAsBool converts an expression to a non-null boolean like formulaBool
+func sqlAsBool(e sqlExpr) string {
+	switch e.kind {
+	case sqlBoolean:
+		if e.nullable {
+			return "COALESCE(" + e.sql + ", FALSE)"
+		}
+		return e.sql
+	case sqlInteger:
+		return "COALESCE(" + e.sql + " <> 0, FALSE)"
+	}
+	return "(" + sqlAsText(e) + " <> '')"
+}
+
+// sqlAsText converts an expression to non-null text like formulaText
+func sqlAsText(e sqlExpr) string {
+	s := e.sql
+	if e.kind != sqlText {
+		s = "(" + s + ")::text"
+	}
+	if e.nullable {
+		return "COALESCE(" + s + ", '')"
+	}
+	return s
+}
+
+func sqlFieldKind(datatype string) sqlKind {
+	switch datatype {
+	case "boolean":
+		return sqlBoolean
+	case "integer":
+		return sqlInteger
+	}
+	return sqlText
+}
+
+// sqlString quotes a string literal
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// =============================================================================
+// DRIFT
+// =============================================================================
+
+// sqlCalcFunction matches a calc function definition through its LANGUAGE clause
+var sqlCalcFunction = regexp.MustCompile(`(?s)CREATE OR REPLACE FUNCTION (calc_\w+)\(.*?\$\$\s*LANGUAGE[^;]*;`)
+
+// sqlCalcFunctions returns the calc functions a script defines, by name, with
+// comments dropped and whitespace collapsed so layout differences are ignored
+func sqlCalcFunctions(script string) map[string]string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	functions := map[string]string{}
+	for _, m := range sqlCalcFunction.FindAllStringSubmatch(strings.Join(lines, "\n"), -1) {
+		functions[m[1]] = strings.Join(strings.Fields(m[0]), " ")
+	}
+	return functions
+}
+
+// SQLFunctionDrift compares the calc functions defined by the .sql scripts
+// in dir (run in name order, as init-db.sh does, so a later definition wins)
+// with the translated formulas, and describes every function that differs,
+// is missing, or belongs to no calculated field
+func (rb *Rulebook) SQLFunctionDrift(dir string) ([]string, error) {
+	scripts, err := rb.SQL()
+	if err != nil {
+		return nil, err
+	}
+	want := sqlCalcFunctions(scripts.Functions)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	got, definedIn := map[string]string{}, map[string]string{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for name, def := range sqlCalcFunctions(string(data)) {
+			got[name], definedIn[name] = def, filepath.Base(path)
+		}
+	}
+
+	var drift []string
+	for _, name := range sortedKeys(want) {
+		switch def, ok := got[name]; {
+		case !ok:
+			drift = append(drift, name+": missing")
+		case def != want[name]:
+			drift = append(drift, fmt.Sprintf("%s: %s differs from the translated formula", name, definedIn[name]))
+		}
+	}
+	for _, name := range sortedKeys(got) {
+		if _, ok := want[name]; !ok {
+			drift = append(drift, fmt.Sprintf("%s: %s defines it, but it is not a calculated field", name, definedIn[name]))
+		}
+	}
+	return drift, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runSQL implements `sql [--rulebook PATH] [--out DIR | --check DIR]`: prints
+// the PostgreSQL schema, writes it to DIR as the three postgres/ scripts, or
+// checks DIR's calc functions against the formulas
+func runSQL(args []string) error {
+	fs := flag.NewFlagSet("sql", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON or YAML)")
+	out := fs.String("out", "", "directory to write the scripts to (default: print them)")
+	check := fs.String("check", "", "postgres/ directory whose calc functions must match the translated formulas")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	if *check != "" {
+		drift, err := rb.SQLFunctionDrift(*check)
+		if err != nil {
+			return err
+		}
+		for _, d := range drift {
+			fmt.Println(d)
+		}
+		if len(drift) > 0 {
+			return fmt.Errorf("%d calc functions in %s have drifted; regenerate them with `sql --out`", len(drift), *check)
+		}
+		fmt.Printf("Calc functions in %s match the rulebook formulas\n", *check)
+		return nil
+	}
+
+	scripts, err := rb.SQL()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(scripts.String())
+		return nil
+	}
+
+	files := scripts.Files()
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(*out, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// ERB SDK - SQLite Store
+// ======================
+// Saves a rulebook as a SQLite database with the postgres substrate's table
+// layout (snake_case tables and columns), plus the calculated columns
+// materialized by the Go calc functions, so it can be queried directly:
+//
+//	err := rb.SaveSQLite("rulebook.sqlite")
+//	rb, err := LoadFromRulebook("rulebook.sqlite") // detected by extension or header
+//
+//	sqlite3 rulebook.sqlite "SELECT name FROM language_candidates WHERE top_family_feud_answer"
+//
+// Field definitions, descriptions and metadata are kept in _erb_rulebook and
+// _erb_tables, so loading the file gives back the same rulebook. Like
+// pgsync, the store runs the sqlite3 command-line shell instead of linking
+// a driver.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SQLiteExecutable is the sqlite3 shell the store runs
+var SQLiteExecutable = "sqlite3"
+
+// sqliteMagic starts every SQLite database file
+const sqliteMagic = "SQLite format 3\x00"
+
+// SaveSQLite writes the rulebook to a new SQLite database at path, replacing
+// any existing file once the database is complete
+func (rb *Rulebook) SaveSQLite(path string) error {
+	script, err := rb.sqliteScript()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if _, err := runSQLite(commandContext(), tmp, script); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// sqliteScript returns the SQL that creates and fills the database
+func (rb *Rulebook) sqliteScript() (string, error) {
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	b.WriteString("CREATE TABLE _erb_rulebook (key TEXT PRIMARY KEY, value TEXT);\n")
+	b.WriteString("CREATE TABLE _erb_tables (position INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, description TEXT, schema TEXT NOT NULL);\n")
+	meta := [][2]string{{"$schema", rb.SchemaURI}, {"model_name", rb.ModelName}, {"Description", rb.Description}, {"_meta", string(rb.Meta)}}
+	for _, kv := range meta {
+		fmt.Fprintf(&b, "INSERT INTO _erb_rulebook VALUES (%s, %s);\n", sqlString(kv[0]), sqlString(kv[1]))
+	}
+
+	for i, t := range rb.Tables {
+		schema, err := json.Marshal(t.Schema)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "INSERT INTO _erb_tables VALUES (%d, %s, %s, %s);\n", i, sqlString(t.Name), sqlString(t.Description), sqlString(string(schema)))
+
+		table := toSnakeCase(t.Name)
+		columns := make([]string, len(t.Schema))
+		for j, f := range t.Schema {
+			columns[j] = toSnakeCase(f.Name) + " " + sqlType(f.Datatype)
+			if j == 0 {
+				columns[j] += " PRIMARY KEY"
+			}
+		}
+		fmt.Fprintf(&b, "CREATE TABLE %s (\n  %s\n);\n", table, strings.Join(columns, ",\n  "))
+
+		rows, err := rb.sqliteRows(t)
+		if err != nil {
+			return "", err
+		}
+		for _, row := range rows {
+			values := make([]string, len(t.Schema))
+			for j, f := range t.Schema {
+				values[j] = pgLiteral(row[toSnakeCase(f.Name)])
+			}
+			fmt.Fprintf(&b, "INSERT INTO %s VALUES (%s);\n", table, strings.Join(values, ", "))
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	return b.String(), nil
+}
+
+// sqliteRows returns a table's computed rows keyed by snake_case column
+func (rb *Rulebook) sqliteRows(t *Table) ([]map[string]any, error) {
+	views, err := rb.TableViews(t.Name, true)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]any, len(views.Records))
+	for i, rec := range views.Records {
+		rows[i] = rec.Values
+	}
+	return rows, nil
+}
+
+// sqliteRulebookJSON reads a database written by SaveSQLite and returns the
+// rulebook as JSON
+func sqliteRulebookJSON(ctx context.Context, data []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "erb-*.sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQLite rulebook: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read SQLite rulebook: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read SQLite rulebook: %w", err)
+	}
+	db := f.Name()
+
+	rb := Record{Values: map[string]any{}}
+	add := func(key string, value any) {
+		rb.Keys = append(rb.Keys, key)
+		rb.Values[key] = value
+	}
+
+	meta, err := querySQLite(ctx, db, "SELECT key, value FROM _erb_rulebook")
+	if err != nil {
+		return nil, err
+	}
+	metaValues := map[string]string{}
+	for _, row := range meta {
+		metaValues[fmt.Sprint(row["key"])], _ = row["value"].(string)
+	}
+	add("$schema", metaValues["$schema"])
+	add("model_name", metaValues["model_name"])
+	add("Description", metaValues["Description"])
+
+	tables, err := querySQLite(ctx, db, "SELECT name, description, schema FROM _erb_tables ORDER BY position")
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range tables {
+		name, _ := row["name"].(string)
+		t := &Table{Name: name}
+		t.Description, _ = row["description"].(string)
+		schema, _ := row["schema"].(string)
+		if err := json.Unmarshal([]byte(schema), &t.Schema); err != nil {
+			return nil, fmt.Errorf("table %s: bad schema: %w", name, err)
+		}
+
+		rows, err := querySQLite(ctx, db, "SELECT * FROM "+toSnakeCase(name))
+		if err != nil {
+			return nil, err
+		}
+		t.Data = make([]map[string]any, len(rows))
+		for i, row := range rows {
+			t.Data[i] = make(map[string]any, len(t.Schema))
+			for _, field := range t.Schema {
+				v := row[toSnakeCase(field.Name)]
+				if n, ok := v.(int); ok && field.Datatype == "boolean" {
+					v = n != 0
+				}
+				t.Data[i][field.Name] = v
+			}
+		}
+		add(name, t)
+	}
+	if m := metaValues["_meta"]; m != "" {
+		add("_meta", json.RawMessage(m))
+	}
+	return rb.MarshalJSON()
+}
+
+// querySQLite runs a query and returns its rows as column -> value maps
+func querySQLite(ctx context.Context, db, query string) ([]map[string]any, error) {
+	out, err := runSQLite(ctx, db, query, "-json")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil // no rows
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("failed to read sqlite3 output: %w", err)
+	}
+	for _, row := range rows {
+		for k, v := range row {
+			if n, ok := v.(float64); ok && n == float64(int(n)) {
+				row[k] = int(n)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// runSQLite runs the sqlite3 shell on db with script on stdin and returns
+// stdout; sqlite3 is killed when ctx is done
+func runSQLite(ctx context.Context, db, script string, args ...string) ([]byte, error) {
+	cmd := subprocess(ctx, SQLiteExecutable, append(append([]string{"-bail"}, args...), db)...)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, subprocessError(ctx, "sqlite3", stderr.String(), err)
+	}
+	return out, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runSQLiteCommand implements `sqlite save|load [--rulebook PATH] FILE`: save
+// writes the rulebook to a SQLite database; load prints a database's rulebook
+// as JSON
+func runSQLiteCommand(args []string) error {
+	const usage = "usage: sqlite save [--rulebook PATH] FILE | sqlite load FILE"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	fs := flag.NewFlagSet("sqlite "+args[0], flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "save: rulebook to store (JSON, YAML, or SQLite)")
+	timeout := fs.Duration("timeout", 0, timeoutUsage)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	defer limitCommand(*timeout)()
+	if fs.NArg() != 1 {
+		return fmt.Errorf(usage)
+	}
+	path := fs.Arg(0)
+
+	switch args[0] {
+	case "save":
+		rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+		if err != nil {
+			return err
+		}
+		printWarnings(rb)
+		if err := rb.SaveSQLite(path); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %d tables to %s\n", len(rb.Tables), path)
+		return nil
+
+	case "load":
+		rb, err := LoadFromRulebookContext(commandContext(), path, WithFormat(FormatSQLite))
+		if err != nil {
+			return err
+		}
+		printWarnings(rb)
+		data, err := json.MarshalIndent(rb, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return fmt.Errorf(usage)
+}
+
+// isSQLitePath reports whether a path has a SQLite database extension
+func isSQLitePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".sqlite", ".sqlite3", ".db":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// ERB SDK - Compute Statistics
+// ============================
+// Telemetry the conformance runner prints for every calculated field after a
+// table is computed. A field that is never (or always) set, that mostly
+// computes from nil inputs, or whose strings are unexpectedly short or long
+// usually points at a fixture or formula bug, and is cheaper to spot here
+// than in a cross-substrate comparison:
+//
+//	Golang substrate: LanguageCandidates.HasGrammar: 14/25 non-default, 0 nil, 0 nil-coerced
+//	Golang substrate: LanguageCandidates.FamilyFeudMismatch: 3/25 non-default, 22 nil, 0 nil-coerced, length 40-83
+
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// FieldStats summarizes one calculated field over a table's computed records
+type FieldStats struct {
+	Field      string `json:"field"`
+	Records    int    `json:"records"`
+	NonDefault int    `json:"non_default"` // values other than nil, false, 0 and ""
+	Nil        int    `json:"nil"`         // records where the field computed to nil
+	NilCoerced int    `json:"nil_coerced"` // records where a field the formula reads was nil
+	MinLength  int    `json:"min_length"`  // shortest string value, in characters (-1 if none)
+	MaxLength  int    `json:"max_length"`  // longest string value, in characters (-1 if none)
+}
+
+func (s FieldStats) String() string {
+	text := fmt.Sprintf("%s: %d/%d non-default, %d nil, %d nil-coerced", s.Field, s.NonDefault, s.Records, s.Nil, s.NilCoerced)
+	if s.MinLength >= 0 {
+		text += fmt.Sprintf(", length %d-%d", s.MinLength, s.MaxLength)
+	}
+	return text
+}
+
+// ComputeStats summarizes every calculated field (field name -> formula, as
+// in the generated <Struct>Formulas maps) over computed records, in record
+// key order. A formula that does not parse is counted without nil coercion.
+func ComputeStats(records []Record, formulas map[string]string) []FieldStats {
+	if len(records) == 0 {
+		return nil
+	}
+	keyOf := func(name string) string {
+		if _, ok := records[0].Get(toSnakeCase(name)); ok {
+			return toSnakeCase(name)
+		}
+		return name
+	}
+	fields := map[string]string{} // record key -> field name
+	for name := range formulas {
+		fields[keyOf(name)] = name
+	}
+
+	var stats []FieldStats
+	for _, key := range records[0].Keys {
+		name, ok := fields[key]
+		if !ok {
+			continue
+		}
+		var reads []string
+		if ast, err := ParseFormula(formulas[name]); err == nil {
+			for _, ref := range FormulaFieldRefs(ast) {
+				reads = append(reads, keyOf(ref))
+			}
+		}
+
+		s := FieldStats{Field: name, Records: len(records), MinLength: -1, MaxLength: -1}
+		for _, rec := range records {
+			v, _ := rec.Get(key)
+			switch x := v.(type) {
+			case nil:
+				s.Nil++
+			case bool:
+				if x {
+					s.NonDefault++
+				}
+			case int:
+				if x != 0 {
+					s.NonDefault++
+				}
+			case string:
+				if x != "" {
+					s.NonDefault++
+				}
+				n := utf8.RuneCountInString(x)
+				if s.MinLength < 0 || n < s.MinLength {
+					s.MinLength = n
+				}
+				s.MaxLength = max(s.MaxLength, n)
+			default:
+				s.NonDefault++
+			}
+			for _, ref := range reads {
+				if input, _ := rec.Get(ref); input == nil {
+					s.NilCoerced++
+					break
+				}
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
@@ -0,0 +1,605 @@
+// ERB SDK - Store
+// ===============
+// A mutable, in-memory set of LanguageCandidates records that stays
+// computed: every edit recomputes the record's calculated fields. Edits are
+// made in transactions, which commit all at once or not at all, and callers
+// observe every field a commit changed, raw or calculated, for audit logs
+// and live UIs:
+//
+//	store := NewStore(rb)
+//	stop := store.OnChange(func(id, field string, old, new any) {
+//		log.Printf("%s.%s: %v -> %v", id, field, old, new)
+//	})
+//	defer stop()
+//	err := store.Set("english", "CanBeHeld", true)
+//	// english.CanBeHeld: false -> true
+//	// english.TopFamilyFeudAnswer: true -> false
+//	// english.FamilyFeudMismatch: <nil> -> English Isn't a Family Feud Language, ...
+//
+//	tx := store.Begin()
+//	tx.AddCandidate(LanguageCandidate{LanguageCandidateId: "latin", Name: optPtr("Latin")})
+//	tx.UpdateCandidate("french", map[string]any{"SortOrder": 30, "Category": "Natural"})
+//	tx.DeleteCandidate("falsifier-a")
+//	err = tx.Commit() // or tx.Rollback()
+//
+// Records added with a blank id get a generated one, and argument steps can
+// be added too, but only pointing at candidates that exist (see erb_ids.go);
+// a candidate an argument step points at cannot be deleted.
+//
+// Preview is a dry run of UpdateCandidate for editing UIs: it returns the
+// calculated fields the edit would change, leaving the store as it is:
+//
+//	impact, err := store.Preview("english", map[string]any{"CanBeHeld": true})
+//	for _, c := range impact {
+//		fmt.Printf("%s: %v -> %v\n", c.Field, c.Old, c.New)
+//	}
+//	// TopFamilyFeudAnswer: true -> false
+//	// FamilyFeudMismatch: <nil> -> English Isn't a Family Feud Language, ...
+//
+// AddCandidate, UpdateCandidate, DeleteCandidate and Set on the store are
+// one-edit transactions. A transaction keeps its edits to itself until
+// Commit, which fails with ErrTxConflict if the store changed since Begin,
+// and reports changes in the order records were first edited. Records
+// are deep copies, in and out: changing a LanguageCandidate returned by
+// Candidate, or one passed to AddCandidate, through its pointer fields
+// does not change the store.
+//
+// The store is safe for concurrent use. Every commit swaps in a new,
+// immutable StoreSnapshot, so readers never wait for writers and a reader
+// holding a snapshot sees one consistent version however many edits land:
+//
+//	snap := store.Snapshot()
+//	lc, ok := snap.Candidate("english")
+//	stop, err := server.ServeStore(store) // serve each commit's snapshot
+//
+// Commits are serialized, and observers run before the next commit starts,
+// so they see changes in commit order; an observer may read the store but
+// must not edit it.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTxConflict is returned by Commit when another commit landed after Begin
+var ErrTxConflict = errors.New("store changed since the transaction began")
+
+// ErrTxDone is returned by a transaction that was already committed or rolled back
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// ErrDanglingReference is returned when a new argument step's
+// RelatedCandidateId names no candidate, or when deleting a candidate an
+// argument step's RelatedCandidateId names
+var ErrDanglingReference = errors.New("dangling candidate reference")
+
+// Store holds LanguageCandidates records by id, computed
+type Store struct {
+	mu      sync.Mutex // serializes commits
+	current atomic.Pointer[StoreSnapshot]
+	bus     *Bus
+	logger  *slog.Logger // see SetLogger; guarded by mu
+}
+
+// StoreSnapshot is the store's records as of one commit; it never changes
+type StoreSnapshot struct {
+	// Version counts the commits before this snapshot
+	Version int
+
+	records []LanguageCandidate
+	index   map[string]int          // id -> position in records
+	steps   []IsEverythingALanguage // argument steps, which are only ever added
+	base    *Rulebook               // the rulebook the store was created from
+}
+
+// NewStore returns a store with a computed copy of the rulebook's
+// candidates and a copy of its argument steps
+func NewStore(rb *Rulebook) *Store {
+	snap := &StoreSnapshot{index: map[string]int{}, base: rb}
+	for _, step := range rb.IsEverythingALanguage {
+		snap.steps = append(snap.steps, cloneRecord(step))
+	}
+	for i := range rb.LanguageCandidates {
+		lc := cloneRecord(rb.LanguageCandidates[i])
+		snap.index[lc.LanguageCandidateId] = len(snap.records)
+		snap.records = append(snap.records, *lc.ComputeAll())
+	}
+	s := &Store{bus: NewBus()}
+	s.current.Store(snap)
+	Logger.Info("store created", "records", len(snap.records))
+	return s
+}
+
+// SetLogger logs the store's commits to l instead of Logger
+func (s *Store) SetLogger(l *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = l
+}
+
+// Snapshot returns the store's current version
+func (s *Store) Snapshot() *StoreSnapshot {
+	return s.current.Load()
+}
+
+// Candidate returns the record with the id
+func (s *Store) Candidate(id string) (LanguageCandidate, bool) {
+	return s.Snapshot().Candidate(id)
+}
+
+// Candidates returns every record, in rulebook order
+func (s *Store) Candidates() []LanguageCandidate {
+	return s.Snapshot().Candidates()
+}
+
+// Len is the number of records
+func (s *Store) Len() int {
+	return s.Snapshot().Len()
+}
+
+// Candidate returns a copy of the record with the id
+func (snap *StoreSnapshot) Candidate(id string) (LanguageCandidate, bool) {
+	i, ok := snap.index[id]
+	if !ok {
+		return LanguageCandidate{}, false
+	}
+	return cloneRecord(snap.records[i]), true
+}
+
+// Candidates returns a copy of every record, in rulebook order
+func (snap *StoreSnapshot) Candidates() []LanguageCandidate {
+	return cloneRecords(snap.records)
+}
+
+// Len is the number of records
+func (snap *StoreSnapshot) Len() int {
+	return len(snap.records)
+}
+
+// ArgumentSteps returns a copy of every argument step, in rulebook order
+func (snap *StoreSnapshot) ArgumentSteps() []IsEverythingALanguage {
+	return cloneRecords(snap.steps)
+}
+
+// cloneRecords returns a deep copy of records (see cloneRecord)
+func cloneRecords[T any](records []T) []T {
+	clones := make([]T, len(records))
+	for i, rec := range records {
+		clones[i] = cloneRecord(rec)
+	}
+	return clones
+}
+
+// Rulebook returns the rulebook the store was created from with the
+// snapshot's records as its LanguageCandidates data (and its argument
+// steps as its IsEverythingALanguage data)
+func (snap *StoreSnapshot) Rulebook() (*Rulebook, error) {
+	t := snap.base.Table("LanguageCandidates")
+	if t == nil {
+		return nil, fmt.Errorf("rulebook has no LanguageCandidates table")
+	}
+	data := map[string][]map[string]any{t.Name: structRows(t, snap.records)}
+	if t := snap.base.Table("IsEverythingALanguage"); t != nil {
+		data[t.Name] = structRows(t, snap.steps)
+	}
+	return snap.base.withTableData(data)
+}
+
+// structRows returns records as rows keyed by the table's field names
+func structRows[T any](t *Table, records []T) []map[string]any {
+	rows := make([]map[string]any, len(records))
+	for i := range records {
+		rows[i] = make(map[string]any, len(t.Schema))
+		for _, f := range t.Schema {
+			rows[i][f.Name] = recordField(&records[i], f.Name)
+		}
+	}
+	return rows
+}
+
+// withTableData returns a copy of rb, reloaded, with the rows of the named
+// tables replaced
+func (rb *Rulebook) withTableData(data map[string][]map[string]any) (*Rulebook, error) {
+	copied := &Rulebook{SchemaURI: rb.SchemaURI, ModelName: rb.ModelName, Description: rb.Description, Meta: rb.Meta}
+	for _, t := range rb.Tables {
+		rows, ok := data[t.Name]
+		if !ok {
+			rows = t.Data
+		}
+		copied.Tables = append(copied.Tables, &Table{Name: t.Name, Description: t.Description, Schema: t.Schema, Data: rows})
+	}
+	encoded, err := copied.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return ParseRulebook(encoded)
+}
+
+// AddCandidate adds a record; its id must be new (see InsertCandidate)
+func (s *Store) AddCandidate(lc LanguageCandidate) error {
+	return s.Transact(func(tx *Tx) error { return tx.AddCandidate(lc) })
+}
+
+// InsertCandidate adds a record and returns its id, generated from its
+// Name if blank (see erb_ids.go)
+func (s *Store) InsertCandidate(lc LanguageCandidate) (string, error) {
+	var id string
+	err := s.Transact(func(tx *Tx) (err error) {
+		id, err = tx.InsertCandidate(lc)
+		return err
+	})
+	return id, err
+}
+
+// AddArgumentStep adds an argument step and returns its id, generated if
+// blank; its RelatedCandidateId, if set, must name a candidate
+func (s *Store) AddArgumentStep(step IsEverythingALanguage) (string, error) {
+	var id string
+	err := s.Transact(func(tx *Tx) (err error) {
+		id, err = tx.AddArgumentStep(step)
+		return err
+	})
+	return id, err
+}
+
+// ArgumentSteps returns every argument step, in rulebook order
+func (s *Store) ArgumentSteps() []IsEverythingALanguage {
+	return s.Snapshot().ArgumentSteps()
+}
+
+// UpdateCandidate sets raw fields of a record (names in any casing; nil
+// clears a field)
+func (s *Store) UpdateCandidate(id string, changes map[string]any) error {
+	return s.Transact(func(tx *Tx) error { return tx.UpdateCandidate(id, changes) })
+}
+
+// DeleteCandidate removes a record no argument step references (see Tx.DeleteCandidate)
+func (s *Store) DeleteCandidate(id string) error {
+	return s.Transact(func(tx *Tx) error { return tx.DeleteCandidate(id) })
+}
+
+// Set sets one raw field of a record
+func (s *Store) Set(id, field string, value any) error {
+	return s.UpdateCandidate(id, map[string]any{field: value})
+}
+
+// Preview reports the calculated fields UpdateCandidate(id, changes) would
+// change, with their current and new values, without changing the store
+func (s *Store) Preview(id string, changes map[string]any) ([]FieldChanged, error) {
+	tx := s.Begin()
+	defer tx.Rollback()
+	before, _ := tx.Candidate(id)
+	if err := tx.UpdateCandidate(id, changes); err != nil {
+		return nil, err
+	}
+	after, _ := tx.Candidate(id)
+	_, calculated := fieldChanges(&before, &after)
+	return calculated, nil
+}
+
+// OnChange calls fn with every field a commit alters, record by record, raw
+// fields first (an added record's fields change from nil, a deleted one's to
+// nil), then the fields of added argument steps, and returns the function
+// that stops it
+func (s *Store) OnChange(fn func(id, field string, old, new any)) func() {
+	return Subscribe(s.bus, func(e FieldChanged) { fn(e.RecordID, e.Field, e.Old, e.New) })
+}
+
+// OnCommit calls fn with the snapshot every commit creates, after its
+// OnChange calls, and returns the function that stops it
+func (s *Store) OnCommit(fn func(*StoreSnapshot)) func() {
+	return Subscribe(s.bus, func(e StoreCommitted) { fn(e.Snapshot) })
+}
+
+// Transact runs fn in a transaction, committing it if fn succeeds and
+// rolling it back if fn fails. If another commit lands first, fn is run
+// again on the new snapshot, so it must not have other side effects.
+func (s *Store) Transact(fn func(tx *Tx) error) error {
+	for {
+		tx := s.Begin()
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != ErrTxConflict {
+			return err
+		}
+	}
+}
+
+// Begin starts a transaction on the store's current snapshot
+func (s *Store) Begin() *Tx {
+	return &Tx{store: s, base: s.Snapshot(), edits: map[string]*LanguageCandidate{}}
+}
+
+// =============================================================================
+// TRANSACTIONS
+// =============================================================================
+
+// Tx is a set of edits that are applied to the store together by Commit
+type Tx struct {
+	store *Store
+	base  *StoreSnapshot                // the snapshot the transaction began from
+	edits map[string]*LanguageCandidate // id -> edited record, nil when deleted
+	order []string                      // edited ids, in order of first edit
+	steps []IsEverythingALanguage       // added argument steps
+	done  bool
+}
+
+// Candidate returns the record with the id, as the transaction sees it
+func (tx *Tx) Candidate(id string) (LanguageCandidate, bool) {
+	if lc, ok := tx.edits[id]; ok {
+		if lc == nil {
+			return LanguageCandidate{}, false
+		}
+		return cloneRecord(*lc), true
+	}
+	return tx.base.Candidate(id)
+}
+
+// AddCandidate adds a record, computing its calculated fields (any values
+// given for them are replaced)
+func (tx *Tx) AddCandidate(lc LanguageCandidate) error {
+	_, err := tx.InsertCandidate(lc)
+	return err
+}
+
+// InsertCandidate is AddCandidate, returning the record's id: a blank
+// LanguageCandidateId is generated from the Name, unique in the transaction
+func (tx *Tx) InsertCandidate(lc LanguageCandidate) (string, error) {
+	if tx.done {
+		return "", ErrTxDone
+	}
+	if lc.LanguageCandidateId == "" {
+		if optGet(lc.Name, "") == "" {
+			return "", fmt.Errorf("a LanguageCandidates record needs a LanguageCandidateId or a Name")
+		}
+		lc.LanguageCandidateId = uniqueSlugID(*lc.Name, "candidate", func(id string) bool {
+			_, taken := tx.Candidate(id)
+			return taken
+		})
+	} else if _, ok := tx.Candidate(lc.LanguageCandidateId); ok {
+		return "", fmt.Errorf("LanguageCandidates record %q already exists", lc.LanguageCandidateId)
+	}
+	lc = cloneRecord(lc)
+	tx.edit(lc.LanguageCandidateId, lc.ComputeAll())
+	return lc.LanguageCandidateId, nil
+}
+
+// ArgumentSteps returns every argument step, as the transaction sees it
+func (tx *Tx) ArgumentSteps() []IsEverythingALanguage {
+	return append(tx.base.ArgumentSteps(), cloneRecords(tx.steps)...)
+}
+
+// AddArgumentStep adds an argument step and returns its id: a blank
+// IsEverythingALanguageId continues the table's numbering. A set
+// RelatedCandidateId must name a candidate the transaction sees, and a
+// blank RelatedCandidateName is filled in from it.
+func (tx *Tx) AddArgumentStep(step IsEverythingALanguage) (string, error) {
+	if tx.done {
+		return "", ErrTxDone
+	}
+	steps := tx.ArgumentSteps()
+	ids := make([]string, len(steps))
+	for i, s := range steps {
+		ids[i] = s.IsEverythingALanguageId
+	}
+	if step.IsEverythingALanguageId == "" {
+		step.IsEverythingALanguageId = nextSequenceID(ids, "step")
+	} else if containsString(ids, step.IsEverythingALanguageId) {
+		return "", fmt.Errorf("IsEverythingALanguage record %q already exists", step.IsEverythingALanguageId)
+	}
+	if id := optGet(step.RelatedCandidateId, ""); id != "" {
+		lc, ok := tx.Candidate(id)
+		if !ok {
+			return "", fmt.Errorf("%s: RelatedCandidateId %q: %w", step.IsEverythingALanguageId, id, ErrDanglingReference)
+		}
+		if optGet(step.RelatedCandidateName, "") == "" {
+			step.RelatedCandidateName = lc.Name
+		}
+	}
+	tx.steps = append(tx.steps, cloneRecord(step))
+	return step.IsEverythingALanguageId, nil
+}
+
+// UpdateCandidate sets raw fields of a record and recomputes it; no field
+// is set if any change is invalid
+func (tx *Tx) UpdateCandidate(id string, changes map[string]any) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	edited, ok := tx.Candidate(id)
+	if !ok {
+		return fmt.Errorf("no LanguageCandidates record %q", id)
+	}
+	for _, field := range sortedKeys(changes) {
+		if err := setCandidateField(&edited, field, changes[field]); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	tx.edit(id, edited.ComputeAll())
+	return nil
+}
+
+// DeleteCandidate removes a record; it fails with ErrDanglingReference while
+// an argument step names the record as its RelatedCandidateId
+func (tx *Tx) DeleteCandidate(id string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if _, ok := tx.Candidate(id); !ok {
+		return fmt.Errorf("no LanguageCandidates record %q", id)
+	}
+	for _, step := range tx.ArgumentSteps() {
+		if optGet(step.RelatedCandidateId, "") == id {
+			return fmt.Errorf("cannot delete %q: argument step %s names it as its RelatedCandidateId: %w", id, step.IsEverythingALanguageId, ErrDanglingReference)
+		}
+	}
+	tx.edit(id, nil)
+	return nil
+}
+
+func (tx *Tx) edit(id string, lc *LanguageCandidate) {
+	if _, ok := tx.edits[id]; !ok {
+		tx.order = append(tx.order, id)
+	}
+	tx.edits[id] = lc
+}
+
+// Commit applies the transaction's edits to the store as a new snapshot,
+// added records at the end, and reports the changed fields to the OnChange
+// observers
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	s := tx.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.current.Load()
+	if cur != tx.base {
+		return ErrTxConflict
+	}
+
+	next := &StoreSnapshot{Version: cur.Version + 1, index: cur.index, base: cur.base, steps: cur.steps}
+	if len(tx.steps) > 0 {
+		next.steps = append(append(make([]IsEverythingALanguage, 0, len(cur.steps)+len(tx.steps)), cur.steps...), tx.steps...)
+	}
+	next.records = append(make([]LanguageCandidate, 0, len(cur.records)+len(tx.order)), cur.records...)
+	reindex := false
+	for _, id := range tx.order {
+		lc := tx.edits[id]
+		i, exists := cur.index[id]
+		switch {
+		case lc == nil:
+			reindex = reindex || exists
+		case exists:
+			next.records[i] = *lc
+		default:
+			next.records = append(next.records, *lc)
+			reindex = true
+		}
+	}
+	if reindex {
+		kept := next.records[:0]
+		for _, lc := range next.records {
+			if edit, ok := tx.edits[lc.LanguageCandidateId]; !ok || edit != nil {
+				kept = append(kept, lc)
+			}
+		}
+		next.records = kept
+		next.index = make(map[string]int, len(kept))
+		for i, lc := range kept {
+			next.index[lc.LanguageCandidateId] = i
+		}
+	}
+	s.current.Store(next)
+	loggerOr(s.logger).Info("store committed", "version", next.Version, "edits", len(tx.order)+len(tx.steps), "records", len(next.records))
+
+	if HasSubscribers[FieldChanged](s.bus) {
+		for _, e := range tx.changes() {
+			s.bus.Publish(e)
+		}
+		for _, step := range tx.steps {
+			for _, field := range structFields[IsEverythingALanguage]() {
+				if v := recordField(&step, field); v != nil {
+					s.bus.Publish(FieldChanged{Table: "IsEverythingALanguage", RecordID: step.IsEverythingALanguageId, Field: field, New: v})
+				}
+			}
+		}
+	}
+	s.bus.Publish(StoreCommitted{Snapshot: next})
+	return nil
+}
+
+// Rollback discards the transaction's edits
+func (tx *Tx) Rollback() {
+	tx.done = true
+	tx.edits, tx.order, tx.steps = nil, nil, nil
+}
+
+// changes returns every field the transaction's edits change, record by
+// record in order of first edit, raw fields first
+func (tx *Tx) changes() []FieldChanged {
+	var changes []FieldChanged
+	for _, id := range tx.order {
+		old, new := &LanguageCandidate{LanguageCandidateId: id}, &LanguageCandidate{LanguageCandidateId: id}
+		if lc, ok := tx.base.Candidate(id); ok {
+			old = &lc
+		}
+		if lc, ok := tx.Candidate(id); ok {
+			new = &lc
+		}
+		raw, calculated := fieldChanges(old, new)
+		changes = append(append(changes, raw...), calculated...)
+	}
+	return changes
+}
+
+// fieldChanges returns the raw and the calculated fields that differ between
+// two versions of a record, in schema order
+func fieldChanges(old, new *LanguageCandidate) (raw, calculated []FieldChanged) {
+	for _, field := range candidateFields() {
+		from, to := recordField(old, field), recordField(new, field)
+		if valuesEqual(from, to) {
+			continue
+		}
+		e := FieldChanged{Table: "LanguageCandidates", RecordID: new.LanguageCandidateId, Field: field, Old: from, New: to}
+		if _, ok := LanguageCandidateFormulas[field]; ok {
+			calculated = append(calculated, e)
+		} else {
+			raw = append(raw, e)
+		}
+	}
+	return raw, calculated
+}
+
+// candidateFields are the LanguageCandidate field names, in schema order
+func candidateFields() []string {
+	return structFields[LanguageCandidate]()
+}
+
+// structFields are the field names of a generated record struct, in schema order
+func structFields[T any]() []string {
+	t := reflect.TypeFor[T]()
+	fields := make([]string, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i).Name
+	}
+	return fields
+}
+
+// setCandidateField sets a raw field of lc; a new pointer is stored, so
+// copies sharing lc's values are unaffected
+func setCandidateField(lc *LanguageCandidate, field string, value any) error {
+	sf, found := candidateStructField(field)
+	switch {
+	case !found:
+		return fmt.Errorf("unknown field %q", field)
+	case sf.Name == "LanguageCandidateId":
+		return fmt.Errorf("the id cannot be changed")
+	}
+	if _, ok := LanguageCandidateFormulas[sf.Name]; ok {
+		return fmt.Errorf("%s is calculated", sf.Name)
+	}
+
+	converted, err := importValue(value, sf.Type)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sf.Name, err)
+	}
+	target := reflect.ValueOf(lc).Elem().FieldByIndex(sf.Index)
+	if converted == nil {
+		target.Set(reflect.Zero(sf.Type))
+		return nil
+	}
+	p := reflect.New(sf.Type.Elem())
+	p.Elem().Set(reflect.ValueOf(converted))
+	target.Set(p)
+	return nil
+}
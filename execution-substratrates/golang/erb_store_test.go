@@ -0,0 +1,99 @@
+// ERB SDK - Store Tests
+// =====================
+// Unit tests for the store: records are deep copies in and out, so no
+// caller can edit the store, or a snapshot, behind its transactions, and
+// argument steps never point at a deleted candidate:
+//
+//	go test $(ls *.go) -run Store
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// storeForTest returns a store of the default rulebook
+func storeForTest(t *testing.T) (*Rulebook, *Store) {
+	t.Helper()
+	rb, err := LoadFromRulebook(DefaultRulebookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rb, NewStore(rb)
+}
+
+func TestStoreReturnsCopies(t *testing.T) {
+	_, store := storeForTest(t)
+	snap := store.Snapshot()
+
+	lc, ok := store.Candidate("english")
+	if !ok {
+		t.Fatal("no english candidate")
+	}
+	*lc.CanBeHeld = !*lc.CanBeHeld
+	*lc.TopFamilyFeudAnswer = !*lc.TopFamilyFeudAnswer
+	all := snap.Candidates()
+	*all[0].Name = "Mutated"
+	steps := store.ArgumentSteps()
+	*steps[0].Statement = "Mutated"
+
+	for _, read := range []*StoreSnapshot{snap, store.Snapshot()} {
+		again, _ := read.Candidate("english")
+		if *again.CanBeHeld == *lc.CanBeHeld || *again.TopFamilyFeudAnswer == *lc.TopFamilyFeudAnswer {
+			t.Errorf("mutating a returned record changed the store: CanBeHeld %v, TopFamilyFeudAnswer %v",
+				*again.CanBeHeld, *again.TopFamilyFeudAnswer)
+		}
+		if got := read.Candidates()[0].Name; *got == "Mutated" {
+			t.Error("mutating a record returned by Candidates changed the store")
+		}
+		if got := read.ArgumentSteps()[0].Statement; *got == "Mutated" {
+			t.Error("mutating a step returned by ArgumentSteps changed the store")
+		}
+	}
+}
+
+func TestStoreCopiesInput(t *testing.T) {
+	rb, store := storeForTest(t)
+	id := rb.LanguageCandidates[0].LanguageCandidateId
+	*rb.LanguageCandidates[0].Name = "Mutated"
+	if lc, _ := store.Candidate(id); *lc.Name == "Mutated" {
+		t.Error("mutating the rulebook after NewStore changed the store")
+	}
+
+	name := "Latin"
+	if err := store.AddCandidate(LanguageCandidate{LanguageCandidateId: "latin", Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	name = "Mutated"
+	if lc, _ := store.Candidate("latin"); *lc.Name != "Latin" {
+		t.Errorf("mutating an added record changed the store: Name %q", *lc.Name)
+	}
+}
+
+func TestStoreDeleteReferencedCandidate(t *testing.T) {
+	_, store := storeForTest(t)
+
+	// neial-004 names english as its RelatedCandidateId
+	if err := store.DeleteCandidate("english"); !errors.Is(err, ErrDanglingReference) {
+		t.Errorf("deleting english: got %v, want ErrDanglingReference", err)
+	}
+	if _, ok := store.Candidate("english"); !ok {
+		t.Error("english was deleted though neial-004 references it")
+	}
+
+	// A step added in the same transaction counts too
+	err := store.Transact(func(tx *Tx) error {
+		if _, err := tx.AddArgumentStep(IsEverythingALanguage{RelatedCandidateId: optPtr("french")}); err != nil {
+			return err
+		}
+		return tx.DeleteCandidate("french")
+	})
+	if !errors.Is(err, ErrDanglingReference) {
+		t.Errorf("deleting french after adding a step naming it: got %v, want ErrDanglingReference", err)
+	}
+
+	if err := store.DeleteCandidate("french"); err != nil {
+		t.Errorf("deleting unreferenced french: %v", err)
+	}
+}
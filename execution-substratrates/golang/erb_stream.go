@@ -0,0 +1,158 @@
+// ERB SDK - Streaming Records
+// ===========================
+// LoadRecords reads a whole file before computing it. StreamRecords decodes a
+// JSON array one element at a time instead, so memory stays at one record no
+// matter how large the file is, and RecordStreamWriter writes the computed
+// records back out as they arrive, in the layout of the json exporter:
+//
+//	out := NewRecordStreamWriter(w)
+//	err := StreamRecords(r, func(c LanguageCandidate) error {
+//		return out.Write(c.ComputeAll())
+//	})
+//	if err == nil {
+//		err = out.Close()
+//	}
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StreamRecords calls fn with every record of a JSON array of candidates, in
+// order, and stops at the first error fn returns
+func StreamRecords(r io.Reader, fn func(LanguageCandidate) error) error {
+	return streamRecords(r, fn)
+}
+
+func streamRecords[T any](r io.Reader, fn func(T) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to parse records: %w", err)
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("failed to parse records: expected an array, got %v", tok)
+	}
+
+	for i := 0; dec.More(); i++ {
+		var record T
+		if err := dec.Decode(&record); err != nil {
+			return fmt.Errorf("failed to parse record %d: %w", i, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to parse records: %w", err)
+	}
+	return nil
+}
+
+// RecordStreamWriter writes records one at a time as a canonical JSON array
+// (see MarshalCanonical); Close ends the array and flushes it
+type RecordStreamWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+// NewRecordStreamWriter starts a JSON array on w
+func NewRecordStreamWriter(w io.Writer) *RecordStreamWriter {
+	return &RecordStreamWriter{w: bufio.NewWriter(w)}
+}
+
+// Write appends one record (a generated struct, a pointer to one, or a Record)
+func (s *RecordStreamWriter) Write(record any) error {
+	data, err := MarshalCanonical(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record %d: %w", s.count, err)
+	}
+	// Indent the record one level; JSON strings hold no raw newlines
+	data = bytes.ReplaceAll(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"), []byte("\n  "))
+
+	sep := ",\n  "
+	if s.count == 0 {
+		sep = "[\n  "
+	}
+	s.count++
+	if _, err := s.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = s.w.Write(data)
+	return err
+}
+
+// Close ends the array and flushes; it does not close the underlying writer
+func (s *RecordStreamWriter) Close() error {
+	end := "\n]\n"
+	if s.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := s.w.WriteString(end); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runStream implements `stream [--in FILE] [--out FILE]`: computes a candidate
+// file record by record (stdin and stdout by default)
+func runStream(args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ContinueOnError)
+	inPath := fs.String("in", "-", "JSON array of candidates to compute (- for stdin)")
+	outPath := fs.String("out", "-", "file to write the computed candidates to (- for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *inPath != "-" {
+		f, err := os.Open(*inPath)
+		if err != nil {
+			return fmt.Errorf("failed to open input: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	out := io.Writer(os.Stdout)
+	var outFile *os.File
+	if *outPath != "-" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output: %w", err)
+		}
+		outFile, out = f, f
+	}
+
+	w := NewRecordStreamWriter(out)
+	err := StreamRecords(in, func(c LanguageCandidate) error {
+		return w.Write(c.ComputeAll())
+	})
+	if err == nil {
+		if err = w.Close(); err != nil {
+			err = fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	// The file is closed explicitly, after the closing bracket is written,
+	// so a failed flush to disk fails the command
+	if outFile != nil {
+		if closeErr := outFile.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write output: %w", closeErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if *outPath != "-" {
+		fmt.Fprintf(os.Stderr, "Computed %d LanguageCandidates records, saved results to %s\n", w.count, *outPath)
+	}
+	return nil
+}
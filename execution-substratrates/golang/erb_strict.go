@@ -0,0 +1,201 @@
+// ERB SDK - Strict Record Loading
+// ===============================
+// By default LoadRecords ignores keys it does not know, so a typo such as
+// has_sytax silently leaves has_syntax null. WithStrictFields rejects such
+// files instead, reporting the unexpected and missing keys of every record:
+//
+//	records, err := LoadRecords("blank-test.json", WithStrictFields())
+//	var fe *FieldError
+//	if errors.As(err, &fe) {
+//		for _, r := range fe.Reports { ... }
+//	}
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// RecordOption configures LoadRecords and record computation
+type RecordOption func(*recordConfig)
+
+type recordConfig struct {
+	strict       bool
+	workers      int               // see WithWorkers
+	emptyStrings EmptyStringPolicy // see WithEmptyStrings
+	tolerant     bool              // see WithTolerantFields
+	onAlias      func(AliasUse)
+	views        *ViewCache      // see WithViewCache
+	partial      bool            // see WithPartialLoad
+	logger       *slog.Logger    // see WithComputeLogger
+	traceCtx     context.Context // see WithTraceContext
+	ctx          context.Context // see withContext; nil: never cancelled
+}
+
+// WithStrictFields fails loading when a record has keys the table does not
+// define, or lacks keys it does
+func WithStrictFields() RecordOption {
+	return func(c *recordConfig) {
+		c.strict = true
+	}
+}
+
+// FieldReport lists the key problems of one record
+type FieldReport struct {
+	Record     int      `json:"record"` // zero-based position in the file
+	ID         string   `json:"id,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	Missing    []string `json:"missing,omitempty"`
+}
+
+func (r FieldReport) String() string {
+	name := fmt.Sprintf("record %d", r.Record)
+	if r.ID != "" {
+		name += " (" + r.ID + ")"
+	}
+	return name + ": " + r.problems()
+}
+
+// problems describes the record's unexpected and missing keys
+func (r FieldReport) problems() string {
+	var parts []string
+	if len(r.Unexpected) > 0 {
+		parts = append(parts, "unexpected "+strings.Join(r.Unexpected, ", "))
+	}
+	if len(r.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(r.Missing, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// FieldError is returned by a strict load when any record has key problems
+type FieldError struct {
+	Reports []FieldReport
+}
+
+func (e *FieldError) Error() string {
+	lines := make([]string, len(e.Reports))
+	for i, r := range e.Reports {
+		lines[i] = r.String()
+	}
+	return fmt.Sprintf("%d records have unknown or missing fields:\n  %s", len(e.Reports), strings.Join(lines, "\n  "))
+}
+
+// CheckRecordFields compares the keys of each record in a JSON array with
+// the JSON fields of T, returning a report for every record that differs
+func CheckRecordFields[T any](data []byte) ([]FieldReport, error) {
+	return checkRecordFields(reflect.TypeFor[T](), data)
+}
+
+// checkRecordFields is CheckRecordFields for a record type known at run time
+func checkRecordFields(t reflect.Type, data []byte) ([]FieldReport, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	required := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name, omitempty := jsonKey(t.Field(i))
+		if name == "" || !t.Field(i).IsExported() {
+			continue
+		}
+		fields = append(fields, name)
+		required[name] = !omitempty
+	}
+
+	var reports []FieldReport
+	for i, rec := range raw {
+		report := FieldReport{Record: i}
+		if len(fields) > 0 {
+			var id string
+			if json.Unmarshal(rec[fields[0]], &id) == nil {
+				report.ID = id
+			}
+		}
+		for _, k := range sortedKeys(rec) {
+			if _, ok := required[k]; !ok {
+				report.Unexpected = append(report.Unexpected, k)
+			}
+		}
+		for _, k := range fields {
+			if _, ok := rec[k]; !ok && required[k] {
+				report.Missing = append(report.Missing, k)
+			}
+		}
+		if len(report.Unexpected) > 0 || len(report.Missing) > 0 {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// decodeRecords unmarshals a JSON array of records, renaming keys spelled
+// another way with WithTolerantFields and rejecting unknown and missing
+// fields when WithStrictFields is given
+func decodeRecords[T any](data []byte, opts []RecordOption) ([]T, error) {
+	cfg := recordConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	typeName := reflect.TypeFor[T]().Name()
+	_, span := startSpan(cfg.traceContext(), "erb.load_records", slog.String("erb.type", typeName))
+	records, err := decodeRecordsConfig[T](data, cfg)
+	var skipped *MultiError
+	if err == nil || errors.As(err, &skipped) {
+		n := 0
+		if skipped != nil {
+			n = len(skipped.Errors)
+		}
+		loggerOr(cfg.logger).Info("records loaded", "type", typeName, "records", len(records), "skipped", n)
+		span.SetAttributes(slog.Int("erb.records", len(records)), slog.Int("erb.skipped", n))
+		span.End() // a partial load is not a failed one
+		return records, err
+	}
+	endSpan(span, err)
+	return records, err
+}
+
+// decodeRecordsConfig is decodeRecords with its options applied
+func decodeRecordsConfig[T any](data []byte, cfg recordConfig) ([]T, error) {
+	if cfg.tolerant {
+		var err error
+		if data, err = normalizeRecordKeys(reflect.TypeFor[T](), data, cfg.onAlias); err != nil {
+			return nil, err
+		}
+	}
+
+	decoding := recordDecoding{disallowUnknown: cfg.strict, partial: cfg.partial}
+	if cfg.strict {
+		reports, err := CheckRecordFields[T](data)
+		if err != nil {
+			return nil, err
+		}
+		if len(reports) > 0 && !cfg.partial {
+			return nil, &FieldError{Reports: reports}
+		}
+		decoding.rejected = map[int]error{}
+		for _, r := range reports {
+			bad := &MalformedRecordError{Index: r.Record, Err: errors.New(r.problems())}
+			switch {
+			case len(r.Unexpected) == 1 && len(r.Missing) == 0:
+				bad.Field = r.Unexpected[0]
+			case len(r.Unexpected) == 0 && len(r.Missing) == 1:
+				bad.Field = r.Missing[0]
+			}
+			decoding.rejected[r.Record] = bad
+		}
+	}
+
+	var records []T
+	err := decodeRecordArray(data, &records, decoding)
+	return records, err
+}
@@ -0,0 +1,444 @@
+// ERB SDK - Candidate Templates
+// =============================
+// Archetypes new records start from instead of a copy of the nearest
+// existing row. A template names a table, the default values of its raw
+// fields and the records derived from it; templates live next to the
+// rulebook in candidate-templates.json:
+//
+//	derive physical-artifact a-hammer --set Name="A Hammer" --set SortOrder=26
+//
+// adds a LanguageCandidates record with the physical-artifact criteria (and
+// its calculated fields computed) to the JSON rulebook and lists it as a
+// member. Values that differ from the template are overrides: `templates`
+// lists every member's overrides, so a deliberate deviation stays visible
+// and an accidental one stands out.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CandidateTemplate is a named set of default values for new records
+type CandidateTemplate struct {
+	Name        string         `json:"name"`
+	Table       string         `json:"table"`
+	Description string         `json:"description,omitempty"`
+	Defaults    map[string]any `json:"defaults"` // raw field name -> value
+	Members     []string       `json:"members"`  // IDs of the records derived from it
+}
+
+// TemplateOverride is a member's value that differs from its template's default
+type TemplateOverride struct {
+	Template string `json:"template"`
+	Table    string `json:"table"`
+	ID       string `json:"id"`
+	Field    string `json:"field"`
+	Default  any    `json:"default"`
+	Value    any    `json:"value"`
+}
+
+func (o TemplateOverride) String() string {
+	return fmt.Sprintf("%s overrides %s: %s -> %s", o.ID, o.Field, pgDiffValue(o.Default), pgDiffValue(o.Value))
+}
+
+// CandidateTemplatesPath is the template file of a rulebook
+func CandidateTemplatesPath(rulebookPath string) string {
+	return filepath.Join(filepath.Dir(rulebookPath), "candidate-templates.json")
+}
+
+// LoadCandidateTemplates reads a template file; a missing file has no templates
+func LoadCandidateTemplates(path string) ([]CandidateTemplate, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var templates []CandidateTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i := range templates {
+		for field, v := range templates[i].Defaults {
+			templates[i].Defaults[field] = runtimeValue(v)
+		}
+	}
+	return templates, nil
+}
+
+// LookupTemplate returns the template called name
+func LookupTemplate(templates []CandidateTemplate, name string) (*CandidateTemplate, bool) {
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], true
+		}
+	}
+	return nil, false
+}
+
+// CheckTemplates reports templates that do not fit the rulebook: unknown
+// tables or members, defaults for fields that are not raw or have the wrong
+// type, and records that are members of more than one template
+func (rb *Rulebook) CheckTemplates(templates []CandidateTemplate) []error {
+	var errs []error
+	memberOf := map[string]string{}
+	for _, tmpl := range templates {
+		t := rb.Table(tmpl.Table)
+		if t == nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", tmpl.Name, unknownTable(tmpl.Table)))
+			continue
+		}
+		for _, field := range sortedKeys(tmpl.Defaults) {
+			if err := checkTemplateValue(t, field, tmpl.Defaults[field]); err != nil {
+				errs = append(errs, fmt.Errorf("template %s: %w", tmpl.Name, err))
+			}
+		}
+		ids := map[string]bool{}
+		for _, row := range t.Data {
+			ids[fmt.Sprint(row[t.IDField()])] = true
+		}
+		for _, id := range tmpl.Members {
+			key := tmpl.Table + "\x00" + id
+			switch {
+			case !ids[id]:
+				errs = append(errs, fmt.Errorf("template %s: no %s record %q", tmpl.Name, tmpl.Table, id))
+			case memberOf[key] != "":
+				errs = append(errs, fmt.Errorf("template %s: %s is already a member of %s", tmpl.Name, id, memberOf[key]))
+			default:
+				memberOf[key] = tmpl.Name
+			}
+		}
+	}
+	return errs
+}
+
+// checkTemplateValue checks that a template may set a field to v
+func checkTemplateValue(t *Table, field string, v any) error {
+	f, ok := t.Field(field)
+	switch {
+	case !ok:
+		return fmt.Errorf("%s has no field %s", t.Name, field)
+	case f.IsCalculated():
+		return fmt.Errorf("%s.%s is calculated", t.Name, field)
+	case f.Name == t.IDField():
+		return fmt.Errorf("%s.%s is the ID", t.Name, field)
+	}
+	valid := v == nil
+	switch v.(type) {
+	case bool:
+		valid = f.Datatype == "boolean"
+	case int:
+		valid = f.Datatype == "integer" || f.Datatype == "number"
+	case float64:
+		valid = f.Datatype == "number"
+	case string:
+		valid = f.Datatype == "string"
+	}
+	if !valid {
+		return fmt.Errorf("%s.%s is %s, not %s", t.Name, field, f.Datatype, pgDiffValue(v))
+	}
+	return nil
+}
+
+// TemplateOverrides lists the values of every template member that differ
+// from the template's defaults, in template and schema order
+func (rb *Rulebook) TemplateOverrides(templates []CandidateTemplate) []TemplateOverride {
+	var overrides []TemplateOverride
+	for _, tmpl := range templates {
+		t := rb.Table(tmpl.Table)
+		if t == nil {
+			continue
+		}
+		rows := map[string]map[string]any{}
+		for _, row := range t.Data {
+			rows[fmt.Sprint(row[t.IDField()])] = row
+		}
+		for _, id := range tmpl.Members {
+			row, ok := rows[id]
+			if !ok {
+				continue
+			}
+			for _, f := range t.Schema {
+				def, ok := tmpl.Defaults[f.Name]
+				if !ok {
+					continue
+				}
+				if value := runtimeValue(row[f.Name]); !reflect.DeepEqual(value, def) {
+					overrides = append(overrides, TemplateOverride{Template: tmpl.Name, Table: t.Name, ID: id, Field: f.Name, Default: def, Value: value})
+				}
+			}
+		}
+	}
+	return overrides
+}
+
+// Derive returns a new record of the template's table (rulebook field names,
+// schema order, nil values left out): the ID, the template's defaults with
+// the overrides applied, and the calculated fields computed from them
+func (rb *Rulebook) Derive(tmpl *CandidateTemplate, id string, overrides map[string]any) (Record, error) {
+	t := rb.Table(tmpl.Table)
+	if t == nil {
+		return Record{}, fmt.Errorf("template %s: %w", tmpl.Name, unknownTable(tmpl.Table))
+	}
+	if id == "" {
+		return Record{}, fmt.Errorf("a new record needs an ID")
+	}
+	for _, row := range t.Data {
+		if fmt.Sprint(row[t.IDField()]) == id {
+			return Record{}, fmt.Errorf("%s already has a record %q", t.Name, id)
+		}
+	}
+
+	row := map[string]any{t.IDField(): id}
+	for field, v := range tmpl.Defaults {
+		row[field] = v
+	}
+	for field, v := range overrides {
+		if err := checkTemplateValue(t, field, v); err != nil {
+			return Record{}, err
+		}
+		row[field] = v
+	}
+	computed, err := t.ComputeRow(row)
+	if err != nil {
+		return Record{}, err
+	}
+
+	rec := Record{Values: map[string]any{}}
+	for _, f := range t.Schema {
+		v := row[f.Name]
+		if f.IsCalculated() {
+			v, _ = computed.Get(toSnakeCase(f.Name))
+		}
+		if v != nil {
+			rec.Keys = append(rec.Keys, f.Name)
+			rec.Values[f.Name] = v
+		}
+	}
+	return rec, nil
+}
+
+// parseFieldValue converts command-line text to a value of a field's datatype;
+// empty text is nil
+func parseFieldValue(f Field, text string) (any, error) {
+	if text == "" {
+		return nil, nil
+	}
+	switch f.Datatype {
+	case "boolean":
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a boolean", f.Name, text)
+		}
+		return b, nil
+	case "integer":
+		i, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", f.Name, text)
+		}
+		return i, nil
+	case "number":
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", f.Name, text)
+		}
+		return n, nil
+	}
+	return text, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// fieldAssignments collects repeated --set Field=Value flags
+type fieldAssignments []string
+
+func (a *fieldAssignments) String() string { return strings.Join(*a, " ") }
+
+func (a *fieldAssignments) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("want Field=Value, got %q", s)
+	}
+	*a = append(*a, s)
+	return nil
+}
+
+// runTemplates implements `templates [--rulebook PATH] [--templates FILE]
+// [--json]`: lists the templates, their members and the members' overrides
+func runTemplates(args []string) error {
+	fs := flag.NewFlagSet("templates", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	templatesPath := fs.String("templates", "", "template file (default: candidate-templates.json next to the rulebook)")
+	asJSON := fs.Bool("json", false, "print the overrides as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templatesPath == "" {
+		*templatesPath = CandidateTemplatesPath(*rulebookPath)
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+	templates, err := LoadCandidateTemplates(*templatesPath)
+	if err != nil {
+		return err
+	}
+	if errs := rb.CheckTemplates(templates); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	overrides := rb.TemplateOverrides(templates)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(append([]TemplateOverride{}, overrides...))
+	}
+
+	for _, tmpl := range templates {
+		fmt.Printf("%s (%s, %d members, %d defaults)", tmpl.Name, tmpl.Table, len(tmpl.Members), len(tmpl.Defaults))
+		if tmpl.Description != "" {
+			fmt.Printf(": %s", tmpl.Description)
+		}
+		fmt.Println()
+		for _, o := range overrides {
+			if o.Template == tmpl.Name {
+				fmt.Printf("  %s\n", o)
+			}
+		}
+	}
+	fmt.Printf("%d templates, %d overrides\n", len(templates), len(overrides))
+	return nil
+}
+
+// runDerive implements `derive TEMPLATE [ID] [--set Field=Value]...
+// [--rulebook PATH] [--templates FILE] [--dry-run]`; without ID, one is
+// generated from the Name (see erb_ids.go)
+func runDerive(args []string) error {
+	const usage = "usage: derive TEMPLATE [ID] [--set Field=Value]... [flags]"
+	fs := flag.NewFlagSet("derive", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the JSON rulebook to edit")
+	templatesPath := fs.String("templates", "", "template file (default: candidate-templates.json next to the rulebook)")
+	dryRun := fs.Bool("dry-run", false, "print the new record instead of editing the rulebook")
+	var sets fieldAssignments
+	fs.Var(&sets, "set", "Field=Value to set instead of the template's default (repeatable)")
+
+	// TEMPLATE and ID come first; flags may follow them
+	var positional []string
+	rest := args
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional, rest = append(positional, rest[0]), rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) != 1 && len(positional) != 2 {
+		return fmt.Errorf(usage)
+	}
+	if *templatesPath == "" {
+		*templatesPath = CandidateTemplatesPath(*rulebookPath)
+	}
+
+	data, err := os.ReadFile(*rulebookPath)
+	if err != nil {
+		return rulebookReadError(err)
+	}
+	if DetectRulebookFormat(*rulebookPath, data) != FormatJSON {
+		return fmt.Errorf("deriving records needs a JSON rulebook")
+	}
+	rb, err := ParseRulebook(data)
+	if err != nil {
+		return err
+	}
+	templates, err := LoadCandidateTemplates(*templatesPath)
+	if err != nil {
+		return err
+	}
+	tmpl, ok := LookupTemplate(templates, positional[0])
+	if !ok {
+		return fmt.Errorf("no template %q in %s", positional[0], *templatesPath)
+	}
+	t := rb.Table(tmpl.Table)
+	if t == nil {
+		return fmt.Errorf("template %s: %w", tmpl.Name, unknownTable(tmpl.Table))
+	}
+
+	overrides := map[string]any{}
+	for _, s := range sets {
+		name, text, _ := strings.Cut(s, "=")
+		f, ok := t.Field(name)
+		if !ok {
+			return fmt.Errorf("%s has no field %s", t.Name, name)
+		}
+		if overrides[f.Name], err = parseFieldValue(f, text); err != nil {
+			return err
+		}
+	}
+	if len(positional) == 1 {
+		name, _ := overrides["Name"].(string)
+		if name == "" {
+			name, _ = tmpl.Defaults["Name"].(string)
+		}
+		if name == "" {
+			return fmt.Errorf("derive needs an ID or --set Name=...")
+		}
+		ids := map[string]bool{}
+		for _, row := range t.Data {
+			ids[fmt.Sprint(row[t.IDField()])] = true
+		}
+		positional = append(positional, uniqueSlugID(name, "candidate", func(id string) bool { return ids[id] }))
+	}
+	rec, err := rb.Derive(tmpl, positional[1], overrides)
+	if err != nil {
+		return err
+	}
+	for _, f := range t.Schema {
+		if _, set := rec.Values[f.Name]; !set && !f.IsCalculated() {
+			fmt.Fprintf(os.Stderr, "note: %s has no default in %s; set it with --set %s=...\n", f.Name, tmpl.Name, f.Name)
+		}
+	}
+
+	if *dryRun {
+		out, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	edited, err := appendTableEntry(data, t.Name, "data", rec)
+	if err != nil {
+		return err
+	}
+	if _, err := ParseRulebook(edited); err != nil {
+		return fmt.Errorf("edited rulebook does not load: %w", err)
+	}
+	tmpl.Members = append(tmpl.Members, positional[1])
+	templateData, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*rulebookPath, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write rulebook: %w", err)
+	}
+	if err := os.WriteFile(*templatesPath, append(templateData, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *templatesPath, err)
+	}
+	fmt.Printf("Added %s %s from template %s to %s\n", t.Name, positional[1], tmpl.Name, *rulebookPath)
+	return nil
+}
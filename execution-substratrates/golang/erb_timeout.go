@@ -0,0 +1,152 @@
+// ERB SDK - Timeouts
+// ==================
+// Commands run under commandContext, which --timeout bounds, so rulebook
+// (local or remote) and snapshot loads, computing blank tests, LLM calls, and
+// the psql, sqlite3, age and git subprocesses cannot hang an interactive
+// session. A timeout given before the command (or in $ERB_TIMEOUT) applies to
+// any command but the servers (serve, grpc), which run until stopped;
+// commands that wait on the network or a subprocess (pgsync, sqlite,
+// history, changelog, bulk-add, pipeline run, compute) also take their own
+// --timeout, and serve's applies to each request:
+//
+//	erb --timeout 30s pgsync push
+//	erb history --snapshots https://example.org/erb --timeout 10s
+//	erb serve --rulebook https://example.org/rulebook.json --timeout 5s
+//
+// When the deadline passes the work in flight fails with
+// context.DeadlineExceeded.
+//
+// Only command implementations read commandContext; the SDK calls they make
+// take a ctx (LoadFromRulebookContext, SnapshotReader.Index, ...), and the
+// servers give each request its own (r.Context()) and their watcher its own.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// timeoutUsage is the help text of every --timeout flag
+const timeoutUsage = "give up after this long, e.g. 30s or 2m (0: no limit)"
+
+// longRunningCommands run until stopped, so the global --timeout does not
+// bound them
+var longRunningCommands = map[string]bool{"serve": true, "grpc": true}
+
+// commandCtx is the context of the running command (see commandContext)
+var commandCtx = context.Background()
+
+// commandContext returns the context the running command's remote loads and
+// subprocesses use; it is done when a --timeout passes
+func commandContext() context.Context {
+	return commandCtx
+}
+
+// limitCommand bounds the rest of the command by timeout (0: no further
+// limit) and returns the function that releases it
+func limitCommand(timeout time.Duration) context.CancelFunc {
+	if timeout <= 0 {
+		return func() {}
+	}
+	parent := commandCtx
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	commandCtx = ctx
+	return func() {
+		cancel()
+		commandCtx = parent
+	}
+}
+
+// subprocess returns a command that is killed when ctx is done; output
+// pipes are closed a second later even if the process left children behind
+func subprocess(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// subprocessError describes a failed subprocess: the context's error if it
+// was killed for the deadline, else its stderr, else err
+func subprocessError(ctx context.Context, name string, stderr string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", name, ctx.Err())
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%s: %s", name, msg)
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
+
+// globalFlags are the flags given before the command name
+type globalFlags struct {
+	timeout  time.Duration
+	logLevel string // see erb_logging.go; "" leaves logging off
+	trace    string // see erb_tracing.go; "" leaves tracing off
+}
+
+// parseGlobalFlags reads the flags given before the command name (--timeout
+// D or $ERB_TIMEOUT, --log-level L or $ERB_LOG_LEVEL, --trace FILE or
+// $ERB_TRACE) and returns the remaining arguments; -h or --help prints them
+// and the commands, and returns flag.ErrHelp
+func parseGlobalFlags(args []string) (globalFlags, []string, error) {
+	flags := globalFlags{logLevel: os.Getenv("ERB_LOG_LEVEL"), trace: os.Getenv("ERB_TRACE")}
+	if env := os.Getenv("ERB_TIMEOUT"); env != "" {
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return flags, nil, fmt.Errorf("invalid ERB_TIMEOUT %q: %w", env, err)
+		}
+		flags.timeout = d
+	}
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		if name == "h" || name == "help" {
+			printGlobalUsage()
+			return flags, nil, flag.ErrHelp
+		}
+		if name != "timeout" && name != "log-level" && name != "trace" {
+			return flags, nil, fmt.Errorf("unknown global flag %s (want --timeout DURATION, --log-level LEVEL or --trace FILE before the command)", args[0])
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				return flags, nil, fmt.Errorf("--%s needs a value", name)
+			}
+			value, args = args[0], args[1:]
+		}
+		switch name {
+		case "log-level":
+			flags.logLevel = value
+			continue
+		case "trace":
+			flags.trace = value
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return flags, nil, fmt.Errorf("invalid --timeout %q: %w", value, err)
+		}
+		flags.timeout = d
+	}
+	return flags, args, nil
+}
+
+// printGlobalUsage prints the global flags and the commands
+func printGlobalUsage() {
+	fmt.Println("Usage: erb [--timeout DURATION] [--log-level LEVEL] [--trace FILE] <command> [flags]")
+	fmt.Println()
+	fmt.Println("Global flags:")
+	fmt.Println("  --timeout DURATION  give up after DURATION, e.g. 30s (default $ERB_TIMEOUT)")
+	fmt.Println("  --log-level LEVEL   log at LEVEL on stderr: debug, info, warn or error (default $ERB_LOG_LEVEL)")
+	fmt.Println("  --trace FILE        write trace spans to FILE as JSON lines (default $ERB_TRACE)")
+	fmt.Println()
+	printUsage()
+	fmt.Println()
+	fmt.Println("Run erb <command> -h for a command's flags.")
+}
@@ -0,0 +1,243 @@
+// ERB SDK - Tracing
+// =================
+// Loading, computing and saving records, the conformance runner and every
+// server request run in spans, so a trace of a larger system shows where a
+// substrate spent its time:
+//
+//	erb.take_test      one table of take-test (erb.table)
+//	erb.load_records   decoding records (erb.type, erb.records, erb.skipped)
+//	erb.compute_all    computing them (erb.records, erb.workers, erb.mismatches)
+//	erb.save_records   writing them (erb.path, erb.format, erb.records)
+//	GET /candidates    a server request, named by its route (http.status_code)
+//
+// Spans go to DefaultTracer, which drops them until it is set. Tracer has the
+// shape of OpenTelemetry's trace.Tracer, so the SDK needs no OpenTelemetry
+// dependency and an adapter is a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attrs ...slog.Attr) {
+//		for _, a := range attrs {
+//			s.span.SetAttributes(attribute.String(a.Key, a.Value.String()))
+//		}
+//	}
+//	func (s otelSpan) RecordError(err error) { s.span.RecordError(err); s.span.SetStatus(codes.Error, err.Error()) }
+//	func (s otelSpan) End()                  { s.span.End() }
+//
+//	DefaultTracer = otelTracer{otel.Tracer("erb")}
+//
+// Spans started by LoadRecords, ComputeAllRecords and SaveRecords are children
+// of the span in the WithTraceContext context. NewJSONTracer writes ended
+// spans as JSON lines instead; the CLI's global --trace FILE uses it:
+//
+//	erb --trace spans.jsonl take-test
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Tracer starts spans, like OpenTelemetry's trace.Tracer
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx (if any) and returns
+	// a context holding the new span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced operation
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error) // marks the span failed
+	End()
+}
+
+// DefaultTracer receives the SDK's spans
+var DefaultTracer Tracer = noopTracer{}
+
+// WithTraceContext starts the spans of loading, computing and saving records
+// as children of the span in ctx
+func WithTraceContext(ctx context.Context) RecordOption {
+	return func(c *recordConfig) {
+		c.traceCtx = ctx
+	}
+}
+
+// startSpan starts a span on DefaultTracer with attributes
+func startSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	if ctx == nil {
+		ctx = commandContext()
+	}
+	ctx, span := DefaultTracer.Start(ctx, name)
+	span.SetAttributes(attrs...)
+	return ctx, span
+}
+
+// endSpan records err (if any) on span and ends it
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// traceContext is the parent context of the spans the record options start
+func (c recordConfig) traceContext() context.Context {
+	switch {
+	case c.traceCtx != nil:
+		return c.traceCtx
+	case c.ctx != nil:
+		return c.ctx
+	}
+	return commandContext()
+}
+
+// mismatcher is a computed record that can disagree with its curation
+type mismatcher interface {
+	mismatched() bool
+}
+
+// mismatched reports whether the Family Feud test disagrees with ChosenLanguageCandidate
+func (tc *LanguageCandidate) mismatched() bool {
+	return optGet(tc.TopFamilyFeudAnswer, false) != optGet(tc.ChosenLanguageCandidate, false)
+}
+
+// mismatchAttrs returns erb.mismatches for records that can mismatch
+func mismatchAttrs[T any](records []T) []slog.Attr {
+	n, counted := 0, false
+	for i := range records {
+		m, ok := any(&records[i]).(mismatcher)
+		if !ok {
+			return nil
+		}
+		counted = true
+		if m.mismatched() {
+			n++
+		}
+	}
+	if !counted {
+		return nil
+	}
+	return []slog.Attr{slog.Int("erb.mismatches", n)}
+}
+
+// =============================================================================
+// TRACERS
+// =============================================================================
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// SpanRecord is an ended span as NewJSONTracer writes it
+type SpanRecord struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Name       string         `json:"name"`
+	Start      time.Time      `json:"start"`
+	DurationMS float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// NewJSONTracer returns a tracer writing each ended span to w as a line of JSON
+func NewJSONTracer(w io.Writer) Tracer {
+	return &jsonTracer{enc: json.NewEncoder(w)}
+}
+
+type jsonTracer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// jsonSpanKey holds the current *jsonSpan in a context
+type jsonSpanKey struct{}
+
+func (t *jsonTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &jsonSpan{tracer: t, rec: SpanRecord{Name: name, Start: time.Now(), SpanID: randomHex(8)}}
+	if parent, ok := ctx.Value(jsonSpanKey{}).(*jsonSpan); ok {
+		s.rec.TraceID, s.rec.ParentID = parent.rec.TraceID, parent.rec.SpanID
+	} else {
+		s.rec.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, jsonSpanKey{}, s), s
+}
+
+type jsonSpan struct {
+	tracer *jsonTracer
+	mu     sync.Mutex
+	rec    SpanRecord
+}
+
+func (s *jsonSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		if s.rec.Attributes == nil {
+			s.rec.Attributes = map[string]any{}
+		}
+		s.rec.Attributes[a.Key] = a.Value.Resolve().Any()
+	}
+}
+
+func (s *jsonSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Error = err.Error()
+}
+
+func (s *jsonSpan) End() {
+	s.mu.Lock()
+	rec := s.rec
+	s.mu.Unlock()
+	rec.DurationMS = float64(time.Since(rec.Start).Microseconds()) / 1000
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.enc.Encode(rec)
+}
+
+// traceToFile makes DefaultTracer write spans to a new JSON lines file at
+// path ("" leaves tracing off) and returns the function that closes it
+func traceToFile(path string) (func(), error) {
+	if path == "" {
+		return func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("--trace: %w", err)
+	}
+	DefaultTracer = NewJSONTracer(f)
+	return func() { f.Close() }, nil
+}
+
+// randomHex returns n random bytes as hex, for trace and span IDs
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,640 @@
+// ERB SDK - Formula Transpilers
+// =============================
+// Translates the rulebook's formulas into the other substrates' languages
+// from the same parsed AST the Go runtime evaluates, so every substrate's
+// calculation code is generated here instead of by a parser of its own:
+//
+//	go run $(ls *.go | grep -v _test.go) transpile --target python --out erb_calc.py
+//
+// A module has one calc function per calculated field, reading a record
+// keyed by snake_case field names, and a compute function per table that
+// fills in the calculated fields in DAG level order (empty strings and zero
+// integers become null, as in the generated ComputeAll). The Go nil handling
+// lives in a small runtime at the top of each module (formulaBool,
+// formulaText and compareFormulaValues ported), so translated expressions
+// behave like the Go code for any input, typed or not.
+//
+// Backends are registered by name like exporters:
+//
+//	RegisterFormulaBackend(myBackend{})
+//
+// Built-in targets: js (ES module), python, csharp. The postgres calc
+// functions have their own, statically typed translation (erb_sql.go).
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// FormulaBackend renders translated formulas in one target language.
+// Expressions are built from the shared AST walk in TranspileFormula: the
+// backend supplies literals, field access, conditionals and calls to its
+// runtime helpers, named in lower case:
+//
+//	bool(v)  text(v)  not(v)  and(v...)  or(v...)  concat(v...)
+//	compare(op, l, r)  lower(v)  find(needle, haystack)  nilIfZero(v)
+type FormulaBackend interface {
+	// Name is the target name used by --target (e.g. "python")
+	Name() string
+	// Extension is the file extension of a module (e.g. ".py")
+	Extension() string
+	// Literal renders a bool, int or string literal
+	Literal(v any) string
+	// Field renders the value of a record field by its snake_case key
+	Field(key string) string
+	// Call renders a call to a runtime helper
+	Call(helper string, args ...string) string
+	// Cond renders a conditional expression
+	Cond(cond, then, otherwise string) string
+	// Module renders a source file with the runtime helpers and the tables
+	Module(tables []TranspiledTable) string
+}
+
+// TranspiledTable is a table's translated calculated fields
+type TranspiledTable struct {
+	Table  *Table
+	Fields []TranspiledField // in DAG level order
+}
+
+// TranspiledField is one calculated field and its translated formula
+type TranspiledField struct {
+	Field Field
+	Key   string // snake_case record key
+	Expr  string
+}
+
+// formulaBackends holds the registered backends by name
+var formulaBackends = map[string]FormulaBackend{}
+
+func init() {
+	for _, b := range []FormulaBackend{jsBackend{}, pythonBackend{}, csharpBackend{}} {
+		RegisterFormulaBackend(b)
+	}
+}
+
+// RegisterFormulaBackend adds a backend, replacing any registered under the same name
+func RegisterFormulaBackend(b FormulaBackend) {
+	formulaBackends[b.Name()] = b
+}
+
+// LookupFormulaBackend returns the backend registered under name
+func LookupFormulaBackend(name string) (FormulaBackend, bool) {
+	b, ok := formulaBackends[strings.ToLower(name)]
+	return b, ok
+}
+
+// FormulaTargets returns the names of the registered backends
+func FormulaTargets() []string {
+	return sortedKeys(formulaBackends)
+}
+
+// Transpile renders the calculation module of every table with calculated
+// fields for a backend
+func (rb *Rulebook) Transpile(b FormulaBackend) (string, error) {
+	var tables []TranspiledTable
+	for _, t := range rb.Tables {
+		c, err := t.compiler()
+		if err != nil {
+			return "", err
+		}
+		if len(c.order) == 0 {
+			continue
+		}
+		tt := TranspiledTable{Table: t}
+		for _, f := range c.order {
+			expr, err := transpileNode(b, t, c.formulas[f.Name])
+			if err != nil {
+				return "", fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+			}
+			tt.Fields = append(tt.Fields, TranspiledField{Field: f, Key: toSnakeCase(f.Name), Expr: expr})
+		}
+		tables = append(tables, tt)
+	}
+	return b.Module(tables), nil
+}
+
+// TranspileFormula translates one formula over a table's fields
+func TranspileFormula(b FormulaBackend, t *Table, formula string) (string, error) {
+	ast, err := ParseFormula(formula)
+	if err != nil {
+		return "", err
+	}
+	return transpileNode(b, t, ast)
+}
+
+func transpileNode(b FormulaBackend, t *Table, node FormulaNode) (string, error) {
+	switch n := node.(type) {
+	case LiteralBool:
+		return b.Literal(n.Value), nil
+	case LiteralInt:
+		return b.Literal(n.Value), nil
+	case LiteralString:
+		return b.Literal(n.Value), nil
+
+	case FieldRef:
+		f, ok := t.Field(n.Name)
+		if !ok {
+			return "", fmt.Errorf("unknown field %s", n.Name)
+		}
+		return b.Field(toSnakeCase(f.Name)), nil
+
+	case UnaryOp:
+		operand, err := transpileNode(b, t, n.Operand)
+		if err != nil {
+			return "", err
+		}
+		return b.Call("not", operand), nil
+
+	case BinaryOp:
+		l, err := transpileNode(b, t, n.Left)
+		if err != nil {
+			return "", err
+		}
+		r, err := transpileNode(b, t, n.Right)
+		if err != nil {
+			return "", err
+		}
+		return b.Call("compare", b.Literal(n.Op), l, r), nil
+
+	case Concat:
+		parts, err := transpileNodes(b, t, n.Parts)
+		if err != nil {
+			return "", err
+		}
+		return b.Call("concat", parts...), nil
+
+	case FuncCall:
+		args, err := transpileNodes(b, t, n.Args)
+		if err != nil {
+			return "", err
+		}
+		arity := func(min, max int) error {
+			if len(args) < min || len(args) > max {
+				return fmt.Errorf("%s expects %s", n.Name, arityText(min, max))
+			}
+			return nil
+		}
+		switch n.Name {
+		case "AND", "OR":
+			return b.Call(strings.ToLower(n.Name), args...), nil
+		case "IF":
+			if err := arity(2, 3); err != nil {
+				return "", err
+			}
+			otherwise := b.Literal("")
+			if len(args) == 3 {
+				otherwise = args[2]
+			}
+			return b.Cond(b.Call("bool", args[0]), args[1], otherwise), nil
+		case "NOT":
+			if err := arity(1, 1); err != nil {
+				return "", err
+			}
+			return b.Call("not", args[0]), nil
+		case "LOWER":
+			if err := arity(1, 1); err != nil {
+				return "", err
+			}
+			return b.Call("lower", args[0]), nil
+		case "FIND":
+			if err := arity(2, 2); err != nil {
+				return "", err
+			}
+			return b.Call("find", args[0], args[1]), nil
+		case "CAST":
+			if err := arity(1, 2); err != nil {
+				return "", err
+			}
+			return b.Call("text", args[0]), nil
+		}
+		return "", fmt.Errorf("unknown function %s", n.Name)
+	}
+	return "", fmt.Errorf("unknown formula node %T", node)
+}
+
+func transpileNodes(b FormulaBackend, t *Table, nodes []FormulaNode) ([]string, error) {
+	exprs := make([]string, len(nodes))
+	for i, n := range nodes {
+		e, err := transpileNode(b, t, n)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = e
+	}
+	return exprs, nil
+}
+
+// quoteString renders a double-quoted string literal that JS, Python and C#
+// all read the same way: only quotes, backslashes and control characters are
+// escaped (modules are UTF-8)
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formulaComment is a formula on one line, for a comment
+func formulaComment(formula string) string {
+	return strings.Join(strings.Fields(formula), " ")
+}
+
+// transpileHeader is the first line of every generated module
+func transpileHeader(target string) string {
+	return "Generated from the rulebook by `erb transpile --target " + target + "` - do not edit"
+}
+
+// =============================================================================
+// JAVASCRIPT
+// =============================================================================
+
+type jsBackend struct{}
+
+func (jsBackend) Name() string      { return "js" }
+func (jsBackend) Extension() string { return ".js" }
+
+func (jsBackend) Literal(v any) string {
+	if s, ok := v.(string); ok {
+		return quoteString(s)
+	}
+	return fmt.Sprint(v)
+}
+
+func (jsBackend) Field(key string) string { return "r[" + quoteString(key) + "]" }
+
+func (jsBackend) Call(helper string, args ...string) string {
+	return "erb" + strings.ToUpper(helper[:1]) + helper[1:] + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (jsBackend) Cond(cond, then, otherwise string) string {
+	return "(" + cond + " ? " + then + " : " + otherwise + ")"
+}
+
+const jsRuntime = `const erbInt = (v) => Number.isInteger(v);
+
+export function erbBool(v) {
+  if (typeof v === "boolean") return v;
+  if (erbInt(v)) return v !== 0;
+  if (typeof v === "string") return v !== "";
+  return false;
+}
+
+export function erbText(v) {
+  if (v === null || v === undefined) return "";
+  return String(v);
+}
+
+export const erbNot = (v) => !erbBool(v);
+export const erbAnd = (...vs) => vs.every(erbBool);
+export const erbOr = (...vs) => vs.some(erbBool);
+export const erbConcat = (...vs) => vs.map(erbText).join("");
+export const erbLower = (v) => erbText(v).toLowerCase();
+export const erbFind = (needle, haystack) => erbText(haystack).includes(erbText(needle));
+export const erbNilIfZero = (v) => (v === "" || v === 0 ? null : v);
+
+// erbCompare compares like the Go code: integers compared with anything else
+// are unequal, booleans treat null as false, everything else compares as text
+export function erbCompare(op, l, r) {
+  if (erbInt(l) || erbInt(r)) {
+    if (!erbInt(l) || !erbInt(r)) return op === "<>";
+    return erbOrdered(op, l, r);
+  }
+  if (typeof l === "boolean" || typeof r === "boolean" || (l == null && r == null)) {
+    return erbOrdered(op, Number(erbBool(l)), Number(erbBool(r)));
+  }
+  return erbOrdered(op, erbText(l), erbText(r));
+}
+
+function erbOrdered(op, l, r) {
+  switch (op) {
+    case "=": return l === r;
+    case "<>": return l !== r;
+    case "<": return l < r;
+    case "<=": return l <= r;
+    case ">": return l > r;
+    case ">=": return l >= r;
+  }
+  return false;
+}
+`
+
+func (b jsBackend) Module(tables []TranspiledTable) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s\n\n%s", transpileHeader(b.Name()), jsRuntime)
+	for _, tt := range tables {
+		name := structName(tt.Table.Name)
+		fmt.Fprintf(&s, "\n// %s\n", tt.Table.Name)
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "\n// Formula: %s\n", formulaComment(f.Field.Formula))
+			fmt.Fprintf(&s, "export function calc%s%s(r) {\n  return %s;\n}\n", name, f.Field.Name, f.Expr)
+		}
+		fmt.Fprintf(&s, "\n// compute%s returns a copy of record with its calculated fields filled in\n", name)
+		fmt.Fprintf(&s, "export function compute%s(record) {\n  const r = { ...record };\n", name)
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "  r[%s] = erbNilIfZero(calc%s%s(r));\n", quoteString(f.Key), name, f.Field.Name)
+		}
+		s.WriteString("  return r;\n}\n")
+	}
+	return s.String()
+}
+
+// =============================================================================
+// PYTHON
+// =============================================================================
+
+type pythonBackend struct{}
+
+func (pythonBackend) Name() string      { return "python" }
+func (pythonBackend) Extension() string { return ".py" }
+
+func (pythonBackend) Literal(v any) string {
+	switch x := v.(type) {
+	case string:
+		return quoteString(x)
+	case bool:
+		return map[bool]string{true: "True", false: "False"}[x]
+	}
+	return fmt.Sprint(v)
+}
+
+func (pythonBackend) Field(key string) string { return "r.get(" + quoteString(key) + ")" }
+
+func (pythonBackend) Call(helper string, args ...string) string {
+	return "erb_" + toSnakeCase(helper) + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (pythonBackend) Cond(cond, then, otherwise string) string {
+	return "(" + then + " if " + cond + " else " + otherwise + ")"
+}
+
+const pythonRuntime = `from typing import Any, Optional
+
+
+def _erb_int(v: Any) -> bool:
+    return isinstance(v, int) and not isinstance(v, bool)
+
+
+def erb_bool(v: Any) -> bool:
+    if isinstance(v, bool):
+        return v
+    if _erb_int(v):
+        return v != 0
+    if isinstance(v, str):
+        return v != ""
+    return False
+
+
+def erb_text(v: Any) -> str:
+    if v is None:
+        return ""
+    if isinstance(v, bool):
+        return "true" if v else "false"
+    return str(v)
+
+
+def erb_not(v: Any) -> bool:
+    return not erb_bool(v)
+
+
+def erb_and(*vs: Any) -> bool:
+    return all(erb_bool(v) for v in vs)
+
+
+def erb_or(*vs: Any) -> bool:
+    return any(erb_bool(v) for v in vs)
+
+
+def erb_concat(*vs: Any) -> str:
+    return "".join(erb_text(v) for v in vs)
+
+
+def erb_lower(v: Any) -> str:
+    return erb_text(v).lower()
+
+
+def erb_find(needle: Any, haystack: Any) -> bool:
+    return erb_text(needle) in erb_text(haystack)
+
+
+def erb_nil_if_zero(v: Any) -> Optional[Any]:
+    return None if v == "" or (_erb_int(v) and v == 0) else v
+
+
+def erb_compare(op: str, l: Any, r: Any) -> bool:
+    """Compares like the Go code: integers compared with anything else are
+    unequal, booleans treat None as false, everything else compares as text"""
+    if _erb_int(l) or _erb_int(r):
+        if not (_erb_int(l) and _erb_int(r)):
+            return op == "<>"
+        return _erb_ordered(op, l, r)
+    if isinstance(l, bool) or isinstance(r, bool) or (l is None and r is None):
+        return _erb_ordered(op, int(erb_bool(l)), int(erb_bool(r)))
+    return _erb_ordered(op, erb_text(l), erb_text(r))
+
+
+def _erb_ordered(op: str, l: Any, r: Any) -> bool:
+    return {
+        "=": l == r,
+        "<>": l != r,
+        "<": l < r,
+        "<=": l <= r,
+        ">": l > r,
+        ">=": l >= r,
+    }.get(op, False)
+`
+
+func (b pythonBackend) Module(tables []TranspiledTable) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "# %s\n\n%s", transpileHeader(b.Name()), pythonRuntime)
+	for _, tt := range tables {
+		name := toSnakeCase(structName(tt.Table.Name))
+		fmt.Fprintf(&s, "\n\n# %s\n", tt.Table.Name)
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "\n\ndef calc_%s_%s(r: dict) -> Any:\n", name, f.Key)
+			fmt.Fprintf(&s, "    # Formula: %s\n    return %s\n", formulaComment(f.Field.Formula), f.Expr)
+		}
+		fmt.Fprintf(&s, "\n\ndef compute_%s(record: dict) -> dict:\n", name)
+		s.WriteString("    \"\"\"Returns a copy of record with its calculated fields filled in\"\"\"\n")
+		s.WriteString("    r = dict(record)\n")
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "    r[%s] = erb_nil_if_zero(calc_%s_%s(r))\n", quoteString(f.Key), name, f.Key)
+		}
+		s.WriteString("    return r\n")
+	}
+	return s.String()
+}
+
+// =============================================================================
+// C#
+// =============================================================================
+
+type csharpBackend struct{}
+
+func (csharpBackend) Name() string      { return "csharp" }
+func (csharpBackend) Extension() string { return ".cs" }
+
+func (csharpBackend) Literal(v any) string {
+	if s, ok := v.(string); ok {
+		return quoteString(s)
+	}
+	return fmt.Sprint(v)
+}
+
+func (csharpBackend) Field(key string) string { return "r.GetValueOrDefault(" + quoteString(key) + ")" }
+
+func (csharpBackend) Call(helper string, args ...string) string {
+	return "Formula." + strings.ToUpper(helper[:1]) + helper[1:] + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (csharpBackend) Cond(cond, then, otherwise string) string {
+	return "(" + cond + " ? (object?)(" + then + ") : " + otherwise + ")"
+}
+
+const csharpRuntime = `#nullable enable
+using System;
+using System.Collections.Generic;
+using System.Globalization;
+using System.Linq;
+
+namespace Erb;
+
+// Formula is the runtime the translated formulas call: values are bool,
+// int, long, string or null
+public static class Formula
+{
+    static bool IsInt(object? v) => v is int || v is long;
+
+    public static bool Bool(object? v) => v switch
+    {
+        bool b => b,
+        int i => i != 0,
+        long l => l != 0,
+        string s => s != "",
+        _ => false,
+    };
+
+    public static string Text(object? v) => v switch
+    {
+        null => "",
+        string s => s,
+        bool b => b ? "true" : "false",
+        _ => Convert.ToString(v, CultureInfo.InvariantCulture) ?? "",
+    };
+
+    public static bool Not(object? v) => !Bool(v);
+    public static bool And(params object?[] vs) => vs.All(Bool);
+    public static bool Or(params object?[] vs) => vs.Any(Bool);
+    public static string Concat(params object?[] vs) => string.Concat(vs.Select(Text));
+    public static string Lower(object? v) => Text(v).ToLowerInvariant();
+    public static bool Find(object? needle, object? haystack) => Text(haystack).Contains(Text(needle), StringComparison.Ordinal);
+    public static object? NilIfZero(object? v) => v is "" || (IsInt(v) && Convert.ToInt64(v) == 0) ? null : v;
+
+    // Compare compares like the Go code: integers compared with anything else
+    // are unequal, booleans treat null as false, everything else compares as text
+    public static bool Compare(string op, object? l, object? r)
+    {
+        if (IsInt(l) || IsInt(r))
+        {
+            if (!IsInt(l) || !IsInt(r)) return op == "<>";
+            return Ordered(op, Convert.ToInt64(l).CompareTo(Convert.ToInt64(r)));
+        }
+        if (l is bool || r is bool || (l == null && r == null))
+        {
+            return Ordered(op, Bool(l).CompareTo(Bool(r)));
+        }
+        return Ordered(op, string.CompareOrdinal(Text(l), Text(r)));
+    }
+
+    static bool Ordered(string op, int cmp) => op switch
+    {
+        "=" => cmp == 0,
+        "<>" => cmp != 0,
+        "<" => cmp < 0,
+        "<=" => cmp <= 0,
+        ">" => cmp > 0,
+        ">=" => cmp >= 0,
+        _ => false,
+    };
+}
+`
+
+func (b csharpBackend) Module(tables []TranspiledTable) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s\n%s", transpileHeader(b.Name()), csharpRuntime)
+	for _, tt := range tables {
+		fmt.Fprintf(&s, "\n// %s calculated fields\npublic static class %sCalc\n{\n", tt.Table.Name, structName(tt.Table.Name))
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "    // Formula: %s\n", formulaComment(f.Field.Formula))
+			fmt.Fprintf(&s, "    public static object? %s(IReadOnlyDictionary<string, object?> r) => %s;\n\n", f.Field.Name, f.Expr)
+		}
+		s.WriteString("    // Compute returns a copy of record with its calculated fields filled in\n")
+		s.WriteString("    public static Dictionary<string, object?> Compute(IReadOnlyDictionary<string, object?> record)\n    {\n")
+		s.WriteString("        var r = new Dictionary<string, object?>(record);\n")
+		for _, f := range tt.Fields {
+			fmt.Fprintf(&s, "        r[%s] = Formula.NilIfZero(%s(r));\n", quoteString(f.Key), f.Field.Name)
+		}
+		s.WriteString("        return r;\n    }\n}\n")
+	}
+	return s.String()
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runTranspile implements `transpile --target js|python|csharp
+// [--rulebook PATH] [--out FILE]`: writes the calculation module for a
+// target (stdout by default)
+func runTranspile(args []string) error {
+	fs := flag.NewFlagSet("transpile", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	target := fs.String("target", "", "target language: "+strings.Join(FormulaTargets(), ", "))
+	out := fs.String("out", "", "file to write (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	b, ok := LookupFormulaBackend(*target)
+	if !ok {
+		return fmt.Errorf("unknown target %q (available: %s)", *target, strings.Join(FormulaTargets(), ", "))
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	module, err := rb.Transpile(b)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(module)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(module), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Wrote %s\n", *out)
+	return nil
+}
@@ -0,0 +1,115 @@
+// ERB SDK - Typed Tables
+// ======================
+// TypedTable binds a generated struct to its rulebook table, so loading,
+// computing and saving records work the same for every table. erb_sdk.go
+// declares one per table (LanguageCandidatesTable, IsEverythingALanguageTable,
+// and whatever tables the rulebook grows):
+//
+//	steps, err := IsEverythingALanguageTable.Rows(rb)
+//	records, err := LanguageCandidatesTable.Load("blank-test.json")
+//	records = LanguageCandidatesTable.ComputeAll(records)
+//	err = LanguageCandidatesTable.Save("test-answers.json", records)
+//
+// (The rulebook's own tables are Table, hence the name.)
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// TypedTable reads and writes the records of one table as T
+type TypedTable[T any] struct {
+	name    string
+	compute func(*T) *T
+}
+
+// NewTypedTable binds T to a table; compute is the generated ComputeAll,
+// nil for tables without calculated fields
+func NewTypedTable[T any](name string, compute func(*T) *T) *TypedTable[T] {
+	return &TypedTable[T]{name: name, compute: compute}
+}
+
+// Name is the rulebook table name
+func (tt *TypedTable[T]) Name() string {
+	return tt.name
+}
+
+// Rows returns the table's rows in the rulebook as T
+func (tt *TypedTable[T]) Rows(rb *Rulebook) ([]T, error) {
+	t := rb.Table(tt.name)
+	if t == nil {
+		return nil, fmt.Errorf("rulebook has no %s table", tt.name)
+	}
+	var rows []T
+	if err := decodeRows(t.Data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", tt.name, err)
+	}
+	return rows, nil
+}
+
+// Decode parses a JSON array of records; WithStrictFields rejects unknown or
+// missing keys and WithPartialLoad skips records that do not decode
+func (tt *TypedTable[T]) Decode(data []byte, opts ...RecordOption) ([]T, error) {
+	return decodeRecords[T](data, opts)
+}
+
+// Load reads records from a JSON file; WithStrictFields rejects unknown or
+// missing keys and WithPartialLoad returns the records that parsed along with a *MultiError
+func (tt *TypedTable[T]) Load(path string, opts ...RecordOption) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	records, err := tt.Decode(data, opts...)
+	if err != nil {
+		return records, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	return records, nil
+}
+
+// ComputeAll computes the calculated fields of every record, in parallel
+// (see WithWorkers); records of a table without calculated fields are copied
+func (tt *TypedTable[T]) ComputeAll(records []T, opts ...RecordOption) []T {
+	if tt.compute == nil {
+		return append([]T(nil), records...)
+	}
+	start := time.Now()
+	computed, _ := computeAllParallel(records, tt.compute, opts) // fails only when cancelled
+	Events.Publish(ComputeCompleted{Table: tt.name, Records: len(computed), Duration: time.Since(start)})
+	return computed
+}
+
+// Save writes records to a JSON file, as canonical JSON; WithEmptyStrings
+// picks how empty calculated strings are written
+func (tt *TypedTable[T]) Save(path string, records []T, opts ...RecordOption) error {
+	return saveRecords(path, records, opts)
+}
+
+// saveRecords writes records to a JSON file as canonical JSON, in a span
+func saveRecords[T any](path string, records []T, opts []RecordOption) (err error) {
+	cfg := recordConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	_, span := startSpan(cfg.traceContext(), "erb.save_records",
+		slog.String("erb.format", "json"), slog.String("erb.path", path), slog.Int("erb.records", len(records)))
+	defer func() { endSpan(span, err) }()
+
+	data, err := MarshalCanonical(withEmptyStrings(records, opts))
+	if err != nil {
+		return fmt.Errorf("failed to marshal records: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+
+	loggerOr(cfg.logger).Info("records written", "path", path, "records", len(records))
+	return nil
+}
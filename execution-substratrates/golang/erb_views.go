@@ -0,0 +1,94 @@
+// ERB SDK - Computed Views
+// ========================
+// View types mirror the PostgreSQL vw_* views: every raw field plus every
+// calculated field, computed in DAG order by the generated ComputeAll() (or
+// by Table.Compute for tables the generator does not know).
+
+package main
+
+// LanguageCandidateView is a LanguageCandidate with all calculated fields populated (mirrors vw_language_candidates)
+type LanguageCandidateView = LanguageCandidate
+
+// IsEverythingALanguageView mirrors vw_is_everything_a_language (the table has no calculated fields)
+type IsEverythingALanguageView = IsEverythingALanguage
+
+// ToView returns the record with every calculated field computed;
+// WithEmptyStrings picks how empty calculated strings are stored and
+// WithViewCache reuses the view while the raw fields are unchanged
+func (tc *LanguageCandidate) ToView(opts ...RecordOption) LanguageCandidateView {
+	cfg := recordConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var view LanguageCandidateView
+	if cfg.views != nil {
+		view = cfg.views.View(tc)
+	} else {
+		view = *tc.ComputeAll()
+	}
+	applyEmptyStrings(&view, cfg.emptyStrings)
+	return view
+}
+
+// CandidateViews computes the view of every LanguageCandidate in the rulebook
+func (rb *Rulebook) CandidateViews() []LanguageCandidateView {
+	views := make([]LanguageCandidateView, 0, len(rb.LanguageCandidates))
+	for i := range rb.LanguageCandidates {
+		views = append(views, rb.LanguageCandidates[i].ToView())
+	}
+	return views
+}
+
+// ArgumentViews returns the view of every IsEverythingALanguage argument step
+func (rb *Rulebook) ArgumentViews() []IsEverythingALanguageView {
+	views := make([]IsEverythingALanguageView, len(rb.IsEverythingALanguage))
+	copy(views, rb.IsEverythingALanguage)
+	return views
+}
+
+// TableViews returns a table's computed views as ordered records for an
+// exporter, without internal fields unless includeInternal is set
+func (rb *Rulebook) TableViews(table string, includeInternal bool) (ExportViews, error) {
+	var rows any
+	switch table {
+	case "LanguageCandidates":
+		rows = rb.CandidateViews()
+	case "IsEverythingALanguage":
+		rows = rb.ArgumentViews()
+	default:
+		return rb.runtimeViews(table, includeInternal)
+	}
+	return ExportViews{Table: table, Records: rb.ExportRecords(table, rows, includeInternal), Calculated: rb.calculatedFields(table)}, nil
+}
+
+// calculatedFields names a table's calculated fields (empty, not nil, for
+// a table without any)
+func (rb *Rulebook) calculatedFields(table string) []string {
+	names := []string{}
+	if t := rb.Table(table); t != nil {
+		for _, f := range t.Schema {
+			if f.IsCalculated() {
+				names = append(names, f.Name)
+			}
+		}
+	}
+	return names
+}
+
+// runtimeViews computes a table the generator does not know with Table.Compute
+func (rb *Rulebook) runtimeViews(table string, includeInternal bool) (ExportViews, error) {
+	t := rb.Table(table)
+	if t == nil {
+		return ExportViews{}, unknownTable(table)
+	}
+	records, err := t.Compute()
+	if err != nil {
+		return ExportViews{}, err
+	}
+	if internal := t.InternalFields(); len(internal) > 0 && !includeInternal {
+		for i, rec := range records {
+			records[i] = rec.Without(internal)
+		}
+	}
+	return ExportViews{Table: table, Records: records, Calculated: rb.calculatedFields(table)}, nil
+}
@@ -0,0 +1,328 @@
+// ERB SDK - Field Visibility
+// ==========================
+// Schema fields may be marked `"visibility": "internal"` (maintainer notes,
+// contributor emails, ...). Internal fields are stripped from everything
+// published or served unless a maintainer opts in with --include-internal.
+//
+// Calculated fields can be internal too: helper calculations are still
+// computed, and public formulas may read them, but they are left out of views,
+// exports, the HTTP API and the SQL vw_* views. A redacted rulebook inlines
+// the helpers' formulas into the public formulas that read them, so it still
+// computes the same published values.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Field visibilities; "" is public
+const (
+	VisibilityInternal = "internal"
+	VisibilityPublic   = "public"
+)
+
+// IsInternal reports whether the field is excluded from public exports
+func (f Field) IsInternal() bool {
+	return strings.EqualFold(f.Visibility, VisibilityInternal)
+}
+
+// InternalFields returns the names of the table's internal fields, keyed by
+// both the schema name (PascalCase) and the JSON key (snake_case)
+func (t *Table) InternalFields() map[string]bool {
+	internal := map[string]bool{}
+	for _, f := range t.Schema {
+		if f.IsInternal() {
+			internal[f.Name] = true
+			internal[toSnakeCase(f.Name)] = true
+		}
+	}
+	return internal
+}
+
+// checkVisibility warns about visibility values other than public and
+// internal, since a misspelled "internal" would publish the field
+func (rb *Rulebook) checkVisibility() []error {
+	var warnings []error
+	for _, issue := range checkUnknownVisibility(rb) {
+		warnings = append(warnings, fmt.Errorf("%s.%s has %s", issue.Table, issue.Field, issue.Message))
+	}
+	return warnings
+}
+
+// checkUnknownVisibility is the unknown-visibility validation rule
+func checkUnknownVisibility(rb *Rulebook) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, t := range rb.Tables {
+		for _, f := range t.Schema {
+			if f.Visibility != "" && !f.IsInternal() && !strings.EqualFold(f.Visibility, VisibilityPublic) {
+				issues = append(issues, ValidationIssue{Table: t.Name, Field: f.Name, Message: fmt.Sprintf("unknown visibility %q (want %q or %q); it is treated as public", f.Visibility, VisibilityPublic, VisibilityInternal)})
+			}
+		}
+	}
+	return issues
+}
+
+// HasInternalFields reports whether any table marks a field internal
+func (rb *Rulebook) HasInternalFields() bool {
+	for _, t := range rb.Tables {
+		if len(t.InternalFields()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// REDACTION
+// =============================================================================
+
+// Redacted returns a copy of the rulebook with every internal field removed
+// from table schemas, table data, and the typed records. Public formulas that
+// read internal calculated fields get those fields' formulas inlined.
+func (rb *Rulebook) Redacted() (*Rulebook, error) {
+	out := *rb
+	out.Tables = make([]*Table, len(rb.Tables))
+
+	for i, t := range rb.Tables {
+		internal := t.InternalFields()
+		formulas, err := inlineInternalFormulas(t)
+		if err != nil {
+			return nil, err
+		}
+		rt := &Table{Name: t.Name, Description: t.Description}
+		for _, f := range t.Schema {
+			if !internal[f.Name] {
+				if formula, ok := formulas[f.Name]; ok {
+					f.Formula = formula
+				}
+				rt.Schema = append(rt.Schema, f)
+			}
+		}
+		levels := rt.Levels()
+		for j := range rt.Schema {
+			rt.Schema[j].Level = levels[rt.Schema[j].Name]
+		}
+		for _, row := range t.Data {
+			r := make(map[string]any, len(row))
+			for k, v := range row {
+				if !internal[k] {
+					r[k] = v
+				}
+			}
+			rt.Data = append(rt.Data, r)
+		}
+		out.Tables[i] = rt
+	}
+
+	out.LanguageCandidates = nil
+	out.IsEverythingALanguage = nil
+	if err := out.decodeTypedTables(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// inlineInternalFormulas returns the rewritten formula of every public
+// calculated field that reads an internal calculated field, with each such
+// reference replaced by the internal field's own (inlined) formula
+func inlineInternalFormulas(t *Table) (map[string]string, error) {
+	helpers := map[string]FormulaNode{}
+	for _, f := range sqlCalculatedFields(t) { // by level: helpers before their readers
+		if !f.IsInternal() {
+			continue
+		}
+		ast, err := ParseFormula(f.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+		}
+		helpers[f.Name] = substituteFields(ast, helpers)
+	}
+	if len(helpers) == 0 {
+		return nil, nil
+	}
+
+	formulas := map[string]string{}
+	for _, f := range t.Schema {
+		if !f.IsCalculated() || f.IsInternal() {
+			continue
+		}
+		ast, err := ParseFormula(f.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name, f.Name, err)
+		}
+		for _, ref := range FormulaFieldRefs(ast) {
+			if _, ok := helpers[ref]; ok {
+				formulas[f.Name] = "=" + substituteFields(ast, helpers).String()
+				break
+			}
+		}
+	}
+	return formulas, nil
+}
+
+// substituteFields returns node with every reference to a field in repl
+// replaced by that field's expression
+func substituteFields(node FormulaNode, repl map[string]FormulaNode) FormulaNode {
+	sub := func(nodes []FormulaNode) []FormulaNode {
+		out := make([]FormulaNode, len(nodes))
+		for i, n := range nodes {
+			out[i] = substituteFields(n, repl)
+		}
+		return out
+	}
+	switch n := node.(type) {
+	case FieldRef:
+		if expr, ok := repl[n.Name]; ok {
+			return expr
+		}
+	case BinaryOp:
+		return BinaryOp{Op: n.Op, Left: substituteFields(n.Left, repl), Right: substituteFields(n.Right, repl)}
+	case UnaryOp:
+		return UnaryOp{Op: n.Op, Operand: substituteFields(n.Operand, repl)}
+	case FuncCall:
+		return FuncCall{Name: n.Name, Args: sub(n.Args)}
+	case Concat:
+		return Concat{Parts: sub(n.Parts)}
+	}
+	return node
+}
+
+// ExportRecords converts typed rows (e.g. CandidateViews()) into ordered
+// records, dropping the table's internal fields unless includeInternal is set
+func (rb *Rulebook) ExportRecords(tableName string, rows any, includeInternal bool) []Record {
+	internal := map[string]bool{}
+	if t := rb.Table(tableName); t != nil && !includeInternal {
+		internal = t.InternalFields()
+	}
+
+	records := RecordsOf(rows)
+	if len(internal) > 0 {
+		for i, rec := range records {
+			records[i] = rec.Without(internal)
+		}
+	}
+	return records
+}
+
+// RecordsOf converts a slice of typed rows into ordered records with every field
+func RecordsOf(rows any) []Record {
+	v := reflect.ValueOf(rows)
+	records := make([]Record, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		records = append(records, recordFromStruct(v.Index(i)))
+	}
+	return records
+}
+
+// =============================================================================
+// ORDERED RECORDS
+// =============================================================================
+
+// Record is a row as ordered JSON key/value pairs (struct field order is kept)
+type Record struct {
+	Keys   []string
+	Values map[string]any
+}
+
+// Get returns the value stored under a JSON key
+func (r Record) Get(key string) (any, bool) {
+	v, ok := r.Values[key]
+	return v, ok
+}
+
+// Without returns a copy of the record minus the given keys
+func (r Record) Without(drop map[string]bool) Record {
+	out := Record{Values: map[string]any{}}
+	for _, k := range r.Keys {
+		if !drop[k] {
+			out.Keys = append(out.Keys, k)
+			out.Values[k] = r.Values[k]
+		}
+	}
+	return out
+}
+
+// MarshalJSON encodes the record as an object in key order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range r.Keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(r.Values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// recordFromStruct reads a struct's JSON-tagged fields in declaration order,
+// dereferencing pointers (nil pointers become nil values)
+func recordFromStruct(v reflect.Value) Record {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	rec := Record{Values: map[string]any{}}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if key == "" || key == "-" || !sf.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		var value any
+		if fv.Kind() == reflect.Pointer {
+			if !fv.IsNil() {
+				value = fv.Elem().Interface()
+			}
+		} else {
+			value = fv.Interface()
+		}
+
+		rec.Keys = append(rec.Keys, key)
+		rec.Values[key] = value
+	}
+	return rec
+}
+
+// =============================================================================
+// RULEBOOK ENCODING
+// =============================================================================
+
+// MarshalJSON encodes the rulebook in its file layout, keeping table order
+func (rb *Rulebook) MarshalJSON() ([]byte, error) {
+	rec := Record{Values: map[string]any{}}
+	add := func(key string, value any) {
+		rec.Keys = append(rec.Keys, key)
+		rec.Values[key] = value
+	}
+
+	add("$schema", rb.SchemaURI)
+	add("model_name", rb.ModelName)
+	add("Description", rb.Description)
+	for _, t := range rb.Tables {
+		add(t.Name, t)
+	}
+	if len(rb.Meta) > 0 {
+		add("_meta", rb.Meta)
+	}
+	return rec.MarshalJSON()
+}
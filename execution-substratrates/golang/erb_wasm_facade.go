@@ -0,0 +1,62 @@
+// ERB SDK - WebAssembly Facade
+// ============================
+// The calculation engine as plain JSON-in, JSON-out functions with no file
+// or network access, so it runs anywhere the SDK compiles, including a
+// browser (GOOS=js GOARCH=wasm). wasm/erb_wasm_js.go exposes them to
+// JavaScript as globalThis.erb, and wasm/erb.js wraps that in a small API:
+//
+//	wasm/build.sh    # writes wasm/erb.wasm and copies Go's wasm_exec.js next to it
+//
+//	const erb = await loadERB("erb.wasm");
+//	const answers = erb.computeAll(blankTest); // array of candidates
+//	const view = erb.toView({Name: "SQL", HasSyntax: true});
+//
+// Input keys may be spelled in any casing (WithTolerantFields).
+
+package main
+
+import "fmt"
+
+// startWASM, set by wasm/erb_wasm_js.go in js/wasm builds, serves the
+// JavaScript API in place of the CLI
+var startWASM func()
+
+// ComputeAllJSON computes a JSON array of LanguageCandidates and returns
+// the computed records as canonical JSON
+func ComputeAllJSON(input string) (string, error) {
+	records, err := decodeWASMCandidates(input)
+	if err != nil {
+		return "", err
+	}
+	out, err := MarshalCanonical(ComputeAllRecords(records, WithWorkers(1)))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ToViewJSON computes one LanguageCandidate given as a JSON object and
+// returns its view as canonical JSON
+func ToViewJSON(input string) (string, error) {
+	records, err := decodeWASMCandidates("[" + input + "]")
+	if err != nil {
+		return "", err
+	}
+	if len(records) != 1 {
+		return "", fmt.Errorf("want one candidate object, got %d", len(records))
+	}
+	out, err := MarshalCanonical(records[0].ToView())
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// decodeWASMCandidates parses a JSON array of candidates with keys in any casing
+func decodeWASMCandidates(input string) ([]LanguageCandidate, error) {
+	records, err := decodeRecords[LanguageCandidate]([]byte(input), []RecordOption{WithTolerantFields(nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse candidates: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,242 @@
+// ERB SDK - Rulebook Watch
+// ========================
+// Watch keeps a long-running process on the current rulebook: the file is
+// polled (the SDK has no dependencies, so no fsnotify), and once it has
+// stopped changing for the debounce period it is loaded and validated before
+// it replaces the live rulebook. A broken edit (bad JSON, a formula cycle, a
+// value of the wrong type, a formula that fails to compute) is reported and
+// the previous rulebook stays in place:
+//
+//	w, err := Watch(DefaultRulebookPath, func(rb *Rulebook) {
+//		server.SetRulebook(rb)
+//	})
+//	defer w.Close()
+//
+// Reloads are also published on the event bus (see erb_events.go): a
+// RulebookLoaded, a RecordChanged per edited record, and an
+// InvariantViolated per problem of a rejected edit.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watch defaults
+const (
+	DefaultWatchInterval = 500 * time.Millisecond
+	DefaultWatchDebounce = time.Second
+)
+
+// WatchOption configures Watch
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	interval time.Duration
+	debounce time.Duration
+	onError  func(error)
+	loadOpts []LoadOption
+	bus      *Bus
+}
+
+// WithPollInterval sets how often the rulebook file is checked for changes
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.interval = d
+	}
+}
+
+// WithDebounce sets how long the file must stay unchanged before it is
+// reloaded, so an editor's save in several writes reloads once
+func WithDebounce(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.debounce = d
+	}
+}
+
+// WithReloadErrors receives the reason a changed rulebook was rejected
+// (default: a warning on stderr)
+func WithReloadErrors(fn func(error)) WatchOption {
+	return func(c *watchConfig) {
+		c.onError = fn
+	}
+}
+
+// WithLoadOptions passes options to every load (e.g. WithStrictReferences)
+func WithLoadOptions(opts ...LoadOption) WatchOption {
+	return func(c *watchConfig) {
+		c.loadOpts = append(c.loadOpts, opts...)
+	}
+}
+
+// WithBus publishes the watcher's events on b instead of Events
+func WithBus(b *Bus) WatchOption {
+	return func(c *watchConfig) {
+		c.bus = b
+	}
+}
+
+// Watcher polls a rulebook file; see Watch
+type Watcher struct {
+	path     string
+	onReload func(*Rulebook)
+	cfg      watchConfig
+	current  atomic.Pointer[Rulebook]
+	hash     [sha256.Size]byte // content of the current rulebook
+	ctx      context.Context   // bounds reloads; canceled by Close
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Watch loads the rulebook at path and starts watching it; after every edit
+// that loads and validates, onReload is called with the new rulebook (never
+// concurrently). The initial load must succeed.
+func Watch(path string, onReload func(*Rulebook), opts ...WatchOption) (*Watcher, error) {
+	cfg := watchConfig{
+		interval: DefaultWatchInterval,
+		debounce: DefaultWatchDebounce,
+		onError: func(err error) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		},
+		bus: Events,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	w := &Watcher{path: path, onReload: onReload, cfg: cfg, stop: make(chan struct{}), done: make(chan struct{})}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, rulebookReadError(err)
+	}
+	rb, err := w.load(data)
+	if err != nil {
+		w.cancel()
+		return nil, err
+	}
+	w.current.Store(rb)
+	w.hash = sha256.Sum256(data)
+
+	go w.run()
+	return w, nil
+}
+
+// Rulebook returns the live rulebook
+func (w *Watcher) Rulebook() *Rulebook {
+	return w.current.Load()
+}
+
+// Close stops watching; it returns once no reload is in progress
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		close(w.stop)
+		w.cancel()
+	})
+	<-w.done
+}
+
+// fileState is what the poll compares between ticks
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.interval)
+	defer ticker.Stop()
+
+	last, _ := w.stat()
+	var changedAt time.Time // zero unless a change is waiting out the debounce
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			state, err := w.stat()
+			if err != nil {
+				continue // mid-rename, or deleted; keep the current rulebook
+			}
+			if state != last {
+				last, changedAt = state, now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= w.cfg.debounce {
+				changedAt = time.Time{}
+				w.reload()
+			}
+		}
+	}
+}
+
+func (w *Watcher) stat() (fileState, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{info.ModTime(), info.Size()}, nil
+}
+
+// reload swaps in the file's rulebook if its content changed and it validates
+func (w *Watcher) reload() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.cfg.onError(rulebookReadError(err))
+		return
+	}
+	hash := sha256.Sum256(data)
+	if bytes.Equal(hash[:], w.hash[:]) {
+		return // touched, not changed
+	}
+	w.hash = hash
+
+	rb, err := w.load(data)
+	if err != nil {
+		w.cfg.onError(fmt.Errorf("rulebook change rejected, keeping the previous one: %w", err))
+		return
+	}
+	previous := w.current.Swap(rb)
+	if w.onReload != nil {
+		w.onReload(rb)
+	}
+	w.cfg.bus.Publish(RulebookLoaded{Source: w.path, Rulebook: rb})
+	publishRecordChanges(w.cfg.bus, w.path, previous, rb)
+}
+
+// load parses data and checks every table validates and computes
+func (w *Watcher) load(data []byte) (*Rulebook, error) {
+	rb, err := loadRulebookData(w.ctx, w.path, data, w.cfg.loadOpts)
+	if err != nil {
+		return nil, err
+	}
+	var problems []error
+	for _, t := range rb.Tables {
+		for _, err := range t.Validate() {
+			problems = append(problems, err)
+			issue := rowIssue(err.(*RowError))
+			logValidationIssue(Logger, w.path, issue)
+			w.cfg.bus.Publish(InvariantViolated{Source: w.path, Issue: issue})
+		}
+		if _, err := t.Compute(); err != nil {
+			problems = append(problems, err)
+			issue := ValidationIssue{Rule: "compute", Severity: SeverityError, Table: t.Name, Message: err.Error()}
+			logValidationIssue(Logger, w.path, issue)
+			w.cfg.bus.Publish(InvariantViolated{Source: w.path, Issue: issue})
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s: %w", w.path, errors.Join(problems...))
+	}
+	return rb, nil
+}
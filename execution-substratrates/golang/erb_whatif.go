@@ -0,0 +1,259 @@
+// ERB SDK - What-If Scenarios
+// ===========================
+// A what-if scenario edits raw fields of every candidate a condition selects
+// and reports what that does to the Family Feud test across the table. The
+// condition is a rulebook formula over the candidate's fields (empty selects
+// every candidate; a reference to no LanguageCandidates field, in any
+// casing, fails rather than reading null), so "set CanBeHeld=true for all
+// Physical candidates" is:
+//
+//	report, err := rb.WhatIf(WhatIfScenario{
+//		Name:  "physical things can be held",
+//		Where: `=FIND("Physical", {{Category}})`,
+//		Set:   map[string]any{"CanBeHeld": true},
+//	})
+//	fmt.Print(report)
+//	// physical things can be held: 4 of 25 candidates edited
+//	//   top answers 14 -> 14, mismatches 2 -> 2
+//
+// Each candidate whose calculated fields changed is listed with the old and
+// new values, and its TopFamilyFeudAnswer and mismatch before and after.
+//
+// Unlike the scenario files of `compute`, which overlay records by id, these
+// select records by their values. `what-if` runs one from flags or a JSON
+// array of them from a file:
+//
+//	what-if --where '=FIND("Physical", {{Category}})' --set CanBeHeld=true
+//	what-if --file scenarios/what-if.json --json
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// WhatIfScenario sets raw fields on the candidates a condition selects
+type WhatIfScenario struct {
+	Name  string         `json:"name"`
+	Where string         `json:"where,omitempty"` // formula selecting candidates; "" selects all
+	Set   map[string]any `json:"set"`             // raw field name (any casing) -> value; nil clears it
+}
+
+// ScenarioTotals counts the Family Feud outcomes on one side of a scenario
+type ScenarioTotals struct {
+	TopFamilyFeudAnswers int `json:"top_family_feud_answers"` // candidates passing the test
+	Mismatches           int `json:"mismatches"`              // candidates whose answer disagrees with their curation
+}
+
+// ScenarioReport is the effect of a WhatIfScenario on the whole table
+type ScenarioReport struct {
+	Scenario   string           `json:"scenario"`
+	Candidates int              `json:"candidates"`
+	Matched    int              `json:"matched"` // candidates the scenario edited
+	Before     ScenarioTotals   `json:"before"`
+	After      ScenarioTotals   `json:"after"`
+	Changes    []CandidateDelta `json:"changes"` // candidates whose calculated fields changed, in rulebook order
+}
+
+// CandidateDelta is one candidate a scenario changed
+type CandidateDelta struct {
+	CandidateId         string       `json:"candidate_id"`
+	Name                string       `json:"name"`
+	TopFamilyFeudAnswer [2]bool      `json:"top_family_feud_answer"` // before, after
+	Mismatch            [2]bool      `json:"mismatch"`               // before, after
+	Fields              []FieldDelta `json:"fields"`                 // calculated fields that changed
+}
+
+// FieldDelta is a field's value before and after a scenario
+type FieldDelta struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+func (r *ScenarioReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d of %d candidates edited\n", r.Scenario, r.Matched, r.Candidates)
+	fmt.Fprintf(&b, "  top answers %d -> %d, mismatches %d -> %d\n",
+		r.Before.TopFamilyFeudAnswers, r.After.TopFamilyFeudAnswers, r.Before.Mismatches, r.After.Mismatches)
+	for _, c := range r.Changes {
+		fmt.Fprintf(&b, "  %s:", c.CandidateId)
+		for _, f := range c.Fields {
+			fmt.Fprintf(&b, " %s %s -> %s;", f.Field, formatValue(f.Old), formatValue(f.New))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// WhatIf computes every candidate with the scenario's edits applied to the
+// ones it selects and reports the changes; the rulebook is not changed
+func (rb *Rulebook) WhatIf(s WhatIfScenario) (*ScenarioReport, error) {
+	var where FormulaNode
+	if s.Where != "" {
+		var err error
+		if where, err = ParseFormula(s.Where); err != nil {
+			return nil, fmt.Errorf("%s: where: %w", s.Name, err)
+		}
+		if err := checkFormulaFields("LanguageCandidates", where, candidateFields()); err != nil {
+			return nil, fmt.Errorf("%s: where: %w", s.Name, err)
+		}
+	}
+
+	report := &ScenarioReport{Scenario: s.Name, Candidates: len(rb.LanguageCandidates)}
+	for i := range rb.LanguageCandidates {
+		before := rb.LanguageCandidates[i].ComputeAll()
+		if where != nil {
+			eval := &FormulaEvaluator{Lookup: func(name string) any {
+				sf, _ := candidateStructField(name)
+				return recordField(before, sf.Name)
+			}}
+			selected, err := eval.Eval(where)
+			if err != nil {
+				return nil, fmt.Errorf("%s: where: %s: %w", s.Name, before.LanguageCandidateId, err)
+			}
+			if !formulaBool(selected) {
+				report.tally(before, before)
+				continue
+			}
+		}
+
+		edited := *before
+		for _, field := range sortedKeys(s.Set) {
+			if err := setCandidateField(&edited, field, s.Set[field]); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", s.Name, before.LanguageCandidateId, err)
+			}
+		}
+		after := edited.ComputeAll()
+		report.Matched++
+		report.tally(before, after)
+
+		_, calculated := fieldChanges(before, after)
+		if len(calculated) == 0 && before.mismatched() == after.mismatched() {
+			continue
+		}
+		delta := CandidateDelta{
+			CandidateId:         before.LanguageCandidateId,
+			Name:                optGet(after.Name, ""),
+			TopFamilyFeudAnswer: [2]bool{optGet(before.TopFamilyFeudAnswer, false), optGet(after.TopFamilyFeudAnswer, false)},
+			Mismatch:            [2]bool{before.mismatched(), after.mismatched()},
+		}
+		for _, c := range calculated {
+			delta.Fields = append(delta.Fields, FieldDelta{Field: c.Field, Old: c.Old, New: c.New})
+		}
+		report.Changes = append(report.Changes, delta)
+	}
+	return report, nil
+}
+
+// tally counts a candidate's outcomes before and after the scenario
+func (r *ScenarioReport) tally(before, after *LanguageCandidate) {
+	for _, side := range []struct {
+		totals *ScenarioTotals
+		lc     *LanguageCandidate
+	}{{&r.Before, before}, {&r.After, after}} {
+		if optGet(side.lc.TopFamilyFeudAnswer, false) {
+			side.totals.TopFamilyFeudAnswers++
+		}
+		if side.lc.mismatched() {
+			side.totals.Mismatches++
+		}
+	}
+}
+
+// LoadWhatIfScenarios reads a JSON array of scenarios
+func LoadWhatIfScenarios(path string) ([]WhatIfScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenarios []WhatIfScenario
+	if err := json.Unmarshal(data, &scenarios); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range scenarios {
+		if scenarios[i].Name == "" {
+			scenarios[i].Name = fmt.Sprintf("scenario %d", i+1)
+		}
+	}
+	return scenarios, nil
+}
+
+// =============================================================================
+// CLI
+// =============================================================================
+
+// runWhatIf implements `what-if [--rulebook PATH] [--name NAME] [--where
+// FORMULA] [--set Field=Value]... | --file SCENARIOS.json [--json]`
+func runWhatIf(args []string) error {
+	const usage = "usage: what-if [--where FORMULA] --set Field=Value... | what-if --file SCENARIOS.json [flags]"
+	fs := flag.NewFlagSet("what-if", flag.ContinueOnError)
+	rulebookPath := fs.String("rulebook", DefaultRulebookPath, "path to the rulebook (JSON, YAML, or SQLite)")
+	file := fs.String("file", "", "JSON array of scenarios ({name, where, set}) to run instead of --where/--set")
+	name := fs.String("name", "what-if", "name of the scenario given by --where and --set")
+	where := fs.String("where", "", "formula selecting the candidates to edit (default: all)")
+	asJSON := fs.Bool("json", false, "print the reports as JSON")
+	var sets fieldAssignments
+	fs.Var(&sets, "set", "Field=Value to set on the selected candidates (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	inline := len(sets) > 0 // --where and --name only describe a --set scenario
+	fs.Visit(func(f *flag.Flag) { inline = inline || f.Name == "where" || f.Name == "name" })
+	if fs.NArg() > 0 || (*file == "") == !inline || (*file == "" && len(sets) == 0) {
+		return fmt.Errorf(usage)
+	}
+
+	rb, err := LoadFromRulebookContext(commandContext(), *rulebookPath)
+	if err != nil {
+		return err
+	}
+	printWarnings(rb)
+
+	var scenarios []WhatIfScenario
+	if *file != "" {
+		if scenarios, err = LoadWhatIfScenarios(*file); err != nil {
+			return err
+		}
+	} else {
+		t := rb.Table("LanguageCandidates")
+		if t == nil {
+			return unknownTable("LanguageCandidates")
+		}
+		s := WhatIfScenario{Name: *name, Where: *where, Set: map[string]any{}}
+		for _, a := range sets {
+			field, text, _ := strings.Cut(a, "=")
+			sf, ok := candidateStructField(field)
+			f, found := t.Field(sf.Name)
+			if !ok || !found {
+				return fmt.Errorf("%s has no field %s", t.Name, field)
+			}
+			if s.Set[f.Name], err = parseFieldValue(f, text); err != nil {
+				return err
+			}
+		}
+		scenarios = append(scenarios, s)
+	}
+
+	var reports []*ScenarioReport
+	for _, s := range scenarios {
+		report, err := rb.WhatIf(s)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	for _, r := range reports {
+		fmt.Print(r)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+// ERB SDK - Excel Import/Export
+// =============================
+// Writes computed records as a single-sheet .xlsx workbook (Office Open XML):
+// a header row of JSON keys, then one row per record with booleans, numbers
+// and inline strings as native cell types. Nulls are empty cells. The
+// importer reads the first worksheet of any workbook the same way, and the
+// header names map to fields in any casing (see ImportRecords).
+//
+// The sheet is protected without a password: raw columns stay editable,
+// including in new rows, while calculated columns are shaded and locked, so
+// collaborators edit the inputs and leave the formulas' results to `compute`.
+// Review > Unprotect Sheet lifts it.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxExporter writes an Excel workbook
+type xlsxExporter struct{}
+
+func (xlsxExporter) Name() string         { return "xlsx" }
+func (xlsxExporter) Extensions() []string { return []string{".xlsx"} }
+func (xlsxExporter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (xlsxExporter) Write(views ExportViews, w io.Writer) error {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	keys := recordKeys(views.Records)
+	header := make([]any, len(keys))
+	headerStyles := make([]int, len(keys))
+	styles := make([]int, len(keys))
+	for i, k := range keys {
+		header[i], headerStyles[i], styles[i] = k, xlsxStyleHeader, xlsxStyleRaw
+		if views.IsCalculated(k) {
+			styles[i] = xlsxStyleCalculated
+		}
+	}
+	if len(keys) > 0 {
+		sheet.WriteString(`<cols>`)
+		for i, s := range styles {
+			fmt.Fprintf(&sheet, `<col min="%d" max="%d" width="16" style="%d" customWidth="1"/>`, i+1, i+1, s)
+		}
+		sheet.WriteString(`</cols>`)
+	}
+	sheet.WriteString(`<sheetData>`)
+	writeXLSXRow(&sheet, 1, header, headerStyles)
+	for r, rec := range views.Records {
+		row := make([]any, len(keys))
+		for i, k := range keys {
+			row[i] = rec.Values[k]
+		}
+		writeXLSXRow(&sheet, r+2, row, styles)
+	}
+	sheet.WriteString(`</sheetData>`)
+	sheet.WriteString(`<sheetProtection sheet="1" objects="1" scenarios="1" formatColumns="0" insertRows="0" deleteRows="0" sort="0" autoFilter="0"/>`)
+	sheet.WriteString(`</worksheet>`)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + xmlEscape(xlsxSheetName(views.Table)) + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Cell styles (indexes into cellXfs in xlsxStyles)
+const (
+	xlsxStyleRaw        = 1 // unlocked
+	xlsxStyleCalculated = 2 // locked, shaded
+	xlsxStyleHeader     = 3 // locked, bold
+)
+
+// xlsxStyles is the workbook's stylesheet
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFE7E6E6"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyProtection="1"><protection locked="0"/></xf>` +
+	`<xf numFmtId="0" fontId="0" fillId="2" borderId="0" xfId="0" applyFill="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+// writeXLSXRow appends a <row> with one typed cell per non-nil value, in the
+// column's style
+func writeXLSXRow(b *strings.Builder, row int, values []any, styles []int) {
+	fmt.Fprintf(b, `<row r="%d">`, row)
+	for i, v := range values {
+		ref := fmt.Sprintf("%s%d", xlsxColumn(i), row)
+		switch v := v.(type) {
+		case nil:
+			continue
+		case bool:
+			n := 0
+			if v {
+				n = 1
+			}
+			fmt.Fprintf(b, `<c r="%s" s="%d" t="b"><v>%d</v></c>`, ref, styles[i], n)
+		case int, int64, float64:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%v</v></c>`, ref, styles[i], v)
+		default:
+			fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styles[i], xmlEscape(fmt.Sprint(v)))
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+// xlsxColumn converts a zero-based column index to its letters (0 -> A, 26 -> AA)
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSheetName trims a table name to Excel's 31-character sheet name limit
+func xlsxSheetName(table string) string {
+	if table == "" {
+		return "Sheet1"
+	}
+	if len(table) > 31 {
+		return table[:31]
+	}
+	return table
+}
+
+// xmlEscape escapes text for XML content and attributes
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// =============================================================================
+// IMPORT
+// =============================================================================
+
+// xlsxImporter reads the first worksheet of an Excel workbook: a header row
+// of column names, then one record per row
+type xlsxImporter struct{}
+
+func (xlsxImporter) Name() string         { return "xlsx" }
+func (xlsxImporter) Extensions() []string { return []string{".xlsx"} }
+
+// xlsxText is an inline or shared string: plain text or rich-text runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.Text
+	for _, r := range t.Runs {
+		s += r.Text
+	}
+	return s
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func (xlsxImporter) Read(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	var sheet xlsxWorksheet
+	sheetName := ""
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			err = readZipXML(f, &shared)
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && (sheetName == "" || f.Name < sheetName):
+			sheetName = f.Name
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+	}
+	if sheetName == "" {
+		return nil, fmt.Errorf("workbook has no worksheets")
+	}
+	for _, f := range zr.File {
+		if f.Name == sheetName {
+			if err := readZipXML(f, &sheet); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+			}
+		}
+	}
+
+	rows := make([][]any, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var cells []any
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = xlsxColumnIndex(c.Ref)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s: bad shared string index %q", c.Ref, c.Value)
+				}
+				cells[col] = shared.Items[n].String()
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			case "b":
+				cells[col] = c.Value == "1"
+			case "str", "e":
+				cells[col] = c.Value
+			default:
+				if c.Value == "" {
+					continue
+				}
+				f, err := strconv.ParseFloat(c.Value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("cell %s: bad number %q", c.Ref, c.Value)
+				}
+				cells[col] = f
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return tableRecords(rows), nil
+}
+
+// readZipXML decodes an XML part of a zip archive
+func readZipXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// xlsxColumnIndex returns the zero-based column of a cell reference (B7 -> 1)
+func xlsxColumnIndex(ref string) int {
+	n := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		n = n*26 + int(c-'A'+1)
+	}
+	return n - 1
+}
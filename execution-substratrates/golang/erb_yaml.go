@@ -0,0 +1,822 @@
+// ERB SDK - YAML Reader
+// =====================
+// A dependency-free reader for the YAML subset used to author rulebooks:
+// block mappings and sequences, plain/quoted/block scalars, flow collections,
+// and comments. Documents are converted to JSON (keeping key order) so they
+// load through the same path as effortless-rulebook.json.
+//
+// Not supported: anchors/aliases, tags, multiple documents, complex keys.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// =============================================================================
+// NODES
+// =============================================================================
+
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlMapping
+	yamlSequence
+)
+
+// yamlNode is a parsed YAML value; mappings keep their key order
+type yamlNode struct {
+	kind   yamlKind
+	value  any // scalar: nil, bool, json.Number, or string
+	keys   []string
+	values []*yamlNode
+	items  []*yamlNode
+}
+
+// writeJSON encodes the node as JSON, preserving mapping key order
+func (n *yamlNode) writeJSON(b *bytes.Buffer) error {
+	switch n.kind {
+	case yamlMapping:
+		b.WriteByte('{')
+		for i, k := range n.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			b.Write(key)
+			b.WriteByte(':')
+			if err := n.values[i].writeJSON(b); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case yamlSequence:
+		b.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := item.writeJSON(b); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		data, err := json.Marshal(n.value)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	return nil
+}
+
+// yamlToJSON converts a YAML document to JSON
+func yamlToJSON(data []byte) ([]byte, error) {
+	node, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := node.writeJSON(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// =============================================================================
+// BLOCK PARSER
+// =============================================================================
+
+type yamlLine struct {
+	num    int    // 1-based line number
+	indent int    // leading spaces
+	text   string // content after indentation, trailing comment removed
+	raw    string // full line as written (for block scalars)
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func parseYAML(data []byte) (*yamlNode, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") && strings.TrimSpace(trimmed) != "" {
+			return nil, fmt.Errorf("yaml line %d: tabs are not allowed for indentation", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{
+			num:    i + 1,
+			indent: len(raw) - len(trimmed),
+			text:   strings.TrimRight(stripYAMLComment(trimmed), " \t"),
+			raw:    raw,
+		})
+	}
+
+	p.skipBlank()
+	if line, ok := p.peek(); ok && (line.text == "---" || strings.HasPrefix(line.text, "%")) {
+		p.pos++
+		p.skipBlank()
+	}
+
+	line, ok := p.peek()
+	if !ok {
+		return &yamlNode{kind: yamlScalar}, nil
+	}
+	node, err := p.parseBlock(line.indent)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipBlank()
+	if line, ok := p.peek(); ok && line.text != "..." {
+		return nil, fmt.Errorf("yaml line %d: unexpected content %q", line.num, line.text)
+	}
+	return node, nil
+}
+
+func (p *yamlParser) peek() (yamlLine, bool) {
+	if p.pos >= len(p.lines) {
+		return yamlLine{}, false
+	}
+	return p.lines[p.pos], true
+}
+
+// skipBlank advances past empty and comment-only lines
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseBlock parses the node starting at the current line, which is indented by indent
+func (p *yamlParser) parseBlock(indent int) (*yamlNode, error) {
+	line, _ := p.peek()
+	if isSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitMappingKey(line.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return p.parseInlineValue(line.text, line.num, indent-1)
+}
+
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{kind: yamlMapping}
+	seen := map[string]bool{}
+
+	for {
+		p.skipBlank()
+		line, ok := p.peek()
+		if !ok || line.indent < indent || line.text == "..." || line.text == "---" {
+			return node, nil
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("yaml line %d: unexpected indentation", line.num)
+		}
+		if isSequenceItem(line.text) {
+			return node, nil
+		}
+
+		key, rest, ok := splitMappingKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml line %d: expected \"key: value\", got %q", line.num, line.text)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("yaml line %d: duplicate key %q", line.num, key)
+		}
+		seen[key] = true
+		p.pos++
+
+		value, err := p.parseValue(rest, line.num, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, value)
+	}
+}
+
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{kind: yamlSequence}
+
+	for {
+		p.skipBlank()
+		line, ok := p.peek()
+		if !ok || line.indent != indent || !isSequenceItem(line.text) {
+			if ok && line.indent > indent {
+				return nil, fmt.Errorf("yaml line %d: unexpected indentation", line.num)
+			}
+			return node, nil
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			value, err := p.parseValue("", line.num, indent, false)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, value)
+			continue
+		}
+
+		// "- key: value" and "- - item" open a nested block at the column of the content
+		_, _, isKey := splitMappingKey(rest)
+		if isKey || isSequenceItem(rest) {
+			column := indent + (len(line.text) - len(rest))
+			p.lines[p.pos].indent = column
+			p.lines[p.pos].text = rest
+			item, err := p.parseBlock(column)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, item)
+			continue
+		}
+
+		p.pos++
+		item, err := p.parseValue(rest, line.num, indent, false)
+		if err != nil {
+			return nil, err
+		}
+		node.items = append(node.items, item)
+	}
+}
+
+// parseValue parses the value that follows "key:" or "-" on a line at parentIndent
+func (p *yamlParser) parseValue(rest string, num, parentIndent int, inMapping bool) (*yamlNode, error) {
+	if rest == "" {
+		p.skipBlank()
+		next, ok := p.peek()
+		switch {
+		case ok && next.indent > parentIndent:
+			return p.parseBlock(next.indent)
+		case ok && inMapping && next.indent == parentIndent && isSequenceItem(next.text):
+			// Sequences may sit at the same indentation as their parent key
+			return p.parseSequence(parentIndent)
+		default:
+			return &yamlNode{kind: yamlScalar}, nil
+		}
+	}
+	if rest[0] == '|' || rest[0] == '>' {
+		return p.parseBlockScalar(rest, num, parentIndent)
+	}
+	return p.parseInlineValue(rest, num, parentIndent)
+}
+
+// parseInlineValue parses a scalar or flow collection, joining continuation lines
+// (indented deeper than parentIndent) for multi-line quoted, plain, and flow values
+func (p *yamlParser) parseInlineValue(text string, num, parentIndent int) (*yamlNode, error) {
+	if strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "!") {
+		return nil, fmt.Errorf("yaml line %d: anchors, aliases, and tags are not supported", num)
+	}
+
+	var continuation []string
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.text != "" && line.indent <= parentIndent {
+			break
+		}
+		if line.text == "" && !inQuotedText(text, continuation) {
+			// Blank lines only belong to the value if more continuation follows
+			j := p.pos
+			for j < len(p.lines) && p.lines[j].text == "" {
+				j++
+			}
+			if j >= len(p.lines) || p.lines[j].indent <= parentIndent {
+				break
+			}
+		}
+		if line.text != "" && !inQuotedText(text, continuation) && !isOpenFlow(text, continuation) {
+			if _, _, isKey := splitMappingKey(line.text); isKey || isSequenceItem(line.text) {
+				return nil, fmt.Errorf("yaml line %d: unexpected structure inside a scalar value", line.num)
+			}
+		}
+		if inQuotedText(text, continuation) {
+			continuation = append(continuation, strings.TrimSpace(line.raw))
+		} else {
+			continuation = append(continuation, line.text)
+		}
+		p.pos++
+	}
+
+	full := foldLines(text, continuation)
+	if strings.ContainsRune(`"[{`, rune(text[0])) {
+		full = joinDoubleQuotedLines(text, continuation)
+	}
+	if full[0] == '[' || full[0] == '{' {
+		fp := &yamlFlowParser{s: full, line: num}
+		node, err := fp.parse()
+		if err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	value, err := parseYAMLScalar(full, num)
+	if err != nil {
+		return nil, err
+	}
+	return &yamlNode{kind: yamlScalar, value: value}, nil
+}
+
+// parseBlockScalar parses a "|" (literal) or ">" (folded) block scalar
+func (p *yamlParser) parseBlockScalar(header string, num, parentIndent int) (*yamlNode, error) {
+	style := header[0]
+	chomp := byte(0)
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c >= '1' && c <= '9':
+			// explicit indentation indicators are accepted but inferred from content
+		default:
+			return nil, fmt.Errorf("yaml line %d: invalid block scalar header %q", num, header)
+		}
+	}
+
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= parentIndent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			break
+		}
+		lines = append(lines, line.raw[blockIndent:])
+		p.pos++
+	}
+
+	// Trailing blank lines are governed by chomping
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	// Give back blank lines that separate this scalar from the next key
+	p.pos -= trailing
+
+	var text string
+	if style == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = foldBlock(lines)
+	}
+
+	switch chomp {
+	case '-':
+	case '+':
+		text += "\n" + strings.Repeat("\n", trailing)
+	default:
+		if len(lines) > 0 {
+			text += "\n"
+		}
+	}
+	return &yamlNode{kind: yamlScalar, value: text}, nil
+}
+
+// foldBlock joins ">" block lines: single line breaks become spaces, each blank
+// line becomes a newline, and more-indented lines keep their line breaks
+func foldBlock(lines []string) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case l == "":
+				b.WriteByte('\n')
+			case prev == "":
+			case strings.HasPrefix(l, " ") || strings.HasPrefix(prev, " "):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// foldLines joins a multi-line flow scalar the way YAML folds it:
+// line breaks become spaces, and each blank line becomes a newline
+func foldLines(first string, continuation []string) string {
+	if len(continuation) == 0 {
+		return first
+	}
+	var b strings.Builder
+	b.WriteString(first)
+	pendingBlank := 0
+	for _, l := range continuation {
+		if l == "" {
+			pendingBlank++
+			continue
+		}
+		if pendingBlank > 0 {
+			b.WriteString(strings.Repeat("\n", pendingBlank))
+			pendingBlank = 0
+		} else {
+			b.WriteByte(' ')
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// joinDoubleQuotedLines folds a multi-line double-quoted scalar, honoring
+// escaped line breaks (a trailing backslash joins lines without a space)
+func joinDoubleQuotedLines(first string, continuation []string) string {
+	var b strings.Builder
+	b.WriteString(first)
+	pendingBlank := 0
+	for _, l := range continuation {
+		if l == "" {
+			pendingBlank++
+			continue
+		}
+		current := b.String()
+		trailing := len(current) - len(strings.TrimRight(current, "\\"))
+		switch {
+		case pendingBlank == 0 && trailing%2 == 1:
+			b.Reset()
+			b.WriteString(current[:len(current)-1])
+		case pendingBlank > 0:
+			b.WriteString(strings.Repeat("\n", pendingBlank))
+		default:
+			b.WriteByte(' ')
+		}
+		pendingBlank = 0
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// inQuotedText reports whether a quoted scalar or flow collection started on the
+// first line is still inside an unterminated quoted string
+func inQuotedText(first string, continuation []string) bool {
+	if first == "" || !strings.ContainsRune(`"'[{`, rune(first[0])) {
+		return false
+	}
+	s := joinDoubleQuotedLines(first, continuation)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '"' && s[i] != '\'' {
+			continue
+		}
+		if !atTokenStart(s, i) {
+			continue
+		}
+		_, end, err := scanQuoted(s, i)
+		if err != nil {
+			return true
+		}
+		i = end - 1
+	}
+	return false
+}
+
+// atTokenStart reports whether s[i] begins a flow token (quotes elsewhere are literal)
+func atTokenStart(s string, i int) bool {
+	prev := strings.TrimRight(s[:i], " ")
+	return prev == "" || strings.ContainsRune("[{,:", rune(prev[len(prev)-1]))
+}
+
+// isOpenFlow reports whether a flow collection started on the first line is still unbalanced
+func isOpenFlow(first string, continuation []string) bool {
+	if first == "" || (first[0] != '[' && first[0] != '{') {
+		return false
+	}
+	s := joinDoubleQuotedLines(first, continuation)
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			if !atTokenStart(s, i) {
+				continue
+			}
+			_, end, err := scanQuoted(s, i)
+			if err != nil {
+				return true
+			}
+			i = end - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+// =============================================================================
+// SCALARS
+// =============================================================================
+
+var (
+	yamlIntPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// parseYAMLScalar resolves a quoted or plain scalar to nil, bool, json.Number, or string
+func parseYAMLScalar(s string, num int) (any, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		value, end, err := scanQuoted(s, 0)
+		if err != nil {
+			return nil, fmt.Errorf("yaml line %d: %w", num, err)
+		}
+		if strings.TrimSpace(s[end:]) != "" {
+			return nil, fmt.Errorf("yaml line %d: unexpected text after quoted string: %q", num, s[end:])
+		}
+		return value, nil
+	}
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if yamlIntPattern.MatchString(s) {
+		return json.Number(strings.TrimPrefix(s, "+")), nil
+	}
+	if yamlFloatPattern.MatchString(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+		}
+	}
+	return s, nil
+}
+
+// scanQuoted decodes the quoted string starting at s[start], returning the value and the index after it
+func scanQuoted(s string, start int) (string, int, error) {
+	quote := s[start]
+	var b strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+		if quote == '\'' {
+			if c == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				return b.String(), i + 1, nil
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("unterminated escape in quoted string")
+			}
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case ' ':
+				b.WriteByte(' ')
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'x', 'u', 'U':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+				if i+size >= len(s) {
+					return "", 0, fmt.Errorf("truncated \\%c escape", e)
+				}
+				code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid \\%c escape: %w", e, err)
+				}
+				b.WriteRune(rune(code))
+				i += size
+			default:
+				return "", 0, fmt.Errorf("unsupported escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+// splitMappingKey splits "key: value" (or "key:") into its key and value text
+func splitMappingKey(text string) (string, string, bool) {
+	if text == "" {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		key, end, err := scanQuoted(text, 0)
+		if err != nil {
+			return "", "", false
+		}
+		after := text[end:]
+		if after == ":" {
+			return key, "", true
+		}
+		if strings.HasPrefix(after, ": ") {
+			return key, strings.TrimSpace(after[2:]), true
+		}
+		return "", "", false
+	}
+	if text[0] == '[' || text[0] == '{' || isSequenceItem(text) {
+		return "", "", false
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a trailing "# comment" that is not inside quotes
+func stripYAMLComment(s string) string {
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// Quotes only open a quoted scalar at the start of a token
+			if i == 0 || strings.ContainsRune(" :-[{,", rune(s[i-1])) {
+				quote = c
+			}
+		case c == '#' && i > 0 && (s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// =============================================================================
+// FLOW COLLECTIONS
+// =============================================================================
+
+type yamlFlowParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (fp *yamlFlowParser) parse() (*yamlNode, error) {
+	node, err := fp.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	fp.skipSpace()
+	if fp.i != len(fp.s) {
+		return nil, fmt.Errorf("yaml line %d: unexpected text after flow collection: %q", fp.line, fp.s[fp.i:])
+	}
+	return node, nil
+}
+
+func (fp *yamlFlowParser) skipSpace() {
+	for fp.i < len(fp.s) && (fp.s[fp.i] == ' ' || fp.s[fp.i] == '\n' || fp.s[fp.i] == '\t') {
+		fp.i++
+	}
+}
+
+func (fp *yamlFlowParser) parseValue() (*yamlNode, error) {
+	fp.skipSpace()
+	if fp.i >= len(fp.s) {
+		return nil, fmt.Errorf("yaml line %d: unterminated flow collection", fp.line)
+	}
+	switch fp.s[fp.i] {
+	case '[':
+		return fp.parseCollection(']')
+	case '{':
+		return fp.parseCollection('}')
+	}
+
+	text, err := fp.scanScalar()
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseYAMLScalar(text, fp.line)
+	if err != nil {
+		return nil, err
+	}
+	return &yamlNode{kind: yamlScalar, value: value}, nil
+}
+
+// scanScalar returns the raw text of the next flow scalar
+func (fp *yamlFlowParser) scanScalar() (string, error) {
+	start := fp.i
+	if c := fp.s[fp.i]; c == '"' || c == '\'' {
+		_, end, err := scanQuoted(fp.s, fp.i)
+		if err != nil {
+			return "", fmt.Errorf("yaml line %d: %w", fp.line, err)
+		}
+		fp.i = end
+		return fp.s[start:end], nil
+	}
+	for fp.i < len(fp.s) {
+		c := fp.s[fp.i]
+		if c == ',' || c == ']' || c == '}' || (c == ':' && (fp.i+1 == len(fp.s) || fp.s[fp.i+1] == ' ')) {
+			break
+		}
+		fp.i++
+	}
+	return strings.TrimSpace(fp.s[start:fp.i]), nil
+}
+
+func (fp *yamlFlowParser) parseCollection(closing byte) (*yamlNode, error) {
+	fp.i++ // opening bracket
+	node := &yamlNode{kind: yamlSequence}
+	if closing == '}' {
+		node.kind = yamlMapping
+	}
+
+	for {
+		fp.skipSpace()
+		if fp.i >= len(fp.s) {
+			return nil, fmt.Errorf("yaml line %d: unterminated flow collection", fp.line)
+		}
+		if fp.s[fp.i] == closing {
+			fp.i++
+			return node, nil
+		}
+
+		if node.kind == yamlMapping {
+			keyText, err := fp.scanScalar()
+			if err != nil {
+				return nil, err
+			}
+			key, err := parseYAMLScalar(keyText, fp.line)
+			if err != nil {
+				return nil, err
+			}
+			fp.skipSpace()
+			value := &yamlNode{kind: yamlScalar}
+			if fp.i < len(fp.s) && fp.s[fp.i] == ':' {
+				fp.i++
+				if value, err = fp.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+			node.keys = append(node.keys, fmt.Sprint(key))
+			node.values = append(node.values, value)
+		} else {
+			item, err := fp.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, item)
+		}
+
+		fp.skipSpace()
+		if fp.i < len(fp.s) && fp.s[fp.i] == ',' {
+			fp.i++
+			continue
+		}
+		if fp.i < len(fp.s) && fp.s[fp.i] == closing {
+			continue
+		}
+		return nil, fmt.Errorf("yaml line %d: expected ',' or '%c' in flow collection", fp.line, closing)
+	}
+}
@@ -10,12 +10,23 @@ it reads whatever tables and schemas are defined and generates corresponding Go
 
 Generated files:
 - erb_sdk.go - Structs, individual Calc* methods, and ComputeAll functions
-- main.go - Test runner for the primary table (LanguageCandidates)
+- erb_runner.go - Conformance runner for every table with calculated fields
+  (rendered from runner.go.tmpl)
+- erb_golden_test.go - `go test` comparing every runner table's answers with
+  the checked-in golden files (rendered from golden_test.go.tmpl)
+- main.go - CLI entry point (created once if missing)
+
+erb_gen.go ports this script to Go (the `gen` command, run by `go generate`);
+keep the two generating the same bytes.
 """
 
 import sys
 import re
+import json
+import difflib
+import hashlib
 from pathlib import Path
+from string import Template
 from typing import Dict, List, Any, Set
 
 # Add project root to path for shared imports
@@ -23,7 +34,7 @@ sys.path.insert(0, str(Path(__file__).resolve().parent.parent.parent))
 
 from orchestration.shared import load_rulebook, get_candidate_name_from_cwd, handle_clean_arg
 from orchestration.formula_parser import (
-    parse_formula, compile_to_go, get_field_dependencies,
+    parse_formula, compile_to_go, get_field_dependencies, infer_formula_type,
     to_snake_case, to_pascal_case, ASTNode
 )
 
@@ -78,11 +89,107 @@ def table_name_to_struct_name(table_name: str) -> str:
     return table_name
 
 
+def find_unknown_references(table_name: str, schema: List[Dict]) -> List[str]:
+    """Report formula {{Field}} references that name no field in the table.
+
+    Each message suggests the closest existing field name when there is one,
+    since an unknown reference would generate Go that does not compile.
+    """
+    field_names = [f['name'] for f in schema]
+    by_lower = {name.lower(): name for name in field_names}
+    problems = []
+    for field in get_calculated_fields(schema):
+        try:
+            deps = get_field_dependencies(parse_formula(field['formula']))
+        except Exception:
+            continue  # parse errors are reported when the formula is compiled
+        for dep in deps:
+            if dep in field_names:
+                continue
+            message = f"{table_name}.{field['name']} references unknown field {{{{{dep}}}}}"
+            matches = difflib.get_close_matches(dep.lower(), list(by_lower), n=1, cutoff=0.7)
+            if matches:
+                message += f" (did you mean {{{{{by_lower[matches[0]]}}}}}?)"
+            problems.append(message)
+    return problems
+
+
+def find_type_errors(table_name: str, schema: List[Dict]) -> List[str]:
+    """Report calculated fields whose formula returns a different datatype.
+
+    The Go code for a formula is typed by what the formula returns, while the
+    struct field is typed by the declared datatype, so a mismatch (e.g. a
+    string-valued formula in a boolean field) would generate Go that does not
+    compile or silently converts. Formulas of unknown type are not reported.
+    """
+    field_types = {f['name']: f.get('datatype') for f in schema}
+    problems = []
+    for field in get_calculated_fields(schema):
+        try:
+            inferred = infer_formula_type(parse_formula(field['formula']), field_types)
+        except Exception:
+            continue  # parse errors are reported when the formula is compiled
+        if inferred and inferred != field.get('datatype'):
+            problems.append(
+                f"{table_name}.{field['name']} is {field.get('datatype')} but its formula "
+                f"returns {inferred}: {field['formula']}"
+            )
+    return problems
+
+
+class DependencyCycleError(Exception):
+    """Raised when calculated fields reference each other in a cycle."""
+
+    def __init__(self, cycle: List[str], table: str = ''):
+        self.cycle = cycle
+        self.table = table
+        super().__init__(cycle, table)
+
+    def __str__(self) -> str:
+        where = f" in {self.table}" if self.table else ""
+        return f"circular dependency between calculated fields{where}: {' -> '.join(self.cycle)}"
+
+
+def find_dependency_cycle(field_deps: Dict[str, Set[str]]) -> List[str]:
+    """Find a cycle among calculated fields (e.g. ['A', 'B', 'A']).
+
+    Only dependencies on other calculated fields are followed. Returns an
+    empty list if the fields can be ordered.
+    """
+    visiting, done = set(), set()
+    stack = []
+
+    def visit(name: str) -> List[str]:
+        visiting.add(name)
+        stack.append(name)
+        for dep in sorted(field_deps.get(name, ())):
+            if dep not in field_deps or dep in done:
+                continue
+            if dep in visiting:
+                return stack[stack.index(dep):] + [dep]
+            cycle = visit(dep)
+            if cycle:
+                return cycle
+        stack.pop()
+        visiting.discard(name)
+        done.add(name)
+        return []
+
+    for name in field_deps:
+        if name not in done:
+            cycle = visit(name)
+            if cycle:
+                return cycle
+    return []
+
+
 def build_dag_levels(calculated_fields: List[Dict], raw_field_names: Set[str]) -> List[List[Dict]]:
     """Build DAG levels for calculated fields based on dependencies.
 
     This ensures fields are computed in the correct order - fields that depend
     on other calculated fields are placed in later levels.
+
+    Raises DependencyCycleError if calculated fields reference each other.
     """
     field_deps = {}
     for field in calculated_fields:
@@ -107,6 +214,9 @@ def build_dag_levels(calculated_fields: List[Dict], raw_field_names: Set[str]) -
                 current_level.append(field)
 
         if not current_level:
+            cycle = find_dependency_cycle(field_deps)
+            if cycle:
+                raise DependencyCycleError(cycle)
             print(f"Warning: Could not resolve dependencies for: {list(remaining.keys())}")
             levels.append(list(remaining.values()))
             break
@@ -153,20 +263,20 @@ def compile_formula_to_go(field: Dict, struct_var: str = 'tc', calc_vars: Dict[s
         # with their local variable names. Order matters - more specific patterns first.
         if calc_vars:
             for field_name, var_name in calc_vars.items():
-                # Pattern 1: boolVal(tc.Field) -> var_name (already a bool)
-                go_expr = go_expr.replace(f'boolVal({struct_var}.{field_name})', var_name)
+                # Pattern 1: optGet(tc.Field, false) -> var_name (already a bool)
+                go_expr = go_expr.replace(f'optGet({struct_var}.{field_name}, false)', var_name)
 
-                # Pattern 2: stringVal(tc.Field) -> var_name (already a string)
-                go_expr = go_expr.replace(f'stringVal({struct_var}.{field_name})', var_name)
+                # Pattern 2: optGet(tc.Field, "") -> var_name (already a string)
+                go_expr = go_expr.replace(f'optGet({struct_var}.{field_name}, "")', var_name)
 
-                # Pattern 3: (tc.Field != nil && *tc.Field == X) -> (var_name == boolVal(X))
-                def wrap_rhs_in_boolval(match):
+                # Pattern 3: (tc.Field != nil && *tc.Field == X) -> (var_name == optGet(X, false))
+                def wrap_rhs_in_opt_get(match):
                     rhs = match.group(1)
                     if rhs.startswith(f'{struct_var}.'):
-                        return f'({var_name} == boolVal({rhs}))'
+                        return f'({var_name} == optGet({rhs}, false))'
                     return f'({var_name} == {rhs})'
                 pattern = rf'\({struct_var}\.{field_name} != nil && \*{struct_var}\.{field_name} == ([^)]+)\)'
-                go_expr = re.sub(pattern, wrap_rhs_in_boolval, go_expr)
+                go_expr = re.sub(pattern, wrap_rhs_in_opt_get, go_expr)
 
                 # Pattern 4: tc.Field != nil && *tc.Field (without == ) -> var_name
                 go_expr = go_expr.replace(f'{struct_var}.{field_name} != nil && *{struct_var}.{field_name}', var_name)
@@ -174,8 +284,8 @@ def compile_formula_to_go(field: Dict, struct_var: str = 'tc', calc_vars: Dict[s
                 # Pattern 5: Any remaining tc.Field -> var_name
                 go_expr = go_expr.replace(f'{struct_var}.{field_name}', var_name)
 
-        # Fix IF conditions with pointer fields: `if tc.Field {` -> `if boolVal(tc.Field) {`
-        go_expr = re.sub(rf'if ({struct_var}\.\w+) \{{', r'if boolVal(\1) {', go_expr)
+        # Fix IF conditions with pointer fields: `if tc.Field {` -> `if optGet(tc.Field, false) {`
+        go_expr = re.sub(rf'if ({struct_var}\.\w+) \{{', r'if optGet(\1, false) {', go_expr)
 
         return go_expr
     except Exception as e:
@@ -257,17 +367,35 @@ def generate_compute_all_function(
         name = field['name']
         var_name = calc_vars[name]
         datatype = field.get('datatype', 'string')
-        # Use nilIfEmpty for string fields to return null for empty strings
+        # Use optNilIfZero for string fields to return null for empty strings
         if datatype == 'string' or datatype not in ('boolean', 'integer'):
-            lines.append(f'\t\t{name}: nilIfEmpty({var_name}),')
+            lines.append(f'\t\t{name}: optNilIfZero({var_name}),')
         else:
-            lines.append(f'\t\t{name}: &{var_name},')
+            lines.append(f'\t\t{name}: optPtr({var_name}),')
     lines.append('\t}')
     lines.append('}')
 
     return lines
 
 
+def generate_raw_equal_function(struct_name: str, raw_fields: List[Dict], struct_var: str = 'tc') -> List[str]:
+    """Generate RawEqual, which compares the raw fields of two records."""
+    comparisons = []
+    for field in raw_fields:
+        name = field['name']
+        if field.get('nullable', True):
+            comparisons.append(f'optEqual({struct_var}.{name}, other.{name})')
+        else:
+            comparisons.append(f'{struct_var}.{name} == other.{name}')
+
+    lines = []
+    lines.append('// RawEqual reports whether tc and other hold the same raw field values')
+    lines.append(f'func ({struct_var} *{struct_name}) RawEqual(other *{struct_name}) bool {{')
+    lines.append('\treturn ' + ' &&\n\t\t'.join(comparisons))
+    lines.append('}')
+    return lines
+
+
 def generate_struct_for_table(table_name: str, schema: List[Dict]) -> List[str]:
     """Generate the struct definition for a table."""
     lines = []
@@ -289,6 +417,110 @@ def generate_struct_for_table(table_name: str, schema: List[Dict]) -> List[str]:
     return lines
 
 
+def generate_formulas_map(struct_name: str, calculated_fields: List[Dict]) -> List[str]:
+    """Generate a map from each calculated field to its rulebook formula."""
+    lines = []
+    lines.append(f'// {struct_name}Formulas maps each calculated field to its rulebook formula')
+    lines.append(f'var {struct_name}Formulas = map[string]string{{')
+    for field in calculated_fields:
+        lines.append(f'\t"{field["name"]}": {json.dumps(field["formula"])},')
+    lines.append('}')
+    return lines
+
+
+def generate_levels_map(rulebook: Dict, table_names: List[str]) -> List[str]:
+    """Generate GeneratedLevels: the DAG level ComputeAll assigned each calculated field.
+
+    The Go SDK recomputes levels from the loaded rulebook and compares them
+    with this map to detect generated code that is stale.
+    """
+    lines = []
+    lines.append('// =============================================================================')
+    lines.append('// GENERATION METADATA')
+    lines.append('// =============================================================================')
+    lines.append('')
+    lines.append('// GeneratedLevels maps table -> calculated field -> the DAG level ComputeAll computes it at')
+    lines.append('var GeneratedLevels = map[string]map[string]int{')
+    for table_name in table_names:
+        table_data = rulebook[table_name]
+        if not isinstance(table_data, dict) or 'schema' not in table_data:
+            continue
+        schema = table_data['schema']
+        calculated_fields = get_calculated_fields(schema)
+        if not calculated_fields:
+            continue
+        raw_field_names = {f['name'] for f in get_raw_fields(schema)}
+        lines.append(f'\t"{table_name}": {{')
+        for level_idx, level_fields in enumerate(build_dag_levels(calculated_fields, raw_field_names)):
+            for field in level_fields:
+                lines.append(f'\t\t"{field["name"]}": {level_idx + 1},')
+        lines.append('\t},')
+    lines.append('}')
+    lines.append('')
+    return lines
+
+
+def field_definition_hash(table_name: str, field: Dict) -> str:
+    """Hash the parts of a field definition that affect generated code.
+
+    Must match fieldDefinitionHash in erb_generated.go.
+    """
+    parts = [
+        f"{table_name}.{field['name']}",
+        field.get('datatype', ''),
+        field.get('type', ''),
+        'true' if field.get('nullable', False) else 'false',
+        field.get('formula', ''),
+    ]
+    return hashlib.sha256('\x00'.join(parts).encode('utf-8')).hexdigest()[:12]
+
+
+def generate_field_hashes(rulebook: Dict, table_names: List[str]) -> List[str]:
+    """Generate GeneratedFieldHashes, used by `check-generated` to detect drift."""
+    lines = []
+    lines.append('// GeneratedFieldHashes maps "Table.Field" to a hash of the field definition this file was generated from')
+    lines.append('var GeneratedFieldHashes = map[string]string{')
+    for table_name in table_names:
+        table_data = rulebook[table_name]
+        if not isinstance(table_data, dict) or 'schema' not in table_data:
+            continue
+        for field in table_data['schema']:
+            lines.append(f'\t"{table_name}.{field["name"]}": "{field_definition_hash(table_name, field)}",')
+    lines.append('}')
+    lines.append('')
+    return lines
+
+
+def generate_query_fields(table_name: str, struct_name: str, schema: List[Dict]) -> List[str]:
+    """Generate query accessors for each field of the primary table.
+
+    Boolean fields become predicate functions (HasSyntax(true)); string and
+    integer fields become QueryField values (SortOrder, Name.Eq("English")).
+    """
+    lines = []
+    lines.append('// =============================================================================')
+    lines.append(f'// QUERY FIELDS (for {table_name})')
+    lines.append('// =============================================================================')
+    lines.append('')
+    for field in schema:
+        name = field['name']
+        datatype = field.get('datatype', 'string').lower()
+        nullable = field.get('nullable', True)
+        ref = f'r.{name}' if nullable else f'&r.{name}'
+
+        if datatype == 'boolean':
+            lines.append(f'// {name} matches {table_name} whose {name} is want (null counts as false)')
+            lines.append(f'func {name}(want bool) Predicate[{struct_name}] {{')
+            lines.append(f'\treturn func(r *{struct_name}) bool {{ return optGet(r.{name}, false) == want }}')
+            lines.append('}')
+        else:
+            go_type = 'int' if datatype == 'integer' else 'string'
+            lines.append(f'// {name} is the {name} field, for Where and SortBy')
+            lines.append(f'var {name} = QueryField[{struct_name}, {go_type}]{{Name: "{name}", Get: func(r *{struct_name}) *{go_type} {{ return {ref} }}}}')
+        lines.append('')
+    return lines
+
+
 def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
     """Generate complete SDK code for a single table.
 
@@ -315,9 +547,22 @@ def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
     lines.extend(generate_struct_for_table(table_name, schema))
     lines.append('')
 
+    # Typed table binding (erb_typed_table.go): load, compute and save
+    compute = f'(*{struct_name}).ComputeAll' if calculated_fields else 'nil'
+    lines.append(f'// {table_name}Table loads, computes and saves {table_name} records')
+    lines.append(f'var {table_name}Table = NewTypedTable[{struct_name}]("{table_name}", {compute})')
+    lines.append('')
+
     if calculated_fields:
+        # Formula source, used at runtime by Explain()
+        lines.extend(generate_formulas_map(struct_name, calculated_fields))
+        lines.append('')
+
         # Build DAG for calculation ordering
-        dag_levels = build_dag_levels(calculated_fields, raw_field_names)
+        try:
+            dag_levels = build_dag_levels(calculated_fields, raw_field_names)
+        except DependencyCycleError as e:
+            raise DependencyCycleError(e.cycle, table_name) from None
 
         # Individual Calc* functions
         lines.append(f'// --- Individual Calculation Functions ---')
@@ -334,6 +579,10 @@ def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
         ))
         lines.append('')
 
+        # RawEqual function (memoized views, erb_memo.go)
+        lines.extend(generate_raw_equal_function(struct_name, raw_fields))
+        lines.append('')
+
     return lines
 
 
@@ -356,7 +605,6 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     lines.append('package main')
     lines.append('')
     lines.append('import (')
-    lines.append('\t"encoding/json"')
     lines.append('\t"fmt"')
     lines.append('\t"os"')
     lines.append(')')
@@ -367,28 +615,34 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     lines.append('// HELPER FUNCTIONS')
     lines.append('// =============================================================================')
     lines.append('')
-    lines.append('// boolVal safely dereferences a *bool, returning false if nil')
-    lines.append('func boolVal(b *bool) bool {')
-    lines.append('\tif b == nil {')
-    lines.append('\t\treturn false')
+    lines.append('// optGet dereferences an optional value, returning def if it is nil')
+    lines.append('func optGet[T any](p *T, def T) T {')
+    lines.append('\tif p == nil {')
+    lines.append('\t\treturn def')
     lines.append('\t}')
-    lines.append('\treturn *b')
+    lines.append('\treturn *p')
     lines.append('}')
     lines.append('')
-    lines.append('// stringVal safely dereferences a *string, returning "" if nil')
-    lines.append('func stringVal(s *string) string {')
-    lines.append('\tif s == nil {')
-    lines.append('\t\treturn ""')
-    lines.append('\t}')
-    lines.append('\treturn *s')
+    lines.append('// optPtr returns a pointer to a copy of v')
+    lines.append('func optPtr[T any](v T) *T {')
+    lines.append('\treturn &v')
     lines.append('}')
     lines.append('')
-    lines.append('// nilIfEmpty returns nil for empty strings, otherwise a pointer to the string')
-    lines.append('func nilIfEmpty(s string) *string {')
-    lines.append('\tif s == "" {')
+    lines.append('// optNilIfZero returns nil for the zero value (e.g. ""), otherwise a pointer to v')
+    lines.append('func optNilIfZero[T comparable](v T) *T {')
+    lines.append('\tvar zero T')
+    lines.append('\tif v == zero {')
     lines.append('\t\treturn nil')
     lines.append('\t}')
-    lines.append('\treturn &s')
+    lines.append('\treturn &v')
+    lines.append('}')
+    lines.append('')
+    lines.append('// optEqual reports whether two optional values are both nil, or both set and equal')
+    lines.append('func optEqual[T comparable](a, b *T) bool {')
+    lines.append('\tif a == nil || b == nil {')
+    lines.append('\t\treturn a == b')
+    lines.append('\t}')
+    lines.append('\treturn *a == *b')
     lines.append('}')
     lines.append('')
 
@@ -404,6 +658,9 @@ def generate_erb_sdk(rulebook: Dict) -> str:
 
         lines.extend(generate_table_sdk(table_name, table_data))
 
+    lines.extend(generate_levels_map(rulebook, table_names))
+    lines.extend(generate_field_hashes(rulebook, table_names))
+
     # Find the primary table (first table with calculated fields)
     primary_table = None
     for table_name in table_names:
@@ -417,88 +674,124 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     if primary_table:
         struct_name = table_name_to_struct_name(primary_table)
 
+        # Query field accessors for the primary table (used with erb_query.go)
+        lines.extend(generate_query_fields(primary_table, struct_name, rulebook[primary_table]['schema']))
+
         # File I/O functions for the primary table
         lines.append('// =============================================================================')
         lines.append(f'// FILE I/O (for {primary_table})')
         lines.append('// =============================================================================')
         lines.append('')
-        lines.append(f'// LoadRecords loads records from a JSON file')
-        lines.append(f'func LoadRecords(path string) ([]{struct_name}, error) {{')
+        lines.append(f'// LoadRecords loads records from a JSON file; WithStrictFields rejects unknown or missing keys')
+        lines.append(f'// and WithPartialLoad returns the records that parsed along with a *MultiError')
+        lines.append(f'func LoadRecords(path string, opts ...RecordOption) ([]{struct_name}, error) {{')
         lines.append('\tdata, err := os.ReadFile(path)')
         lines.append('\tif err != nil {')
         lines.append('\t\treturn nil, fmt.Errorf("failed to read file: %w", err)')
         lines.append('\t}')
         lines.append('')
-        lines.append(f'\tvar records []{struct_name}')
-        lines.append('\tif err := json.Unmarshal(data, &records); err != nil {')
-        lines.append('\t\treturn nil, fmt.Errorf("failed to parse file: %w", err)')
+        lines.append(f'\trecords, err := decodeRecords[{struct_name}](data, opts)')
+        lines.append('\tif err != nil {')
+        lines.append('\t\treturn records, fmt.Errorf("failed to parse file: %w", err)')
         lines.append('\t}')
         lines.append('')
         lines.append('\treturn records, nil')
         lines.append('}')
         lines.append('')
-        lines.append(f'// SaveRecords saves computed records to a JSON file')
-        lines.append(f'func SaveRecords(path string, records []{struct_name}) error {{')
-        lines.append('\tdata, err := json.MarshalIndent(records, "", "  ")')
-        lines.append('\tif err != nil {')
-        lines.append('\t\treturn fmt.Errorf("failed to marshal records: %w", err)')
-        lines.append('\t}')
-        lines.append('')
-        lines.append('\tif err := os.WriteFile(path, data, 0644); err != nil {')
-        lines.append('\t\treturn fmt.Errorf("failed to write records: %w", err)')
-        lines.append('\t}')
-        lines.append('')
-        lines.append('\treturn nil')
+        lines.append(f'// SaveRecords saves computed records to a JSON file, as canonical JSON;')
+        lines.append(f'// WithEmptyStrings picks how empty calculated strings are written')
+        lines.append(f'func SaveRecords(path string, records []{struct_name}, opts ...RecordOption) error {{')
+        lines.append('\treturn saveRecords(path, records, opts)')
         lines.append('}')
 
     return '\n'.join(lines)
 
 
-def generate_main_go(struct_name: str) -> str:
-    """Generate main.go content for the given struct type."""
-    return f'''// ERB SDK - Go Test Runner (GENERATED - DO NOT EDIT)
-package main
+# Options for the generated conformance runner (erb_runner.go). Paths are
+# relative to this directory; the primary table keeps the file names the
+# orchestrator grades, other tables get per-table files.
+RUNNER_OPTIONS = {
+    'testing_dir': '../../testing',
+    'answers_dir': '.',
+    'label': 'Golang substrate',
+}
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-)
 
-func main() {{
-	scriptDir, err := os.Getwd()
-	if err != nil {{
-		fmt.Printf("Failed to get working directory: %v\\n", err)
-		os.Exit(1)
-	}}
+def runner_table_suffix(table_name: str, is_primary: bool) -> str:
+    """Return the file name suffix for a table in the conformance flow.
 
-	// Paths
-	blankTestPath := filepath.Join(scriptDir, "..", "..", "testing", "blank-test.json")
-	answersPath := filepath.Join(scriptDir, "test-answers.json")
+    The primary table has none (blank-test.json -> test-answers.json); others
+    use their snake_case name (blank-test.is_everything_a_language.json).
+    """
+    return '' if is_primary else '.' + to_snake_case(table_name)
 
-	// Step 1: Load blank test data
-	records, err := LoadRecords(blankTestPath)
-	if err != nil {{
-		fmt.Printf("Failed to load blank test: %v\\n", err)
-		os.Exit(1)
-	}}
 
-	fmt.Printf("Golang substrate: Processing %d records...\\n", len(records))
+def generate_runner_go(rulebook: Dict, template_path: Path, options: Dict = RUNNER_OPTIONS) -> str:
+    """Render erb_runner.go from the runner template.
 
-	// Step 2: Compute all calculated fields using the SDK
-	var computed []{struct_name}
-	for _, r := range records {{
-		computed = append(computed, *r.ComputeAll())
-	}}
+    Every table with calculated fields joins the conformance flow; the first
+    (primary) table's blank test is required, the others are skipped until
+    their blank test exists.
+    """
+    entries = []
+    for table_name in get_table_names(rulebook):
+        table_data = rulebook.get(table_name, {})
+        if not isinstance(table_data, dict) or not get_calculated_fields(table_data.get('schema', [])):
+            continue
+        struct_name = table_name_to_struct_name(table_name)
+        is_primary = not entries
+        suffix = runner_table_suffix(table_name, is_primary)
+        entries.append(
+            f'\t{{\n'
+            f'\t\tTable:    "{table_name}",\n'
+            f'\t\tInput:    "blank-test{suffix}.json",\n'
+            f'\t\tOutput:   "test-answers{suffix}.json",\n'
+            f'\t\tSuffix:   "{suffix}",\n'
+            f'\t\tRequired: {"true" if is_primary else "false"},\n'
+            f'\t\tFormulas: {struct_name}Formulas,\n'
+            f'\t\tcompute: func(data []byte, opts []RecordOption) ([]Record, error) {{\n'
+            f'\t\t\treturn computeRecords(data, (*{struct_name}).ComputeAll, opts)\n'
+            f'\t\t}},\n'
+            f'\t}},\n'
+        )
+
+    template = Template(template_path.read_text(encoding='utf-8'))
+    return template.substitute(options, tables=''.join(entries))
+
+
+# Options for the generated golden tests (erb_golden_test.go). The golden
+# files are created with `go test $(ls *.go) -update` and checked in.
+GOLDEN_OPTIONS = {
+    'golden_dir': 'testdata/golden',
+}
+
+
+def generate_golden_test_go(template_path: Path, options: Dict = GOLDEN_OPTIONS) -> str:
+    """Render erb_golden_test.go from the golden test template.
+
+    The test walks RunnerTables at run time, so the template only needs the
+    golden directory.
+    """
+    template = Template(template_path.read_text(encoding='utf-8'))
+    return template.substitute(options)
 
-	// Step 3: Save test answers
-	if err := SaveRecords(answersPath, computed); err != nil {{
-		fmt.Printf("Failed to save test answers: %v\\n", err)
-		os.Exit(1)
-	}}
 
-	fmt.Printf("Golang substrate: Saved results to %s\\n", answersPath)
-}}
+def generate_main_go() -> str:
+    """Generate the initial main.go: a take-test entry point over the generated runner."""
+    return '''// ERB SDK - Go Test Runner
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := RunConformance(DefaultTestingDir, DefaultAnswersDir, nil); err != nil {
+		fmt.Printf("take-test failed: %v\\n", err)
+		os.Exit(1)
+	}
+}
 '''
 
 
@@ -508,10 +801,12 @@ def main():
     # Note: erb_test, test-answers.json, test-results.md are build/test outputs
     GENERATED_FILES = [
         'erb_sdk.go',
+        'erb_runner.go',
+        'erb_golden_test.go',
     ]
 
     # Handle --clean argument
-    if handle_clean_arg(GENERATED_FILES, "Golang substrate: Removes generated erb_sdk.go"):
+    if handle_clean_arg(GENERATED_FILES, "Golang substrate: Removes generated erb_sdk.go, erb_runner.go and erb_golden_test.go"):
         return
 
     candidate_name = get_candidate_name_from_cwd()
@@ -551,6 +846,20 @@ def main():
                     print(f"    - {field['name']}")
                 total_calc_fields += len(calc_fields)
 
+    # Unknown references and formula/datatype mismatches would generate
+    # Go that does not compile, so stop before writing anything
+    schema_problems = []
+    for table_name in table_names:
+        table_data = rulebook.get(table_name, {})
+        if isinstance(table_data, dict) and 'schema' in table_data:
+            schema_problems.extend(find_unknown_references(table_name, table_data['schema']))
+            schema_problems.extend(find_type_errors(table_name, table_data['schema']))
+    if schema_problems:
+        print()
+        for problem in schema_problems:
+            print(f"ERROR: {problem}")
+        sys.exit(1)
+
     print()
     print(f"Total calculated fields to compile: {total_calc_fields}")
     if primary_table:
@@ -561,18 +870,35 @@ def main():
 
     # Generate erb_sdk.go
     print("Generating erb_sdk.go...")
-    erb_sdk_content = generate_erb_sdk(rulebook)
+    try:
+        erb_sdk_content = generate_erb_sdk(rulebook)
+    except DependencyCycleError as e:
+        print(f"ERROR: {e}")
+        sys.exit(1)
 
     erb_sdk_path = script_dir / "erb_sdk.go"
     erb_sdk_path.write_text(erb_sdk_content, encoding='utf-8')
     print(f"Wrote: {erb_sdk_path} ({len(erb_sdk_content)} bytes)")
 
+    # Generate erb_runner.go from the runner template
+    print("Generating erb_runner.go...")
+    runner_content = generate_runner_go(rulebook, script_dir / "runner.go.tmpl")
+    runner_path = script_dir / "erb_runner.go"
+    runner_path.write_text(runner_content, encoding='utf-8')
+    print(f"Wrote: {runner_path} ({len(runner_content)} bytes)")
+
+    # Generate erb_golden_test.go from the golden test template
+    print("Generating erb_golden_test.go...")
+    golden_content = generate_golden_test_go(script_dir / "golden_test.go.tmpl")
+    golden_path = script_dir / "erb_golden_test.go"
+    golden_path.write_text(golden_content, encoding='utf-8')
+    print(f"Wrote: {golden_path} ({len(golden_content)} bytes)")
+
     # Generate main.go (only if it doesn't exist - it's a source file, not regenerated)
     main_go_path = script_dir / "main.go"
     if not main_go_path.exists():
         print("Generating main.go (first time only)...")
-        struct_name = table_name_to_struct_name(primary_table) if primary_table else "Record"
-        main_go_content = generate_main_go(struct_name)
+        main_go_content = generate_main_go()
         main_go_path.write_text(main_go_content, encoding='utf-8')
         print(f"Wrote: {main_go_path} ({len(main_go_content)} bytes)")
     else:
@@ -1,43 +1,215 @@
-// ERB SDK - Go Test Runner (GENERATED - DO NOT EDIT)
+// ERB SDK - Go Test Runner and CLI
 package main
 
+//go:generate sh -c "go run $(ls *.go | grep -v _test.go) gen --rulebook ../../effortless-rulebook/effortless-rulebook.json --dir ."
+
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
+// commands maps each CLI subcommand to its implementation
+var commands = map[string]func(args []string) error{
+	"take-test":         runTakeTest,
+	"changelog":         runChangelog,
+	"publish":           runPublish,
+	"history":           runHistory,
+	"serve":             runServe,
+	"explain":           runExplain,
+	"levels":            runLevels,
+	"check-generated":   runCheckGenerated,
+	"export":            runExport,
+	"import":            runImport,
+	"json-schema":       runJSONSchema,
+	"pipeline":          runPipeline,
+	"sql":               runSQL,
+	"pgsync":            runPGSync,
+	"sqlite":            runSQLiteCommand,
+	"validate":          runValidate,
+	"compare-answers":   runCompareAnswers,
+	"init":              runInit,
+	"schema":            runSchema,
+	"lint":              runLint,
+	"stream":            runStream,
+	"migrate":           runMigrate,
+	"graphql":           runGraphQL,
+	"stats":             runStats,
+	"proto":             runProto,
+	"grpc":              runGRPC,
+	"outliers":          runOutliers,
+	"transpile":         runTranspile,
+	"conflicts":         runConflicts,
+	"resolve-conflicts": runResolveConflicts,
+	"templates":         runTemplates,
+	"derive":            runDerive,
+	"gen":               runGen,
+	"bulk-add":          runBulkAdd,
+	"names":             runNames,
+	"roundtrip":         runRoundTrip,
+	"compute":           runCompute,
+	"scenario":          runScenario,
+	"eval":              runEval,
+	"capabilities":      runCapabilities,
+	"argument":          runArgument,
+	"airtable":          runAirtable,
+	"scoreboard":        runScoreboard,
+	"digest":            runDigest,
+	"profile":           runProfile,
+	"what-if":           runWhatIf,
+	"dedupe":            runDedupe,
+}
+
 func main() {
-	scriptDir, err := os.Getwd()
+	if startWASM != nil {
+		startWASM() // a js/wasm build serves the JavaScript API, not the CLI
+		return
+	}
+	global, args, err := parseGlobalFlags(os.Args[1:])
+	if err == nil {
+		err = setLogLevel(global.logLevel)
+	}
+	closeTrace := func() {}
+	if err == nil {
+		closeTrace, err = traceToFile(global.trace)
+	}
+	if errors.Is(err, flag.ErrHelp) { // -h: parseGlobalFlags printed the usage
+		os.Exit(ExitCode(err))
+	}
 	if err != nil {
-		fmt.Printf("Failed to get working directory: %v\n", err)
-		os.Exit(1)
+		fmt.Println(err)
+		os.Exit(ExitUsage)
+	}
+	command := "take-test"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	run, ok := commands[command]
+	if !ok {
+		fmt.Printf("Unknown command: %s\n", command)
+		printUsage()
+		os.Exit(ExitUsage)
 	}
 
-	// Paths
-	blankTestPath := filepath.Join(scriptDir, "..", "..", "testing", "blank-test.json")
-	answersPath := filepath.Join(scriptDir, "test-answers.json")
+	flags, err := LoadFeatureFlags()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(ExitUsage)
+	}
+	if !flags.Canonical() {
+		fmt.Fprintf(os.Stderr, "note: experimental flags on (%s); results are not canonical\n", flags)
+	}
+	SetActiveFlags(flags)
+	if err := RegisterExtendedFormulaFunctions(); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitUsage)
+	}
 
-	// Step 1: Load blank test data
-	records, err := LoadRecords(blankTestPath)
+	release := func() {}
+	if !longRunningCommands[command] {
+		release = limitCommand(global.timeout)
+	}
+	err = run(args)
+	release()
+	closeTrace()
 	if err != nil {
-		fmt.Printf("Failed to load blank test: %v\n", err)
-		os.Exit(1)
+		if !errors.Is(err, flag.ErrHelp) { // -h: the flag set printed the usage
+			fmt.Printf("%s failed: %v\n", command, err)
+		}
+		os.Exit(ExitCode(err)) // see erb_errors.go
+	}
+}
+
+func printUsage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("Available commands:")
+	for _, name := range names {
+		fmt.Printf("  %s\n", name)
 	}
+}
 
-	fmt.Printf("Golang substrate: Processing %d records...\n", len(records))
+// printWarnings reports the rulebook's load warnings on stderr
+func printWarnings(rb *Rulebook) {
+	for _, w := range rb.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+	}
+}
 
-	// Step 2: Compute all calculated fields using the SDK
-	var computed []LanguageCandidate
-	for _, r := range records {
-		computed = append(computed, *r.ComputeAll())
+// runTakeTest implements `take-test [--testing-dir DIR] [--answers-dir DIR] [--outputs FORMAT=PATH,...]`:
+// computes the answers for every table in the generated RunnerTables
+func runTakeTest(args []string) error {
+	fs := flag.NewFlagSet("take-test", flag.ContinueOnError)
+	testingDir := fs.String("testing-dir", DefaultTestingDir, "directory holding the blank tests")
+	answersDir := fs.String("answers-dir", DefaultAnswersDir, "directory to write the test answers to")
+	strict := fs.Bool("strict", false, "fail when a blank test record has unknown or missing fields")
+	tolerant := fs.Bool("tolerant-fields", false, "accept blank test keys in any casing or under a historical name (FieldAliases), noting each on stderr")
+	skipBad := fs.Bool("skip-bad-records", false, "skip blank test records that do not parse, noting each on stderr, instead of failing")
+	workers := fs.Int("workers", 0, "goroutines computing records (0 = GOMAXPROCS)")
+	emptyStrings := fs.String("empty-strings", "null", "how empty calculated strings are written: null or preserve")
+	answerKey := fs.String("answer-key", "", "compare the JSON answers with this answer key and fail on any difference (e.g. ../../testing/answer-key.json)")
+	outputsSpec := fs.String("outputs", "", "comma-separated format=path targets to write instead of the JSON answers (formats: "+strings.Join(ExportFormats(), ", ")+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if flags := ActiveFlags(); !flags.Canonical() {
+		return fmt.Errorf("experimental flags are on (%s); conformance answers use canonical semantics, so unset $ERB_FLAGS and erb-flags.json", flags)
+	}
+	outputs, err := ParseOutputs(*outputsSpec)
+	if err != nil {
+		return err
 	}
 
-	// Step 3: Save test answers
-	if err := SaveRecords(answersPath, computed); err != nil {
-		fmt.Printf("Failed to save test answers: %v\n", err)
-		os.Exit(1)
+	scriptDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	for i := range outputs {
+		outputs[i].Path = resolvePath(scriptDir, outputs[i].Path)
 	}
+	policy, err := ParseEmptyStringPolicy(*emptyStrings)
+	if err != nil {
+		return err
+	}
+	opts := []RecordOption{WithWorkers(*workers), WithEmptyStrings(policy)}
+	if *strict {
+		opts = append(opts, WithStrictFields())
+	}
+	if *skipBad {
+		opts = append(opts, WithPartialLoad())
+	}
+	if *tolerant {
+		opts = append(opts, WithTolerantFields(func(u AliasUse) {
+			fmt.Fprintf(os.Stderr, "note: %s\n", u)
+		}))
+	}
+	if *answerKey != "" && len(outputs) > 0 {
+		return fmt.Errorf("--answer-key compares the JSON answers and cannot be used with --outputs")
+	}
+	answers := resolvePath(scriptDir, *answersDir)
+	if err := RunConformance(resolvePath(scriptDir, *testingDir), answers, outputs, opts...); err != nil {
+		return err
+	}
+	if *answerKey == "" {
+		return nil
+	}
+	return compareAnswerFiles(resolvePath(scriptDir, *answerKey), filepath.Join(answers, RunnerTables[0].Output))
+}
 
-	fmt.Printf("Golang substrate: Saved results to %s\n", answersPath)
+// resolvePath resolves a slash-separated path against dir unless it is already absolute
+func resolvePath(dir, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
 }
@@ -10,4 +10,5 @@ cd "$SCRIPT_DIR"
 rm -f "$SCRIPT_DIR/test-answers.json"
 
 # Step 2: Run the Go test runner to compute answers
-go run erb_sdk.go main.go take-test
+GO_FILES=$(ls *.go | grep -v '_test\.go$')
+go run $GO_FILES take-test
@@ -0,0 +1,29 @@
+#!/bin/bash
+
+# build.sh - builds the Go SDK for the browser (GOOS=js GOARCH=wasm)
+# Writes erb.wasm and copies Go's wasm_exec.js next to erb.js; serve this
+# directory and call loadERB("erb.wasm") (see ../erb_wasm_facade.go)
+
+set -euo pipefail
+
+WASM_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+SDK_DIR="$(dirname "$WASM_DIR")"
+
+# Build from a copy: the SDK's files plus the syscall/js interop in this directory
+BUILD_DIR="$(mktemp -d)"
+trap 'rm -rf "$BUILD_DIR"' EXIT
+cd "$SDK_DIR"
+cp $(ls *.go | grep -v '_test\.go$') *.tmpl "$BUILD_DIR"
+cp "$WASM_DIR/erb_wasm_js.go" "$BUILD_DIR"
+
+cd "$BUILD_DIR"
+GOOS=js GOARCH=wasm go build -o "$WASM_DIR/erb.wasm" $(ls *.go)
+
+GOROOT="$(go env GOROOT)"
+for exec_js in "$GOROOT/lib/wasm/wasm_exec.js" "$GOROOT/misc/wasm/wasm_exec.js"; do
+    if [ -f "$exec_js" ]; then
+        cp "$exec_js" "$WASM_DIR/"
+        break
+    fi
+done
+echo "Wrote $WASM_DIR/erb.wasm"
@@ -0,0 +1,35 @@
+// ERB SDK - Browser Wrapper
+// =========================
+// Loads the js/wasm build of the Go SDK (see erb_wasm_facade.go) and exposes its
+// calculation engine with objects in and out:
+//
+//   <script src="wasm_exec.js"></script>
+//   <script src="erb.js"></script>
+//   const erb = await loadERB("erb.wasm");
+//   const answers = erb.computeAll(candidates);
+//   const view = erb.toView({Name: "SQL", HasSyntax: true});
+
+async function loadERB(url) {
+  const go = new Go();
+  const source = fetch(url);
+  const { instance } = WebAssembly.instantiateStreaming
+    ? await WebAssembly.instantiateStreaming(source, go.importObject)
+    : await WebAssembly.instantiate(await (await source).arrayBuffer(), go.importObject);
+  go.run(instance); // registers globalThis.erb, then waits for calls
+
+  const call = (name, value) => {
+    const out = globalThis.erb[name](JSON.stringify(value));
+    if (out instanceof Error) {
+      throw out;
+    }
+    return JSON.parse(out);
+  };
+  return {
+    computeAll: (candidates) => call("computeAll", candidates),
+    toView: (candidate) => call("toView", candidate),
+  };
+}
+
+if (typeof module !== "undefined") {
+  module.exports = { loadERB };
+}
@@ -0,0 +1,44 @@
+//go:build js && wasm
+
+// ERB SDK - JavaScript Interop
+// ============================
+// In a js/wasm build, main serves globalThis.erb instead of the CLI:
+// erb.computeAll(json) and erb.toView(json) call ComputeAllJSON and
+// ToViewJSON and return the computed JSON, or an Error. erb.js wraps them to
+// take and return objects and throw the errors.
+//
+// This file is part of package main but lives here, since the SDK is built
+// from an explicit file list (which ignores build constraints); build.sh
+// compiles it with the SDK's files.
+
+package main
+
+import "syscall/js"
+
+func init() {
+	startWASM = serveJS
+}
+
+// serveJS registers globalThis.erb and keeps the Go runtime alive for its calls
+func serveJS() {
+	api := js.Global().Get("Object").New()
+	api.Set("computeAll", jsFunc(ComputeAllJSON))
+	api.Set("toView", jsFunc(ToViewJSON))
+	js.Global().Set("erb", api)
+	select {}
+}
+
+// jsFunc adapts a JSON facade function to JavaScript: one string argument,
+// the result string or an Error
+func jsFunc(fn func(string) (string, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) != 1 || args[0].Type() != js.TypeString {
+			return js.Global().Get("Error").New("expected one JSON string argument")
+		}
+		out, err := fn(args[0].String())
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return out
+	})
+}
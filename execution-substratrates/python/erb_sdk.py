@@ -51,14 +51,12 @@ class LanguageCandidate:
             return False
         return "language" in self.category.lower()
 
-    def calc_has_grammar(self) -> str:
+    def calc_has_grammar(self) -> bool:
         """
         Mirrors: calc_language_candidates_has_grammar()
-        Formula: CAST(has_syntax AS TEXT)
+        Formula: {{HasSyntax}} = TRUE()
         """
-        if self.has_syntax is None:
-            return ""
-        return "true" if self.has_syntax else ""
+        return self.has_syntax is True
 
     def calc_relationship_to_concept(self) -> str:
         """
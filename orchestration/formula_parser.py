@@ -16,7 +16,7 @@ Extracted from: execution-substratrates/owl/inject-into-owl.py
 
 import re
 from dataclasses import dataclass
-from typing import List, Any
+from typing import Dict, List, Any, Optional
 from enum import Enum, auto
 
 
@@ -397,6 +397,34 @@ def get_field_dependencies(ast: ASTNode) -> List[str]:
     return deps
 
 
+def infer_formula_type(ast: ASTNode, field_types: Dict[str, str]) -> Optional[str]:
+    """Infer the rulebook datatype a formula returns.
+
+    field_types maps field names to their datatypes. Returns 'boolean',
+    'integer' or 'string', or None when it cannot be known (an unknown field,
+    or IF branches of different types). An IF without an else branch returns
+    "" when false, so it is a string unless its then branch is one too.
+    """
+    if isinstance(ast, (LiteralBool, BinaryOp, UnaryOp)):
+        return 'boolean'
+    if isinstance(ast, LiteralInt):
+        return 'integer'
+    if isinstance(ast, (LiteralString, Concat)):
+        return 'string'
+    if isinstance(ast, FieldRef):
+        return field_types.get(ast.name)
+    if isinstance(ast, FuncCall):
+        if ast.name in ('AND', 'OR', 'NOT', 'FIND'):
+            return 'boolean'
+        if ast.name in ('LOWER', 'CAST'):
+            return 'string'
+        if ast.name == 'IF' and len(ast.args) >= 2:
+            then_type = infer_formula_type(ast.args[1], field_types)
+            else_type = infer_formula_type(ast.args[2], field_types) if len(ast.args) > 2 else 'string'
+            return then_type if then_type == else_type else None
+    return None
+
+
 # =============================================================================
 # PYTHON CODE GENERATOR
 # =============================================================================
@@ -621,7 +649,7 @@ def compile_to_javascript(ast: ASTNode, obj_name: str = 'candidate') -> str:
 def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
     """Compile an AST to a Go expression.
 
-    Uses boolVal() helper for nil-safe boolean access.
+    Uses the generic optGet() helper for nil-safe access to pointer fields.
     Field references use PascalCase struct field names.
     """
     if isinstance(ast, LiteralBool):
@@ -642,20 +670,20 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
     if isinstance(ast, UnaryOp):
         if ast.op == 'NOT':
             operand = compile_to_go(ast.operand, struct_name)
-            # Wrap in boolVal for nil-safe access
+            # Wrap in optGet for nil-safe access
             if isinstance(ast.operand, FieldRef):
-                return f'!boolVal({operand})'
+                return f'!optGet({operand}, false)'
             return f'!({operand})'
         raise ValueError(f"Unknown unary op: {ast.op}")
 
     if isinstance(ast, BinaryOp):
         # Handle comparisons involving field refs (pointer fields in Go)
         if isinstance(ast.left, FieldRef) and isinstance(ast.right, FieldRef):
-            # Both sides are field refs - wrap both in boolVal for nil-safe comparison
+            # Both sides are field refs - wrap both in optGet for nil-safe comparison
             left = compile_to_go(ast.left, struct_name)
             right = compile_to_go(ast.right, struct_name)
             op_map = {'=': '==', '<>': '!=', '<': '<', '<=': '<=', '>': '>', '>=': '>='}
-            return f'(boolVal({left}) {op_map[ast.op]} boolVal({right}))'
+            return f'(optGet({left}, false) {op_map[ast.op]} optGet({right}, false))'
 
         if isinstance(ast.left, FieldRef) and isinstance(ast.right, LiteralInt):
             # Field ref compared to integer - need nil check and dereference
@@ -671,11 +699,11 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
                 return f'({struct_name}.{left_field} != nil && *{struct_name}.{left_field} {op_go} {right})'
 
         if isinstance(ast.left, FieldRef) and isinstance(ast.right, LiteralBool):
-            # Field ref compared to boolean literal - use boolVal for nil-safe access
+            # Field ref compared to boolean literal - use optGet for nil-safe access
             left = compile_to_go(ast.left, struct_name)
             right = compile_to_go(ast.right, struct_name)
             op_map = {'=': '==', '<>': '!='}
-            return f'(boolVal({left}) {op_map[ast.op]} {right})'
+            return f'(optGet({left}, false) {op_map[ast.op]} {right})'
 
         left = compile_to_go(ast.left, struct_name)
         right = compile_to_go(ast.right, struct_name)
@@ -688,9 +716,9 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
             for arg in ast.args:
                 compiled = compile_to_go(arg, struct_name)
                 if isinstance(arg, FieldRef):
-                    parts.append(f'boolVal({compiled})')
+                    parts.append(f'optGet({compiled}, false)')
                 elif isinstance(arg, UnaryOp) and arg.op == 'NOT':
-                    # NOT already handles boolVal
+                    # NOT already handles optGet
                     parts.append(compiled)
                 elif isinstance(arg, BinaryOp):
                     # Binary ops handle their own nil checks
@@ -704,7 +732,7 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
             for arg in ast.args:
                 compiled = compile_to_go(arg, struct_name)
                 if isinstance(arg, FieldRef):
-                    parts.append(f'boolVal({compiled})')
+                    parts.append(f'optGet({compiled}, false)')
                 else:
                     parts.append(compiled)
             return '(' + ' || '.join(parts) + ')'
@@ -723,27 +751,27 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
                 raise ValueError("NOT requires 1 argument")
             operand = compile_to_go(ast.args[0], struct_name)
             if isinstance(ast.args[0], FieldRef):
-                return f'!boolVal({operand})'
+                return f'!optGet({operand}, false)'
             return f'!({operand})'
 
         if ast.name == 'LOWER':
             if len(ast.args) != 1:
                 raise ValueError("LOWER requires 1 argument")
             arg = compile_to_go(ast.args[0], struct_name)
-            return f'strings.ToLower(stringVal({arg}))'
+            return f'strings.ToLower(optGet({arg}, ""))'
 
         if ast.name == 'FIND':
             if len(ast.args) != 2:
                 raise ValueError("FIND requires 2 arguments")
             needle = compile_to_go(ast.args[0], struct_name)
             haystack = compile_to_go(ast.args[1], struct_name)
-            return f'strings.Contains(stringVal({haystack}), {needle})'
+            return f'strings.Contains(optGet({haystack}, ""), {needle})'
 
         if ast.name == 'CAST':
             if len(ast.args) >= 1:
                 arg = compile_to_go(ast.args[0], struct_name)
                 if isinstance(ast.args[0], FieldRef):
-                    return f'boolToString(boolVal({arg}))'
+                    return f'fmt.Sprint(optGet({arg}, false))'
                 return f'fmt.Sprintf("%v", {arg})'
             raise ValueError("CAST requires at least 1 argument")
 
@@ -758,7 +786,7 @@ def compile_to_go(ast: ASTNode, struct_name: str = 'lc') -> str:
             else:
                 var = compile_to_go(part, struct_name)
                 if isinstance(part, FieldRef):
-                    parts.append(f'stringVal({var})')
+                    parts.append(f'optGet({var}, "")')
                 else:
                     parts.append(var)
         if len(parts) == 1:
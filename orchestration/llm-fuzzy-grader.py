@@ -240,7 +240,7 @@ The computed columns are defined as follows:
 - `family_fued_question`: A string in the format "Is [name] a language?"
 - `top_family_feud_answer`: Boolean - true if the candidate satisfies all language criteria
 - `family_feud_mismatch`: String describing any mismatch between computed and expected, or null if no mismatch
-- `has_grammar`: String representation of has_syntax field (e.g., "True" or "False")
+- `has_grammar`: Boolean - equals has_syntax (false when has_syntax is null)
 - `relationship_to_concept`: "IsMirrorOf" if distance_from_concept=1, else "IsDescriptionOf"
 
 ## Example Records (for reference)
@@ -271,7 +271,7 @@ Respond with ONLY a JSON object in this format (no other text):
     "family_fued_question": "...",
     "top_family_feud_answer": true/false,
     "family_feud_mismatch": "..." or null,
-    "has_grammar": "True" or "False",
+    "has_grammar": true/false,
     "relationship_to_concept": "IsMirrorOf" or "IsDescriptionOf"
 }}
 ```
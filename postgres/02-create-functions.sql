@@ -1,72 +1,89 @@
 -- ============================================================================
--- CREATE FUNCTIONS - Calculation functions for lookup/calculated fields
+-- CREATE FUNCTIONS - One per calculated field, in DAG level order
 -- ============================================================================
--- Generated by rulebook-to-postgres tool
+-- Generated from the rulebook by the golang substrate (`sql` command)
 -- ============================================================================
 
--- ============================================================================
--- LOOKUP FUNCTIONS
--- These functions perform lookups via foreign key relationships
--- ============================================================================
-
-
+-- FamilyFuedQuestion
+-- Formula: ="Is " & {{Name}} & " a language?"
 CREATE OR REPLACE FUNCTION calc_language_candidates_family_fued_question(p_language_candidate_id TEXT)
 RETURNS TEXT AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN ('Is " & (SELECT NULLIF(name, '''') FROM language_candidates WHERE language_candidate_id = p_language_candidate_id) & " a language?')::text;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN NULLIF(('Is ' || COALESCE(r.name, '') || ' a language?'), '');
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_top_family_feud_answer(p_language_candidate_id TEXT)
+-- HasGrammar
+-- Formula: ={{HasSyntax}} = TRUE()
+CREATE OR REPLACE FUNCTION calc_language_candidates_has_grammar(p_language_candidate_id TEXT)
 RETURNS BOOLEAN AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN ((COALESCE((SELECT has_syntax FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND COALESCE((SELECT requires_parsing FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND (calc_language_candidates_is_description_of(p_language_candidate_id) = 'true') AND COALESCE((SELECT has_linear_decoding_pressure FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND COALESCE((SELECT resolves_to_an_ast FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND COALESCE((SELECT is_stable_ontology_reference FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND NOT (COALESCE((SELECT can_be_held FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE)) AND NOT (COALESCE((SELECT has_identity FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE))))::boolean;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN (COALESCE(r.has_syntax, FALSE) = TRUE);
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_family_feud_mismatch(p_language_candidate_id TEXT)
-RETURNS TEXT AS $$
+-- IsOpenClosedWorldConflicted
+-- Formula: =AND({{IsOpenWorld}}, {{IsClosedWorld}})
+CREATE OR REPLACE FUNCTION calc_language_candidates_is_open_closed_world_conflicted(p_language_candidate_id TEXT)
+RETURNS BOOLEAN AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN NULL; -- Formula translation failed
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN (COALESCE(r.is_open_world, FALSE) AND COALESCE(r.is_closed_world, FALSE));
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_has_grammar(p_language_candidate_id TEXT)
+-- IsDescriptionOf
+-- Formula: ={{DistanceFromConcept}} > 1
+CREATE OR REPLACE FUNCTION calc_language_candidates_is_description_of(p_language_candidate_id TEXT)
 RETURNS BOOLEAN AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN ((SELECT has_syntax FROM language_candidates WHERE language_candidate_id = p_language_candidate_id) = TRUE)::boolean;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN COALESCE(r.distance_from_concept > 1, FALSE);
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_is_open_closed_world_conflicted(p_language_candidate_id TEXT)
-RETURNS BOOLEAN AS $$
+-- RelationshipToConcept
+-- Formula: =IF({{DistanceFromConcept}} = 1, "IsMirrorOf", "IsDescriptionOf")
+CREATE OR REPLACE FUNCTION calc_language_candidates_relationship_to_concept(p_language_candidate_id TEXT)
+RETURNS TEXT AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN ((COALESCE((SELECT is_open_world FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE) AND COALESCE((SELECT is_closed_world FROM language_candidates WHERE language_candidate_id = p_language_candidate_id), FALSE)))::boolean;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN NULLIF(CASE WHEN COALESCE(r.distance_from_concept = 1, FALSE) THEN 'IsMirrorOf' ELSE 'IsDescriptionOf' END, '');
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_is_description_of(p_language_candidate_id TEXT)
+-- TopFamilyFeudAnswer
+-- Formula: =AND( {{HasSyntax}}, {{RequiresParsing}}, {{IsDescriptionOf}}, {{HasLinearDecodingPressure}}, {{ResolvesToAnAST}}, {{IsStableOntologyReference}}, NOT({{CanBeHeld}}), NOT({{HasIdentity}}) )
+CREATE OR REPLACE FUNCTION calc_language_candidates_top_family_feud_answer(p_language_candidate_id TEXT)
 RETURNS BOOLEAN AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN ((SELECT distance_from_concept FROM language_candidates WHERE language_candidate_id = p_language_candidate_id) > 1)::boolean;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN (COALESCE(r.has_syntax, FALSE) AND COALESCE(r.requires_parsing, FALSE) AND COALESCE(calc_language_candidates_is_description_of(r.language_candidate_id), FALSE) AND COALESCE(r.has_linear_decoding_pressure, FALSE) AND COALESCE(r.resolves_to_an_ast, FALSE) AND COALESCE(r.is_stable_ontology_reference, FALSE) AND NOT COALESCE(r.can_be_held, FALSE) AND NOT COALESCE(r.has_identity, FALSE));
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
 
-
-CREATE OR REPLACE FUNCTION calc_language_candidates_relationship_to_concept(p_language_candidate_id TEXT)
+-- FamilyFeudMismatch
+-- Formula: =IF(NOT({{TopFamilyFeudAnswer}} = {{ChosenLanguageCandidate}}), {{Name}} & " " & IF({{TopFamilyFeudAnswer}}, "Is", "Isn't") & " a Family Feud Language, but " & IF({{ChosenLanguageCandidate}}, "Is", "Is Not") & " marked as a 'Language Candidate.'") & IF({{IsOpenClosedWorldConflicted}}, " - Open World vs. Closed World Conflict.")
+CREATE OR REPLACE FUNCTION calc_language_candidates_family_feud_mismatch(p_language_candidate_id TEXT)
 RETURNS TEXT AS $$
+DECLARE
+  r language_candidates%ROWTYPE;
 BEGIN
-  RETURN (CASE WHEN COALESCE((SELECT distance_from_concept FROM language_candidates WHERE language_candidate_id = p_language_candidate_id) = 1, FALSE) THEN 'IsMirrorOf' ELSE 'IsDescriptionOf' END)::text;
+  SELECT * INTO r FROM language_candidates WHERE language_candidate_id = p_language_candidate_id;
+  RETURN NULLIF((CASE WHEN NOT (COALESCE(calc_language_candidates_top_family_feud_answer(r.language_candidate_id), FALSE) = COALESCE(r.chosen_language_candidate, FALSE)) THEN (COALESCE(r.name, '') || ' ' || CASE WHEN COALESCE(calc_language_candidates_top_family_feud_answer(r.language_candidate_id), FALSE) THEN 'Is' ELSE 'Isn''t' END || ' a Family Feud Language, but ' || CASE WHEN COALESCE(r.chosen_language_candidate, FALSE) THEN 'Is' ELSE 'Is Not' END || ' marked as a ''Language Candidate.''') ELSE '' END || CASE WHEN COALESCE(calc_language_candidates_is_open_closed_world_conflicted(r.language_candidate_id), FALSE) THEN ' - Open World vs. Closed World Conflict.' ELSE '' END), '');
 END;
 $$ LANGUAGE plpgsql STABLE SECURITY DEFINER;
-
--- ============================================================================
--- MANY-SIDE RELATIONSHIP FUNCTIONS
--- These functions aggregate child records for many-side relationships
--- ============================================================================
-
@@ -1,7 +1,7 @@
 -- ============================================================================
 -- CREATE VIEWS - Combine raw data with calculated fields
 -- ============================================================================
--- Generated by rulebook-to-postgres tool
+-- Generated from the rulebook by the golang substrate (`sql` command)
 -- Total Views: 2
 -- ============================================================================
 
@@ -48,4 +48,3 @@ SELECT
   t.evidence_from_rulebook,
   t.notes
 FROM is_everything_a_language t;
-